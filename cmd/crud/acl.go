@@ -0,0 +1,288 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// externalNamePattern matches the names of the fields of an external payload,
+// in whatever case the third-party API uses.
+var externalNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// SourceSpec declares the third-party system an entity is sourced from, whose
+// payloads an anti-corruption layer translates into the entity's dto:
+//
+//	source:
+//	  system: acme-crm           # kebab-case name of the system
+//	  fields:                    # entity field: field of the system's payload
+//	    title: productName
+//	    quantity: stock_level
+type SourceSpec struct {
+	System string            `yaml:"system"`
+	Fields map[string]string `yaml:"fields"`
+}
+
+func (s SourceSpec) validate(entity EntitySpec) error {
+	if !serviceNamePattern.MatchString(s.System) {
+		return fmt.Errorf("%s: source system %q must be kebab-case", entity.Name, s.System)
+	}
+	fieldNames := make([]string, 0, len(entity.Fields))
+	for _, field := range entity.Fields {
+		fieldNames = append(fieldNames, field.Name)
+	}
+	goNames := make(map[string]string, len(s.Fields))
+	for _, name := range slices.Sorted(maps.Keys(s.Fields)) {
+		external := s.Fields[name]
+		if _, ok := entity.field(name); !ok {
+			return fmt.Errorf("%s: source field %s is not a field%s", entity.Name, name, suggest(name, fieldNames))
+		}
+		if !externalNamePattern.MatchString(external) {
+			return fmt.Errorf("%s.%s: source field %q must be letters, digits, '_' and '-', starting with a letter", entity.Name, name, external)
+		}
+		goName := externalGoName(external)
+		if other, ok := goNames[goName]; ok {
+			return fmt.Errorf("%s: source fields %s and %s map to the same payload field %s", entity.Name, other, name, goName)
+		}
+		goNames[goName] = name
+	}
+	return nil
+}
+
+// externalGoName is the Go name of the payload field holding the external
+// field, e.g. ProductName for productName or stock_level.
+func externalGoName(external string) string {
+	return FieldSpec{Name: strings.NewReplacer("-", "_").Replace(external)}.GoName()
+}
+
+// sourceField is a field of the entity mapped to a field of the payload of its
+// source system.
+type sourceField struct {
+	FieldSpec
+	// External is the name of the field in the payload.
+	External string
+}
+
+// SourceFields lists the mapped fields of the entity, in the order of the spec.
+func (d TemplateData) SourceFields() []sourceField {
+	if d.Entity.Source == nil {
+		return nil
+	}
+	var fields []sourceField
+	for _, field := range d.Entity.Fields {
+		if external, ok := d.Entity.Source.Fields[field.Name]; ok {
+			fields = append(fields, sourceField{FieldSpec: field, External: external})
+		}
+	}
+	return fields
+}
+
+// SourceSystem is the name of the system the entity is sourced from, empty
+// when the spec declares none.
+func (d TemplateData) SourceSystem() string {
+	if d.Entity.Source == nil {
+		return ""
+	}
+	return d.Entity.Source.System
+}
+
+// SourcePackage is the package of the entity's anti-corruption layer, named
+// after its source system, e.g. acmecrm for acme-crm.
+func (d TemplateData) SourcePackage() string {
+	return strings.ReplaceAll(d.SourceSystem(), "-", "")
+}
+
+// SourcePayloadFields renders the fields of the payload struct, aligned like
+// gofmt would. Nullable fields are pointers, which the payload leaves nil.
+func (d TemplateData) SourcePayloadFields() []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	for _, field := range d.SourceFields() {
+		goType := fieldTypes[field.Type].Go
+		if field.Nullable {
+			goType = "*" + goType
+		}
+		fmt.Fprintf(w, "%s\t%s\t`json:\"%s\"`\n", externalGoName(field.External), goType, field.External)
+	}
+	w.Flush()
+	return splitLines(buf.String())
+}
+
+// SourceValues renders the entries of the literal translating between the
+// payload and the dto, aligned like gofmt would: into the dto, or into the
+// payload with reverse. Nullable fields are left out, as the dto may hold
+// them in another shape.
+func (d TemplateData) SourceValues(reverse bool) []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	for _, field := range d.SourceFields() {
+		if field.Nullable {
+			continue
+		}
+		if reverse {
+			fmt.Fprintf(w, "%s:\t%s.%s,\n", externalGoName(field.External), d.CamelCase, field.GoName())
+		} else {
+			fmt.Fprintf(w, "%s:\tpayload.%s,\n", field.GoName(), externalGoName(field.External))
+		}
+	}
+	w.Flush()
+	return splitLines(buf.String())
+}
+
+// SourceUnmapped lists the fields the translation leaves to be mapped by hand,
+// for its TODO: those the spec does not map and the nullable ones.
+func (d TemplateData) SourceUnmapped() string {
+	if d.Entity.Source == nil {
+		return ""
+	}
+	var names []string
+	for _, field := range d.Entity.Fields {
+		if _, mapped := d.Entity.Source.Fields[field.Name]; !mapped || field.Nullable {
+			names = append(names, field.GoName())
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// SourceImports lists the packages the payload struct needs for its fields.
+func (d TemplateData) SourceImports() []string {
+	var fields []FieldSpec
+	for _, field := range d.SourceFields() {
+		fields = append(fields, field.FieldSpec)
+	}
+	return fieldImports(fields)
+}
+
+// --- ANTI-CORRUPTION LAYER TEMPLATES ---
+
+const sourceMapperTemplate = `package {{.SourcePackage}}
+
+import (
+{{- range .SourceImports}}
+	"{{.}}"
+{{- end}}
+{{- if .SourceImports}}
+{{end}}
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// {{.PascalCase}}Payload is a {{.LowerCase}} as {{.SourceSystem}} represents it. It stays in
+// this package; the rest of the service only sees the dto.{{.PascalCase}} it
+// translates into.
+type {{.PascalCase}}Payload struct {
+{{- range .SourcePayloadFields}}
+	{{.}}
+{{- end}}
+}
+
+// To{{.PascalCase}} translates the payload of a {{.LowerCase}} from {{.SourceSystem}} into a
+// dto.{{.PascalCase}}, failing on payloads the service cannot accept.
+func To{{.PascalCase}}(payload {{.PascalCase}}Payload) (dto.{{.PascalCase}}, error) {
+	{{.CamelCase}} := dto.{{.PascalCase}}{
+{{- range .SourceValues false}}
+		{{.}}
+{{- end}}
+	}
+{{- with .SourceUnmapped}}
+	// TODO: Map {{.}} from the payload, converting the values
+	// {{$.SourceSystem}} holds in another shape.
+{{- end}}
+	return {{.CamelCase}}, nil
+}
+
+// From{{.PascalCase}} translates a dto.{{.PascalCase}} back into its payload, for the writes
+// to {{.SourceSystem}}.
+func From{{.PascalCase}}({{.CamelCase}} dto.{{.PascalCase}}) {{.PascalCase}}Payload {
+	return {{.PascalCase}}Payload{
+{{- range .SourceValues true}}
+		{{.}}
+{{- end}}
+	}
+}
+`
+
+const sourcePortTemplate = `package client
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// {{.PascalCase}}Source reads the {{.LowerCase}}s sourced from {{.SourceSystem}}, translated into
+// dto.{{.PascalCase}}, so the service never depends on the payloads of {{.SourceSystem}}.
+type {{.PascalCase}}Source interface {
+	// Get{{.PascalCase}} returns the {{.LowerCase}} {{.SourceSystem}} knows by externalID, failing
+	// with a not found error when it has none.
+	Get{{.PascalCase}}(ctx context.Context, externalID string) (dto.{{.PascalCase}}, error)
+}
+`
+
+const sourceAdapterTemplate = `package {{.SourcePackage}}
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/client"
+)
+
+type {{.CamelCase}}Source struct {
+	baseURL    string
+	httpClient *http.Client
+	log        ports.LoggerWithTraceID
+}
+
+// New{{.PascalCase}}Source reads {{.LowerCase}}s from the {{.SourceSystem}} API at baseURL. A nil
+// httpClient gives up on calls after 5s.
+func New{{.PascalCase}}Source(baseURL string, httpClient *http.Client, log ports.LoggerWithTraceID) client.{{.PascalCase}}Source {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &{{.CamelCase}}Source{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+		log:        log,
+	}
+}
+
+func (s *{{.CamelCase}}Source) Get{{.PascalCase}}(ctx context.Context, externalID string) (dto.{{.PascalCase}}, error) {
+	// TODO: Point the request at the {{.SourceSystem}} endpoint of a {{.LowerCase}} and add
+	// its authentication.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/{{.KebabCase}}s/"+url.PathEscape(externalID), nil)
+	if err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return dto.{{.PascalCase}}{}, fmt.Errorf("fetching {{.LowerCase}} %s from {{.SourceSystem}}: %w", externalID, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return dto.{{.PascalCase}}{}, appErr.NewNotFoundErr(errors.New("{{.SourceSystem}} has no {{.LowerCase}} " + externalID))
+	case resp.StatusCode != http.StatusOK:
+		return dto.{{.PascalCase}}{}, fmt.Errorf("fetching {{.LowerCase}} %s from {{.SourceSystem}}: %s", externalID, resp.Status)
+	}
+
+	var payload {{.PascalCase}}Payload
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return dto.{{.PascalCase}}{}, fmt.Errorf("decoding {{.LowerCase}} %s from {{.SourceSystem}}: %w", externalID, err)
+	}
+	return To{{.PascalCase}}(payload)
+}
+`
@@ -0,0 +1,10 @@
+package crud
+
+// WriteRoutePrefix is the route prefix of the create, update and delete
+// handlers, which move to the admin router group with --admin.
+func (d TemplateData) WriteRoutePrefix() string {
+	if d.Admin {
+		return "/admin/api/v1"
+	}
+	return "/api/v1"
+}
@@ -0,0 +1,189 @@
+package crud
+
+// --- ADMINCTL TEMPLATES ---
+
+// adminctlMainTemplate is shared by every entity, so it is generated once and
+// left alone on later runs. Entities add their subcommands from their own files.
+const adminctlMainTemplate = `// Command adminctl manages the service's entities through its HTTP API, for
+// ops and debugging.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	baseURL string
+	token   string
+)
+
+var rootCmd = &cobra.Command{
+	Use:          "adminctl",
+	Short:        "Manages the service's entities through its HTTP API.",
+	SilenceUsage: true,
+}
+
+func main() {
+	rootCmd.PersistentFlags().StringVar(&baseURL, "addr", envOr("ADMINCTL_ADDR", "http://localhost:8080"), "Base URL of the service")
+	rootCmd.PersistentFlags().StringVar(&token, "token", os.Getenv("ADMINCTL_TOKEN"), "Bearer token sent with every request")
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// call sends body as JSON to path and prints the response body indented.
+// Responses outside the 2xx range are returned as errors.
+func call(method, path string, body []byte) error {
+	req, err := http.NewRequest(method, strings.TrimRight(baseURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(payload))
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, payload, "", "  "); err != nil {
+		_, err = os.Stdout.Write(payload)
+		return err
+	}
+	fmt.Println(indented.String())
+	return nil
+}
+
+// readBody returns the JSON body given with --data, or read from stdin when
+// the flag is "-".
+func readBody(data string) ([]byte, error) {
+	if data == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	if !json.Valid([]byte(data)) {
+		return nil, fmt.Errorf("--data is not valid JSON")
+	}
+	return []byte(data), nil
+}
+`
+
+const adminctlEntityTemplate = `package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	{{.CamelCase}}Cmd := &cobra.Command{
+		Use:   "{{.KebabCase}}",
+		Short: "Manages {{.PascalCase}}s.",
+	}
+
+	var page, pageSize int
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists {{.PascalCase}}s a page at a time.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			query.Set("page", strconv.Itoa(page))
+			query.Set("page_size", strconv.Itoa(pageSize))
+			return call("GET", "/api/v1/{{.KebabCase}}/?"+query.Encode(), nil)
+		},
+	}
+	listCmd.Flags().IntVar(&page, "page", 1, "Page to fetch")
+	listCmd.Flags().IntVar(&pageSize, "page-size", 10, "Number of {{.PascalCase}}s per page")
+
+	getCmd := &cobra.Command{
+		Use:   "get ID",
+		Short: "Prints one {{.PascalCase}}.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parse{{.PascalCase}}ID(args[0])
+			if err != nil {
+				return err
+			}
+			return call("GET", fmt.Sprintf("/api/v1/{{.KebabCase}}/%d", id), nil)
+		},
+	}
+
+	var data string
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Creates a {{.PascalCase}} from a JSON create{{.PascalCase}}Request.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			body, err := readBody(data)
+			if err != nil {
+				return err
+			}
+			return call("POST", "{{.WriteRoutePrefix}}/{{.KebabCase}}/", body)
+		},
+	}
+	createCmd.Flags().StringVar(&data, "data", "-", "Request body as JSON, or '-' to read it from stdin")
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete ID",
+		Short: "Deletes a {{.PascalCase}}.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parse{{.PascalCase}}ID(args[0])
+			if err != nil {
+				return err
+			}
+			return call("DELETE", fmt.Sprintf("{{.WriteRoutePrefix}}/{{.KebabCase}}/%d", id), nil)
+		},
+	}
+
+	{{.CamelCase}}Cmd.AddCommand(listCmd, getCmd, createCmd, deleteCmd)
+	rootCmd.AddCommand({{.CamelCase}}Cmd)
+}
+
+func parse{{.PascalCase}}ID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid {{.PascalCase}} ID %q", arg)
+	}
+	return id, nil
+}
+`
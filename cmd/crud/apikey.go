@@ -0,0 +1,308 @@
+package crud
+
+const authAPIKey = "apikey"
+
+// --- API KEY TEMPLATES ---
+
+const apiKeyDTOTemplate = `package dto
+
+import "time"
+
+type {{.PascalCase}}APIKey struct {
+	ID        int64     ` + "`json:\"id\" db:\"id\"`" + `
+	Name      string    ` + "`json:\"name\" db:\"name\"`" + `
+	Prefix    string    ` + "`json:\"prefix\" db:\"prefix\"`" + `
+	Hash      string    ` + "`json:\"-\" db:\"hash\"`" + `
+	CreatedAt time.Time ` + "`json:\"created_at\" db:\"created_at\"`" + `
+}
+`
+
+const apiKeyTemplate = `package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// {{.PascalCase}}KeyPrefix starts every {{.LowerCase}} API key, so leaked keys are easy to
+// recognise in logs and secret scanners.
+const {{.PascalCase}}KeyPrefix = "{{.SnakeCase}}_"
+
+type {{.PascalCase}}KeyRepository interface {
+	Create(ctx context.Context, key *dto.{{.PascalCase}}APIKey) error
+	Delete(ctx context.Context, id int64) error
+	FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}APIKey, *dto.Pagination, error)
+	FindByHash(ctx context.Context, hash string) (dto.{{.PascalCase}}APIKey, error)
+}
+
+// New{{.PascalCase}}Key returns a random key and the record to store for it. Only
+// the key's hash is stored, so the key can be shown once and never again.
+func New{{.PascalCase}}Key(name string) (string, dto.{{.PascalCase}}APIKey, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", dto.{{.PascalCase}}APIKey{}, err
+	}
+	key := {{.PascalCase}}KeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return key, dto.{{.PascalCase}}APIKey{
+		Name:   name,
+		Prefix: key[:len({{.PascalCase}}KeyPrefix)+6],
+		Hash:   Hash{{.PascalCase}}Key(key),
+	}, nil
+}
+
+// Hash{{.PascalCase}}Key is the stored form of key. Keys are random, so a fast hash
+// is enough to make a leaked table useless.
+func Hash{{.PascalCase}}Key(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+`
+
+const apiKeyRepositoryTemplate = `package postgres
+
+import (
+	"context"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/apikey"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+)
+
+type {{.CamelCase}}APIKeyRepository struct {
+	repository.GenericRepository[dto.{{.PascalCase}}APIKey]
+	db  ports.Database
+	log ports.LoggerWithTraceID
+}
+
+func New{{.PascalCase}}APIKeyRepository(db ports.Database, log ports.LoggerWithTraceID) apikey.{{.PascalCase}}KeyRepository {
+	return &{{.CamelCase}}APIKeyRepository{
+		GenericRepository: repository.NewGenericRepository[dto.{{.PascalCase}}APIKey](db, log),
+		db:                db,
+		log:               log,
+	}
+}
+
+func (r *{{.CamelCase}}APIKeyRepository) FindByHash(ctx context.Context, hash string) (dto.{{.PascalCase}}APIKey, error) {
+	// TODO: Select the key with the given hash.
+	// Example:
+	// SELECT * FROM {{.SnakeCase}}_api_key WHERE hash = $1
+	return dto.{{.PascalCase}}APIKey{}, nil
+}
+`
+
+const apiKeyControllerTemplate = `package {{.LowerCase}}
+
+import (
+	"errors"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/apikey"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/consts"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/validator"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/utils"
+	"go.elastic.co/apm"
+)
+
+// apiKeyHeader carries the key on requests to the {{.PascalCase}} routes.
+const apiKeyHeader = "X-API-Key"
+
+// APIKeyAuth returns the middleware for the {{.PascalCase}} route group. It only
+// lets requests through that carry a key created with CreateAPIKey.
+func APIKeyAuth(keys apikey.{{.PascalCase}}KeyRepository) func(c *ports.HttpContext) error {
+	return func(c *ports.HttpContext) error {
+		plain := c.Get(apiKeyHeader)
+		if plain == "" {
+			return appErr.NewUnauthorizedErr(errors.New("missing " + apiKeyHeader + " header"))
+		}
+		key, err := keys.FindByHash(c.Context(), apikey.Hash{{.PascalCase}}Key(plain))
+		if err != nil || key.ID == 0 {
+			return appErr.NewUnauthorizedErr(errors.New("invalid API key"))
+		}
+		c.Locals("api_key_id", key.ID)
+		return c.Next()
+	}
+}
+
+{{if eq .Swagger "openapi-gen" -}}
+// +k8s:openapi-gen=true
+{{end -}}
+type createAPIKeyRequest struct {
+	Name string ` + "`json:\"name\" validate:\"required,max=100\"`" + `
+}
+
+{{if eq .Swagger "openapi-gen" -}}
+// +k8s:openapi-gen=true
+{{end -}}
+type createAPIKeyResponse struct {
+	ID     int64  ` + "`json:\"id\"`" + `
+	Name   string ` + "`json:\"name\"`" + `
+	Prefix string ` + "`json:\"prefix\"`" + `
+	Key    string ` + "`json:\"key\"`" + `
+}
+
+type APIKey interface {
+	CreateAPIKey(c *ports.HttpContext) error
+	GetPaginatedAPIKeys(c *ports.HttpContext) error
+	DeleteAPIKey(c *ports.HttpContext) error
+}
+
+type apiKeyController struct {
+	keyRepository    apikey.{{.PascalCase}}KeyRepository
+	customValidation validator.CustomValidation
+	log              ports.LoggerWithTraceID
+}
+
+func NewAPIKey(log ports.LoggerWithTraceID, keyRepository apikey.{{.PascalCase}}KeyRepository, customValidation validator.CustomValidation) APIKey {
+	return &apiKeyController{
+		keyRepository:    keyRepository,
+		customValidation: customValidation,
+		log:              log,
+	}
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Create a {{.PascalCase}} API key
+// @Description	This route will create an API key for the {{.LowerCase}} routes; the key is only returned once
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			body	body		createAPIKeyRequest	true	"Create API key request"
+// @Success		201		{{.SwagResponse "createAPIKeyResponse"}}
+// @Failure		400		{object}	{{.ErrorSchema}}
+// @Failure		500		{object}	{{.ErrorSchema}}
+// @Router			/admin/api/v1/{{.KebabCase}}/api-keys [post]
+{{else -}}
+// CreateAPIKey handles POST /admin/api/v1/{{.KebabCase}}/api-keys.
+{{end -}}
+func (ctrl *apiKeyController) CreateAPIKey(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Create{{.PascalCase}}APIKey", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	var inputRequest createAPIKeyRequest
+	if err := c.BodyParser(&inputRequest); err != nil {
+		ctrl.log.Error(ctx, err.Error())
+		return appErr.NewBadRequestErr(err)
+	}
+
+	validationErrs := ctrl.customValidation.ValidateStruct(inputRequest)
+	if validationErrs != nil {
+		return utils.WithFieldErrors(
+			appErr.NewBadRequestErr(errors.New(consts.ErrValidationFailedMsg)),
+			validationErrs...,
+		)
+	}
+
+	plain, key, err := apikey.New{{.PascalCase}}Key(inputRequest.Name)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.keyRepository.Create(ctx, &key); err != nil {
+		return err
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 201, {{else}}c.Status(201).JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data: createAPIKeyResponse{
+			ID:     key.ID,
+			Name:   key.Name,
+			Prefix: key.Prefix,
+			Key:    plain,
+		},
+	}{{if .Envelope.IsSet}}){{end}})
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Get All {{.PascalCase}} API keys
+// @Description	Get all paginated {{.LowerCase}} API keys, without the keys themselves
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			params	query		httpUtils.ListRequest	false	"Pagination parameters"
+// @Success		200		{{.SwagResponse (print "[]dto." .PascalCase "APIKey")}}
+// @Failure		400		{object}	{{.ErrorSchema}}
+// @Failure		500		{object}	{{.ErrorSchema}}
+// @Router			/admin/api/v1/{{.KebabCase}}/api-keys [get]
+{{else -}}
+// GetPaginatedAPIKeys handles GET /admin/api/v1/{{.KebabCase}}/api-keys.
+{{end -}}
+func (ctrl *apiKeyController) GetPaginatedAPIKeys(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "GetPaginated{{.PascalCase}}APIKeys", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	pagination, err := httpUtils.ParseAndValidatePagination(ctx, c, ctrl.customValidation, ctrl.log, map[string]string{})
+	if err != nil {
+		return err
+	}
+
+	keys, resultPagination, err := ctrl.keyRepository.FindAll(ctx, pagination)
+	if err != nil {
+		return err
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, {{else}}c.JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Data: keys,
+		Meta: &ports.Meta{
+			Pagination: &resultPagination.Pagination,
+		},
+	}{{if .Envelope.IsSet}}){{end}})
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Revoke a {{.PascalCase}} API key
+// @Description	This route will delete a {{.LowerCase}} API key
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			id	path	int	true	"API key ID"
+// @Success		204
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			/admin/api/v1/{{.KebabCase}}/api-keys/{id} [delete]
+{{else -}}
+// DeleteAPIKey handles DELETE /admin/api/v1/{{.KebabCase}}/api-keys/{id}.
+{{end -}}
+func (ctrl *apiKeyController) DeleteAPIKey(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Delete{{.PascalCase}}APIKey", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return appErr.NewBadRequestErr(err)
+	}
+
+	if err := ctrl.keyRepository.Delete(ctx, int64(id)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+`
+
+const apiKeyMigrationTemplate = `CREATE TABLE IF NOT EXISTS {{.SnakeCase}}_api_key (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT        NOT NULL,
+    prefix     TEXT        NOT NULL,
+    hash       TEXT        NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+`
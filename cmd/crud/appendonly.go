@@ -0,0 +1,392 @@
+package crud
+
+const (
+	appendOnlyDefaultLimit = 20
+	appendOnlyMaxLimit     = 100
+)
+
+// AppendOnlyLimits are the default and maximum page sizes of the append-only
+// list handler, taken from the pagination config when it sets them.
+func (d TemplateData) AppendOnlyLimits() [2]int {
+	limits := [2]int{appendOnlyDefaultLimit, appendOnlyMaxLimit}
+	if d.Pagination.DefaultPageSize > 0 {
+		limits[0] = d.Pagination.DefaultPageSize
+	}
+	if d.Pagination.MaxPageSize > 0 {
+		limits[1] = d.Pagination.MaxPageSize
+	}
+	if limits[0] > limits[1] {
+		limits[0] = limits[1]
+	}
+	return limits
+}
+
+// --- APPEND-ONLY TEMPLATES ---
+
+// The append-only templates replace the repository, service and controller of
+// event and log style entities, whose rows are inserted and listed newest
+// first but never read by id, updated or deleted.
+
+const appendOnlyRepositoryInterfaceTemplate = `package repository
+
+import (
+	"context"
+	"time"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// {{.PascalCase}} stores {{.LowerCase}} rows, which are only ever appended. Rows have
+// no key; they are ordered by their created_at column.
+type {{.PascalCase}} interface {
+	Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error
+	// FindBefore returns up to limit rows created before the given time, newest
+	// first, or the newest rows when before is zero. next is the created_at of
+	// the last row when more rows may follow, and zero otherwise.
+	FindBefore(ctx context.Context, before time.Time, limit int) (rows []dto.{{.PascalCase}}, next time.Time, err error)
+{{- if .ClickHouse}}
+	// CountBuckets counts the rows created in [from, to) per bucket of width
+	// interval, oldest first. Empty buckets are left out.
+	CountBuckets(ctx context.Context, from, to time.Time, interval time.Duration) ([]dto.TimeBucket, error)
+{{- end}}
+}
+`
+
+const appendOnlyRepositoryTemplate = `package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+)
+
+// {{.CamelCase}}InsertQuery inserts the JSON encoding of a dto.{{.PascalCase}} and stamps
+// created_at with the database clock, so listing order matches insertion order.
+// Columns missing from the JSON are inserted as NULL, not as their defaults.
+const {{.CamelCase}}InsertQuery = ` + "`" + `
+INSERT INTO {{.TableIdent}}
+SELECT (jsonb_populate_record(NULL::{{.TableIdent}}, $1::jsonb || jsonb_build_object('created_at', now()))).*
+RETURNING to_jsonb({{.TableIdent}}.*)` + "`" + `
+
+// {{.CamelCase}}ListQuery is a keyset query on created_at; a NULL $1 starts from the newest row.
+const {{.CamelCase}}ListQuery = ` + "`" + `
+SELECT to_jsonb(t.*), t.created_at FROM {{.TableIdent}} t
+WHERE $1::timestamptz IS NULL OR t.created_at < $1
+ORDER BY t.created_at DESC
+LIMIT $2` + "`" + `
+
+type {{.CamelCase}}Repository struct {
+	db  *sql.DB
+	log ports.LoggerWithTraceID
+}
+
+func New{{.PascalCase}}Repository(db *sql.DB, log ports.LoggerWithTraceID) repository.{{.PascalCase}} {
+	return &{{.CamelCase}}Repository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *{{.CamelCase}}Repository) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	row, err := json.Marshal({{.CamelCase}})
+	if err != nil {
+		return err
+	}
+{{- if .RLS}}
+	tx, err := repository.BeginScoped(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+{{- end}}
+
+	var created []byte
+	if err := {{if .RLS}}tx{{else}}r.db{{end}}.QueryRowContext(ctx, {{.CamelCase}}InsertQuery, row).Scan(&created); err != nil {
+		return err
+	}
+{{- if .RLS}}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+{{- end}}
+	return json.Unmarshal(created, {{.CamelCase}})
+}
+
+func (r *{{.CamelCase}}Repository) FindBefore(ctx context.Context, before time.Time, limit int) ([]dto.{{.PascalCase}}, time.Time, error) {
+{{- if .RLS}}
+	tx, err := repository.BeginScoped(ctx, r.db)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer tx.Rollback()
+{{end}}
+	rows, err := {{if .RLS}}tx{{else}}r.db{{end}}.QueryContext(ctx, {{.CamelCase}}ListQuery, sql.NullTime{Time: before, Valid: !before.IsZero()}, limit)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+
+	var {{.CamelCase}}s []dto.{{.PascalCase}}
+	var last time.Time
+	for rows.Next() {
+		var row []byte
+		var {{.CamelCase}} dto.{{.PascalCase}}
+		if err := rows.Scan(&row, &last); err != nil {
+			return nil, time.Time{}, err
+		}
+		if err := json.Unmarshal(row, &{{.CamelCase}}); err != nil {
+			return nil, time.Time{}, err
+		}
+		{{.CamelCase}}s = append({{.CamelCase}}s, {{.CamelCase}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	if len({{.CamelCase}}s) < limit {
+		return {{.CamelCase}}s, time.Time{}, nil
+	}
+	return {{.CamelCase}}s, last, nil
+}
+`
+
+const appendOnlyServiceTemplate = `package service
+
+import (
+	"context"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+)
+
+// {{.PascalCase}} appends {{.LowerCase}}s and lists them newest first. There is no
+// way to read a single {{.LowerCase}}, update it or delete it.
+type {{.PascalCase}} interface {
+	Create{{.PascalCase}}(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}) (dto.{{.PascalCase}}, error)
+	List{{.PascalCase}}s(ctx context.Context, before time.Time, limit int) ([]dto.{{.PascalCase}}, time.Time, error)
+{{- if .ClickHouse}}
+	Count{{.PascalCase}}Buckets(ctx context.Context, from, to time.Time, interval time.Duration) ([]dto.TimeBucket, error)
+{{- end}}
+}
+
+type {{.CamelCase}}Service struct {
+	log              ports.LoggerWithTraceID
+	{{.CamelCase}}Repository repository.{{.PascalCase}}
+}
+
+func New{{.PascalCase}}Service(log ports.LoggerWithTraceID, {{.CamelCase}}Repository repository.{{.PascalCase}}) {{.PascalCase}} {
+	return &{{.CamelCase}}Service{
+		log:              log,
+		{{.CamelCase}}Repository: {{.CamelCase}}Repository,
+	}
+}
+
+func (s *{{.CamelCase}}Service) Create{{.PascalCase}}(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}) (dto.{{.PascalCase}}, error) {
+	err := s.{{.CamelCase}}Repository.Create(ctx, &{{.CamelCase}})
+	if err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	return {{.CamelCase}}, nil
+}
+
+func (s *{{.CamelCase}}Service) List{{.PascalCase}}s(ctx context.Context, before time.Time, limit int) ([]dto.{{.PascalCase}}, time.Time, error) {
+	return s.{{.CamelCase}}Repository.FindBefore(ctx, before, limit)
+}
+{{- if .ClickHouse}}
+
+func (s *{{.CamelCase}}Service) Count{{.PascalCase}}Buckets(ctx context.Context, from, to time.Time, interval time.Duration) ([]dto.TimeBucket, error) {
+	return s.{{.CamelCase}}Repository.CountBuckets(ctx, from, to, interval)
+}
+{{- end}}
+`
+
+const appendOnlyControllerTemplate = `package {{.LowerCase}}
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if not .I18n}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/consts"
+{{- end}}
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+	"{{.ServiceImport}}"
+{{- if or .ContentNegotiation .Envelope.IsSet .ProblemJSON}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+{{- end}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/validator"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/utils"
+	"go.elastic.co/apm"
+)
+
+type {{.PascalCase}} interface {
+	List{{.PascalCase}}s(c *ports.HttpContext) error
+	Create{{.PascalCase}}(c *ports.HttpContext) error
+{{- if .ClickHouse}}
+	Count{{.PascalCase}}Buckets(c *ports.HttpContext) error
+{{- end}}
+}
+
+// {{.CamelCase}}NextHeader carries the before cursor of the next page of a list
+// response; it is absent on the last page.
+const {{.CamelCase}}NextHeader = "X-Next-Before"
+
+const (
+	{{.CamelCase}}DefaultLimit = {{index .AppendOnlyLimits 0}}
+	{{.CamelCase}}MaxLimit     = {{index .AppendOnlyLimits 1}}
+)
+
+type {{.CamelCase}}Controller struct {
+	{{.CamelCase}}Service    service.{{.PascalCase}}
+	customValidation validator.CustomValidation
+	log              ports.LoggerWithTraceID
+}
+
+func New(log ports.LoggerWithTraceID, {{.CamelCase}}Service service.{{.PascalCase}}, customValidation validator.CustomValidation) {{.PascalCase}} {
+	return &{{.CamelCase}}Controller{
+		{{.CamelCase}}Service:    {{.CamelCase}}Service,
+		customValidation: customValidation,
+		log:              log,
+	}
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Append a {{.PascalCase}}
+// @Description	This route will append a {{.LowerCase}}; {{.LowerCase}}s cannot be updated or deleted
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			body	body		create{{.PascalCase}}Request 	true	"Create {{.PascalCase}} request"
+// @Success		201		{{.SwagResponse (print "dto." .PascalCase)}}
+// @Failure		400		{object}	{{.ErrorSchema}}
+// @Failure		422		{object}	{{.ErrorSchema}}
+// @Failure		500		{object}	{{.ErrorSchema}}
+// @Router			/api/v1/{{.KebabCase}}/ [post]
+{{else -}}
+// Create{{.PascalCase}} handles POST /api/v1/{{.KebabCase}}/.
+{{end -}}
+func (ctrl *{{.CamelCase}}Controller) Create{{.PascalCase}}(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Create{{.PascalCase}}", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
+
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return err
+	}
+{{- end}}
+
+	var inputRequest create{{.PascalCase}}Request
+	if err := c.BodyParser(&inputRequest); err != nil {
+		ctrl.log.Error(ctx, err.Error())
+		return appErr.NewBadRequestErr(err)
+	}
+
+	validationErrs := ctrl.customValidation.ValidateStruct(inputRequest)
+	if validationErrs != nil {
+		return utils.WithFieldErrors(
+			appErr.NewBadRequestErr(errors.New({{if .I18n}}msg{{.PascalCase}}ValidationFailed{{else}}consts.ErrValidationFailedMsg{{end}})),
+			validationErrs...,
+		)
+	}
+
+	// gocrud-gen:begin create-mapping
+	// TODO: Map inputRequest to a dto.{{.PascalCase}} struct.
+	// Example:
+	// entityDto := dto.{{.PascalCase}}{
+	// 	Name: inputRequest.Name,
+	// }
+	var entityDto dto.{{.PascalCase}}
+	// gocrud-gen:end create-mapping
+
+	createdEntity, err := ctrl.{{.CamelCase}}Service.Create{{.PascalCase}}(ctx, entityDto)
+	if err != nil {
+		return {{if .I18n}}localize{{.PascalCase}}Error(err){{else}}err{{end}}
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 201, {{else}}c.Status(201).JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   createdEntity,
+	}{{if .Envelope.IsSet}}){{end}})
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		List {{.PascalCase}}s
+// @Description	List {{.LowerCase}}s newest first. Pass the X-Next-Before header of a response as before to get the next page.
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			before	query		string	false	"Only {{.LowerCase}}s created before this RFC 3339 time"	format(date-time)
+// @Param			limit	query		int		false	"Page size"	minimum(1)	maximum({{index .AppendOnlyLimits 1}})	default({{index .AppendOnlyLimits 0}})
+// @Success		200		{{.SwagResponse (print "[]dto." .PascalCase)}}
+// @Header			200		{string}	X-Next-Before	"Cursor of the next page"
+// @Failure		400		{object}	{{.ErrorSchema}}
+// @Failure		500		{object}	{{.ErrorSchema}}
+// @Router			/api/v1/{{.KebabCase}}/ [get]
+{{else -}}
+// List{{.PascalCase}}s handles GET /api/v1/{{.KebabCase}}/.
+{{end -}}
+func (ctrl *{{.CamelCase}}Controller) List{{.PascalCase}}s(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "List{{.PascalCase}}s", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
+
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return err
+	}
+{{- end}}
+
+	limit := c.QueryInt("limit", {{.CamelCase}}DefaultLimit)
+	if limit < 1 || limit > {{.CamelCase}}MaxLimit {
+		return appErr.NewBadRequestErr(fmt.Errorf("limit must be between 1 and %d", {{.CamelCase}}MaxLimit))
+	}
+
+	var before time.Time
+	if raw := c.Query("before"); raw != "" {
+		var err error
+		if before, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+			return appErr.NewBadRequestErr(fmt.Errorf("before must be an RFC 3339 time: %w", err))
+		}
+	}
+
+	{{.CamelCase}}s, next, err := ctrl.{{.CamelCase}}Service.List{{.PascalCase}}s(ctx, before, limit)
+	if err != nil {
+		return err
+	}
+	if !next.IsZero() {
+		c.Set({{.CamelCase}}NextHeader, next.Format(time.RFC3339Nano))
+	}
+
+	resp := {{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   {{.CamelCase}}s,
+	}{{if .Envelope.IsSet}}){{end}}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, resp){{else}}c.JSON(resp){{end}}
+}
+`
+
+const appendOnlyIndexMigrationTemplate = `-- Keyset listing of {{.SnakeCase}} walks created_at newest first.
+ALTER TABLE {{.TableIdent}} ALTER COLUMN created_at SET DEFAULT now();
+ALTER TABLE {{.TableIdent}} ALTER COLUMN created_at SET NOT NULL;
+CREATE INDEX IF NOT EXISTS {{.SnakeCase}}_created_at_idx ON {{.TableIdent}} (created_at DESC);
+`
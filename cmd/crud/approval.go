@@ -0,0 +1,511 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+)
+
+// PendingChangeColumns renders the column definitions of the entity's pending
+// changes table, aligned like the hand-written migrations.
+func (d TemplateData) PendingChangeColumns() []string {
+	idType := "BIGINT"
+	if d.UUID() {
+		idType = "UUID"
+	}
+	return alignColumns([][3]string{
+		{"id", "BIGSERIAL", "PRIMARY KEY"},
+		{d.SnakeCase + "_id", idType, "NOT NULL"},
+		{"changes", "JSONB", "NOT NULL"},
+		{"status", "TEXT", "NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected'))"},
+		{"submitted_by", "TEXT", "NOT NULL"},
+		{"submitted_at", "TIMESTAMPTZ", "NOT NULL DEFAULT now()"},
+		{"reviewed_by", "TEXT", ""},
+		{"reviewed_at", "TIMESTAMPTZ", ""},
+		{"reason", "TEXT", ""},
+	})
+}
+
+// PendingChangeFields renders the fields of the entity's pending change DTO,
+// aligned like gofmt would.
+func (d TemplateData) PendingChangeFields() []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	for _, field := range [][3]string{
+		{"ID", "int64", "id"},
+		{"EntityID", d.IDGoType(), d.SnakeCase + "_id"},
+		{"Changes", d.PascalCase, "changes"},
+		{"Status", "string", "status"},
+		{"SubmittedBy", "string", "submitted_by"},
+		{"SubmittedAt", "time.Time", "submitted_at"},
+		{"ReviewedBy", "string", "reviewed_by,omitempty"},
+		{"ReviewedAt", "*time.Time", "reviewed_at,omitempty"},
+		{"Reason", "string", "reason,omitempty"},
+	} {
+		fmt.Fprintf(w, "%s\t%s\t`json:\"%s\"`\n", field[0], field[1], field[2])
+	}
+	w.Flush()
+	return splitLines(buf.String())
+}
+
+// --- APPROVAL TEMPLATES ---
+
+const pendingChangeMigrationTemplate = `-- {{.SnakeCase}}_pending_change holds the changes to {{.SnakeCase}} rows submitted for
+-- review. Approved changes are applied to the row; all are kept for audits.
+CREATE TABLE IF NOT EXISTS {{.SnakeCase}}_pending_change (
+{{- range .PendingChangeColumns}}
+    {{.}}
+{{- end}}
+);
+
+CREATE INDEX IF NOT EXISTS {{.SnakeCase}}_pending_change_status_idx ON {{.SnakeCase}}_pending_change (status, submitted_at);
+`
+
+// approvalDTOTemplate is shared by every entity, so it is generated once and
+// left alone on later runs.
+const approvalDTOTemplate = `package dto
+
+// The statuses of a change submitted for review.
+const (
+	ChangePending  = "pending"
+	ChangeApproved = "approved"
+	ChangeRejected = "rejected"
+)
+`
+
+const pendingChangeDTOTemplate = `package dto
+
+import (
+	"time"
+{{- if .UUID}}
+
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// {{.PascalCase}}Change is a change to a {{.LowerCase}} submitted for review: the
+// {{.LowerCase}} as it will be stored once a reviewer approves it.
+type {{.PascalCase}}Change struct {
+{{- range .PendingChangeFields}}
+	{{.}}
+{{- end}}
+}
+`
+
+const approvalInterfaceTemplate = `package repository
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// {{.PascalCase}}Approvals stores the changes to {{.LowerCase}}s submitted for review.
+type {{.PascalCase}}Approvals interface {
+	// CreateChange stores change as pending, filling in its id, status and
+	// submission time.
+	CreateChange(ctx context.Context, change *dto.{{.PascalCase}}Change) error
+	FindChange(ctx context.Context, id int64) (dto.{{.PascalCase}}Change, error)
+	// ReviewChange records the review of a pending change, failing with a
+	// conflict error when it was already reviewed.
+	ReviewChange(ctx context.Context, change *dto.{{.PascalCase}}Change) error
+}
+`
+
+const approvalRepositoryTemplate = `package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+)
+
+type {{.CamelCase}}Approvals struct {
+	db  ports.Database
+	log ports.LoggerWithTraceID
+}
+
+func New{{.PascalCase}}Approvals(db ports.Database, log ports.LoggerWithTraceID) repository.{{.PascalCase}}Approvals {
+	return &{{.CamelCase}}Approvals{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *{{.CamelCase}}Approvals) CreateChange(ctx context.Context, change *dto.{{.PascalCase}}Change) error {
+	// TODO: Insert the change on r.db, encoding change.Changes with
+	// json.Marshal, and fill in what the database generated.
+	// Example:
+	// INSERT INTO {{.SnakeCase}}_pending_change ({{.SnakeCase}}_id, changes, submitted_by) VALUES ($1, $2, $3) RETURNING id, status, submitted_at
+	return fmt.Errorf("{{.SnakeCase}} change submission is not implemented: %v", change.EntityID)
+}
+
+func (r *{{.CamelCase}}Approvals) FindChange(ctx context.Context, id int64) (dto.{{.PascalCase}}Change, error) {
+	// TODO: Select the change on r.db, decoding its changes into a
+	// dto.{{.PascalCase}} with json.Unmarshal, and return a not found error when
+	// no row matches.
+	// Example:
+	// SELECT * FROM {{.SnakeCase}}_pending_change WHERE id = $1
+	return dto.{{.PascalCase}}Change{}, fmt.Errorf("{{.SnakeCase}} change lookup is not implemented: %d", id)
+}
+
+func (r *{{.CamelCase}}Approvals) ReviewChange(ctx context.Context, change *dto.{{.PascalCase}}Change) error {
+	// TODO: Update the change on r.db only while it is pending, fill in the
+	// review time and return a conflict error when no row was affected.
+	// Example:
+	// UPDATE {{.SnakeCase}}_pending_change SET status = $2, reviewed_by = $3, reason = $4, reviewed_at = now() WHERE id = $1 AND status = 'pending' RETURNING reviewed_at
+	return fmt.Errorf("{{.SnakeCase}} change review is not implemented: %d", change.ID)
+}
+`
+
+const approvalServiceTemplate = `package service
+
+import (
+	"context"
+	"errors"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+)
+
+// {{.PascalCase}}Approvals gates the changes to {{.LowerCase}}s behind a review: operators
+// submit changes, which are applied only once another caller approves them.
+type {{.PascalCase}}Approvals interface {
+	SubmitChange(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}, submittedBy string) (dto.{{.PascalCase}}Change, error)
+	ApproveChange(ctx context.Context, id int64, reviewer string) (dto.{{.PascalCase}}, error)
+	RejectChange(ctx context.Context, id int64, reviewer, reason string) (dto.{{.PascalCase}}Change, error)
+}
+
+type {{.CamelCase}}ApprovalService struct {
+	log       ports.LoggerWithTraceID
+	approvals repository.{{.PascalCase}}Approvals
+	entities  {{.PascalCase}}
+}
+
+// New{{.PascalCase}}ApprovalService applies approved changes through entities, so they go
+// through the same hooks as direct updates.
+func New{{.PascalCase}}ApprovalService(log ports.LoggerWithTraceID, approvals repository.{{.PascalCase}}Approvals, entities {{.PascalCase}}) {{.PascalCase}}Approvals {
+	return &{{.CamelCase}}ApprovalService{
+		log:       log,
+		approvals: approvals,
+		entities:  entities,
+	}
+}
+
+func (s *{{.CamelCase}}ApprovalService) SubmitChange(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}, submittedBy string) (dto.{{.PascalCase}}Change, error) {
+	if _, err := s.entities.Get{{.PascalCase}}ByID(ctx, {{.CamelCase}}.ID); err != nil {
+		return dto.{{.PascalCase}}Change{}, err
+	}
+	change := dto.{{.PascalCase}}Change{EntityID: {{.CamelCase}}.ID, Changes: {{.CamelCase}}, SubmittedBy: submittedBy}
+	if err := s.approvals.CreateChange(ctx, &change); err != nil {
+		return dto.{{.PascalCase}}Change{}, err
+	}
+	return change, nil
+}
+
+// ApproveChange applies the change before recording its approval, so a failed
+// update leaves it pending. Applying the same change twice is harmless.
+func (s *{{.CamelCase}}ApprovalService) ApproveChange(ctx context.Context, id int64, reviewer string) (dto.{{.PascalCase}}, error) {
+	change, err := s.pendingChange(ctx, id, reviewer)
+	if err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	change.Changes.ID = change.EntityID
+	{{.CamelCase}}, err := s.entities.Update{{.PascalCase}}(ctx, change.Changes)
+	if err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	change.Status = dto.ChangeApproved
+	change.ReviewedBy = reviewer
+	if err := s.approvals.ReviewChange(ctx, &change); err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	return {{.CamelCase}}, nil
+}
+
+func (s *{{.CamelCase}}ApprovalService) RejectChange(ctx context.Context, id int64, reviewer, reason string) (dto.{{.PascalCase}}Change, error) {
+	change, err := s.pendingChange(ctx, id, reviewer)
+	if err != nil {
+		return dto.{{.PascalCase}}Change{}, err
+	}
+	change.Status = dto.ChangeRejected
+	change.ReviewedBy = reviewer
+	change.Reason = reason
+	if err := s.approvals.ReviewChange(ctx, &change); err != nil {
+		return dto.{{.PascalCase}}Change{}, err
+	}
+	return change, nil
+}
+
+// pendingChange loads the change reviewer is about to review, which must still
+// be pending and submitted by someone else.
+func (s *{{.CamelCase}}ApprovalService) pendingChange(ctx context.Context, id int64, reviewer string) (dto.{{.PascalCase}}Change, error) {
+	change, err := s.approvals.FindChange(ctx, id)
+	if err != nil {
+		return dto.{{.PascalCase}}Change{}, err
+	}
+	if change.Status != dto.ChangePending {
+		return dto.{{.PascalCase}}Change{}, appErr.NewConflictErr(errors.New("the change was already " + change.Status))
+	}
+	if change.SubmittedBy == reviewer {
+		return dto.{{.PascalCase}}Change{}, appErr.NewForbiddenErr(errors.New("a change cannot be reviewed by the caller who submitted it"))
+	}
+	return change, nil
+}
+`
+
+const approvalControllerTemplate = `package {{.LowerCase}}
+
+import (
+	"context"
+	"errors"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if not .I18n}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/consts"
+{{- end}}
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+	"{{.ServiceImport}}"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/validator"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/utils"
+	"go.elastic.co/apm"
+)
+
+// {{.CamelCase}}ReviewerRole is the role a caller needs to approve or reject changes
+// to {{.LowerCase}}s.
+const {{.CamelCase}}ReviewerRole = "{{.SnakeCase}}_reviewer"
+
+// {{.PascalCase}}Approvals serves the review of changes to {{.LowerCase}}s: operators
+// submit them and reviewers approve or reject them.
+type {{.PascalCase}}Approvals interface {
+	Submit{{.PascalCase}}Change(c *ports.HttpContext) error
+	Approve{{.PascalCase}}Change(c *ports.HttpContext) error
+	Reject{{.PascalCase}}Change(c *ports.HttpContext) error
+}
+
+type {{.CamelCase}}ApprovalsController struct {
+	approvals        service.{{.PascalCase}}Approvals
+	customValidation validator.CustomValidation
+	log              ports.LoggerWithTraceID
+}
+
+func NewApprovals(log ports.LoggerWithTraceID, approvals service.{{.PascalCase}}Approvals, customValidation validator.CustomValidation) {{.PascalCase}}Approvals {
+	return &{{.CamelCase}}ApprovalsController{
+		approvals:        approvals,
+		customValidation: customValidation,
+		log:              log,
+	}
+}
+
+// reject{{.PascalCase}}ChangeRequest explains why a change is rejected.
+type reject{{.PascalCase}}ChangeRequest struct {
+	Reason string ` + "`json:\"reason\" validate:\"required\"`" + `
+}
+{{- with .ResponseMapper}}
+
+// {{$.CamelCase}}ChangeResponse is a dto.{{$.PascalCase}}Change with its changes shaped
+// like the other {{$.PascalCase}} responses.
+type {{$.CamelCase}}ChangeResponse struct {
+	dto.{{$.PascalCase}}Change
+	Changes {{$.ResponseType}} ` + "`json:\"changes\"`" + `
+}
+
+func to{{$.PascalCase}}ChangeResponse(change dto.{{$.PascalCase}}Change) {{$.CamelCase}}ChangeResponse {
+	return {{$.CamelCase}}ChangeResponse{ {{- $.PascalCase}}Change: change, Changes: {{.}}(change.Changes)}
+}
+{{- end}}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Submit a change to a {{.PascalCase}}
+// @Description	This route will submit a change to a {{.LowerCase}} for review; it is applied once a reviewer approves it
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			id	path		{{.IDParam}}	true	"{{.PascalCase}} ID"{{if .UUID}}	format(uuid){{end}}
+// @Param			body	body		update{{.PascalCase}}Request	true	"Changed {{.PascalCase}}"
+// @Success		202	{{.SwagResponse (print "dto." .PascalCase "Change")}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		404	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			/api/v1/{{.KebabCase}}/{id}/changes [post]
+{{else -}}
+// Submit{{.PascalCase}}Change handles POST /api/v1/{{.KebabCase}}/{id}/changes.
+{{end -}}
+func (ctrl *{{.CamelCase}}ApprovalsController) Submit{{.PascalCase}}Change(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Submit{{.PascalCase}}Change", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return err
+	}
+	claims, _ := httpUtils.ClaimsFromContext(ctx)
+
+	id, err := {{if .UUID}}parse{{.PascalCase}}ID(c){{else}}c.ParamsInt("id"){{end}}
+	if err != nil {
+		return {{if .UUID}}err{{else}}appErr.NewBadRequestErr(err){{end}}
+	}
+
+	var inputRequest update{{.PascalCase}}Request
+	if err := c.BodyParser(&inputRequest); err != nil {
+		ctrl.log.Error(ctx, err.Error())
+		return appErr.NewBadRequestErr(err)
+	}
+
+	validationErrs := ctrl.customValidation.ValidateStruct(inputRequest)
+	if validationErrs != nil {
+		return utils.WithFieldErrors(
+			appErr.NewBadRequestErr(errors.New({{if .I18n}}msg{{.PascalCase}}ValidationFailed{{else}}consts.ErrValidationFailedMsg{{end}})),
+			validationErrs...,
+		)
+	}
+
+	// gocrud-gen:begin change-mapping
+	// TODO: Map inputRequest to a dto.{{.PascalCase}} struct, as in Update{{.PascalCase}}.
+	var entityDto dto.{{.PascalCase}}
+	// gocrud-gen:end change-mapping
+	entityDto.ID = {{.IDArg}} // Set ID from path
+
+	change, err := ctrl.approvals.SubmitChange(ctx, entityDto, claims.UserID)
+	if err != nil {
+		return {{if .I18n}}localize{{.PascalCase}}Error(err){{else}}err{{end}}
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 202, {{else}}c.Status(202).JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   {{if .ResponseMapper}}to{{.PascalCase}}ChangeResponse(change){{else}}change{{end}},
+	}{{if .Envelope.IsSet}}){{end}})
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Approve a change to a {{.PascalCase}}
+// @Description	This route will apply a pending change to a {{.LowerCase}}; the reviewer must not be its submitter
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			changeID	path		int	true	"Change ID"
+// @Success		200	{{.SwagResponse .ResponseType}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		403	{object}	{{.ErrorSchema}}
+// @Failure		404	{object}	{{.ErrorSchema}}
+// @Failure		409	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			/api/v1/{{.KebabCase}}/changes/{changeID}/approve [post]
+{{else -}}
+// Approve{{.PascalCase}}Change handles POST /api/v1/{{.KebabCase}}/changes/{changeID}/approve.
+{{end -}}
+func (ctrl *{{.CamelCase}}ApprovalsController) Approve{{.PascalCase}}Change(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Approve{{.PascalCase}}Change", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	ctx, reviewer, err := withReviewer(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	changeID, err := c.ParamsInt("changeID")
+	if err != nil {
+		return appErr.NewBadRequestErr(err)
+	}
+
+	result, err := ctrl.approvals.ApproveChange(ctx, int64(changeID), reviewer)
+	if err != nil {
+		return {{if .I18n}}localize{{.PascalCase}}Error(err){{else}}err{{end}}
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, {{else}}c.JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   {{with .ResponseMapper}}{{.}}(result){{else}}result{{end}},
+	}{{if .Envelope.IsSet}}){{end}})
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Reject a change to a {{.PascalCase}}
+// @Description	This route will reject a pending change to a {{.LowerCase}}; the reviewer must not be its submitter
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			changeID	path		int	true	"Change ID"
+// @Param			body	body		reject{{.PascalCase}}ChangeRequest	true	"Reason of the rejection"
+// @Success		200	{{.SwagResponse (print "dto." .PascalCase "Change")}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		403	{object}	{{.ErrorSchema}}
+// @Failure		404	{object}	{{.ErrorSchema}}
+// @Failure		409	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			/api/v1/{{.KebabCase}}/changes/{changeID}/reject [post]
+{{else -}}
+// Reject{{.PascalCase}}Change handles POST /api/v1/{{.KebabCase}}/changes/{changeID}/reject.
+{{end -}}
+func (ctrl *{{.CamelCase}}ApprovalsController) Reject{{.PascalCase}}Change(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Reject{{.PascalCase}}Change", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	ctx, reviewer, err := withReviewer(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	changeID, err := c.ParamsInt("changeID")
+	if err != nil {
+		return appErr.NewBadRequestErr(err)
+	}
+
+	var inputRequest reject{{.PascalCase}}ChangeRequest
+	if err := c.BodyParser(&inputRequest); err != nil {
+		ctrl.log.Error(ctx, err.Error())
+		return appErr.NewBadRequestErr(err)
+	}
+
+	validationErrs := ctrl.customValidation.ValidateStruct(inputRequest)
+	if validationErrs != nil {
+		return utils.WithFieldErrors(
+			appErr.NewBadRequestErr(errors.New({{if .I18n}}msg{{.PascalCase}}ValidationFailed{{else}}consts.ErrValidationFailedMsg{{end}})),
+			validationErrs...,
+		)
+	}
+
+	change, err := ctrl.approvals.RejectChange(ctx, int64(changeID), reviewer, inputRequest.Reason)
+	if err != nil {
+		return {{if .I18n}}localize{{.PascalCase}}Error(err){{else}}err{{end}}
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, {{else}}c.JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   {{if .ResponseMapper}}to{{.PascalCase}}ChangeResponse(change){{else}}change{{end}},
+	}{{if .Envelope.IsSet}}){{end}})
+}
+
+// withReviewer returns ctx carrying the caller's claims and their user id,
+// failing with 403 unless they have {{.CamelCase}}ReviewerRole.
+func withReviewer(ctx context.Context, c *ports.HttpContext) (context.Context, string, error) {
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return ctx, "", err
+	}
+	claims, _ := httpUtils.ClaimsFromContext(ctx)
+	if !claims.HasRole({{.CamelCase}}ReviewerRole) {
+		return ctx, "", appErr.NewForbiddenErr(errors.New("reviewing {{.LowerCase}} changes needs the " + {{.CamelCase}}ReviewerRole + " role"))
+	}
+	return ctx, claims.UserID, nil
+}
+`
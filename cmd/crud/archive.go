@@ -0,0 +1,104 @@
+package crud
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	archiveTarGz = "tar.gz"
+	archiveZip   = "zip"
+)
+
+// archiveFormat picks the format of the archive at path from its extension.
+func archiveFormat(path string) (string, error) {
+	switch {
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return archiveTarGz, nil
+	case strings.HasSuffix(path, ".zip"):
+		return archiveZip, nil
+	}
+	return "", fmt.Errorf("invalid --archive %q: must end in .tar.gz, .tgz or .zip", path)
+}
+
+// writeArchive writes files, keyed by their path in the project, into the
+// archive at path with the given mode. Entries are sorted so the same run
+// gives the same listing.
+func writeArchive(path string, files map[string][]byte, mode os.FileMode) error {
+	format, err := archiveFormat(path)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if format == archiveZip {
+		err = writeZip(out, files, mode)
+	} else {
+		err = writeTarGz(out, files, mode)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func writeTarGz(w io.Writer, files map[string][]byte, mode os.FileMode) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		header := &tar.Header{
+			Name:    filepath.ToSlash(name),
+			Mode:    int64(mode),
+			Size:    int64(len(files[name])),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeZip(w io.Writer, files map[string][]byte, mode os.FileMode) error {
+	zw := zip.NewWriter(w)
+	now := time.Now()
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		header := &zip.FileHeader{
+			Name:     filepath.ToSlash(name),
+			Method:   zip.Deflate,
+			Modified: now,
+		}
+		header.SetMode(mode)
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
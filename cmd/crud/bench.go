@@ -0,0 +1,67 @@
+package crud
+
+// --- BENCHMARK TEMPLATES ---
+
+const repositoryBenchTemplate = `package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+)
+
+const {{.CamelCase}}BenchRows = 10000
+
+func Benchmark{{.PascalCase}}RepositoryFindAll(b *testing.B) {
+	ctx := context.Background()
+	repo := new{{.PascalCase}}BenchRepository(b)
+	seed{{.PascalCase}}s(ctx, b, repo, {{.CamelCase}}BenchRows)
+
+	for _, pageSize := range []int{10, 100, 1000} {
+		for _, filtered := range []bool{false, true} {
+			pagination := {{.CamelCase}}BenchPagination(pageSize, filtered)
+			b.Run(fmt.Sprintf("page_size=%d/filtered=%t", pageSize, filtered), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, _, err := repo.FindAll(ctx, pagination); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func new{{.PascalCase}}BenchRepository(b *testing.B) repository.{{.PascalCase}} {
+	b.Helper()
+	if os.Getenv("TEST_DATABASE_URL") == "" {
+		b.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	// TODO: Open the test database and logger from TEST_DATABASE_URL the same way the application does.
+	var db ports.Database
+	var log ports.LoggerWithTraceID
+	return New{{.PascalCase}}Repository(db, log)
+}
+
+func seed{{.PascalCase}}s(ctx context.Context, b *testing.B, repo repository.{{.PascalCase}}, count int) {
+	b.Helper()
+	for i := 0; i < count; i++ {
+		// TODO: Vary the field values so filters select a realistic share of rows.
+		{{.CamelCase}} := dto.{{.PascalCase}}{}
+		if err := repo.Create(ctx, &{{.CamelCase}}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func {{.CamelCase}}BenchPagination(pageSize int, filtered bool) dto.Pagination {
+	// TODO: Set the page size and, when filtered is true, a representative filter.
+	return dto.Pagination{}
+}
+`
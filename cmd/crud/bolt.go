@@ -0,0 +1,495 @@
+package crud
+
+// BoltKey is the bolt.KeyFunc that encodes the field's values in the index
+// bucket of its filters.
+func (f FieldSpec) BoltKey() string {
+	switch f.Type {
+	case "int", "int64":
+		return "KeyInt"
+	case "float":
+		return "KeyFloat"
+	case "bool":
+		return "KeyBool"
+	case "time":
+		return "KeyTime"
+	default:
+		return "KeyString"
+	}
+}
+
+// --- BOLT TEMPLATES ---
+
+// boltStoreTemplate is shared by every entity, so it is generated once and
+// left alone on later runs.
+const boltStoreTemplate = `package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"go.etcd.io/bbolt"
+)
+
+// ErrNotFound is returned for unknown IDs. Align it with the error the
+// generic postgres repository returns if your handlers inspect it.
+var ErrNotFound = errors.New("bolt: record not found")
+
+// KeyFunc encodes a column value into index key bytes that sort like the
+// values. It is given the decoded JSON of stored rows, with numbers as
+// json.Number, and the parsed values of list filters.
+type KeyFunc func(value any) ([]byte, error)
+
+var (
+	KeyString KeyFunc = func(value any) ([]byte, error) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a string", value)
+		}
+		return []byte(s), nil
+	}
+	KeyInt KeyFunc = func(value any) ([]byte, error) {
+		var n int64
+		switch v := value.(type) {
+		case int64:
+			n = v
+		case json.Number:
+			var err error
+			if n, err = v.Int64(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%v is not an integer", value)
+		}
+		// Flipping the sign bit sorts negative numbers first.
+		return binary.BigEndian.AppendUint64(nil, uint64(n)^1<<63), nil
+	}
+	KeyFloat KeyFunc = func(value any) ([]byte, error) {
+		var f float64
+		switch v := value.(type) {
+		case float64:
+			f = v
+		case json.Number:
+			var err error
+			if f, err = v.Float64(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%v is not a number", value)
+		}
+		bits := math.Float64bits(f)
+		if math.Signbit(f) {
+			bits = ^bits
+		} else {
+			bits |= 1 << 63
+		}
+		return binary.BigEndian.AppendUint64(nil, bits), nil
+	}
+	KeyBool KeyFunc = func(value any) ([]byte, error) {
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a boolean", value)
+		}
+		if b {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	}
+	KeyTime KeyFunc = func(value any) ([]byte, error) {
+		t, ok := value.(time.Time)
+		if s, isString := value.(string); isString {
+			var err error
+			if t, err = time.Parse(time.RFC3339Nano, s); err != nil {
+				return nil, err
+			}
+			ok = true
+		}
+		if !ok {
+			return nil, fmt.Errorf("%v is not a time", value)
+		}
+		return KeyInt(t.UnixNano())
+	}
+)
+
+// Index keeps a bucket of the values of Column, keyed by the encoded value and
+// the row's key, which list filters on Column scan instead of every row.
+type Index struct {
+	Column string
+	Key    KeyFunc
+}
+
+// Store keeps JSON-encoded rows in a bucket, keyed so that they sort by id,
+// and maintains an index bucket per Index.
+type Store struct {
+	db      *bbolt.DB
+	bucket  []byte
+	indexes []Index
+}
+
+// NewStore creates bucket and its index buckets, named bucket_by_column, if
+// they do not exist.
+func NewStore(db *bbolt.DB, bucket string, indexes ...Index) (*Store, error) {
+	s := &Store{db: db, bucket: []byte(bucket), indexes: indexes}
+	err := db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(s.bucket); err != nil {
+			return err
+		}
+		for _, index := range indexes {
+			if _, err := tx.CreateBucketIfNotExists(s.indexBucket(index)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) indexBucket(index Index) []byte {
+	return []byte(string(s.bucket) + "_by_" + index.Column)
+}
+
+// Get decodes the row stored under key into row.
+func (s *Store) Get(key []byte, row any) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(s.bucket).Get(key)
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, row)
+	})
+}
+
+// Create stores row under the key assign returns. assign is given the next
+// value of the bucket's sequence and sets the row's id before it is encoded.
+func (s *Store) Create(row any, assign func(sequence uint64) []byte) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		sequence, err := tx.Bucket(s.bucket).NextSequence()
+		if err != nil {
+			return err
+		}
+		return s.put(tx, assign(sequence), row)
+	})
+}
+
+// Update replaces the row stored under key, which must exist.
+func (s *Store) Update(key []byte, row any) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(s.bucket).Get(key) == nil {
+			return ErrNotFound
+		}
+		return s.put(tx, key, row)
+	})
+}
+
+func (s *Store) Delete(key []byte) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(s.bucket).Get(key) == nil {
+			return ErrNotFound
+		}
+		if err := s.unindex(tx, key); err != nil {
+			return err
+		}
+		return tx.Bucket(s.bucket).Delete(key)
+	})
+}
+
+// List passes up to limit rows that match every filter to decode, in key
+// order and starting after the key after, and returns the key of the last.
+// Filters on columns without an Index are rejected.
+func (s *Store) List(filters []dto.Filter, after []byte, limit int, decode func(data []byte) error) ([]byte, error) {
+	var last []byte
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		// matches stays nil without filters, letting every row through.
+		var matches map[string]bool
+		for _, filter := range filters {
+			keys, err := s.match(tx, filter)
+			if err != nil {
+				return err
+			}
+			if matches == nil {
+				matches = keys
+				continue
+			}
+			for key := range matches {
+				if !keys[key] {
+					delete(matches, key)
+				}
+			}
+		}
+
+		cursor := tx.Bucket(s.bucket).Cursor()
+		key, data := cursor.First()
+		if len(after) > 0 {
+			key, data = cursor.Seek(after)
+			if bytes.Equal(key, after) {
+				key, data = cursor.Next()
+			}
+		}
+		for n := 0; key != nil && n < limit; key, data = cursor.Next() {
+			if matches != nil && !matches[string(key)] {
+				continue
+			}
+			if err := decode(data); err != nil {
+				return err
+			}
+			last = bytes.Clone(key)
+			n++
+		}
+		return nil
+	})
+	return last, err
+}
+
+// put writes row under key and replaces its index entries.
+func (s *Store) put(tx *bbolt.Tx, key []byte, row any) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if err := s.unindex(tx, key); err != nil {
+		return err
+	}
+	if err := tx.Bucket(s.bucket).Put(key, data); err != nil {
+		return err
+	}
+	return s.forEachIndexKey(data, key, func(index Index, indexKey []byte) error {
+		return tx.Bucket(s.indexBucket(index)).Put(indexKey, key)
+	})
+}
+
+// unindex removes the index entries of the row stored under key, if any.
+func (s *Store) unindex(tx *bbolt.Tx, key []byte) error {
+	data := tx.Bucket(s.bucket).Get(key)
+	if data == nil {
+		return nil
+	}
+	return s.forEachIndexKey(data, key, func(index Index, indexKey []byte) error {
+		return tx.Bucket(s.indexBucket(index)).Delete(indexKey)
+	})
+}
+
+// forEachIndexKey calls fn with the index entry of each indexed column of the
+// JSON row stored under key: the encoded value, a zero byte and the key. Null
+// and missing columns are not indexed, so no filter matches them.
+func (s *Store) forEachIndexKey(data, key []byte, fn func(index Index, indexKey []byte) error) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var columns map[string]any
+	if err := decoder.Decode(&columns); err != nil {
+		return err
+	}
+	for _, index := range s.indexes {
+		value := columns[index.Column]
+		if value == nil {
+			continue
+		}
+		encoded, err := index.Key(value)
+		if err != nil {
+			return fmt.Errorf("bolt: index %s: %w", index.Column, err)
+		}
+		if err := fn(index, append(append(encoded, 0), key...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match returns the keys of the rows whose column satisfies filter, scanning
+// its index from the smallest value the filter accepts.
+func (s *Store) match(tx *bbolt.Tx, filter dto.Filter) (map[string]bool, error) {
+	i := slices.IndexFunc(s.indexes, func(index Index) bool { return index.Column == filter.Column })
+	if i < 0 {
+		return nil, fmt.Errorf("bolt: %s of %s has no index to filter on", filter.Column, s.bucket)
+	}
+	index := s.indexes[i]
+	values := make([][]byte, len(filter.Values))
+	for j, value := range filter.Values {
+		encoded, err := index.Key(value)
+		if err != nil {
+			return nil, fmt.Errorf("bolt: filter %s: %w", filter.Column, err)
+		}
+		values[j] = encoded
+	}
+
+	keys := map[string]bool{}
+	cursor := tx.Bucket(s.indexBucket(index)).Cursor()
+	indexKey, key := cursor.First()
+	switch filter.Operator {
+	case "eq", "gt", "gte", "between":
+		indexKey, key = cursor.Seek(values[0])
+	case "in":
+		indexKey, key = cursor.Seek(slices.MinFunc(values, bytes.Compare))
+	}
+	for ; indexKey != nil; indexKey, key = cursor.Next() {
+		match, beyond := matchValue(filter.Operator, indexKey[:len(indexKey)-len(key)-1], values)
+		if beyond {
+			break
+		}
+		if match {
+			keys[string(key)] = true
+		}
+	}
+	return keys, nil
+}
+
+// matchValue reports whether the encoded value satisfies operator with the
+// encoded filter values, and whether it is beyond every value that can, which
+// ends the scan of the index.
+func matchValue(operator string, value []byte, values [][]byte) (match, beyond bool) {
+	switch operator {
+	case "eq":
+		c := bytes.Compare(value, values[0])
+		return c == 0, c > 0
+	case "neq":
+		return !bytes.Equal(value, values[0]), false
+	case "gt":
+		return bytes.Compare(value, values[0]) > 0, false
+	case "gte":
+		return bytes.Compare(value, values[0]) >= 0, false
+	case "between":
+		return bytes.Compare(value, values[0]) >= 0, bytes.Compare(value, values[1]) > 0
+	case "in":
+		return slices.ContainsFunc(values, func(v []byte) bool { return bytes.Equal(value, v) }),
+			bytes.Compare(value, slices.MaxFunc(values, bytes.Compare)) > 0
+	case "like":
+		return like(string(value), string(values[0])), false
+	}
+	return false, true
+}
+
+// like matches s against a SQL LIKE pattern, in which % stands for any run of
+// characters and _ for a single one.
+func like(s, pattern string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch pattern[0] {
+	case '%':
+		for i := 0; i <= len(s); i++ {
+			if like(s[i:], pattern[1:]) {
+				return true
+			}
+		}
+		return false
+	case '_':
+		_, size := utf8.DecodeRuneInString(s)
+		return s != "" && like(s[size:], pattern[1:])
+	}
+	return s != "" && s[0] == pattern[0] && like(s[1:], pattern[1:])
+}
+`
+
+const boltRepositoryTemplate = `package bolt
+
+import (
+	"cmp"
+	"context"
+{{- if not .UUID}}
+	"encoding/binary"
+{{- end}}
+	"encoding/json"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+	"go.etcd.io/bbolt"
+)
+
+// {{.CamelCase}}DefaultPageSize is the page size of list requests without one.
+const {{.CamelCase}}DefaultPageSize = 20
+
+var _ repository.{{.PascalCase}} = (*{{.PascalCase}}Repository)(nil)
+
+// {{.PascalCase}}Repository stores {{.LowerCase}}s as JSON in the "{{.SnakeCase}}" bucket, keyed by
+// id{{if .FilterFields}}, and indexes the columns the list endpoint filters on{{end}}.
+type {{.PascalCase}}Repository struct {
+	store *Store
+}
+
+func New{{.PascalCase}}Repository(db *bbolt.DB) (*{{.PascalCase}}Repository, error) {
+	store, err := NewStore(db, "{{.SnakeCase}}"{{range .FilterFields}},
+		Index{Column: "{{.Name}}", Key: {{.BoltKey}}}
+{{- end}})
+	if err != nil {
+		return nil, err
+	}
+	return &{{.PascalCase}}Repository{store: store}, nil
+}
+
+// {{.CamelCase}}Key encodes id into a key that sorts like the ids.
+func {{.CamelCase}}Key(id {{.IDGoType}}) []byte {
+{{- if .UUID}}
+	return id[:]
+{{- else}}
+	return binary.BigEndian.AppendUint64(nil, uint64(id))
+{{- end}}
+}
+
+func (r *{{.PascalCase}}Repository) GetByID(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error) {
+	var {{.CamelCase}} dto.{{.PascalCase}}
+	err := r.store.Get({{.CamelCase}}Key(id), &{{.CamelCase}})
+	return {{.CamelCase}}, err
+}
+
+// Create stores {{.CamelCase}} under {{if .UUID}}a random id{{else}}the next id of the bucket's sequence{{end}}.
+func (r *{{.PascalCase}}Repository) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	return r.store.Create({{.CamelCase}}, func(sequence uint64) []byte {
+{{- if .UUID}}
+		{{.CamelCase}}.ID = uuid.New()
+{{- else}}
+		{{.CamelCase}}.ID = int64(sequence)
+{{- end}}
+		return {{.CamelCase}}Key({{.CamelCase}}.ID)
+	})
+}
+
+func (r *{{.PascalCase}}Repository) Update(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	return r.store.Update({{.CamelCase}}Key({{.CamelCase}}.ID), {{.CamelCase}})
+}
+
+func (r *{{.PascalCase}}Repository) Delete(ctx context.Context, id {{.IDGoType}}) error {
+	return r.store.Delete({{.CamelCase}}Key(id))
+}
+
+// FindAll returns one page of {{.LowerCase}}s in id order{{if .FilterFields}} that match the filters
+// attached to ctx{{end}}. It starts after the cursor attached to ctx with
+// dto.WithPageState and sets the cursor of the next page on it; page numbers
+// and sorting are ignored.
+func (r *{{.PascalCase}}Repository) FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+	size := cmp.Or(pagination.PageSize, {{.CamelCase}}DefaultPageSize)
+	state := dto.PageStateFromContext(ctx)
+	var after []byte
+	if state != nil {
+		after = state.Current
+	}
+
+	var {{.CamelCase}}s []dto.{{.PascalCase}}
+	last, err := r.store.List(dto.FiltersFromContext(ctx), after, size, func(data []byte) error {
+		var {{.CamelCase}} dto.{{.PascalCase}}
+		if err := json.Unmarshal(data, &{{.CamelCase}}); err != nil {
+			return err
+		}
+		{{.CamelCase}}s = append({{.CamelCase}}s, {{.CamelCase}})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if state != nil && len({{.CamelCase}}s) == size {
+		state.Next = last
+	}
+	return {{.CamelCase}}s, &pagination, nil
+}
+`
@@ -0,0 +1,452 @@
+package crud
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// CassandraSpec lays out the entity's table for --db cassandra:
+//
+//	cassandra:
+//	  partition_key: [tenant_id]            # default: id
+//	  clustering_key: [placed_at desc, id]  # each column optionally asc or desc
+//
+// The partition key decides which rows are stored together and the clustering
+// key orders them within a partition. id must be part of the primary key, so
+// rows are unique by id.
+type CassandraSpec struct {
+	PartitionKey  []string `yaml:"partition_key"`
+	ClusteringKey []string `yaml:"clustering_key"`
+}
+
+var clusteringColumn = regexp.MustCompile(`^([a-z][a-z0-9_]*)(?: (asc|desc))?$`)
+
+func (c CassandraSpec) validate(entity EntitySpec) error {
+	columns := map[string]FieldSpec{"id": {Name: "id", Type: "uuid"}}
+	for _, field := range entity.Fields {
+		columns[field.Name] = field
+	}
+	for _, relation := range entity.Relations {
+		if relation.Kind == relationBelongsTo {
+			columns[relation.ForeignKey()] = FieldSpec{Name: relation.ForeignKey(), Type: "int64"}
+		}
+	}
+
+	if len(c.PartitionKey) == 0 && len(c.ClusteringKey) > 0 {
+		return fmt.Errorf("%s: cassandra clustering_key needs a partition_key", entity.Name)
+	}
+	key := slices.Clone(c.PartitionKey)
+	for _, column := range c.ClusteringKey {
+		match := clusteringColumn.FindStringSubmatch(column)
+		if match == nil {
+			return fmt.Errorf("%s: cassandra clustering column %q must be a column optionally followed by asc or desc", entity.Name, column)
+		}
+		key = append(key, match[1])
+	}
+	for i, column := range key {
+		field, ok := columns[column]
+		switch {
+		case !ok:
+			return fmt.Errorf("%s: cassandra key column %q is not a field", entity.Name, column)
+		case field.Nullable:
+			return fmt.Errorf("%s: cassandra key column %s must not be nullable", entity.Name, column)
+		case field.Type == "json":
+			return fmt.Errorf("%s: cassandra key column %s cannot be a json field", entity.Name, column)
+		case slices.Contains(key[:i], column):
+			return fmt.Errorf("%s: cassandra key column %s is listed more than once", entity.Name, column)
+		}
+	}
+	if len(key) > 0 && !slices.Contains(key, "id") {
+		return fmt.Errorf("%s: cassandra primary key must include id", entity.Name)
+	}
+	return nil
+}
+
+// cassandraTypes maps a spec field type to its CQL column type.
+var cassandraTypes = map[string]string{
+	"string": "text",
+	"text":   "text",
+	"int":    "int",
+	"int64":  "bigint",
+	"float":  "double",
+	"bool":   "boolean",
+	"time":   "timestamp",
+	"uuid":   "uuid",
+	"json":   "text",
+}
+
+// cassandraSpec is the entity's table layout, keyed by id alone by default.
+func (d TemplateData) cassandraSpec() CassandraSpec {
+	if d.Entity.Cassandra == nil || len(d.Entity.Cassandra.PartitionKey) == 0 {
+		return CassandraSpec{PartitionKey: []string{"id"}}
+	}
+	return *d.Entity.Cassandra
+}
+
+// CassandraKeyColumns lists the columns of the entity's primary key, partition
+// key first.
+func (d TemplateData) CassandraKeyColumns() []string {
+	spec := d.cassandraSpec()
+	columns := slices.Clone(spec.PartitionKey)
+	for _, column := range spec.ClusteringKey {
+		columns = append(columns, clusteringColumn.FindStringSubmatch(column)[1])
+	}
+	return columns
+}
+
+// CassandraKeyList is the comma separated CassandraKeyColumns.
+func (d TemplateData) CassandraKeyList() string {
+	return strings.Join(d.CassandraKeyColumns(), ", ")
+}
+
+// CassandraPartitionedByID reports whether id is the whole partition key, so
+// rows are found by id without an index on it.
+func (d TemplateData) CassandraPartitionedByID() bool {
+	return slices.Equal(d.cassandraSpec().PartitionKey, []string{"id"})
+}
+
+// CassandraPrimaryKey is the PRIMARY KEY clause of the entity's table.
+func (d TemplateData) CassandraPrimaryKey() string {
+	spec := d.cassandraSpec()
+	key := "(" + strings.Join(spec.PartitionKey, ", ") + ")"
+	for _, column := range spec.ClusteringKey {
+		key += ", " + clusteringColumn.FindStringSubmatch(column)[1]
+	}
+	return "PRIMARY KEY (" + key + ")"
+}
+
+// CassandraClusteringOrder is the CLUSTERING ORDER BY list of the entity's
+// table, or "" without a clustering key.
+func (d TemplateData) CassandraClusteringOrder() string {
+	var order []string
+	for _, column := range d.cassandraSpec().ClusteringKey {
+		match := clusteringColumn.FindStringSubmatch(column)
+		order = append(order, match[1]+" "+strings.ToUpper(cmp.Or(match[2], "asc")))
+	}
+	return strings.Join(order, ", ")
+}
+
+// CassandraColumns renders the column definitions of the entity's table from
+// the spec, aligned on the type: the id, the fields and the foreign keys of
+// belongs_to relations. Cassandra has no defaults or checks; the request
+// validation still mirrors the checks.
+func (d TemplateData) CassandraColumns() []string {
+	type column struct{ name, definition string }
+	columns := []column{{"id", "uuid"}}
+	for _, field := range d.Entity.Fields {
+		columns = append(columns, column{field.Name, cassandraTypes[field.Type]})
+	}
+	for _, relation := range d.Entity.Relations {
+		if relation.Kind == relationBelongsTo {
+			columns = append(columns, column{relation.ForeignKey(), "bigint"})
+		}
+	}
+
+	width := 0
+	for _, c := range columns {
+		width = max(width, len(c.name))
+	}
+	rendered := make([]string, 0, len(columns))
+	for _, c := range columns {
+		rendered = append(rendered, fmt.Sprintf("%-*s %s", width, c.name, c.definition))
+	}
+	return rendered
+}
+
+// CassandraTimeColumns lists the entity's timestamp columns, whose values are
+// cut to the milliseconds Cassandra stores before they are written.
+func (d TemplateData) CassandraTimeColumns() []string {
+	var columns []string
+	for _, field := range d.Entity.Fields {
+		if field.Type == "time" {
+			columns = append(columns, field.Name)
+		}
+	}
+	return columns
+}
+
+// --- CASSANDRA TEMPLATES ---
+
+// The Cassandra templates store the entity with gocql. Rows are written and
+// read as JSON, so the columns follow the json tags of the DTO, and the list
+// endpoint pages with Cassandra's paging state instead of page numbers.
+
+const cassandraRepositoryTemplate = `package cassandra
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+{{- if .CassandraTimeColumns}}
+	"time"
+{{- end}}
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+	"github.com/gocql/gocql"
+	"github.com/google/uuid"
+)
+
+// {{.CamelCase}}DefaultPageSize is the page size of list requests without one.
+const {{.CamelCase}}DefaultPageSize = 20
+
+const (
+	{{.CamelCase}}SelectQuery = ` + "`" + `SELECT * FROM {{.TableIdent}} WHERE id = ?` + "`" + `
+	// {{.CamelCase}}KeyQuery reads the primary key of a row as JSON, which updates
+	// and deletes address it by.
+	{{.CamelCase}}KeyQuery    = ` + "`" + `SELECT JSON {{.CassandraKeyList}} FROM {{.TableIdent}} WHERE id = fromJson(?)` + "`" + `
+	{{.CamelCase}}InsertQuery = ` + "`" + `INSERT INTO {{.TableIdent}} JSON ?` + "`" + `
+	// {{.CamelCase}}UpdateQuery leaves the columns missing from the JSON unchanged.
+	{{.CamelCase}}UpdateQuery = ` + "`" + `INSERT INTO {{.TableIdent}} JSON ? DEFAULT UNSET` + "`" + `
+	{{.CamelCase}}DeleteQuery = ` + "`" + `DELETE FROM {{.TableIdent}} WHERE {{range $i, $column := .CassandraKeyColumns}}{{if $i}} AND {{end}}{{$column}} = fromJson(?){{end}}` + "`" + `
+	{{.CamelCase}}ListQuery   = ` + "`" + `SELECT * FROM {{.TableIdent}}` + "`" + `
+)
+{{- if .CassandraTimeColumns}}
+
+// {{.CamelCase}}TimeColumns are the timestamp columns, which Cassandra only
+// accepts to the millisecond.
+var {{.CamelCase}}TimeColumns = []string{ {{- range $i, $column := .CassandraTimeColumns}}{{if $i}}, {{end}}"{{$column}}"{{end -}} }
+{{- end}}
+
+type {{.CamelCase}}Repository struct {
+	session *gocql.Session
+}
+
+// New{{.PascalCase}}Repository returns a repository on session, which must use the
+// keyspace of the {{.SnakeCase}} table. Unknown ids are reported as gocql.ErrNotFound.
+func New{{.PascalCase}}Repository(session *gocql.Session) repository.{{.PascalCase}} {
+	return &{{.CamelCase}}Repository{session: session}
+}
+
+func (r *{{.CamelCase}}Repository) GetByID(ctx context.Context, id uuid.UUID) (dto.{{.PascalCase}}, error) {
+	row := map[string]any{}
+	if err := r.session.Query({{.CamelCase}}SelectQuery, id.String()).WithContext(ctx).MapScan(row); err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	return decode{{.PascalCase}}(row)
+}
+
+// Create assigns {{.CamelCase}} a random id and inserts it.
+func (r *{{.CamelCase}}Repository) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	values, err := encode{{.PascalCase}}({{.CamelCase}})
+	if err != nil {
+		return err
+	}
+	if values["id"], err = json.Marshal(uuid.New()); err != nil {
+		return err
+	}
+	row, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	if err := r.session.Query({{.CamelCase}}InsertQuery, string(row)).WithContext(ctx).Exec(); err != nil {
+		return err
+	}
+	return json.Unmarshal(row, {{.CamelCase}})
+}
+
+// Update overwrites the columns of the row with the id of {{.CamelCase}}. Primary key
+// columns cannot change and keep their stored values.
+func (r *{{.CamelCase}}Repository) Update(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	values, err := encode{{.PascalCase}}({{.CamelCase}})
+	if err != nil {
+		return err
+	}
+	key, err := r.key(ctx, values["id"])
+	if err != nil {
+		return err
+	}
+	for column, value := range key {
+		values[column] = value
+	}
+	row, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return r.session.Query({{.CamelCase}}UpdateQuery, string(row)).WithContext(ctx).Exec()
+}
+
+func (r *{{.CamelCase}}Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	rawID, err := json.Marshal(id)
+	if err != nil {
+		return err
+	}
+	key, err := r.key(ctx, rawID)
+	if err != nil {
+		return err
+	}
+	return r.session.Query({{.CamelCase}}DeleteQuery{{range .CassandraKeyColumns}}, string(key["{{.}}"]){{end}}).WithContext(ctx).Exec()
+}
+
+// FindAll returns one page of rows in token order{{if .CassandraClusteringOrder}},
+// clustered by {{.CassandraClusteringOrder}} within a partition{{end}}. It starts at
+// the paging state attached to ctx with dto.WithPageState and sets the state
+// of the next page on it; page numbers and sorting are ignored.
+func (r *{{.CamelCase}}Repository) FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+	state := dto.PageStateFromContext(ctx)
+	var current []byte
+	if state != nil {
+		current = state.Current
+	}
+	// Setting the paging state, even to nil, stops gocql fetching later pages.
+	iter := r.session.Query({{.CamelCase}}ListQuery).
+		WithContext(ctx).
+		PageSize(cmp.Or(pagination.PageSize, {{.CamelCase}}DefaultPageSize)).
+		PageState(current).
+		Iter()
+
+	var {{.CamelCase}}s []dto.{{.PascalCase}}
+	for row := map[string]any{}; iter.MapScan(row); row = map[string]any{} {
+		{{.CamelCase}}, err := decode{{.PascalCase}}(row)
+		if err != nil {
+			iter.Close()
+			return nil, nil, err
+		}
+		{{.CamelCase}}s = append({{.CamelCase}}s, {{.CamelCase}})
+	}
+	next := iter.PageState()
+	if err := iter.Close(); err != nil {
+		return nil, nil, err
+	}
+	if state != nil {
+		state.Next = next
+	}
+	return {{.CamelCase}}s, &pagination, nil
+}
+
+// key returns the primary key columns of the row with the JSON id as JSON
+// values.
+func (r *{{.CamelCase}}Repository) key(ctx context.Context, id json.RawMessage) (map[string]json.RawMessage, error) {
+	var row string
+	if err := r.session.Query({{.CamelCase}}KeyQuery, string(id)).WithContext(ctx).Scan(&row); err != nil {
+		return nil, err
+	}
+	var key map[string]json.RawMessage
+	return key, json.Unmarshal([]byte(row), &key)
+}
+
+// encode{{.PascalCase}} returns the columns of {{.CamelCase}} as JSON values.
+func encode{{.PascalCase}}({{.CamelCase}} *dto.{{.PascalCase}}) (map[string]json.RawMessage, error) {
+	row, err := json.Marshal({{.CamelCase}})
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(row, &values); err != nil {
+		return nil, err
+	}
+{{- if .CassandraTimeColumns}}
+	for _, column := range {{.CamelCase}}TimeColumns {
+		var t time.Time
+		if err := json.Unmarshal(values[column], &t); err != nil || t.IsZero() {
+			continue
+		}
+		if values[column], err = json.Marshal(t.UTC().Format("2006-01-02T15:04:05.000Z")); err != nil {
+			return nil, err
+		}
+	}
+{{- end}}
+	return values, nil
+}
+
+// decode{{.PascalCase}} converts a row read by gocql, whose values encode to JSON as
+// the DTO expects them.
+func decode{{.PascalCase}}(row map[string]any) (dto.{{.PascalCase}}, error) {
+	var {{.CamelCase}} dto.{{.PascalCase}}
+	encoded, err := json.Marshal(row)
+	if err != nil {
+		return {{.CamelCase}}, err
+	}
+	return {{.CamelCase}}, json.Unmarshal(encoded, &{{.CamelCase}})
+}
+`
+
+// pageStateDTOTemplate is shared by every entity, so it is generated once and
+// left alone on later runs.
+const pageStateDTOTemplate = `package dto
+
+import "context"
+
+// PageState carries the paging state of a list request from the controller to
+// a repository paging with database cursors, and the state of the next page
+// back. Next is empty on the last page.
+type PageState struct {
+	Current []byte
+	Next    []byte
+}
+
+type pageStateKey struct{}
+
+// WithPageState returns a copy of ctx carrying state.
+func WithPageState(ctx context.Context, state *PageState) context.Context {
+	return context.WithValue(ctx, pageStateKey{}, state)
+}
+
+// PageStateFromContext returns the state attached by WithPageState, or nil.
+func PageStateFromContext(ctx context.Context) *PageState {
+	state, _ := ctx.Value(pageStateKey{}).(*PageState)
+	return state
+}
+`
+
+// pageStateParserTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const pageStateParserTemplate = `package httpUtils
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+const (
+	// PageStateParam is the query parameter carrying the paging state of the
+	// requested page.
+	PageStateParam = "page_state"
+	// NextPageStateHeader carries the paging state of the next page of a list
+	// response; it is absent on the last page.
+	NextPageStateHeader = "X-Next-Page-State"
+)
+
+// ParsePageState reads the paging state of a list request, which is empty for
+// the first page.
+func ParsePageState(c *ports.HttpContext) (*dto.PageState, error) {
+	current, err := base64.RawURLEncoding.DecodeString(c.Query(PageStateParam))
+	if err != nil {
+		return nil, appErr.NewBadRequestErr(fmt.Errorf("invalid %s: %w", PageStateParam, err))
+	}
+	return &dto.PageState{Current: current}, nil
+}
+
+// SetNextPageState sets the NextPageStateHeader of a list response, unless it
+// is the last page.
+func SetNextPageState(c *ports.HttpContext, state *dto.PageState) {
+	if len(state.Next) > 0 {
+		c.Set(NextPageStateHeader, base64.RawURLEncoding.EncodeToString(state.Next))
+	}
+}
+`
+
+const cassandraTableMigrationTemplate = `-- {{.SnakeCase}} is keyed by {{.CassandraKeyList}}.
+CREATE TABLE IF NOT EXISTS {{.TableIdent}} (
+{{- if not .Entity.Fields}}
+    -- TODO: Add the {{.SnakeCase}} columns.
+{{- end}}
+{{- range .CassandraColumns}}
+    {{.}},
+{{- end}}
+    {{.CassandraPrimaryKey}}
+){{with .CassandraClusteringOrder}} WITH CLUSTERING ORDER BY ({{.}}){{end}};
+{{- if not .CassandraPartitionedByID}}
+
+-- Reads, updates and deletes by id find the row through this index, as id is
+-- not the whole partition key.
+CREATE INDEX IF NOT EXISTS {{.SnakeCase}}_id_idx ON {{.TableIdent}} (id);
+{{- end}}
+`
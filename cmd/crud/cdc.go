@@ -0,0 +1,228 @@
+package crud
+
+const (
+	cdcNotify   = "notify"
+	cdcDebezium = "debezium"
+)
+
+// --- CHANGE DATA CAPTURE TEMPLATES ---
+
+// cdcOpTemplate is shared by every entity, so it is generated once and left
+// alone on later runs.
+const cdcOpTemplate = `package cdc
+
+// Op is the kind of change made to a row.
+type Op string
+
+const (
+	OpInsert Op = "insert"
+	OpUpdate Op = "update"
+	OpDelete Op = "delete"
+)
+`
+
+const cdcChangeTemplate = `package cdc
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// {{.PascalCase}}Change is a committed change to a {{.LowerCase}} row. Row is the row
+// after an insert or update and before a delete; it is nil when the change
+// arrived without it and has to be loaded by ID.
+type {{.PascalCase}}Change struct {
+	Op  Op
+	ID  {{.IDGoType}}
+	Row *dto.{{.PascalCase}}
+}
+
+// {{.PascalCase}}ChangeHandler applies a change, e.g. to a cache or a search index.
+// Changes of one row arrive in commit order.
+type {{.PascalCase}}ChangeHandler func(ctx context.Context, change {{.PascalCase}}Change) error
+`
+
+const cdcNotifyMigrationTemplate = `-- {{.SnakeCase}}_notify publishes every committed change of a {{.SnakeCase}} row on the
+-- {{.SnakeCase}}_changes channel. Notifications are limited to 8000 bytes, so the
+-- row is left out when it does not fit and listeners load it by id.
+CREATE OR REPLACE FUNCTION {{.SnakeCase}}_notify() RETURNS TRIGGER AS $$
+DECLARE
+    changed {{.TableIdent}}%ROWTYPE;
+    payload TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+    payload := json_build_object('op', lower(TG_OP), 'id', changed.id, 'row', to_jsonb(changed))::text;
+    IF octet_length(payload) >= 8000 THEN
+        payload := json_build_object('op', lower(TG_OP), 'id', changed.id)::text;
+    END IF;
+    PERFORM pg_notify('{{.SnakeCase}}_changes', payload);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS {{.SnakeCase}}_notify ON {{.TableIdent}};
+CREATE TRIGGER {{.SnakeCase}}_notify
+    AFTER INSERT OR UPDATE OR DELETE ON {{.TableIdent}}
+    FOR EACH ROW EXECUTE FUNCTION {{.SnakeCase}}_notify();
+`
+
+const cdcListenerTemplate = `package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if .RedactedFields}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/redact"
+{{- end}}
+	"github.com/lib/pq"
+)
+
+// {{.PascalCase}}Channel is the channel the {{.SnakeCase}}_notify trigger publishes on.
+const {{.PascalCase}}Channel = "{{.SnakeCase}}_changes"
+
+type {{.PascalCase}}Listener struct {
+	dsn     string
+	handler {{.PascalCase}}ChangeHandler
+	log     ports.LoggerWithTraceID
+}
+
+// New{{.PascalCase}}Listener returns a listener for the database at dsn. LISTEN
+// needs a dedicated connection, so it does not use the application's pool.
+func New{{.PascalCase}}Listener(dsn string, log ports.LoggerWithTraceID, handler {{.PascalCase}}ChangeHandler) *{{.PascalCase}}Listener {
+	return &{{.PascalCase}}Listener{
+		dsn:     dsn,
+		handler: handler,
+		log:     log,
+	}
+}
+
+// Start passes every change published on {{.PascalCase}}Channel to the handler until
+// ctx is cancelled. Postgres does not queue notifications for disconnected
+// listeners, so changes made while the connection was down are lost.
+func (l *{{.PascalCase}}Listener) Start(ctx context.Context) error {
+	listener := pq.NewListener(l.dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			l.log.Error(ctx, fmt.Sprintf("{{.LowerCase}} listener: %v", err))
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen({{.PascalCase}}Channel); err != nil {
+		return fmt.Errorf("{{.LowerCase}} listener: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			if notification == nil {
+				l.log.Info(ctx, "{{.LowerCase}} listener: reconnected, changes made while disconnected were missed")
+				// TODO: Resync whatever the handler keeps up to date.
+				continue
+			}
+			l.handle(ctx, notification.Extra)
+		case <-time.After(90 * time.Second):
+			if err := listener.Ping(); err != nil {
+				l.log.Error(ctx, fmt.Sprintf("{{.LowerCase}} listener: %v", err))
+			}
+		}
+	}
+}
+
+func (l *{{.PascalCase}}Listener) handle(ctx context.Context, payload string) {
+	// The op, id and row keys of the payload match the fields of the change.
+	var change {{.PascalCase}}Change
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+{{- if .RedactedFields}}
+		l.log.Error(ctx, fmt.Sprintf("{{.LowerCase}} listener: decoding %s: %v", redact.{{.PascalCase}}(payload), err))
+{{- else}}
+		l.log.Error(ctx, fmt.Sprintf("{{.LowerCase}} listener: decoding %q: %v", payload, err))
+{{- end}}
+		return
+	}
+	if err := l.handler(ctx, change); err != nil {
+		l.log.Error(ctx, fmt.Sprintf("{{.LowerCase}} listener: handling %s of %v: %v", change.Op, change.ID, err))
+	}
+}
+`
+
+const cdcDebeziumTemplate = `package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// {{.PascalCase}}Table is the table the Debezium connector captures; its topic is
+// named <topic.prefix>.public.{{.SnakeCase}}.
+const {{.PascalCase}}Table = "public.{{.SnakeCase}}"
+
+// {{.CamelCase}}DebeziumEvent is a change event of the JSON converter, with or
+// without the schema envelope it adds when schemas are enabled.
+type {{.CamelCase}}DebeziumEvent struct {
+	Payload *{{.CamelCase}}DebeziumEvent
+	Op      string
+	Before  *dto.{{.PascalCase}}
+	After   *dto.{{.PascalCase}}
+}
+
+type {{.PascalCase}}DebeziumConsumer struct {
+	handler {{.PascalCase}}ChangeHandler
+}
+
+func New{{.PascalCase}}DebeziumConsumer(handler {{.PascalCase}}ChangeHandler) *{{.PascalCase}}DebeziumConsumer {
+	return &{{.PascalCase}}DebeziumConsumer{handler: handler}
+}
+
+// Handle decodes the value of a message from the {{.SnakeCase}} topic and passes
+// the change to the handler. Call it from the project's Kafka consumer and
+// commit the offset only once it returns nil. dto.{{.PascalCase}} must decode the
+// connector's column encoding, e.g. decimal.handling.mode=double.
+func (c *{{.PascalCase}}DebeziumConsumer) Handle(ctx context.Context, value []byte) error {
+	if len(value) == 0 {
+		// The tombstone following a delete, only relevant to log compaction.
+		return nil
+	}
+
+	var event {{.CamelCase}}DebeziumEvent
+	if err := json.Unmarshal(value, &event); err != nil {
+		return fmt.Errorf("{{.LowerCase}} cdc: decoding change event: %w", err)
+	}
+	if event.Payload != nil {
+		event = *event.Payload
+	}
+
+	change := {{.PascalCase}}Change{Row: event.After}
+	switch event.Op {
+	case "c", "r":
+		change.Op = OpInsert
+	case "u":
+		change.Op = OpUpdate
+	case "d":
+		change.Op, change.Row = OpDelete, event.Before
+	default:
+		return fmt.Errorf("{{.LowerCase}} cdc: unknown operation %q", event.Op)
+	}
+	if change.Row == nil {
+		return fmt.Errorf("{{.LowerCase}} cdc: %s event without a row", change.Op)
+	}
+	change.ID = change.Row.ID
+	return c.handler(ctx, change)
+}
+`
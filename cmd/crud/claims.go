@@ -0,0 +1,153 @@
+package crud
+
+// AuthConfig tells --claims where the JWT middleware leaves the verified
+// token's claims and which of them identify the caller.
+type AuthConfig struct {
+	// ClaimsLocal is the request local holding the claims as a map.
+	ClaimsLocal string `yaml:"claims_local"`
+	// UserIDClaim, RolesClaim and TenantIDClaim name the claims to read.
+	UserIDClaim   string `yaml:"user_id_claim"`
+	RolesClaim    string `yaml:"roles_claim"`
+	TenantIDClaim string `yaml:"tenant_id_claim"`
+}
+
+// withDefaults fills in the claim names left out of the config.
+func (a AuthConfig) withDefaults() AuthConfig {
+	if a.ClaimsLocal == "" {
+		a.ClaimsLocal = "claims"
+	}
+	if a.UserIDClaim == "" {
+		a.UserIDClaim = "sub"
+	}
+	if a.RolesClaim == "" {
+		a.RolesClaim = "roles"
+	}
+	if a.TenantIDClaim == "" {
+		a.TenantIDClaim = "tenant_id"
+	}
+	return a
+}
+
+// --- CLAIMS TEMPLATES ---
+
+// claimsTemplate is shared by every entity, so it is generated once and left
+// alone on later runs; delete it to pick up changed claim names.
+const claimsTemplate = `package httpUtils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+// The request local the JWT middleware stores the verified claims in, and the
+// claims identifying the caller, from the auth section of the generator config.
+const (
+	claimsLocal   = "{{.Auth.ClaimsLocal}}"
+	userIDClaim   = "{{.Auth.UserIDClaim}}"
+	rolesClaim    = "{{.Auth.RolesClaim}}"
+	tenantIDClaim = "{{.Auth.TenantIDClaim}}"
+)
+
+// Claims identifies the caller of a request.
+type Claims struct {
+	UserID   string
+	Roles    []string
+	TenantID string
+}
+
+// HasRole reports whether the caller has role.
+func (c Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+type claimsKey struct{}
+
+// ContextWithClaims returns a copy of ctx carrying claims.
+func ContextWithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims attached by ContextWithClaims.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(Claims)
+	return claims, ok
+}
+
+// ClaimsFromRequest reads the caller's claims from the request. It fails with
+// 401 when the JWT middleware left no claims or no user id.
+func ClaimsFromRequest(c *ports.HttpContext) (Claims, error) {
+	raw, ok := c.Locals(claimsLocal).(map[string]any)
+	if !ok {
+		return Claims{}, appErr.NewUnauthorizedErr(errors.New("missing token claims"))
+	}
+
+	claims := Claims{
+		UserID:   claimString(raw[userIDClaim]),
+		TenantID: claimString(raw[tenantIDClaim]),
+	}
+	switch roles := raw[rolesClaim].(type) {
+	case string:
+		claims.Roles = strings.Fields(roles)
+	case []string:
+		claims.Roles = roles
+	case []any:
+		for _, role := range roles {
+			claims.Roles = append(claims.Roles, claimString(role))
+		}
+	}
+	if claims.UserID == "" {
+		return Claims{}, appErr.NewUnauthorizedErr(fmt.Errorf("missing %s claim", userIDClaim))
+	}
+	return claims, nil
+}
+
+// claimString formats a claim, which JSON decodes as a string or a float64.
+func claimString(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return fmt.Sprintf("%.0f", value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+`
+
+const callerTemplate = `package {{.LowerCase}}
+
+import (
+	"context"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+{{- if .RLS}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+{{- end}}
+)
+
+// withCaller returns ctx carrying the caller's claims{{if .RLS}} and their {{if eq .RLS "owner"}}user{{else}}tenant{{end}} id
+// for row-level security{{end}}. Every {{.PascalCase}} handler calls it first.
+func withCaller(ctx context.Context, c *ports.HttpContext) (context.Context, error) {
+	claims, err := httpUtils.ClaimsFromRequest(c)
+	if err != nil {
+		return ctx, err
+	}
+
+	// TODO: Reject callers without the roles the {{.PascalCase}} endpoints require, e.g.
+	// if !claims.HasRole("admin") { return ctx, appErr.NewForbiddenErr(...) }
+	ctx = httpUtils.ContextWithClaims(ctx, claims)
+{{- if .RLS}}
+	ctx = repository.WithRLS(ctx, repository.{{.RLSSettingConst}}, claims.{{if eq .RLS "owner"}}UserID{{else}}TenantID{{end}})
+{{- end}}
+	return ctx, nil
+}
+`
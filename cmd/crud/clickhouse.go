@@ -0,0 +1,396 @@
+package crud
+
+import "fmt"
+
+// clickHouseTypes maps a spec field type to its ClickHouse column type.
+var clickHouseTypes = map[string]string{
+	"string": "String",
+	"text":   "String",
+	"int":    "Int32",
+	"int64":  "Int64",
+	"float":  "Float64",
+	"bool":   "Bool",
+	"time":   "DateTime64(3, 'UTC')",
+	"uuid":   "UUID",
+	"json":   "String",
+}
+
+// ClickHouseColumns renders the column definitions of the entity's ClickHouse
+// table from the spec, aligned on the type: the fields, the foreign keys of
+// belongs_to relations and created_at, followed by the fields' checks. There
+// is no id, and foreign keys are not enforced.
+func (d TemplateData) ClickHouseColumns() []string {
+	type column struct{ name, definition string }
+	var (
+		columns []column
+		checks  []string
+	)
+	for _, field := range d.Entity.Fields {
+		definition := clickHouseTypes[field.Type]
+		if field.Nullable {
+			definition = "Nullable(" + definition + ")"
+		}
+		if field.Default != "" {
+			definition += " DEFAULT " + field.Default
+		}
+		columns = append(columns, column{field.Name, definition})
+		if field.Check != "" {
+			checks = append(checks, fmt.Sprintf("CONSTRAINT %s_%s_check CHECK (%s)", d.SnakeCase, field.Name, field.Check))
+		}
+	}
+	for _, relation := range d.Entity.Relations {
+		if relation.Kind == relationBelongsTo {
+			columns = append(columns, column{relation.ForeignKey(), "Int64"})
+		}
+	}
+	columns = append(columns, column{"created_at", "DateTime64(3, 'UTC') DEFAULT now64(3)"})
+
+	width := 0
+	for _, c := range columns {
+		width = max(width, len(c.name))
+	}
+	rendered := make([]string, 0, len(columns)+len(checks))
+	for _, c := range columns {
+		rendered = append(rendered, fmt.Sprintf("%-*s %s", width, c.name, c.definition))
+	}
+	return append(rendered, checks...)
+}
+
+// --- CLICKHOUSE TEMPLATES ---
+
+// The ClickHouse templates store event-like entities for analytics: rows are
+// inserted in batches and listed newest first or counted per time bucket, and
+// are never read by id, updated or deleted. They reuse the append-only service
+// and controller.
+
+const clickHouseRepositoryTemplate = `package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+const (
+	// {{.CamelCase}}BatchSize is the most rows sent in one insert. Smaller batches
+	// are sent every {{.CamelCase}}FlushInterval.
+	{{.CamelCase}}BatchSize     = 10000
+	{{.CamelCase}}FlushInterval = time.Second
+)
+
+// {{.CamelCase}}InsertQuery inserts a batch of JSON rows, one per line. Columns
+// missing from the JSON get their defaults, so created_at is stamped by the
+// server, and times are accepted in RFC 3339.
+const {{.CamelCase}}InsertQuery = "INSERT INTO {{.TableIdent | js}} SETTINGS date_time_input_format = 'best_effort' FORMAT JSONEachRow\n"
+
+// {{.CamelCase}}ListQuery and {{.CamelCase}}ListBeforeQuery list rows as JSON newest
+// first, from the newest row or from before a created_at. 64-bit integers and
+// times are written so encoding/json decodes them.
+const (
+	{{.CamelCase}}ListQuery = ` + "`" + `
+SELECT formatRowNoNewline('JSONEachRow', *), created_at FROM {{.TableIdent}}
+ORDER BY created_at DESC
+LIMIT ?
+SETTINGS output_format_json_quote_64bit_integers = 0, date_time_output_format = 'iso'` + "`" + `
+	{{.CamelCase}}ListBeforeQuery = ` + "`" + `
+SELECT formatRowNoNewline('JSONEachRow', *), created_at FROM {{.TableIdent}}
+WHERE created_at < ?
+ORDER BY created_at DESC
+LIMIT ?
+SETTINGS output_format_json_quote_64bit_integers = 0, date_time_output_format = 'iso'` + "`" + `
+)
+
+// {{.CamelCase}}BucketsQuery counts the rows created in [from, to) per bucket of a
+// number of seconds.
+const {{.CamelCase}}BucketsQuery = ` + "`" + `
+SELECT toStartOfInterval(created_at, toIntervalSecond(?)) AS start, count() AS count
+FROM {{.TableIdent}}
+WHERE created_at >= ? AND created_at < ?
+GROUP BY start
+ORDER BY start` + "`" + `
+
+// {{.CamelCase}}Batch holds rows inserted together. done is closed once the batch
+// has been sent, with err set if it failed.
+type {{.CamelCase}}Batch struct {
+	rows []string
+	done chan struct{}
+	err  error
+}
+
+type {{.CamelCase}}Repository struct {
+	conn driver.Conn
+	log  ports.LoggerWithTraceID
+
+	mu      sync.Mutex
+	pending *{{.CamelCase}}Batch
+	// stopped is set once ctx of the constructor is done; later rows are sent
+	// one batch each.
+	stopped bool
+}
+
+// New{{.PascalCase}}Repository returns a repository batching the rows it creates.
+// Batches are sent when full or every {{.CamelCase}}FlushInterval until ctx is done,
+// when the pending batch is sent, so cancel ctx on shutdown.
+func New{{.PascalCase}}Repository(ctx context.Context, conn driver.Conn, log ports.LoggerWithTraceID) repository.{{.PascalCase}} {
+	r := &{{.CamelCase}}Repository{
+		conn: conn,
+		log:  log,
+	}
+	go r.flushEvery(ctx, {{.CamelCase}}FlushInterval)
+	return r
+}
+
+// Create adds the row to the pending batch and waits until the batch is sent,
+// so the row is stored when it returns nil. The created_at stamped by the
+// server is not set on {{.CamelCase}}.
+func (r *{{.CamelCase}}Repository) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	row, err := json.Marshal({{.CamelCase}})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.pending == nil {
+		r.pending = &{{.CamelCase}}Batch{done: make(chan struct{})}
+	}
+	batch := r.pending
+	batch.rows = append(batch.rows, string(row))
+	if len(batch.rows) >= {{.CamelCase}}BatchSize || r.stopped {
+		r.pending = nil
+		go r.send(batch)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-batch.done:
+		return batch.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *{{.CamelCase}}Repository) flushEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.flush(false)
+		case <-ctx.Done():
+			r.flush(true)
+			return
+		}
+	}
+}
+
+// flush sends the pending batch, if there is one.
+func (r *{{.CamelCase}}Repository) flush(stop bool) {
+	r.mu.Lock()
+	batch := r.pending
+	r.pending = nil
+	r.stopped = r.stopped || stop
+	r.mu.Unlock()
+	if batch != nil {
+		r.send(batch)
+	}
+}
+
+// send inserts batch and wakes the Create calls waiting for it. It does not use
+// their contexts, which may end before the batch is sent.
+func (r *{{.CamelCase}}Repository) send(batch *{{.CamelCase}}Batch) {
+	ctx := context.Background()
+	batch.err = r.conn.Exec(ctx, {{.CamelCase}}InsertQuery+strings.Join(batch.rows, "\n"))
+	if batch.err != nil {
+		r.log.Error(ctx, fmt.Sprintf("inserting %d {{.LowerCase}} rows: %v", len(batch.rows), batch.err))
+	}
+	close(batch.done)
+}
+
+func (r *{{.CamelCase}}Repository) FindBefore(ctx context.Context, before time.Time, limit int) ([]dto.{{.PascalCase}}, time.Time, error) {
+	query, args := {{.CamelCase}}ListQuery, []any{limit}
+	if !before.IsZero() {
+		query, args = {{.CamelCase}}ListBeforeQuery, []any{before, limit}
+	}
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+
+	var {{.CamelCase}}s []dto.{{.PascalCase}}
+	var last time.Time
+	for rows.Next() {
+		var row string
+		var {{.CamelCase}} dto.{{.PascalCase}}
+		if err := rows.Scan(&row, &last); err != nil {
+			return nil, time.Time{}, err
+		}
+		if err := json.Unmarshal([]byte(row), &{{.CamelCase}}); err != nil {
+			return nil, time.Time{}, err
+		}
+		{{.CamelCase}}s = append({{.CamelCase}}s, {{.CamelCase}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	if len({{.CamelCase}}s) < limit {
+		return {{.CamelCase}}s, time.Time{}, nil
+	}
+	return {{.CamelCase}}s, last, nil
+}
+
+func (r *{{.CamelCase}}Repository) CountBuckets(ctx context.Context, from, to time.Time, interval time.Duration) ([]dto.TimeBucket, error) {
+	rows, err := r.conn.Query(ctx, {{.CamelCase}}BucketsQuery, int64(interval/time.Second), from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []dto.TimeBucket
+	for rows.Next() {
+		var bucket dto.TimeBucket
+		if err := rows.Scan(&bucket.Start, &bucket.Count); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, rows.Err()
+}
+`
+
+// timeBucketDTOTemplate is shared by every entity, so it is generated once and
+// left alone on later runs.
+const timeBucketDTOTemplate = `package dto
+
+import "time"
+
+// TimeBucket counts the rows created in the interval starting at Start.
+type TimeBucket struct {
+	Start time.Time ` + "`" + `json:"start"` + "`" + `
+	Count uint64    ` + "`" + `json:"count"` + "`" + `
+}
+`
+
+const clickHouseBucketsControllerTemplate = `package {{.LowerCase}}
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+{{- if or .ContentNegotiation .Envelope.IsSet}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+{{- end}}
+	"go.elastic.co/apm"
+)
+
+const (
+	// {{.CamelCase}}DefaultRange is how far back from to the buckets start when
+	// from is not given.
+	{{.CamelCase}}DefaultRange = 24 * time.Hour
+	// {{.CamelCase}}MaxBuckets bounds the buckets of one response.
+	{{.CamelCase}}MaxBuckets = 1000
+)
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Count {{.PascalCase}}s per time bucket
+// @Description	Count the {{.LowerCase}}s created in a time range per bucket, oldest first. Empty buckets are left out.
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			from		query		string	false	"Start of the range, an RFC 3339 time; defaults to 24 hours before to"	format(date-time)
+// @Param			to			query		string	false	"End of the range, an RFC 3339 time; defaults to now"	format(date-time)
+// @Param			interval	query		string	false	"Width of the buckets, e.g. 5m or 1h"	default(1h)
+// @Success		200			{{.SwagResponse "[]dto.TimeBucket"}}
+// @Failure		400			{object}	{{.ErrorSchema}}
+// @Failure		500			{object}	{{.ErrorSchema}}
+// @Router			/api/v1/{{.KebabCase}}/buckets [get]
+{{else -}}
+// Count{{.PascalCase}}Buckets handles GET /api/v1/{{.KebabCase}}/buckets.
+{{end -}}
+func (ctrl *{{.CamelCase}}Controller) Count{{.PascalCase}}Buckets(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Count{{.PascalCase}}Buckets", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
+
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return err
+	}
+{{- end}}
+
+	to := time.Now()
+	if raw := c.Query("to"); raw != "" {
+		var err error
+		if to, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+			return appErr.NewBadRequestErr(fmt.Errorf("to must be an RFC 3339 time: %w", err))
+		}
+	}
+	from := to.Add(-{{.CamelCase}}DefaultRange)
+	if raw := c.Query("from"); raw != "" {
+		var err error
+		if from, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+			return appErr.NewBadRequestErr(fmt.Errorf("from must be an RFC 3339 time: %w", err))
+		}
+	}
+	interval := time.Hour
+	if raw := c.Query("interval"); raw != "" {
+		var err error
+		if interval, err = time.ParseDuration(raw); err != nil {
+			return appErr.NewBadRequestErr(fmt.Errorf("interval must be a duration such as 5m or 1h: %w", err))
+		}
+	}
+
+	switch {
+	case interval < time.Second || interval%time.Second != 0:
+		return appErr.NewBadRequestErr(errors.New("interval must be a whole number of seconds"))
+	case !from.Before(to):
+		return appErr.NewBadRequestErr(errors.New("from must be before to"))
+	case to.Sub(from)/interval > {{.CamelCase}}MaxBuckets:
+		return appErr.NewBadRequestErr(fmt.Errorf("the range holds more than %d buckets of %s", {{.CamelCase}}MaxBuckets, interval))
+	}
+
+	buckets, err := ctrl.{{.CamelCase}}Service.Count{{.PascalCase}}Buckets(ctx, from, to, interval)
+	if err != nil {
+		return err
+	}
+
+	resp := {{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   buckets,
+	}{{if .Envelope.IsSet}}){{end}}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, resp){{else}}c.JSON(resp){{end}}
+}
+`
+
+const clickHouseTableMigrationTemplate = `-- {{.SnakeCase}} is appended to in batches, listed newest first and counted per
+-- time bucket, so it is sorted and partitioned by created_at.
+CREATE TABLE IF NOT EXISTS {{.TableIdent}} (
+{{- if not .Entity.Fields}}
+    -- TODO: Add the {{.SnakeCase}} columns.
+{{- end}}
+{{- range $i, $column := .ClickHouseColumns}}{{if $i}},{{end}}
+    {{$column}}
+{{- end}}
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMM(created_at)
+ORDER BY created_at;
+`
@@ -0,0 +1,220 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// clientGRPC adapts the enrich_with services through their gRPC APIs.
+	clientGRPC = "grpc"
+	// clientHTTP adapts the enrich_with services through their HTTP APIs.
+	clientHTTP = "http"
+)
+
+// serviceNamePattern matches the kebab-case names of the services an entity is
+// enriched with, e.g. pricing-service.
+var serviceNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// ServiceList is the enrich_with of an entity, written as one service name or
+// a list of them.
+type ServiceList []string
+
+// UnmarshalYAML accepts a single service name as a list of one.
+func (l *ServiceList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = ServiceList{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(l))
+}
+
+// clientDependency is a service the entity is enriched with, which the entity's
+// service calls through a generated client port.
+type clientDependency struct {
+	// Name is the kebab-case name of the service in the spec.
+	Name       string
+	PascalCase string
+	CamelCase  string
+}
+
+// Clients lists the services the spec enriches the entity with.
+func (d TemplateData) Clients() []clientDependency {
+	clients := make([]clientDependency, 0, len(d.Entity.EnrichWith))
+	for _, name := range d.Entity.EnrichWith {
+		pascal := FieldSpec{Name: strings.ReplaceAll(name, "-", "_")}.GoName()
+		clients = append(clients, clientDependency{Name: name, PascalCase: pascal, CamelCase: strings.ToLower(pascal[:1]) + pascal[1:]})
+	}
+	return clients
+}
+
+// ClientFields renders the fields of the entity's clients struct, aligned like
+// gofmt would.
+func (d TemplateData) ClientFields() []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	for _, client := range d.Clients() {
+		fmt.Fprintf(w, "%s\tclient.%s\n", client.PascalCase, client.PascalCase)
+	}
+	w.Flush()
+	return splitLines(buf.String())
+}
+
+// ClientNames lists the names of the services the entity is enriched with, for
+// doc comments.
+func (d TemplateData) ClientNames() string {
+	names := []string(d.Entity.EnrichWith)
+	if len(names) <= 1 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// clientAdapterArtifact is the built-in template of the adapters
+// --client-transport generates.
+func clientAdapterArtifact(transport string) string {
+	if transport == clientHTTP {
+		return "httpClient"
+	}
+	return "grpcClient"
+}
+
+// --- CLIENT TEMPLATES ---
+
+// clientPortTemplate is shared by every entity enriched with the service, so it
+// is generated once and left alone on later runs.
+const clientPortTemplate = `package client
+
+// {{.Client.PascalCase}} is the port of {{.Client.Name}}. Services call it through this
+// interface rather than its API, so the adapter can be swapped and faked in
+// tests.
+type {{.Client.PascalCase}} interface {
+	// TODO: Declare the calls the services make on {{.Client.Name}}.
+	// Example, for a pricing service:
+	// GetPrice(ctx context.Context, productID int64) (int64, error)
+}
+`
+
+// grpcClientTemplate is shared by every entity enriched with the service, so it
+// is generated once and left alone on later runs.
+const grpcClientTemplate = `package grpcclient
+
+import (
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/client"
+	"google.golang.org/grpc"
+)
+
+type {{.Client.CamelCase}} struct {
+	conn *grpc.ClientConn
+	log  ports.LoggerWithTraceID
+}
+
+// New{{.Client.PascalCase}} adapts the gRPC API of {{.Client.Name}} to client.{{.Client.PascalCase}}.
+// conn is dialed once, with the tracing and deadline interceptors, and shared
+// by every call.
+func New{{.Client.PascalCase}}(conn *grpc.ClientConn, log ports.LoggerWithTraceID) client.{{.Client.PascalCase}} {
+	return &{{.Client.CamelCase}}{
+		conn: conn,
+		log:  log,
+	}
+}
+
+// TODO: Implement the methods of client.{{.Client.PascalCase}} on the client generated
+// from the {{.Client.Name}} protos, turning its status codes into appErr errors.
+// Example:
+// func (c *{{.Client.CamelCase}}) GetPrice(ctx context.Context, productID int64) (int64, error) {
+// 	resp, err := pricingpb.NewPricingClient(c.conn).GetPrice(ctx, &pricingpb.GetPriceRequest{ProductId: productID})
+// 	if err != nil {
+// 		return 0, err
+// 	}
+// 	return resp.GetPrice(), nil
+// }
+`
+
+// httpClientTemplate is shared by every entity enriched with the service, so it
+// is generated once and left alone on later runs.
+const httpClientTemplate = `package httpclient
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/client"
+)
+
+type {{.Client.CamelCase}} struct {
+	baseURL    string
+	httpClient *http.Client
+	log        ports.LoggerWithTraceID
+}
+
+// New{{.Client.PascalCase}} adapts the HTTP API of {{.Client.Name}} at baseURL to
+// client.{{.Client.PascalCase}}. A nil httpClient gives up on calls after 5s.
+func New{{.Client.PascalCase}}(baseURL string, httpClient *http.Client, log ports.LoggerWithTraceID) client.{{.Client.PascalCase}} {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &{{.Client.CamelCase}}{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+		log:        log,
+	}
+}
+
+// TODO: Implement the methods of client.{{.Client.PascalCase}} on the {{.Client.Name}} API,
+// turning its error responses into appErr errors.
+// Example:
+// func (c *{{.Client.CamelCase}}) GetPrice(ctx context.Context, productID int64) (int64, error) {
+// 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/prices/%d", c.baseURL, productID), nil)
+// 	if err != nil {
+// 		return 0, err
+// 	}
+// 	resp, err := c.httpClient.Do(req)
+// 	if err != nil {
+// 		return 0, err
+// 	}
+// 	defer resp.Body.Close()
+// 	var price struct {
+// 		Price int64 ` + "`json:\"price\"`" + `
+// 	}
+// 	return price.Price, json.NewDecoder(resp.Body).Decode(&price)
+// }
+`
+
+const serviceClientsTemplate = `package service
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/client"
+)
+
+// {{.PascalCase}}Clients are the ports of the services {{.LowerCase}}s are enriched with.
+type {{.PascalCase}}Clients struct {
+{{- range .ClientFields}}
+	{{.}}
+{{- end}}
+}
+
+// enrich{{.PascalCase}} fills the fields of {{.CamelCase}} owned by {{.ClientNames}}.
+// It is called on every {{.LowerCase}} the service reads.
+func (s *{{.CamelCase}}Service) enrich{{.PascalCase}}(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	// TODO: Fill the fields of {{.CamelCase}} through s.clients.
+	// Example:
+	// price, err := s.clients.{{with .Clients}}{{(index . 0).PascalCase}}{{end}}.GetPrice(ctx, {{.CamelCase}}.ID)
+	// if err != nil {
+	// 	return err
+	// }
+	// {{.CamelCase}}.Price = price
+	return nil
+}
+`
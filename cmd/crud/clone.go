@@ -0,0 +1,115 @@
+package crud
+
+import "fmt"
+
+// CloneResetFields lists the fields a clone leaves at their zero values
+// instead of copying.
+func (d TemplateData) CloneResetFields() []FieldSpec {
+	return d.fieldsWith(func(field FieldSpec) bool { return field.ResetOnClone })
+}
+
+// validateClone checks that clones can be stored: a copied unique field would
+// collide with the original, so unique fields must be reset to NULL.
+func (d TemplateData) validateClone() error {
+	for _, field := range d.Entity.Fields {
+		if field.Unique && !(field.ResetOnClone && field.Nullable) {
+			return fmt.Errorf("--clone would copy %s.%s, which is unique; make it nullable and reset_on_clone in the spec", d.PascalCase, field.Name)
+		}
+	}
+	return nil
+}
+
+// --- CLONE TEMPLATES ---
+
+const cloneServiceTemplate = `package service
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// Clone{{.PascalCase}} creates a copy of the {{.LowerCase}} with the given id through
+// Create{{.PascalCase}}, with a new id{{if .CloneResetFields}} and the fields the spec resets on clone
+// at their zero values{{end}}.
+func (s *{{.CamelCase}}Service) Clone{{.PascalCase}}(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error) {
+	{{.CamelCase}}, err := s.Get{{.PascalCase}}ByID(ctx, id)
+	if err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+
+	{{.CamelCase}}.ID = dto.{{.PascalCase}}{}.ID
+{{- range .CloneResetFields}}
+	{{$.CamelCase}}.{{.GoName}} = dto.{{$.PascalCase}}{}.{{.GoName}}
+{{- else}}
+	// TODO: Reset the identity and audit fields of {{.CamelCase}} that the copy must
+	// not share, or mark them reset_on_clone in the spec.
+{{- end}}
+	return s.Create{{.PascalCase}}(ctx, {{.CamelCase}})
+}
+`
+
+const cloneControllerTemplate = `package {{.LowerCase}}
+
+import (
+{{- if not .UUID}}
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+{{- end}}
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+{{- if or .ContentNegotiation .Envelope.IsSet}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+{{- end}}
+	"go.elastic.co/apm"
+)
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Clone a {{.PascalCase}}
+// @Description	This route will create a copy of a {{.LowerCase}} with a new ID
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			id	path		{{.IDParam}}	true	"ID of the {{.PascalCase}} to clone"{{if .UUID}}	format(uuid){{end}}
+// @Success		201	{{.SwagResponse .ResponseType}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		404	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			{{.WriteRoutePrefix}}/{{.KebabCase}}/{id}/clone [post]
+{{else -}}
+// Clone{{.PascalCase}} handles POST {{.WriteRoutePrefix}}/{{.KebabCase}}/{id}/clone.
+{{end -}}
+func (ctrl *{{.CamelCase}}Controller) Clone{{.PascalCase}}(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Clone{{.PascalCase}}", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
+
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return err
+	}
+{{- end}}
+
+	id, err := {{if .UUID}}parse{{.PascalCase}}ID(c){{else}}c.ParamsInt("id"){{end}}
+	if err != nil {
+		return {{if .UUID}}err{{else}}appErr.NewBadRequestErr(err){{end}}
+	}
+
+	clonedEntity, err := ctrl.{{.CamelCase}}Service.Clone{{.PascalCase}}(ctx, {{.IDArg}})
+	if err != nil {
+		return {{if .I18n}}localize{{.PascalCase}}Error(err){{else}}err{{end}}
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 201, {{else}}c.Status(201).JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   {{with .ResponseMapper}}{{.}}(clonedEntity){{else}}clonedEntity{{end}},
+	}{{if .Envelope.IsSet}}){{end}})
+}
+`
@@ -0,0 +1,112 @@
+package crud
+
+// --- COCKROACHDB TEMPLATES ---
+
+// serializationRetryTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const serializationRetryTemplate = `package repository
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// SerializationRetries bounds how often an operation is retried after
+// CockroachDB aborted it with a serialization failure.
+const SerializationRetries = 5
+
+// RetrySerializable runs fn until it succeeds, fails with another error or
+// SerializationRetries retries are used up. CockroachDB runs every transaction
+// SERIALIZABLE and expects clients to retry the ones it aborts; an aborted
+// attempt was rolled back, so writes are retried as well.
+func RetrySerializable(ctx context.Context, fn func(ctx context.Context) error) error {
+	delay := 10 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !IsSerializationFailure(err) || attempt == SerializationRetries {
+			return err
+		}
+
+		// Jitter keeps conflicting transactions from retrying in lockstep.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay/2 + rand.N(delay)):
+		}
+		delay *= 2
+	}
+}
+
+// IsSerializationFailure reports whether err is SQLSTATE 40001, which
+// CockroachDB returns for transactions to retry.
+func IsSerializationFailure(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "40001"
+}
+`
+
+const retryingRepositoryTemplate = `package repository
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// {{.CamelCase}}Retrying retries the operations of a {{.PascalCase}} repository that
+// CockroachDB aborted with a serialization failure. Methods it does not
+// override pass straight through.
+type {{.CamelCase}}Retrying struct {
+	{{.PascalCase}}
+}
+
+func New{{.PascalCase}}Retrying(next {{.PascalCase}}) {{.PascalCase}} {
+	return &{{.CamelCase}}Retrying{next}
+}
+
+func (r *{{.CamelCase}}Retrying) GetByID(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error) {
+	var result dto.{{.PascalCase}}
+	err := RetrySerializable(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.{{.PascalCase}}.GetByID(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (r *{{.CamelCase}}Retrying) FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+	var (
+		result           []dto.{{.PascalCase}}
+		resultPagination *dto.Pagination
+	)
+	err := RetrySerializable(ctx, func(ctx context.Context) error {
+		var err error
+		result, resultPagination, err = r.{{.PascalCase}}.FindAll(ctx, pagination)
+		return err
+	})
+	return result, resultPagination, err
+}
+
+func (r *{{.CamelCase}}Retrying) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	return RetrySerializable(ctx, func(ctx context.Context) error {
+		return r.{{.PascalCase}}.Create(ctx, {{.CamelCase}})
+	})
+}
+
+func (r *{{.CamelCase}}Retrying) Update(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	return RetrySerializable(ctx, func(ctx context.Context) error {
+		return r.{{.PascalCase}}.Update(ctx, {{.CamelCase}})
+	})
+}
+
+func (r *{{.CamelCase}}Retrying) Delete(ctx context.Context, id {{.IDGoType}}) error {
+	return RetrySerializable(ctx, func(ctx context.Context) error {
+		return r.{{.PascalCase}}.Delete(ctx, id)
+	})
+}
+`
@@ -0,0 +1,108 @@
+package crud
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// registerCompletions adds the dynamic shell completions. It runs from
+// Execute, once every command has defined its flags.
+func registerCompletions() {
+	enums := map[*cobra.Command]map[string][]string{
+		crudCmd: {
+			"swagger":       {swaggerSwaggo, swaggerOpenAPIGen, swaggerNone},
+			"errors":        {errorsDetails, errorsProblemJSON},
+			"id-type":       {idTypeInt64, idTypeUUID},
+			"db":            {dbPostgres, dbCockroach, dbClickHouse, dbCassandra, dbSQLServer, dbOracle, dbFirestore, dbSpanner, dbBolt},
+			"retention-job": {retentionPurge, retentionArchive},
+			"gateway":       slices.Sorted(maps.Keys(gatewayArtifacts)),
+			"transport":     {transportREST, transportGraphQL},
+			"ui":            {uiTempl, uiReactAdmin},
+			"rls":           {rlsTenant, rlsOwner},
+			"cdc":           {cdcNotify, cdcDebezium},
+			"auth":          {authAPIKey},
+			"line-endings":  {lineEndingsLF, lineEndingsCRLF, lineEndingsAuto},
+		},
+		diagramCmd: {"format": {diagramMermaid, diagramPlantUML}},
+		graphCmd:   {"format": {graphDOT, graphMermaid}},
+		reportCmd:  {"format": {reportMarkdown, reportJSON}},
+	}
+	for cmd, flags := range enums {
+		for flag, values := range flags {
+			cmd.RegisterFlagCompletionFunc(flag, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+		}
+	}
+
+	crudCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	crudCmd.MarkFlagFilename("spec", "yaml", "yml")
+	diagramCmd.MarkFlagFilename("spec", "yaml", "yml")
+	reportCmd.MarkFlagFilename("spec", "yaml", "yml")
+	crudCmd.ValidArgsFunction = completeSpecEntities
+	deprecateCmd.ValidArgsFunction = completeGeneratedEntities
+	for _, cmd := range []*cobra.Command{driftCmd, graphCmd, templatesLintCmd} {
+		cmd.ValidArgsFunction = completeDirectories
+	}
+}
+
+// completeSpecEntities suggests the entities of the spec given with --spec.
+func completeSpecEntities(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	specFile, _ := cmd.Flags().GetString("spec")
+	if len(args) > 0 || specFile == "" || specFile == stdinSpecPath {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	spec, err := loadSpec(specFile)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var names []string
+	for _, entity := range spec.Entities {
+		if strings.HasPrefix(entity.Name, toComplete) {
+			names = append(names, entity.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeGeneratedEntities suggests the entities crud generated controllers
+// for in the current directory.
+func completeGeneratedEntities(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	controllers, _ := filepath.Glob(filepath.Join("internal/transport/http/rest/controller/v1", "*", "controller.go"))
+	var names []string
+	for _, controller := range controllers {
+		dir := filepath.Base(filepath.Dir(controller))
+		name := strings.ToUpper(dir[:1]) + dir[1:]
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfiles suggests the built-in profiles and those of the config,
+// which is not loaded yet while completing.
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := slices.Collect(maps.Keys(builtinProfiles))
+	if cfg, err := loadConfig(configFile, presetName); err == nil {
+		for name := range cfg.Profiles {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeDirectories(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveFilterDirs
+}
@@ -0,0 +1,158 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigFile = ".gocrud-gen.yaml"
+
+// Config is the project-level generator configuration, read from
+// .gocrud-gen.yaml in the working directory or the file passed to --config.
+type Config struct {
+	// Preset names the organization preset, added with the preset command,
+	// whose config this one is layered on.
+	Preset string `yaml:"preset"`
+	// FileHeader is rendered with the entity's TemplateData and prepended to
+	// every generated file, each line commented in the file's syntax.
+	FileHeader string `yaml:"file_header"`
+	// Swag configures the docs regeneration triggered by --swag-init.
+	Swag SwagConfig `yaml:"swag"`
+	// UI configures the frontend code generated by --ui.
+	UI UIConfig `yaml:"ui"`
+	// Pagination sets the defaults baked into the generated list handlers.
+	Pagination PaginationConfig `yaml:"pagination"`
+	// I18n configures the locale files updated by --i18n.
+	I18n I18nConfig `yaml:"i18n"`
+	// Auth names the token claims read by --claims.
+	Auth AuthConfig `yaml:"auth"`
+	// Envelope describes the body responses are wrapped in, see
+	// EnvelopeConfig.
+	Envelope EnvelopeConfig `yaml:"envelope"`
+	// Correlation names the header and log field of --correlation-id.
+	Correlation CorrelationConfig `yaml:"correlation"`
+	// Features sets the project's feature toggles, see FeatureConfig.
+	Features FeatureConfig `yaml:"features"`
+	// Profile is the profile crud generates with unless another is chosen.
+	Profile string `yaml:"profile"`
+	// Profiles defines the profiles in addition to the built-in ones.
+	Profiles map[string]Profile `yaml:"profiles"`
+	// Templates is a directory of custom templates replacing built-in ones,
+	// see the templates command, or a template pack shared from a repository
+	// and pinned to a tag, e.g. github.com/org/crud-templates@v1.4.0.
+	Templates string `yaml:"templates"`
+	// TemplatesChecksum is the sha256:<hex> sum the archive of the template
+	// pack in Templates must have.
+	TemplatesChecksum string `yaml:"templates_checksum"`
+	// TemplateSandbox restricts what the custom templates can do, see
+	// SandboxConfig.
+	TemplateSandbox SandboxConfig `yaml:"template_sandbox"`
+	// FileMode and DirMode are the octal permissions, e.g. "0640", generated
+	// files and directories are created with. --file-mode and --dir-mode win.
+	FileMode string `yaml:"file_mode"`
+	DirMode  string `yaml:"dir_mode"`
+	// Overwrite sets which existing files crud regenerates, see
+	// OverwriteConfig.
+	Overwrite OverwriteConfig `yaml:"overwrite"`
+	// UsageReport records the command, flag names and template pack of every
+	// run in .crudgen/usage.json. It is off unless enabled.
+	UsageReport bool `yaml:"usage_report"`
+}
+
+var (
+	configFile string
+	presetName string
+	config     Config
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Path to the generator config file (default "+defaultConfigFile+" if present)")
+	rootCmd.PersistentFlags().StringVar(&presetName, "preset", "", "Organization preset, added with 'preset add', to layer the config on instead of the one it names")
+}
+
+// loadConfig reads the config at path, layered on the preset it names unless
+// preset names another. An empty path falls back to the default file, which
+// is optional; an explicitly requested file must exist.
+func loadConfig(path, preset string) (Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		content, err = nil, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("reading config: %w", err)
+	}
+
+	var project Config
+	if err := yaml.Unmarshal(content, &project); err != nil {
+		return Config{}, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	if preset == "" {
+		preset = project.Preset
+	}
+	// The project's config is decoded over the preset's, so the keys it sets
+	// win and profiles are merged by name.
+	var cfg Config
+	if preset != "" {
+		presetContent, err := readPreset(preset)
+		if err != nil {
+			return Config{}, err
+		}
+		if err := yaml.Unmarshal(presetContent, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parsing preset %s: %w", preset, err)
+		}
+	}
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	cfg.Preset = preset
+	if err := cfg.validate(); err != nil {
+		if preset != "" {
+			return Config{}, fmt.Errorf("invalid config %s with preset %s: %w", path, preset, err)
+		}
+		return Config{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (cfg Config) validate() error {
+	if err := cfg.Pagination.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Correlation.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Envelope.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Overwrite.validate(); err != nil {
+		return err
+	}
+	if err := cfg.TemplateSandbox.validate(); err != nil {
+		return err
+	}
+	if cfg.TemplatesChecksum != "" {
+		if _, ok := parseTemplatePack(cfg.Templates); !ok {
+			return fmt.Errorf("templates_checksum is set but templates %q is not a template pack like github.com/org/crud-templates@v1.4.0", cfg.Templates)
+		}
+		if !templatesChecksumPattern.MatchString(cfg.TemplatesChecksum) {
+			return fmt.Errorf("templates_checksum %q must be sha256: followed by 64 lower case hex digits", cfg.TemplatesChecksum)
+		}
+	}
+	for name, mode := range map[string]string{"file_mode": cfg.FileMode, "dir_mode": cfg.DirMode} {
+		if mode == "" {
+			continue
+		}
+		if _, err := parseFileMode(name, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
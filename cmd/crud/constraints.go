@@ -0,0 +1,237 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fieldOptionKeys are the options of the field shorthand, see UnmarshalYAML.
+var fieldOptionKeys = []string{"default=", "check=", "filters=", "visibility=", "nullable", "unique", "pii", "encrypted", "sortable", "reset_on_clone"}
+
+// UnmarshalYAML accepts a field either as a mapping or as the shorthand
+// name:type[:options], where options is a comma-separated list of default=,
+// check=, filters= (space-separated operators), visibility=, nullable, unique,
+// pii, encrypted, sortable and reset_on_clone:
+//
+//   - quantity:int:default=0,check=quantity >= 0
+//   - status:string:default='draft',check=status IN ('draft', 'sent')
+//   - password_hash:string:visibility=write_only
+//   - phone:string:encrypted
+//   - name:string:sortable
+//   - sku:string:unique,nullable,reset_on_clone
+func (f *FieldSpec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		type plain FieldSpec
+		return node.Decode((*plain)(f))
+	}
+
+	name, rest, _ := strings.Cut(node.Value, ":")
+	fieldType, options, _ := strings.Cut(rest, ":")
+	*f = FieldSpec{Name: strings.TrimSpace(name), Type: strings.TrimSpace(fieldType)}
+	if options == "" {
+		return nil
+	}
+
+	// A comma only separates options when an option follows it, so checks
+	// such as status IN ('a', 'b') keep theirs.
+	var parts []string
+	for _, part := range strings.Split(options, ",") {
+		trimmed := strings.TrimSpace(part)
+		isOption := slices.ContainsFunc(fieldOptionKeys, func(key string) bool { return strings.HasPrefix(trimmed, key) })
+		if isOption || len(parts) == 0 {
+			parts = append(parts, trimmed)
+		} else {
+			parts[len(parts)-1] += "," + part
+		}
+	}
+	for _, part := range parts {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "default":
+			f.Default = value
+		case "check":
+			f.Check = value
+		case "filters":
+			f.Filters = strings.Fields(value)
+		case "visibility":
+			f.Visibility = value
+		case "nullable":
+			f.Nullable = true
+		case "unique":
+			f.Unique = true
+		case "pii":
+			f.PII = true
+		case "encrypted":
+			f.Encrypted = true
+		case "sortable":
+			f.Sortable = true
+		case "reset_on_clone":
+			f.ResetOnClone = true
+		default:
+			return fmt.Errorf("unknown option %q of field %s%s", part, f.Name, suggest(key, []string{"default", "check", "filters", "visibility", "nullable", "unique", "pii", "encrypted", "sortable", "reset_on_clone"}))
+		}
+	}
+	return nil
+}
+
+var (
+	// checkComparison matches checks like "quantity >= 0" and "length(name) <= 100".
+	checkComparison = regexp.MustCompile(`^(length\()?\s*([a-z][a-z0-9_]*)\s*\)?\s*(>=|<=|<>|!=|=|>|<)\s*(-?[0-9]+(?:\.[0-9]+)?)$`)
+	// checkIn matches checks like "status IN ('draft', 'sent')".
+	checkIn  = regexp.MustCompile(`(?i)^([a-z][a-z0-9_]*)\s+in\s*\(\s*('[^',]*'(?:\s*,\s*'[^',]*')*)\s*\)$`)
+	checkAnd = regexp.MustCompile(`(?i)\s+and\s+`)
+)
+
+var checkOperators = map[string]string{">=": "gte", ">": "gt", "<=": "lte", "<": "lt", "=": "eq", "<>": "ne", "!=": "ne"}
+
+// lengthOperators are the validator rules of length(field) checks.
+var lengthOperators = map[string]string{">=": "min", ">": "gt", "<=": "max", "<": "lt", "=": "len", "<>": "ne", "!=": "ne"}
+
+// CheckRules translates the field's check constraint to validator rules. ok is
+// false for checks it cannot translate, which are left to the database.
+func (f FieldSpec) CheckRules() (rules []string, ok bool) {
+	check := strings.TrimSpace(f.Check)
+	if check == "" {
+		return nil, true
+	}
+	for _, conjunct := range checkAnd.Split(check, -1) {
+		conjunct = strings.TrimSpace(conjunct)
+		if strings.HasPrefix(conjunct, "(") && strings.HasSuffix(conjunct, ")") {
+			conjunct = strings.TrimSpace(conjunct[1 : len(conjunct)-1])
+		}
+		if match := checkComparison.FindStringSubmatch(conjunct); match != nil && match[2] == f.Name {
+			operators := checkOperators
+			if match[1] != "" {
+				operators = lengthOperators
+			}
+			rules = append(rules, operators[match[3]]+"="+match[4])
+			continue
+		}
+		if match := checkIn.FindStringSubmatch(conjunct); match != nil && match[1] == f.Name {
+			var values []string
+			for _, value := range strings.Split(match[2], ",") {
+				value = strings.Trim(strings.TrimSpace(value), "'")
+				if strings.ContainsAny(value, " ") {
+					return nil, false
+				}
+				values = append(values, value)
+			}
+			rules = append(rules, "oneof="+strings.Join(values, " "))
+			continue
+		}
+		return nil, false
+	}
+	return rules, true
+}
+
+// GoName is the exported Go name of the field, e.g. CustomerID for customer_id.
+func (f FieldSpec) GoName() string {
+	var name strings.Builder
+	for _, word := range strings.Split(f.Name, "_") {
+		if word == "id" || word == "url" || word == "uuid" {
+			name.WriteString(strings.ToUpper(word))
+		} else if word != "" {
+			name.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return name.String()
+}
+
+// RequestFields renders the fields of the create or update request struct,
+// aligned like gofmt would. Fields that may be left out, because they are
+// nullable, have a database default or belong to an update, are pointers, as
+// are required numbers and booleans so that required accepts their zero value.
+func (d TemplateData) RequestFields(update bool) []string {
+	if len(d.Entity.Fields) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	for _, f := range d.Entity.Fields {
+		optional := update || f.Nullable || f.Default != ""
+		goType := fieldTypes[f.Type].Go
+		switch {
+		case goType == "json.RawMessage":
+		case optional, f.Type == "int", f.Type == "int64", f.Type == "float", f.Type == "bool":
+			goType = "*" + goType
+		}
+
+		rules := []string{"required"}
+		if optional {
+			rules = []string{"omitempty"}
+		}
+		checkRules, ok := f.CheckRules()
+		rules = append(rules, checkRules...)
+
+		fmt.Fprintf(w, "%s\t%s\t`json:\"%s\" validate:\"%s\"%s`", f.GoName(), goType, f.Name, strings.Join(rules, ","), d.exampleTag(f))
+		if !ok {
+			fmt.Fprintf(w, "\t// CHECK (%s) is only enforced by the database.", f.Check)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+// RequestImports lists the packages the request structs need for the spec fields.
+func (d TemplateData) RequestImports() []string {
+	return fieldImports(d.Entity.Fields)
+}
+
+// fieldImports lists the packages the Go types of fields need.
+func fieldImports(fields []FieldSpec) []string {
+	var imports []string
+	for _, field := range fields {
+		pkg, _, ok := strings.Cut(fieldTypes[field.Type].Go, ".")
+		if !ok {
+			continue
+		}
+		if pkg == "json" {
+			pkg = "encoding/json"
+		}
+		if !slices.Contains(imports, pkg) {
+			imports = append(imports, pkg)
+		}
+	}
+	slices.Sort(imports)
+	return imports
+}
+
+// ConstrainedFields lists the spec fields that declare a default or a check.
+func (d TemplateData) ConstrainedFields() []FieldSpec {
+	var fields []FieldSpec
+	for _, field := range d.Entity.Fields {
+		if field.Default != "" || field.Check != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// --- CONSTRAINT TEMPLATES ---
+
+// constraintsMigrationTemplate applies the spec's defaults and checks to an
+// existing table; partitioned tables get them in their CREATE TABLE instead.
+const constraintsMigrationTemplate = `{{if .Cockroach -}}
+-- CockroachDB cannot drop and re-add a constraint of the same name in one
+-- transaction, so checks are only added when missing; drop one by hand to
+-- change it.
+{{end -}}
+{{range .ConstrainedFields -}}
+{{if .Default -}}
+ALTER TABLE {{$.TableIdent}} ALTER COLUMN {{.Name}} SET DEFAULT {{.Default}};
+{{end -}}
+{{if and .Check $.Cockroach -}}
+ALTER TABLE {{$.TableIdent}} ADD CONSTRAINT IF NOT EXISTS {{$.SnakeCase}}_{{.Name}}_check CHECK ({{.Check}});
+{{else if .Check -}}
+ALTER TABLE {{$.TableIdent}} DROP CONSTRAINT IF EXISTS {{$.SnakeCase}}_{{.Name}}_check;
+ALTER TABLE {{$.TableIdent}} ADD CONSTRAINT {{$.SnakeCase}}_{{.Name}}_check CHECK ({{.Check}});
+{{end -}}
+{{end -}}
+`
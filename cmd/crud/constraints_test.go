@@ -0,0 +1,147 @@
+package crud
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestFieldSpecUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want FieldSpec
+		// wantErr is a snippet of the error, empty when the field decodes.
+		wantErr string
+	}{
+		{
+			name: "shorthand without options",
+			yaml: "quantity:int",
+			want: FieldSpec{Name: "quantity", Type: "int"},
+		},
+		{
+			name: "shorthand with spaces",
+			yaml: "' quantity : int '",
+			want: FieldSpec{Name: "quantity", Type: "int"},
+		},
+		{
+			name: "default and check",
+			yaml: "quantity:int:default=0,check=quantity >= 0",
+			want: FieldSpec{Name: "quantity", Type: "int", Default: "0", Check: "quantity >= 0"},
+		},
+		{
+			name: "check keeps its commas",
+			yaml: "\"status:string:default='draft',check=status IN ('draft', 'sent'),sortable\"",
+			want: FieldSpec{Name: "status", Type: "string", Default: "'draft'", Check: "status IN ('draft', 'sent')", Sortable: true},
+		},
+		{
+			name: "flags",
+			yaml: "sku:string:unique,nullable,pii,encrypted,reset_on_clone",
+			want: FieldSpec{Name: "sku", Type: "string", Unique: true, Nullable: true, PII: true, Encrypted: true, ResetOnClone: true},
+		},
+		{
+			name: "filters and visibility",
+			yaml: "password_hash:string:filters=eq in,visibility=write_only",
+			want: FieldSpec{Name: "password_hash", Type: "string", Filters: []string{"eq", "in"}, Visibility: "write_only"},
+		},
+		{
+			name: "mapping",
+			yaml: "{name: quantity, type: int, nullable: true, example: \"42\"}",
+			want: FieldSpec{Name: "quantity", Type: "int", Nullable: true, Example: "42"},
+		},
+		{
+			name:    "unknown option",
+			yaml:    "sku:string:uniqe",
+			wantErr: `unknown option "uniqe" of field sku`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got FieldSpec
+			err := yaml.Unmarshal([]byte(tt.yaml), &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Unmarshal() = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal() = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldSpecCheckRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  FieldSpec
+		want   []string
+		wantOK bool
+	}{
+		{
+			name:   "no check",
+			field:  FieldSpec{Name: "quantity"},
+			wantOK: true,
+		},
+		{
+			name:   "comparison",
+			field:  FieldSpec{Name: "quantity", Check: "quantity >= 0"},
+			want:   []string{"gte=0"},
+			wantOK: true,
+		},
+		{
+			name:   "range",
+			field:  FieldSpec{Name: "price", Check: "price > 0 AND price <= 99.5"},
+			want:   []string{"gt=0", "lte=99.5"},
+			wantOK: true,
+		},
+		{
+			name:   "parenthesized conjuncts",
+			field:  FieldSpec{Name: "price", Check: "(price <> -1) and (price < 10)"},
+			want:   []string{"ne=-1", "lt=10"},
+			wantOK: true,
+		},
+		{
+			name:   "length",
+			field:  FieldSpec{Name: "name", Check: "length(name) <= 100"},
+			want:   []string{"max=100"},
+			wantOK: true,
+		},
+		{
+			name:   "in list",
+			field:  FieldSpec{Name: "status", Check: "status IN ('draft', 'sent')"},
+			want:   []string{"oneof=draft sent"},
+			wantOK: true,
+		},
+		{
+			name:  "in list with spaces",
+			field: FieldSpec{Name: "status", Check: "status IN ('in review', 'sent')"},
+		},
+		{
+			name:  "other column",
+			field: FieldSpec{Name: "quantity", Check: "price >= 0"},
+		},
+		{
+			name:  "untranslatable",
+			field: FieldSpec{Name: "quantity", Check: "quantity % 2 = 0"},
+		},
+		{
+			name:  "partly translatable",
+			field: FieldSpec{Name: "quantity", Check: "quantity >= 0 OR quantity IS NULL"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.field.CheckRules()
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckRules() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package crud
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// CorrelationConfig matches --correlation-id to the project's header and log
+// conventions.
+type CorrelationConfig struct {
+	// Header is the request header carrying the correlation ID.
+	Header string `yaml:"header"`
+	// LogField is the name of the log field the ID is written to.
+	LogField string `yaml:"log_field"`
+}
+
+var (
+	headerName   = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+	logFieldName = regexp.MustCompile(`^[a-z][a-z0-9_.]*$`)
+)
+
+// withDefaults fills in the names left out of the config.
+func (c CorrelationConfig) withDefaults() CorrelationConfig {
+	if c.Header == "" {
+		c.Header = "X-Request-ID"
+	}
+	if c.LogField == "" {
+		c.LogField = "correlation_id"
+	}
+	return c
+}
+
+func (c CorrelationConfig) validate() error {
+	c = c.withDefaults()
+	if !headerName.MatchString(c.Header) {
+		return fmt.Errorf("correlation.header: %q is not a valid header name", c.Header)
+	}
+	if !logFieldName.MatchString(c.LogField) {
+		return fmt.Errorf("correlation.log_field: %q must be lower case letters, digits, '_' and '.'", c.LogField)
+	}
+	return nil
+}
+
+// --- CORRELATION ID TEMPLATES ---
+
+// correlationTemplate is shared by every entity, so it is generated once and
+// left alone on later runs; delete it to pick up a changed header.
+const correlationTemplate = `package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+// Header is the request header carrying the correlation ID. Responses and
+// outgoing requests repeat it.
+const Header = "{{.Correlation.Header}}"
+
+// validID bounds the IDs accepted from clients, which end up in logs.
+var validID = regexp.MustCompile(` + "`" + `^[A-Za-z0-9._-]{1,128}$` + "`" + `)
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying the correlation ID.
+func WithID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the correlation ID carried by ctx, or "" without one.
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// FromRequest adds the correlation ID of the request to ctx, generating one
+// when the client sent none or an invalid one, and repeats it on the response.
+func FromRequest(ctx context.Context, c *ports.HttpContext) context.Context {
+	id := c.Get(Header)
+	if !validID.MatchString(id) {
+		id = newID()
+	}
+	c.Set(Header, id)
+	return WithID(ctx, id)
+}
+
+func newID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Logger adds the correlation ID of ctx to the messages of log as the
+// {{.Correlation.LogField}} field.
+func Logger(log ports.LoggerWithTraceID) ports.LoggerWithTraceID {
+	if _, ok := log.(logger); ok {
+		return log
+	}
+	return logger{log}
+}
+
+type logger struct {
+	ports.LoggerWithTraceID
+}
+
+func (l logger) Info(ctx context.Context, message string) {
+	l.LoggerWithTraceID.Info(ctx, withField(ctx, message))
+}
+
+func (l logger) Error(ctx context.Context, message string) {
+	l.LoggerWithTraceID.Error(ctx, withField(ctx, message))
+}
+
+func withField(ctx context.Context, message string) string {
+	if id := ID(ctx); id != "" {
+		return fmt.Sprintf("%s {{.Correlation.LogField}}=%s", message, id)
+	}
+	return message
+}
+`
@@ -0,0 +1,62 @@
+package crud
+
+import "strings"
+
+// AllowedMethods lists the HTTP methods the generated routes respond to,
+// including OPTIONS for CORS preflight requests.
+func (d TemplateData) AllowedMethods() string {
+	if d.AppendOnly {
+		return "GET, POST, OPTIONS"
+	}
+	methods := []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	return strings.Join(methods, ", ")
+}
+
+// --- CORS TEMPLATES ---
+
+const corsTemplate = `package {{.LowerCase}}
+
+import (
+	"slices"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+const (
+	corsAllowedMethods = "{{.AllowedMethods}}"
+	corsAllowedHeaders = "Origin, Content-Type, Accept, Authorization"
+	corsMaxAge         = "600"
+{{- if .PaginationHeaders}}
+	// corsExposedHeaders are the response headers browsers let clients read.
+	corsExposedHeaders = "X-Total-Count, Link"
+{{- end}}
+)
+
+// CORS returns the middleware for the {{.PascalCase}} route group. It answers
+// preflight requests itself and only echoes origins found in allowedOrigins;
+// pass "*" to allow any origin.
+func CORS(allowedOrigins ...string) func(c *ports.HttpContext) error {
+	allowAny := slices.Contains(allowedOrigins, "*")
+
+	return func(c *ports.HttpContext) error {
+		origin := c.Get("Origin")
+		if origin == "" || !(allowAny || slices.Contains(allowedOrigins, origin)) {
+			return c.Next()
+		}
+
+		c.Set("Access-Control-Allow-Origin", origin)
+		c.Set("Vary", "Origin")
+		if c.Method() != "OPTIONS" {
+{{- if .PaginationHeaders}}
+			c.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+{{- end}}
+			return c.Next()
+		}
+
+		c.Set("Access-Control-Allow-Methods", corsAllowedMethods)
+		c.Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+		c.Set("Access-Control-Max-Age", corsMaxAge)
+		return c.SendStatus(204)
+	}
+}
+`
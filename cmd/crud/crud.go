@@ -1,12 +1,15 @@
 package crud
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
-	"text/template"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -16,13 +19,119 @@ type TemplateData struct {
 	CamelCase  string
 	LowerCase  string
 	KebabCase  string
+	SnakeCase  string
+	Options
+	// Pagination holds the entity's pagination defaults from the config.
+	Pagination PaginationDefaults
+	// Auth holds the claim names from the config, with defaults filled in.
+	Auth AuthConfig
+	// Envelope is the response envelope from the config.
+	Envelope EnvelopeConfig
+	// Correlation holds the correlation ID header and log field from the
+	// config, with defaults filled in.
+	Correlation CorrelationConfig
+	// Features holds the feature toggles of the config and the entity's spec.
+	Features Features
+	// Entity is the entity's definition from --spec, empty without one.
+	Entity EntitySpec
+	// Deprecation is set by the deprecate command.
+	Deprecation Deprecation
+	// RestrictedBy lists the spec entities whose foreign keys block deleting
+	// a referenced row of this entity.
+	RestrictedBy []string
+	// Client is the enrich_with service whose client files are being
+	// rendered; it is empty for the entity's other files.
+	Client clientDependency
+}
+
+// Options holds the optional features selected on the command line.
+type Options struct {
+	Webhooks           bool
+	Worker             bool
+	RetentionJob       string
+	Resilience         bool
+	Timeout            time.Duration
+	InMemory           bool
+	Stub               bool
+	MockServer         bool
+	Pact               bool
+	HTTPFile           bool
+	Fuzz               bool
+	Bench              bool
+	Swagger            string
+	Errors             string
+	SwagInit           bool
+	ContentNegotiation bool
+	CORS               bool
+	Admin              bool
+	InternalAPI        bool
+	Gateway            string
+	UI                 string
+	Adminctl           bool
+	SpecFile           string
+	I18n               bool
+	IDType             string
+	AppendOnly         bool
+	DB                 string
+	RLS                string
+	CDC                string
+	FeatureFlag        string
+	Claims             bool
+	CorrelationID      bool
+	Auth               string
+	LogQueries         bool
+	Makefile           bool
+	Profile            string
+	Archive            string
+	GitCommit          bool
+	FileMode           string
+	DirMode            string
+	LineEndings        string
+	DryRun             bool
+	ModuleGroup        string
+	SummaryJSON        string
+	ReadReplicas       bool
+	SlowQuery          time.Duration
+	GDPR               string
+	PaginationHeaders  bool
+	SparseFields       bool
+	Include            bool
+	CheckDuplicate     bool
+	Clone              bool
+	SoftDelete         bool
+	History            bool
+	Approval           bool
+	Saga               bool
+	ClientTransport    string
+	Transport          string
+}
+
+// writesWorkingTree reports whether the run writes into the working tree,
+// which --archive and --dry-run leave alone.
+func (o Options) writesWorkingTree() bool {
+	return o.Archive == "" && !o.DryRun
 }
 
+var options Options
+
 var rootCmd = &cobra.Command{
 	Use:   "gocrud-gen",
 	Short: "A CLI tool to generate CRUD boilerplate for Go projects.",
 	Long: `gocrud-gen is a command-line tool that automates the creation of 
 repository, service, and controller layers for a new entity.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		config, err = loadConfig(configFile, presetName)
+		if err != nil {
+			return err
+		}
+		startUsage(cmd)
+		config.Templates, err = resolveTemplates(config.Templates, config.TemplatesChecksum)
+		return err
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		recordUsage()
+	},
 }
 
 var crudCmd = &cobra.Command{
@@ -33,119 +142,1362 @@ You must provide the entity name in PascalCase. For example:
 
 go run . crud SbsFee`,
 	Args: cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		profile, err := profileName(options.Profile, options.SpecFile, args[0])
+		if err != nil {
+			return err
+		}
+		if err := applyProfile(profile, cmd.Flags()); err != nil {
+			return err
+		}
+		return validateOptions(options)
+	},
+	// The usage report only records the run when generating succeeds, as
+	// cobra skips the post run of commands that fail.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		entityName := args[0]
-		generateCrud(entityName)
+		writer := newFileWriter(options, resolveFileModes(options, config))
+		return generateCrud(entityName, generationRun(entityName, cmd.LocalNonPersistentFlags()), options, writer)
 	},
 }
 
 func init() {
+	crudCmd.Flags().BoolVar(&options.Webhooks, "webhooks", false, "Generate webhook subscriptions and signed event delivery for the entity")
+	crudCmd.Flags().BoolVar(&options.Worker, "worker", false, "Generate a queue-backed background worker for async post-processing of the entity")
+	crudCmd.Flags().StringVar(&options.RetentionJob, "retention-job", "", "Generate a scheduled job for soft-deleted rows: 'purge' or 'archive'")
+	crudCmd.Flags().BoolVar(&options.Resilience, "resilience", false, "Generate a retry and circuit-breaker decorator around the repository")
+	crudCmd.Flags().DurationVar(&options.Timeout, "timeout", 0, "Default per-operation timeout enforced in the generated service (e.g. 3s); 0 disables it")
+	crudCmd.Flags().BoolVar(&options.InMemory, "inmem", false, "Generate a map-backed in-memory repository for unit tests")
+	crudCmd.Flags().BoolVar(&options.Stub, "stub", false, "Generate the controller and a stub service returning canned data, without a repository")
+	crudCmd.Flags().BoolVar(&options.MockServer, "mock-server", false, "Add the entity's routes to a standalone in-memory mock server in cmd/mockserver")
+	crudCmd.Flags().BoolVar(&options.Pact, "pact", false, "Generate Pact provider verification and example consumer contract tests")
+	crudCmd.Flags().BoolVar(&options.HTTPFile, "http-file", false, "Generate an api/<entity>.http file with ready-to-run requests for every endpoint")
+	crudCmd.Flags().BoolVar(&options.Fuzz, "fuzz", false, "Generate fuzz tests for decoding and validating the create and update requests")
+	crudCmd.Flags().BoolVar(&options.Bench, "bench", false, "Generate FindAll benchmarks against a seeded test database")
+	crudCmd.Flags().StringVar(&options.Swagger, "swagger", swaggerSwaggo, "API doc comments for handlers: 'swaggo', 'openapi-gen' or 'none'")
+	crudCmd.Flags().StringVar(&options.Errors, "errors", errorsDetails, "Error response bodies: 'error-details' (the framework's ErrorDetails) or 'problem-json' (RFC 7807)")
+	crudCmd.Flags().BoolVar(&options.SwagInit, "swag-init", false, "Run swag after generation and verify the new routes appear in the docs")
+	crudCmd.Flags().BoolVar(&options.ContentNegotiation, "content-negotiation", false, "Generate handlers that honour the Accept header (JSON, XML, MessagePack)")
+	crudCmd.Flags().BoolVar(&options.CORS, "cors", false, "Generate CORS and preflight handling for the entity's route group")
+	crudCmd.Flags().BoolVar(&options.Admin, "admin", false, "Serve write operations from a separate admin controller under /admin/api/v1")
+	crudCmd.Flags().BoolVar(&options.InternalAPI, "internal-api", false, "Restrict the public handlers to a response DTO and serve full data from an internal controller under /internal/api/v1")
+	crudCmd.Flags().StringVar(&options.Gateway, "gateway", "", "Generate an API gateway route fragment for the entity: 'kong', 'traefik' or 'envoy'")
+	crudCmd.Flags().StringVar(&options.UI, "ui", "", "Generate admin pages for the entity: 'templ' (server-rendered templ + HTMX) or 'react-admin'")
+	crudCmd.Flags().BoolVar(&options.Adminctl, "adminctl", false, "Add list, get, create and delete subcommands for the entity to the cmd/adminctl CLI")
+	crudCmd.Flags().StringVar(&options.SpecFile, "spec", "", "Entities spec file with the entity's fields, e.g. entities.yaml, or '-' to read it from stdin")
+	crudCmd.Flags().BoolVar(&options.I18n, "i18n", false, "Return translation keys for the entity's errors and register them in the locale files")
+	crudCmd.Flags().StringVar(&options.IDType, "id-type", idTypeInt64, "Type of the entity's primary key: 'int64' or 'uuid'")
+	crudCmd.Flags().BoolVar(&options.AppendOnly, "append-only", false, "Generate only create and keyset-paginated list operations for an event or log style entity without ids")
+	crudCmd.Flags().StringVar(&options.DB, "db", dbPostgres, "Database the entity is stored in: 'postgres', 'clickhouse' (batched inserts, list and time-bucket counts, no updates or deletes) or 'cassandra' (gocql, needs --id-type uuid), 'sqlserver' or 'oracle' (database/sql, need --spec) or 'cockroach' (the Postgres repository with serialization retries), 'firestore' or 'spanner' (Google Cloud clients with batched writes and cursor pages, need --id-type uuid; spanner needs --spec) or 'bolt' (an embedded bbolt file with index buckets for the list filters)")
+	crudCmd.Flags().StringVar(&options.RLS, "rls", "", "Generate a Postgres row-level security policy scoping rows by 'tenant' (tenant_id) or 'owner' (owner_id)")
+	crudCmd.Flags().StringVar(&options.CDC, "cdc", "", "Generate a typed change listener for the entity's table: 'notify' (LISTEN/NOTIFY trigger) or 'debezium' (Kafka change events)")
+	crudCmd.Flags().StringVar(&options.FeatureFlag, "feature-flag", "", "Gate the entity's routes behind the named feature flag, answering 404 while it is disabled")
+	crudCmd.Flags().BoolVar(&options.Claims, "claims", false, "Make the handlers read the caller's user id, roles and tenant from the JWT claims into the request context")
+	crudCmd.Flags().BoolVar(&options.CorrelationID, "correlation-id", false, "Carry the request's correlation ID (X-Request-ID by default) into the context, log messages, worker jobs and webhook deliveries")
+	crudCmd.Flags().StringVar(&options.Auth, "auth", "", "Generate authentication for the entity's routes: 'apikey' (API-key middleware, keys table and management endpoints)")
+	crudCmd.Flags().BoolVar(&options.LogQueries, "log-queries", false, "Log every repository operation with its duration through the logger, which adds the trace ID")
+	crudCmd.Flags().BoolVar(&options.Makefile, "makefile", false, "Add migrate-, test- and mocks- targets for the entity to the project Makefile in a marked block")
+	crudCmd.Flags().StringVar(&options.Profile, "profile", "", "Set of flags to generate with, from the profiles in the config or built in: 'minimal' or 'full'")
+	crudCmd.Flags().StringVar(&options.Archive, "archive", "", "Write the generated files into a .tar.gz or .zip archive instead of the working tree")
+	crudCmd.Flags().BoolVar(&options.GitCommit, "git-commit", false, "Generate on a new crudgen/<entity> branch and commit only the generated and updated files")
+	crudCmd.Flags().StringVar(&options.FileMode, "file-mode", "", "Octal permissions of the generated files, e.g. 0640 (default 0644, or file_mode in the config)")
+	crudCmd.Flags().StringVar(&options.DirMode, "dir-mode", "", "Octal permissions of the created directories, e.g. 0750 (default 0755, or dir_mode in the config)")
+	crudCmd.Flags().StringVar(&options.LineEndings, "line-endings", lineEndingsLF, "Line endings of the generated files: 'lf', 'crlf' or 'auto' (those of go.mod, else the platform's)")
+	crudCmd.Flags().BoolVar(&options.DryRun, "dry-run", false, "Print the files that would be generated without writing anything")
+	crudCmd.Flags().StringVar(&options.ModuleGroup, "module-group", "", "Generate the entity's repository, service and controller into the internal/features/<group> feature module, with its wiring")
+	crudCmd.Flags().StringVar(&options.SummaryJSON, "summary-json", "", "Also write the summary of the generated files, lines, endpoints, migrations and tests as JSON to this file")
+	crudCmd.Flags().BoolVar(&options.ReadReplicas, "read-replicas", false, "Generate a decorator sending the repository's reads to a replica pool and writes to the primary")
+	crudCmd.Flags().DurationVar(&options.SlowQuery, "slow-query", 0, "Trace the repository operations with spans and log a warning for those slower than this threshold (e.g. 200ms); 0 disables it")
+	crudCmd.Flags().StringVar(&options.GDPR, "gdpr", "", "Generate a GET /users/{id}/<entity>/export endpoint and an erasure of a user's rows registered with the data subject request registry: 'anonymize' (overwrites the pii fields) or 'delete'")
+	crudCmd.Flags().BoolVar(&options.PaginationHeaders, "pagination-headers", false, "Also return the list pagination in GitHub-style X-Total-Count and Link (rel=next/prev) headers")
+	crudCmd.Flags().BoolVar(&options.SparseFields, "sparse-fields", false, "Accept a 'fields' query parameter on the list and get endpoints selecting the returned fields, whitelisted from --spec, and read only their columns from the database")
+	crudCmd.Flags().BoolVar(&options.Include, "include", false, "Load the relations declared in --spec only when the list and get endpoints are asked to nest them with an 'include' query parameter, e.g. include=customer,items")
+	crudCmd.Flags().BoolVar(&options.CheckDuplicate, "check-duplicate", false, "Generate a POST /api/v1/<entity>/check-duplicate endpoint reporting which unique fields of --spec a new entity would duplicate")
+	crudCmd.Flags().BoolVar(&options.Clone, "clone", false, "Generate a POST /api/v1/<entity>/{id}/clone endpoint creating a copy of an entity, with the fields marked reset_on_clone in --spec reset")
+	crudCmd.Flags().BoolVar(&options.SoftDelete, "soft-delete", false, "Generate a GET /api/v1/<entity>/trash endpoint listing the soft-deleted entities and a POST /api/v1/<entity>/{id}/restore endpoint restoring one, through a nullable deleted_at column")
+	crudCmd.Flags().BoolVar(&options.History, "history", false, "Generate an <entity>_history table a trigger fills with a snapshot of the row on every update, and a GET /api/v1/<entity>/{id}/history endpoint listing the previous versions")
+	crudCmd.Flags().BoolVar(&options.Approval, "approval", false, "Generate a pending changes table and endpoints submitting changes to an entity and approving or rejecting them, applying approved changes to the row")
+	crudCmd.Flags().BoolVar(&options.Saga, "saga", false, "Create entities through a saga orchestrator running steps on other services, compensating the completed steps when one fails and persisting its progress in a saga_state table")
+	crudCmd.Flags().StringVar(&options.ClientTransport, "client-transport", clientGRPC, "Transport of the client adapters generated for the enrich_with services in --spec: 'grpc' or 'http'")
+	crudCmd.Flags().StringVar(&options.Transport, "transport", transportREST, "Transport the entity is served over: 'rest', or 'graphql' to add a gqlgen schema and resolvers next to the REST handlers, batching the relations in --spec with dataloaders")
 	rootCmd.AddCommand(crudCmd)
 }
 
+func validateOptions(opts Options) error {
+	switch opts.RetentionJob {
+	case "", retentionPurge, retentionArchive:
+	default:
+		return fmt.Errorf("invalid --retention-job %q: must be %q or %q", opts.RetentionJob, retentionPurge, retentionArchive)
+	}
+	switch opts.Swagger {
+	case swaggerSwaggo, swaggerOpenAPIGen, swaggerNone:
+	default:
+		return fmt.Errorf("invalid --swagger %q: must be %q, %q or %q", opts.Swagger, swaggerSwaggo, swaggerOpenAPIGen, swaggerNone)
+	}
+	switch opts.Errors {
+	case errorsDetails, errorsProblemJSON:
+	default:
+		return fmt.Errorf("invalid --errors %q: must be %q or %q", opts.Errors, errorsDetails, errorsProblemJSON)
+	}
+	switch opts.Gateway {
+	case "", gatewayKong, gatewayTraefik, gatewayEnvoy:
+	default:
+		return fmt.Errorf("invalid --gateway %q: must be %q, %q or %q", opts.Gateway, gatewayKong, gatewayTraefik, gatewayEnvoy)
+	}
+	switch opts.UI {
+	case "", uiTempl, uiReactAdmin:
+	default:
+		return fmt.Errorf("invalid --ui %q: must be %q or %q", opts.UI, uiTempl, uiReactAdmin)
+	}
+	switch opts.DB {
+	case dbPostgres:
+	case dbCockroach:
+		if opts.AppendOnly || opts.RLS != "" || opts.CDC != "" || opts.History {
+			return fmt.Errorf("--db %s cannot be combined with --append-only, --rls, --cdc or --history, which rely on PostgreSQL features CockroachDB lacks", dbCockroach)
+		}
+	case dbClickHouse:
+		if opts.needsFullRepository() || opts.RLS != "" || opts.ModuleGroup != "" {
+			return fmt.Errorf("--db %s generates only create, list and time bucket operations and cannot be combined with --rls, --module-group or options that need ids, updates, deletes or the full repository", dbClickHouse)
+		}
+	case dbCassandra, dbSQLServer, dbOracle, dbFirestore, dbSpanner, dbBolt:
+		if opts.DB == dbCassandra && opts.IDType != idTypeUUID {
+			return fmt.Errorf("--db %s needs --id-type %s, as Cassandra cannot generate sequential ids", dbCassandra, idTypeUUID)
+		}
+		if (opts.DB == dbFirestore || opts.DB == dbSpanner) && opts.IDType != idTypeUUID {
+			return fmt.Errorf("--db %s needs --id-type %s, as sequential ids send every write to the same server", opts.DB, idTypeUUID)
+		}
+		if (opts.DB == dbSQLServer || opts.DB == dbOracle) && opts.SpecFile == "" {
+			return fmt.Errorf("--db %s needs --spec, whose field types map the JSON rows to the table's columns", opts.DB)
+		}
+		if opts.DB == dbSpanner && opts.SpecFile == "" {
+			return fmt.Errorf("--db %s needs --spec, whose fields define the table's columns", dbSpanner)
+		}
+		if opts.AppendOnly || opts.Stub || opts.InternalAPI || opts.Webhooks || opts.RetentionJob != "" || opts.Bench || opts.Pact || opts.CDC != "" || opts.RLS != "" || opts.LogQueries || opts.ModuleGroup != "" {
+			return fmt.Errorf("--db %s cannot be combined with --append-only, --stub, --internal-api, --webhooks, --retention-job, --bench, --pact, --cdc, --rls, --log-queries or --module-group, which need the Postgres repository", opts.DB)
+		}
+	default:
+		return fmt.Errorf("invalid --db %q: must be %q, %q, %q, %q, %q, %q, %q, %q or %q", opts.DB, dbPostgres, dbCockroach, dbClickHouse, dbCassandra, dbSQLServer, dbOracle, dbFirestore, dbSpanner, dbBolt)
+	}
+	switch opts.RLS {
+	case "", rlsTenant, rlsOwner:
+	default:
+		return fmt.Errorf("invalid --rls %q: must be %q or %q", opts.RLS, rlsTenant, rlsOwner)
+	}
+	switch opts.GDPR {
+	case "", gdprAnonymize, gdprDelete:
+	default:
+		return fmt.Errorf("invalid --gdpr %q: must be %q or %q", opts.GDPR, gdprAnonymize, gdprDelete)
+	}
+	switch opts.ClientTransport {
+	case "", clientGRPC, clientHTTP:
+	default:
+		return fmt.Errorf("invalid --client-transport %q: must be %q or %q", opts.ClientTransport, clientGRPC, clientHTTP)
+	}
+	switch opts.Transport {
+	case "", transportREST:
+	case transportGraphQL:
+		if opts.AppendOnly || opts.DB == dbClickHouse || opts.DB == dbCassandra || opts.DB == dbFirestore || opts.DB == dbSpanner || opts.DB == dbBolt {
+			return fmt.Errorf("--transport %s resolves single entities and numbered pages and cannot be combined with --append-only or --db %s, %s, %s, %s or %s", transportGraphQL, dbClickHouse, dbCassandra, dbFirestore, dbSpanner, dbBolt)
+		}
+	default:
+		return fmt.Errorf("invalid --transport %q: must be %q or %q", opts.Transport, transportREST, transportGraphQL)
+	}
+	if opts.GDPR != "" && opts.DB != dbPostgres && opts.DB != dbCockroach {
+		return fmt.Errorf("--gdpr needs --db %s or %s, whose database the data subject queries run on", dbPostgres, dbCockroach)
+	}
+	switch opts.Auth {
+	case "", authAPIKey:
+	default:
+		return fmt.Errorf("invalid --auth %q: must be %q", opts.Auth, authAPIKey)
+	}
+	switch opts.CDC {
+	case "", cdcNotify, cdcDebezium:
+	default:
+		return fmt.Errorf("invalid --cdc %q: must be %q or %q", opts.CDC, cdcNotify, cdcDebezium)
+	}
+	switch opts.LineEndings {
+	case lineEndingsLF, lineEndingsCRLF, lineEndingsAuto:
+	default:
+		return fmt.Errorf("invalid --line-endings %q: must be %q, %q or %q", opts.LineEndings, lineEndingsLF, lineEndingsCRLF, lineEndingsAuto)
+	}
+	switch opts.IDType {
+	case idTypeInt64:
+	case idTypeUUID:
+		if opts.InMemory || opts.UI != "" || opts.MockServer || opts.Adminctl {
+			return fmt.Errorf("--id-type=%s cannot be combined with --inmem, --ui, --mock-server or --adminctl, which assume int64 ids", idTypeUUID)
+		}
+	default:
+		return fmt.Errorf("invalid --id-type %q: must be %q or %q", opts.IDType, idTypeInt64, idTypeUUID)
+	}
+	if opts.FeatureFlag != "" && !featureFlagName.MatchString(opts.FeatureFlag) {
+		return fmt.Errorf("invalid --feature-flag %q: must start with a letter and contain only letters, digits, '_', '.' and '-'", opts.FeatureFlag)
+	}
+	if opts.SwagInit && opts.Swagger != swaggerSwaggo {
+		return fmt.Errorf("--swag-init requires --swagger=%s", swaggerSwaggo)
+	}
+	if opts.FileMode != "" {
+		if _, err := parseFileMode("--file-mode", opts.FileMode); err != nil {
+			return err
+		}
+	}
+	if opts.DirMode != "" {
+		if _, err := parseFileMode("--dir-mode", opts.DirMode); err != nil {
+			return err
+		}
+	}
+	if opts.ModuleGroup != "" {
+		if !moduleGroupName.MatchString(opts.ModuleGroup) {
+			return fmt.Errorf("invalid --module-group %q: must be a lowercase Go package name", opts.ModuleGroup)
+		}
+		if opts.AppendOnly {
+			return fmt.Errorf("--module-group cannot be combined with --append-only, whose repository is built from a *sql.DB")
+		}
+	}
+	if opts.DryRun && (opts.Archive != "" || opts.GitCommit || opts.SwagInit) {
+		return fmt.Errorf("--dry-run writes nothing and cannot be combined with --archive, --git-commit or --swag-init")
+	}
+	if opts.Archive != "" {
+		if _, err := archiveFormat(opts.Archive); err != nil {
+			return err
+		}
+		if opts.SwagInit || opts.GitCommit {
+			return fmt.Errorf("--swag-init and --git-commit work on the working tree and cannot be combined with --archive")
+		}
+	}
+	if opts.Timeout < 0 {
+		return fmt.Errorf("invalid --timeout %s: must not be negative", opts.Timeout)
+	}
+	if opts.SlowQuery < 0 {
+		return fmt.Errorf("invalid --slow-query %s: must not be negative", opts.SlowQuery)
+	}
+	if opts.SlowQuery > 0 && opts.DB != dbPostgres && opts.DB != dbCockroach {
+		return fmt.Errorf("--slow-query needs --db %s or %s, whose repository it instruments", dbPostgres, dbCockroach)
+	}
+	if opts.ReadReplicas && opts.DB != dbPostgres && opts.DB != dbCockroach {
+		return fmt.Errorf("--read-replicas needs --db %s or %s, whose repository reads through a Database handle", dbPostgres, dbCockroach)
+	}
+	if opts.PaginationHeaders && (opts.AppendOnly || opts.DB == dbClickHouse || opts.DB == dbCassandra || opts.DB == dbFirestore || opts.DB == dbSpanner || opts.DB == dbBolt) {
+		return fmt.Errorf("--pagination-headers links pages by number and cannot be combined with --append-only or --db %s, %s, %s, %s or %s, whose lists page by cursor", dbClickHouse, dbCassandra, dbFirestore, dbSpanner, dbBolt)
+	}
+	if opts.SparseFields && opts.SpecFile == "" {
+		return fmt.Errorf("--sparse-fields needs --spec, whose fields are the ones a request may select")
+	}
+	if opts.SparseFields && (opts.AppendOnly || (opts.DB != dbPostgres && opts.DB != dbCockroach)) {
+		return fmt.Errorf("--sparse-fields needs --db %s or %s without --append-only, whose repository reads the selected columns", dbPostgres, dbCockroach)
+	}
+	if opts.Include && opts.SpecFile == "" {
+		return fmt.Errorf("--include needs --spec, whose relations are the ones a request may include")
+	}
+	if opts.CheckDuplicate && opts.SpecFile == "" {
+		return fmt.Errorf("--check-duplicate needs --spec, whose unique fields are the ones it checks")
+	}
+	if opts.CheckDuplicate && (opts.AppendOnly || (opts.DB != dbPostgres && opts.DB != dbCockroach)) {
+		return fmt.Errorf("--check-duplicate needs --db %s or %s without --append-only, whose database the duplicate query runs on", dbPostgres, dbCockroach)
+	}
+	if opts.SoftDelete && (opts.AppendOnly || (opts.DB != dbPostgres && opts.DB != dbCockroach)) {
+		return fmt.Errorf("--soft-delete needs --db %s or %s without --append-only, whose repository reads and restores the soft-deleted rows", dbPostgres, dbCockroach)
+	}
+	if opts.History && opts.DB != dbPostgres && opts.DB != dbCockroach {
+		return fmt.Errorf("--history needs --db %s, whose trigger records the versions", dbPostgres)
+	}
+	if opts.Approval && !opts.Claims {
+		return fmt.Errorf("--approval needs --claims, whose caller ids record who submitted and reviewed each change")
+	}
+	if opts.Approval && opts.DB != dbPostgres && opts.DB != dbCockroach {
+		return fmt.Errorf("--approval needs --db %s or %s, whose database stores the pending changes", dbPostgres, dbCockroach)
+	}
+	if opts.Saga && opts.DB != dbPostgres && opts.DB != dbCockroach {
+		return fmt.Errorf("--saga needs --db %s or %s, whose database stores the saga state", dbPostgres, dbCockroach)
+	}
+	if opts.Stub && (opts.Webhooks || opts.Worker || opts.RetentionJob != "" || opts.Resilience || opts.Timeout > 0 || opts.InMemory || opts.Bench || opts.CDC != "" || opts.LogQueries || opts.ReadReplicas || opts.SlowQuery > 0 || opts.GDPR != "" || opts.CheckDuplicate || opts.Clone || opts.SoftDelete || opts.History || opts.Approval || opts.Saga) {
+		return fmt.Errorf("--stub generates no repository and cannot be combined with repository or service options")
+	}
+	if opts.AppendOnly && opts.needsFullRepository() {
+		return fmt.Errorf("--append-only generates only create and list operations and cannot be combined with options that need ids, updates, deletes or the full repository")
+	}
+	return nil
+}
+
+// needsFullRepository reports whether an option needs ids, updates, deletes
+// or the full repository, which append-only entities do not have.
+func (o Options) needsFullRepository() bool {
+	return o.Stub || o.Admin || o.InternalAPI || o.Webhooks || o.Worker || o.RetentionJob != "" || o.Resilience || o.Timeout > 0 ||
+		o.InMemory || o.MockServer || o.Pact || o.Bench || o.UI != "" || o.Adminctl || o.IDType != idTypeInt64 || o.CDC != "" || o.LogQueries || o.ReadReplicas || o.SlowQuery > 0 || o.GDPR != "" || o.Clone || o.SoftDelete || o.History || o.Approval || o.Saga
+}
+
+// ObservesQueries reports whether the Postgres repository wraps its operations
+// to log or trace them.
+func (o Options) ObservesQueries() bool {
+	return o.LogQueries || o.SlowQuery > 0
+}
+
 func Execute() {
+	registerCompletions()
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "An error occurred: '%s'", err)
 		os.Exit(1)
 	}
-}
-
-func toKebabCase(s string) string {
-	var matchFirstCap = regexp.MustCompile("(.)([A-Z][a-z]+)")
-	var matchAllCap = regexp.MustCompile("([a-z0-9])([A-Z])")
-	snake := matchFirstCap.ReplaceAllString(s, "${1}-${2}")
-	snake = matchAllCap.ReplaceAllString(snake, "${1}-${2}")
-	return strings.ToLower(snake)
-}
-
-func generateCrud(namePascal string) {
-	fmt.Printf("--- Generating CRUD for entity: %s ---\n", namePascal)
-
-	data := TemplateData{
-		PascalCase: namePascal,
-		CamelCase:  strings.ToLower(namePascal[:1]) + namePascal[1:],
-		LowerCase:  strings.ToLower(namePascal),
-		KebabCase:  toKebabCase(namePascal),
+}
+
+func toKebabCase(s string) string {
+	var matchFirstCap = regexp.MustCompile("(.)([A-Z][a-z]+)")
+	var matchAllCap = regexp.MustCompile("([a-z0-9])([A-Z])")
+	snake := matchFirstCap.ReplaceAllString(s, "${1}-${2}")
+	snake = matchAllCap.ReplaceAllString(snake, "${1}-${2}")
+	return strings.ToLower(snake)
+}
+
+func toSnakeCase(s string) string {
+	return strings.ReplaceAll(toKebabCase(s), "-", "_")
+}
+
+// newTemplateData derives the entity's names from namePascal.
+func newTemplateData(namePascal string, opts Options) TemplateData {
+	return TemplateData{
+		PascalCase:  namePascal,
+		CamelCase:   strings.ToLower(namePascal[:1]) + namePascal[1:],
+		LowerCase:   strings.ToLower(namePascal),
+		KebabCase:   toKebabCase(namePascal),
+		SnakeCase:   toSnakeCase(namePascal),
+		Options:     opts,
+		Pagination:  config.Pagination.forEntity(namePascal),
+		Auth:        config.Auth.withDefaults(),
+		Envelope:    config.Envelope,
+		Correlation: config.Correlation.withDefaults(),
+		Features:    resolveFeatures(opts, config.Features),
+	}
+}
+
+func generateCrud(namePascal, run string, opts Options, writer FileWriter) error {
+	// ClickHouse entities are appended to, never updated in place.
+	if opts.DB == dbClickHouse {
+		opts.AppendOnly = true
+	}
+	// The spec is validated before anything is generated.
+	data := newTemplateData(namePascal, opts)
+	if opts.SpecFile != "" {
+		spec, err := loadSpec(opts.SpecFile)
+		if err == nil {
+			data.Entity, err = spec.entity(namePascal)
+			data.RestrictedBy = spec.restrictedBy(namePascal)
+			data.Features = resolveFeatures(opts, config.Features, data.Entity.Features)
+		}
+		if err != nil {
+			return fmt.Errorf("loading spec: %w", err)
+		}
+	}
+
+	fmt.Printf("--- Generating CRUD for entity: %s ---\n", namePascal)
+
+	// filesToGenerate maps the path of every file to the built-in template,
+	// by name, it is generated from.
+	filesToGenerate := map[string]string{
+		filepath.Join("internal/transport/repository/postgres", data.CamelCase+".go"):                "repository",
+		filepath.Join("internal/service", data.CamelCase+".go"):                                      "service",
+		filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "controller.go"): "controller",
+		filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "request.go"):    "request",
+		filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "routes.go"):     "routes",
+	}
+
+	if opts.AppendOnly {
+		mode := "--append-only"
+		if data.ClickHouse() {
+			mode = "--db " + dbClickHouse
+		}
+		if len(data.FilterFields()) > 0 {
+			return fmt.Errorf("%s lists by creation time and does not support the filters declared for %s in the spec", mode, data.PascalCase)
+		}
+		if data.ShapesResponse() {
+			return fmt.Errorf("%s returns dto.%s as stored and does not support the derived fields or visibilities declared in the spec", mode, data.PascalCase)
+		}
+		if len(data.EncryptedFields()) > 0 {
+			return fmt.Errorf("%s stores dto.%s as it is and does not support the encrypted fields declared in the spec", mode, data.PascalCase)
+		}
+		filesToGenerate[filepath.Join("internal/transport/repository", data.CamelCase+".go")] = "appendOnlyRepositoryInterface"
+		filesToGenerate[filepath.Join("internal/transport/repository/postgres", data.CamelCase+".go")] = "appendOnlyRepository"
+		filesToGenerate[filepath.Join("internal/service", data.CamelCase+".go")] = "appendOnlyService"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "controller.go")] = "appendOnlyController"
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_created_at_idx.up.sql")] = "appendOnlyIndexMigration"
+	}
+	if data.ClickHouse() {
+		if data.Entity.Partition != nil {
+			return fmt.Errorf("--db %s partitions %s by month of created_at and does not support the partition declared in the spec", dbClickHouse, data.PascalCase)
+		}
+		delete(filesToGenerate, filepath.Join("internal/transport/repository/postgres", data.CamelCase+".go"))
+		delete(filesToGenerate, filepath.Join("migrations", data.SnakeCase+"_created_at_idx.up.sql"))
+		filesToGenerate[filepath.Join("internal/transport/repository/clickhouse", data.CamelCase+".go")] = "clickHouseRepository"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "buckets.go")] = "clickHouseBuckets"
+		filesToGenerate[filepath.Join("internal/DTO", "timeBucket.go")] = "timeBucketDTO"
+		filesToGenerate[filepath.Join("migrations/clickhouse", data.SnakeCase+".up.sql")] = "clickHouseTableMigration"
+	}
+	if data.Cassandra() {
+		if len(data.FilterFields()) > 0 || data.Entity.Partition != nil {
+			return fmt.Errorf("--db %s does not support the filters or partition declared for %s in the spec; lay out its table with the cassandra keys instead", dbCassandra, data.PascalCase)
+		}
+		delete(filesToGenerate, filepath.Join("internal/transport/repository/postgres", data.CamelCase+".go"))
+		filesToGenerate[filepath.Join("internal/transport/repository/cassandra", data.CamelCase+".go")] = "cassandraRepository"
+		filesToGenerate[filepath.Join("internal/DTO", "pageState.go")] = "pageStateDTO"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "pageState.go")] = "pageStateParser"
+		filesToGenerate[filepath.Join("migrations/cassandra", data.SnakeCase+".cql")] = "cassandraTableMigration"
+	}
+	if data.Firestore() || data.Spanner() {
+		if len(data.FilterFields()) > 0 || data.Entity.Partition != nil {
+			return fmt.Errorf("--db %s does not support the filters or partition declared for %s in the spec", opts.DB, data.PascalCase)
+		}
+		delete(filesToGenerate, filepath.Join("internal/transport/repository/postgres", data.CamelCase+".go"))
+		filesToGenerate[filepath.Join("internal/DTO", "pageState.go")] = "pageStateDTO"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "pageState.go")] = "pageStateParser"
+		if data.Firestore() {
+			filesToGenerate[filepath.Join("internal/transport/repository/firestore", data.CamelCase+".go")] = "firestoreRepository"
+		} else {
+			filesToGenerate[filepath.Join("internal/transport/repository/spanner", data.CamelCase+".go")] = "spannerRepository"
+			filesToGenerate[filepath.Join("migrations/spanner", data.SnakeCase+".sql")] = "spannerTableMigration"
+		}
+	}
+	if data.Bolt() {
+		if data.Entity.Partition != nil {
+			return fmt.Errorf("--db %s does not support the partition declared for %s in the spec", dbBolt, data.PascalCase)
+		}
+		delete(filesToGenerate, filepath.Join("internal/transport/repository/postgres", data.CamelCase+".go"))
+		filesToGenerate[filepath.Join("internal/transport/repository/bolt", "store.go")] = "boltStore"
+		filesToGenerate[filepath.Join("internal/transport/repository/bolt", data.CamelCase+".go")] = "boltRepository"
+		// The store filters every list, so it needs dto.Filter even when this
+		// entity declares no filters.
+		filesToGenerate[filepath.Join("internal/DTO", "filter.go")] = "filterDTO"
+		filesToGenerate[filepath.Join("internal/DTO", "pageState.go")] = "pageStateDTO"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "pageState.go")] = "pageStateParser"
+	}
+	if data.Cockroach() {
+		if data.Entity.Partition != nil {
+			return fmt.Errorf("--db %s does not support the partition declared for %s in the spec, whose migration is PL/pgSQL", dbCockroach, data.PascalCase)
+		}
+		// The resilience policy already retries serialization failures.
+		if !opts.Resilience {
+			filesToGenerate[filepath.Join("internal/transport/repository", "serializationRetry.go")] = "serializationRetry"
+			filesToGenerate[filepath.Join("internal/transport/repository", data.CamelCase+"Retrying.go")] = "retryingRepository"
+		}
+	}
+	if data.SQLServer() || data.Oracle() {
+		if len(data.FilterFields()) > 0 || data.Entity.Partition != nil {
+			return fmt.Errorf("--db %s does not support the filters or partition declared for %s in the spec", opts.DB, data.PascalCase)
+		}
+		if len(data.DialectColumns()) == 0 {
+			return fmt.Errorf("--db %s needs fields or belongs_to relations for %s in the spec", opts.DB, data.PascalCase)
+		}
+		delete(filesToGenerate, filepath.Join("internal/transport/repository/postgres", data.CamelCase+".go"))
+		filesToGenerate[filepath.Join("internal/transport/repository", opts.DB, data.CamelCase+".go")] = "dialectRepository"
+		migration := "sqlServerTableMigration"
+		if data.Oracle() {
+			migration = "oracleTableMigration"
+		}
+		filesToGenerate[filepath.Join("migrations", opts.DB, data.SnakeCase+".up.sql")] = migration
+	}
+	if opts.Stub {
+		delete(filesToGenerate, filepath.Join("internal/transport/repository/postgres", data.CamelCase+".go"))
+		filesToGenerate[filepath.Join("internal/service", data.CamelCase+".go")] = "serviceStub"
+	}
+	if data.Entity.Source != nil {
+		aclDir := filepath.Join("internal/acl", data.SourcePackage())
+		filesToGenerate[filepath.Join(aclDir, data.CamelCase+"Payload.go")] = "sourceMapper"
+		filesToGenerate[filepath.Join(aclDir, data.CamelCase+"Source.go")] = "sourceAdapter"
+		filesToGenerate[filepath.Join("internal/transport/client", data.CamelCase+"Source.go")] = "sourcePort"
+	}
+	// clientFiles maps the client files of the enrich_with services to the
+	// service each is rendered for.
+	clientFiles := make(map[string]clientDependency)
+	if clients := data.Clients(); len(clients) > 0 {
+		if opts.Stub || opts.AppendOnly {
+			return fmt.Errorf("the enrich_with services of %s in the spec need the full service, which --stub and --append-only do not generate", data.PascalCase)
+		}
+		for _, client := range clients {
+			port := filepath.Join("internal/transport/client", client.CamelCase+".go")
+			adapter := filepath.Join("internal/transport/client", opts.ClientTransport+"client", client.CamelCase+".go")
+			filesToGenerate[port] = "clientPort"
+			filesToGenerate[adapter] = clientAdapterArtifact(opts.ClientTransport)
+			clientFiles[port], clientFiles[adapter] = client, client
+		}
+		filesToGenerate[filepath.Join("internal/service", data.CamelCase+"Clients.go")] = "serviceClients"
+	}
+	if len(data.FilterFields()) > 0 {
+		filesToGenerate[filepath.Join("internal/DTO", "filter.go")] = "filterDTO"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "filters.go")] = "filterParser"
+		if !data.Bolt() {
+			filesToGenerate[filepath.Join("internal/transport/repository", "filter.go")] = "filterClause"
+		}
+	}
+	if data.Pagination.IsSet() && !opts.AppendOnly {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "paginationDefaults.go")] = "paginationDefaults"
+	}
+	if opts.Webhooks {
+		filesToGenerate[filepath.Join("internal/DTO", data.CamelCase+"Webhook.go")] = "webhookDTO"
+		filesToGenerate[filepath.Join("internal/webhook", data.CamelCase+".go")] = "webhookDispatcher"
+		filesToGenerate[filepath.Join("internal/transport/repository/postgres", data.CamelCase+"Webhook.go")] = "webhookRepository"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "webhook.go")] = "webhookController"
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_webhook_subscription.up.sql")] = "webhookMigration"
+	}
+	if opts.Worker {
+		filesToGenerate[filepath.Join("internal/worker", data.SnakeCase+"_worker.go")] = "worker"
+	}
+	if opts.RetentionJob != "" {
+		filesToGenerate[filepath.Join("internal/job", data.SnakeCase+"_retention.go")] = "retentionJob"
+	}
+	if opts.RetentionJob == retentionArchive {
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_archive.up.sql")] = "retentionArchiveMigration"
+	}
+	if opts.Resilience {
+		filesToGenerate[filepath.Join("internal/transport/repository", "resilience.go")] = "resiliencePolicy"
+		filesToGenerate[filepath.Join("internal/transport/repository", data.CamelCase+"Resilient.go")] = "resilientRepository"
+	}
+	if opts.ReadReplicas {
+		filesToGenerate[filepath.Join("internal/transport/repository", "readFromPrimary.go")] = "readFromPrimary"
+		filesToGenerate[filepath.Join("internal/transport/repository", data.CamelCase+"ReadSplit.go")] = "readSplitRepository"
+	}
+	if opts.InMemory {
+		filesToGenerate[filepath.Join("internal/transport/repository/inmem", "store.go")] = "inmemStore"
+		filesToGenerate[filepath.Join("internal/transport/repository/inmem", data.CamelCase+".go")] = "inmemRepository"
+	}
+	if opts.MockServer {
+		filesToGenerate[filepath.Join("cmd/mockserver", "main.go")] = "mockServerMain"
+		filesToGenerate[filepath.Join("cmd/mockserver", data.CamelCase+".go")] = "mockServerEntity"
+	}
+	if opts.Adminctl {
+		filesToGenerate[filepath.Join("cmd/adminctl", "main.go")] = "adminctlMain"
+		filesToGenerate[filepath.Join("cmd/adminctl", data.CamelCase+".go")] = "adminctlEntity"
+	}
+	if opts.Pact {
+		filesToGenerate[filepath.Join("test/pact", "pact_test.go")] = "pactHelpers"
+		filesToGenerate[filepath.Join("test/pact", data.SnakeCase+"_consumer_test.go")] = "pactConsumer"
+		filesToGenerate[filepath.Join("test/pact", data.SnakeCase+"_provider_test.go")] = "pactProvider"
+	}
+	if opts.HTTPFile {
+		filesToGenerate[filepath.Join("api", data.KebabCase+".http")] = "httpFile"
+	}
+	if opts.ContentNegotiation {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "negotiate.go")] = "negotiation"
+	}
+
+	if opts.CorrelationID {
+		filesToGenerate[filepath.Join("internal/correlation", "correlation.go")] = "correlation"
+	}
+
+	if data.ProblemJSON() {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "problem.go")] = "problem"
+	}
+
+	if data.Envelope.IsSet() {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "envelope.go")] = "envelope"
+	}
+	if opts.CORS {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "cors.go")] = "cors"
+	}
+	if opts.PaginationHeaders {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "paginationHeaders.go")] = "paginationHeaders"
+	}
+	if len(data.SortFields()) > 0 {
+		filesToGenerate[filepath.Join("internal/DTO", "sort.go")] = "sortDTO"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "sort.go")] = "sortParser"
+		if !opts.Stub {
+			filesToGenerate[filepath.Join("internal/transport/repository", "orderBy.go")] = "orderBy"
+		}
+	}
+	if data.Scoped() {
+		filesToGenerate[filepath.Join("internal/DTO", "scope.go")] = "scopeDTO"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "scope.go")] = "scopeParser"
+		if !opts.Stub {
+			filesToGenerate[filepath.Join("internal/transport/repository", "scope.go")] = "scopeClause"
+		}
+	}
+	if opts.SparseFields {
+		filesToGenerate[filepath.Join("internal/DTO", "fields.go")] = "sparseFieldsDTO"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "fields.go")] = "sparseFieldsParser"
+		if !opts.Stub {
+			filesToGenerate[filepath.Join("internal/transport/repository", "fields.go")] = "sparseSelect"
+		}
+	}
+	if opts.Auth == authAPIKey {
+		filesToGenerate[filepath.Join("internal/DTO", data.CamelCase+"APIKey.go")] = "apiKeyDTO"
+		filesToGenerate[filepath.Join("internal/apikey", data.CamelCase+".go")] = "apiKey"
+		filesToGenerate[filepath.Join("internal/transport/repository/postgres", data.CamelCase+"APIKey.go")] = "apiKeyRepository"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "apiKey.go")] = "apiKeyController"
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_api_key.up.sql")] = "apiKeyMigration"
+	}
+	if opts.Claims {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "claims.go")] = "claims"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "caller.go")] = "caller"
+	}
+	if opts.FeatureFlag != "" {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "featureGate.go")] = "featureGate"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "featureFlag.go")] = "featureFlag"
+	}
+	if data.ShapesResponse() && !opts.InternalAPI {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "response.go")] = "shapedResponse"
+	}
+	if len(data.MaskedFields()) > 0 {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "mask.go")] = "mask"
+	}
+	if len(data.RedactedFields()) > 0 {
+		filesToGenerate[filepath.Join("internal/redact", "redact.go")] = "redact"
+		filesToGenerate[filepath.Join("internal/redact", data.CamelCase+".go")] = "redactEntity"
+	}
+	if data.EncryptsFields() {
+		filesToGenerate[filepath.Join("internal/encryption", "envelope.go")] = "envelopeEncryption"
+		filesToGenerate[filepath.Join("internal/transport/repository", data.CamelCase+"Encrypting.go")] = "encryptingRepository"
+	}
+	if len(data.PIIFields()) > 0 && data.Postgres() && !opts.Stub {
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_pii.up.sql")] = "piiMigration"
+	}
+	if opts.InternalAPI {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "response.go")] = "publicResponse"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "internal.go")] = "internalController"
+	}
+	if opts.Gateway != "" {
+		filesToGenerate[filepath.Join("deploy/gateway", opts.Gateway, data.KebabCase+".yaml")] = gatewayArtifacts[opts.Gateway]
+	}
+	if opts.UI == uiTempl {
+		filesToGenerate[filepath.Join("internal/transport/http/ui", data.CamelCase, "handler.go")] = "templHandler"
+		filesToGenerate[filepath.Join("internal/transport/http/ui", data.CamelCase, "pages.templ")] = "templPages"
+	}
+	if opts.UI == uiReactAdmin {
+		frontendDir := config.UI.reactAdminDir()
+		filesToGenerate[filepath.Join(frontendDir, "dataProvider.ts")] = "reactAdminDataProvider"
+		filesToGenerate[filepath.Join(frontendDir, "resources", data.KebabCase, "index.ts")] = "reactAdminResource"
+		filesToGenerate[filepath.Join(frontendDir, "resources", data.KebabCase, data.PascalCase+"List.tsx")] = "reactAdminList"
+		filesToGenerate[filepath.Join(frontendDir, "resources", data.KebabCase, data.PascalCase+"Edit.tsx")] = "reactAdminEdit"
+		filesToGenerate[filepath.Join(frontendDir, "resources", data.KebabCase, data.PascalCase+"Create.tsx")] = "reactAdminCreate"
+	}
+	if partition := data.Entity.Partition; partition != nil {
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_partitioned.up.sql")] = "partitionMigration"
+		if partition.Strategy == partitionRange {
+			filesToGenerate[filepath.Join("internal/job", data.SnakeCase+"_partitions.go")] = "partitionJob"
+		}
+	}
+	if len(data.ConstrainedFields()) > 0 && data.Entity.Partition == nil && data.Postgres() {
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_constraints.up.sql")] = "constraintsMigration"
+	}
+	if len(data.SortFields()) > 0 && (opts.AppendOnly || !data.Postgres()) {
+		mode := "--db " + opts.DB
+		if opts.AppendOnly {
+			mode = "--append-only"
+		}
+		return fmt.Errorf("%s lists in a fixed order and does not support the sortable fields declared for %s in the spec", mode, data.PascalCase)
+	}
+	if data.Entity.DefaultScope != nil && (opts.AppendOnly || !data.Postgres()) {
+		mode := "--db " + opts.DB
+		if opts.AppendOnly {
+			mode = "--append-only"
+		}
+		return fmt.Errorf("%s does not support the default scope declared for %s in the spec", mode, data.PascalCase)
+	}
+	if opts.Include && !data.LoadsRelations() {
+		return fmt.Errorf("--include needs belongs_to, has_one or has_many relations for %s in the spec, loaded from the Postgres repository without --append-only or --stub", data.PascalCase)
+	}
+	if data.LoadsRelations() {
+		filesToGenerate[filepath.Join("internal/transport/repository", data.CamelCase+"Relations.go")] = "relationsInterface"
+		filesToGenerate[filepath.Join("internal/transport/repository/postgres", data.CamelCase+"Relations.go")] = "relationsRepository"
+	}
+	if opts.Include {
+		filesToGenerate[filepath.Join("internal/DTO", "include.go")] = "includeDTO"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/httpUtils", "include.go")] = "includeParser"
+	}
+	if data.GraphQL() {
+		filesToGenerate[filepath.Join("graph", data.SnakeCase+".graphqls")] = "graphQLSchema"
+		filesToGenerate[filepath.Join("graph", "scalars.graphqls")] = "graphQLScalars"
+		filesToGenerate[filepath.Join("internal/transport/graphql", data.CamelCase+".go")] = "graphQLResolver"
+		if len(data.GraphQLLoaders()) > 0 {
+			filesToGenerate[filepath.Join("internal/transport/graphql", data.CamelCase+"Loaders.go")] = "graphQLLoaders"
+		}
+	}
+	if len(data.ForeignKeys()) > 0 && data.Entity.Partition == nil && data.Postgres() {
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_foreign_keys.up.sql")] = "foreignKeysMigration"
+	}
+	if opts.RLS != "" {
+		if opts.SpecFile != "" && !slices.ContainsFunc(data.Entity.Fields, func(field FieldSpec) bool { return field.Name == data.RLSColumn() }) {
+			return fmt.Errorf("--rls %s needs a %s field on %s in the spec", opts.RLS, data.RLSColumn(), data.PascalCase)
+		}
+		filesToGenerate[filepath.Join("internal/transport/repository", "rls.go")] = "rlsScope"
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_rls.up.sql")] = "rlsMigration"
+	}
+	if opts.GDPR != "" {
+		if err := data.validateGDPR(); err != nil {
+			return err
+		}
+		filesToGenerate[filepath.Join("internal/gdpr", "registry.go")] = "gdprRegistry"
+		filesToGenerate[filepath.Join("internal/transport/repository", data.CamelCase+"DataSubject.go")] = "dataSubjectInterface"
+		filesToGenerate[filepath.Join("internal/transport/repository/postgres", data.CamelCase+"DataSubject.go")] = "dataSubjectRepository"
+		filesToGenerate[filepath.Join("internal/service", data.CamelCase+"DataSubject.go")] = "dataSubjectService"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "export.go")] = "dataSubjectController"
+	}
+	if opts.Clone {
+		if err := data.validateClone(); err != nil {
+			return err
+		}
+		filesToGenerate[filepath.Join("internal/service", data.CamelCase+"Clone.go")] = "cloneService"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "clone.go")] = "cloneController"
+	}
+	if opts.SoftDelete {
+		if !data.Features.SoftDelete {
+			return fmt.Errorf("--soft-delete cannot be combined with soft_delete turned off for %s in the config or spec", data.PascalCase)
+		}
+		filesToGenerate[filepath.Join("internal/transport/repository", data.CamelCase+"Trash.go")] = "trashInterface"
+		filesToGenerate[filepath.Join("internal/transport/repository/postgres", data.CamelCase+"Trash.go")] = "trashRepository"
+		filesToGenerate[filepath.Join("internal/service", data.CamelCase+"Trash.go")] = "trashService"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "trash.go")] = "trashController"
+	}
+	if opts.History {
+		if data.EncryptsFields() {
+			return fmt.Errorf("--history cannot be combined with the encrypted fields of %s in the spec, whose snapshots the history would serve as ciphertext", data.PascalCase)
+		}
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_history.up.sql")] = "historyMigration"
+		filesToGenerate[filepath.Join("internal/DTO", "version.go")] = "versionDTO"
+		filesToGenerate[filepath.Join("internal/transport/repository", data.CamelCase+"History.go")] = "historyInterface"
+		filesToGenerate[filepath.Join("internal/transport/repository/postgres", data.CamelCase+"History.go")] = "historyRepository"
+		filesToGenerate[filepath.Join("internal/service", data.CamelCase+"History.go")] = "historyService"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "history.go")] = "historyController"
+		if data.DiffsVersions() {
+			filesToGenerate[filepath.Join("internal/DTO", "fieldChange.go")] = "fieldChangeDTO"
+			filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "historyDiff.go")] = "historyDiffController"
+		}
+	}
+	if opts.Approval {
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_pending_change.up.sql")] = "pendingChangeMigration"
+		filesToGenerate[filepath.Join("internal/DTO", "approval.go")] = "approvalDTO"
+		filesToGenerate[filepath.Join("internal/DTO", data.CamelCase+"Change.go")] = "pendingChangeDTO"
+		filesToGenerate[filepath.Join("internal/transport/repository", data.CamelCase+"Approvals.go")] = "approvalInterface"
+		filesToGenerate[filepath.Join("internal/transport/repository/postgres", data.CamelCase+"Approvals.go")] = "approvalRepository"
+		filesToGenerate[filepath.Join("internal/service", data.CamelCase+"Approvals.go")] = "approvalService"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "approvals.go")] = "approvalController"
+	}
+	if opts.Saga {
+		filesToGenerate[filepath.Join("internal/saga", "saga.go")] = "saga"
+		filesToGenerate[filepath.Join("internal/saga", "postgres.go")] = "sagaStore"
+		filesToGenerate[filepath.Join("migrations", "saga_state.up.sql")] = "sagaMigration"
+		filesToGenerate[filepath.Join("internal/service", data.CamelCase+"Saga.go")] = "sagaSteps"
+	}
+	if opts.CheckDuplicate {
+		if len(data.DuplicateFields()) == 0 {
+			return fmt.Errorf("--check-duplicate needs unique fields on %s in the spec that the public handlers return", data.PascalCase)
+		}
+		filesToGenerate[filepath.Join("internal/DTO", "duplicates.go")] = "duplicatesDTO"
+		filesToGenerate[filepath.Join("internal/transport/repository", data.CamelCase+"Duplicates.go")] = "duplicateInterface"
+		filesToGenerate[filepath.Join("internal/transport/repository/postgres", data.CamelCase+"Duplicates.go")] = "duplicateRepository"
+		filesToGenerate[filepath.Join("internal/service", data.CamelCase+"Duplicates.go")] = "duplicateService"
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "duplicates.go")] = "duplicateController"
+	}
+	if opts.CDC != "" {
+		filesToGenerate[filepath.Join("internal/cdc", "op.go")] = "cdcOp"
+		filesToGenerate[filepath.Join("internal/cdc", data.SnakeCase+"_change.go")] = "cdcChange"
+	}
+	if opts.CDC == cdcNotify {
+		filesToGenerate[filepath.Join("internal/cdc", data.SnakeCase+"_listener.go")] = "cdcListener"
+		filesToGenerate[filepath.Join("migrations", data.SnakeCase+"_notify.up.sql")] = "cdcNotifyMigration"
+	}
+	if opts.CDC == cdcDebezium {
+		filesToGenerate[filepath.Join("internal/cdc", data.SnakeCase+"_debezium.go")] = "cdcDebezium"
+	}
+	if data.UUID() {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "id.go")] = "uuidParam"
+	}
+	if opts.I18n {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "messages.go")] = "i18nMessages"
+	}
+	if opts.Fuzz {
+		filesToGenerate[filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "request_fuzz_test.go")] = "requestFuzz"
+	}
+	if opts.Bench {
+		filesToGenerate[filepath.Join("internal/transport/repository/postgres", data.CamelCase+"_bench_test.go")] = "repositoryBench"
+	}
+	if len(data.HealthDependencies()) > 0 {
+		filesToGenerate[filepath.Join("internal/health", "health.go")] = "healthRegistry"
+		filesToGenerate[filepath.Join("internal/health", data.CamelCase+".go")] = "healthChecks"
+	}
+
+	if opts.GitCommit {
+		if err := gitCreateBranch(gitBranchName(data)); err != nil {
+			return fmt.Errorf("creating branch %s: %w", gitBranchName(data), err)
+		}
+	}
+
+	if opts.ModuleGroup != "" {
+		grouped := make(map[string]string, len(filesToGenerate)+2)
+		for path, artifact := range filesToGenerate {
+			grouped[data.groupPath(path)] = artifact
+		}
+		grouped[filepath.Join(data.FeatureDir(), "module.go")] = "featureModule"
+		grouped[filepath.Join(data.FeatureDir(), data.CamelCase+".go")] = "featureWiring"
+		filesToGenerate = grouped
+	}
+
+	// journal records the files the run changes in the working tree, for
+	// undo.
+	var journal *generationJournal
+	if opts.writesWorkingTree() {
+		journal = newGenerationJournal(run)
+		defer journal.finish()
+		writer = journalingWriter{FileWriter: writer, journal: journal}
+	}
+
+	crlf := useCRLF(opts.LineEndings)
+	header := newFileHeader(opts.SpecFile, run)
+	// generated lists the files the run wrote or updated, which --git-commit
+	// commits.
+	var generated []string
+	summary := generationSummary{Entity: data.PascalCase}
+	for path, artifact := range filesToGenerate {
+
+		exists, err := writer.Exists(path)
+		if err != nil {
+			return fmt.Errorf("checking file status for %s: %w", path, err)
+		} else if exists && !config.Overwrite.regenerates(artifact) {
+			fmt.Printf("Skipping existing file: %s.\n", path)
+			summary.Skipped++
+			continue
+		} else if exists {
+			fmt.Printf("Regenerating file: %s\n", path)
+		} else {
+			fmt.Printf("Generating file: %s\n", path)
+		}
+
+		tmplStr, ok := builtinTemplates[artifact]
+		if !ok {
+			return fmt.Errorf("%s: no built-in template %q", path, artifact)
+		}
+		tmpl, err := parseTemplate(path, config.Templates, artifact)
+		if err != nil {
+			return fmt.Errorf("parsing template for %s: %w", path, err)
+		}
+		fileData := data
+		fileData.Client = clientFiles[path]
+		var body bytes.Buffer
+		if err := tmpl.Execute(&body, fileData); err != nil {
+			return fmt.Errorf("executing template for %s: %w", path, err)
+		}
+		if err := config.TemplateSandbox.checkRendered(path, tmplStr, fileData, body.Bytes()); err != nil {
+			return err
+		}
+		banner, err := renderBanner(path, config.FileHeader, fileData)
+		if err != nil {
+			return fmt.Errorf("rendering file header for %s: %w", path, err)
+		}
+		generatedBody := body.Bytes()
+		if exists {
+			if previous, err := writer.ReadFile(path); err == nil {
+				if _, previousBody, ok := parseHeader(previous); ok {
+					generatedBody = keepRegions(previousBody, generatedBody)
+				}
+			}
+		}
+		content := withLineEndings(append(banner, withHeader(path, header, generatedBody)...), crlf)
+		if err := writer.WriteFile(path, content); err != nil {
+			return fmt.Errorf("writing file %s: %w", path, err)
+		}
+		generated = append(generated, path)
+		summary.add(path, artifact, content, exists)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("finishing the generated files: %w", err)
+	}
+
+	switch {
+	case opts.Archive != "":
+		fmt.Printf("Wrote %d files to %s\n", len(generated), opts.Archive)
+	case opts.DryRun:
+		fmt.Println("Dry run: nothing was written.")
+	}
+	if !opts.writesWorkingTree() && (opts.I18n || opts.Makefile || len(opts.TestServices()) > 0) {
+		fmt.Println("Skipping the locale, Makefile and docker compose registrations, which edit the working tree.")
+	}
+
+	// failed collects the steps after writing that went wrong, which fail the
+	// run once the rest is done.
+	var failed []error
+
+	if opts.I18n && opts.writesWorkingTree() {
+		journal.trackEdits(localePaths(config.I18n)...)
+		paths, err := registerMessages(config.I18n, data)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("registering translations: %w", err))
+		}
+		generated = append(generated, paths...)
+	}
+
+	if services := opts.TestServices(); len(services) > 0 && opts.writesWorkingTree() {
+		journal.trackEdits(composeTestPath)
+		if err := registerTestServices(composeTestPath, services); err != nil {
+			failed = append(failed, fmt.Errorf("registering test services: %w", err))
+		} else {
+			fmt.Printf("Registered test services for %s in %s\n", data.KebabCase, composeTestPath)
+			generated = append(generated, composeTestPath)
+		}
+	}
+
+	if opts.Makefile && opts.writesWorkingTree() {
+		journal.trackEdits(makefilePath)
+		if err := registerMakeTargets(makefilePath, data); err != nil {
+			failed = append(failed, fmt.Errorf("registering make targets: %w", err))
+		} else {
+			fmt.Printf("Registered make targets for %s in %s\n", data.KebabCase, makefilePath)
+			generated = append(generated, makefilePath)
+		}
+	}
+
+	if opts.SwagInit {
+		journal.trackEdits(config.Swag.docsFiles()...)
+		if err := regenerateSwagDocs(config.Swag, data); err != nil {
+			failed = append(failed, fmt.Errorf("regenerating swagger docs: %w", err))
+		} else {
+			fmt.Println("Swagger docs regenerated and all routes verified.")
+			generated = append(generated, filepath.Dir(config.Swag.output()))
+		}
+	}
+
+	if opts.GitCommit {
+		if len(generated) == 0 {
+			fmt.Println("Nothing generated to commit.")
+		} else if err := gitCommitGenerated(generated, gitCommitMessage(data, run)); err != nil {
+			failed = append(failed, fmt.Errorf("committing the generated files: %w", err))
+		} else {
+			fmt.Printf("Committed %d generated file(s) on branch %s\n", len(generated), gitBranchName(data))
+		}
+	}
+
+	fmt.Println("--- CRUD for", data.PascalCase, "generated successfully! ---")
+	fmt.Println("Summary:", summary)
+	if opts.SummaryJSON != "" {
+		if err := summary.writeJSON(opts.SummaryJSON); err != nil {
+			failed = append(failed, fmt.Errorf("writing the summary: %w", err))
+		}
+	}
+	fmt.Println("Next steps:")
+	nextSteps := []string{
+		fmt.Sprintf("Define the 'dto.%s' struct in a relevant DTO file and ensure it implements 'dto.Entity'.", data.PascalCase),
+		fmt.Sprintf("Populate the request structs in '%s'.", filepath.Join("internal/transport/http/rest/controller/v1", data.LowerCase, "request.go")),
+		"Implement the TODOs in the generated controller to map request structs to your DTO.",
+		"Add the new controller, service, and repository to the initializers in 'internal/initializer/app.go'.",
+		fmt.Sprintf("Register the routes with '%s.RegisterRoutes(v1, controller)' on the '/api/v1' router group in 'internal/transport/http/rest/router/route.go', adapting it to '%s.Router'.", data.LowerCase, data.LowerCase),
+	}
+	if opts.ModuleGroup != "" {
+		nextSteps[3] = fmt.Sprintf("Build '%s.Deps' once in 'internal/initializer/app.go' and wire the entity with '%s.New%s(deps)' from '%s'.", opts.ModuleGroup, opts.ModuleGroup, data.PascalCase, filepath.Join(data.FeatureDir(), data.CamelCase+".go"))
+	}
+	if !opts.AppendOnly && data.Postgres() {
+		nextSteps = append(nextSteps, "Update the ColumnMapping in the generated controller for filtering and sorting.")
+	}
+	if data.ClickHouse() {
+		nextSteps = append(nextSteps,
+			fmt.Sprintf("Run the '%s' migration against ClickHouse and add 'github.com/ClickHouse/clickhouse-go/v2' to go.mod.", filepath.Join("migrations/clickhouse", data.SnakeCase+".up.sql")),
+			fmt.Sprintf("Give 'dto.%s' json tags matching the '%s' columns, which rows are inserted and read by.", data.PascalCase, data.SnakeCase),
+			"Construct the repository with a 'driver.Conn' and a context cancelled on shutdown, so the last batch of rows is sent.",
+		)
+	} else if opts.AppendOnly {
+		nextSteps = append(nextSteps, fmt.Sprintf("Give the '%s' table a 'created_at TIMESTAMPTZ' column, run the '%s' migration and construct the repository with the '*sql.DB' of the database.", data.SnakeCase, filepath.Join("migrations", data.SnakeCase+"_created_at_idx.up.sql")))
+	}
+	if data.Cassandra() {
+		nextSteps = append(nextSteps,
+			fmt.Sprintf("Run '%s' in the keyspace with cqlsh and add 'github.com/gocql/gocql' to go.mod.", filepath.Join("migrations/cassandra", data.SnakeCase+".cql")),
+			fmt.Sprintf("Give 'dto.%s' json tags matching the '%s' columns, which rows are written and read by, and construct the repository with the '*gocql.Session' of the keyspace.", data.PascalCase, data.SnakeCase),
+		)
+	}
+	if data.Firestore() {
+		nextSteps = append(nextSteps,
+			"Add 'cloud.google.com/go/firestore' to go.mod.",
+			fmt.Sprintf("Give 'dto.%s' firestore tags, with 'firestore:\"-\"' on its ID, and construct the repository with the '*firestore.Client' of the project; documents go to the '%s' collection.", data.PascalCase, data.Collection()),
+		)
+	}
+	if data.Spanner() {
+		nextSteps = append(nextSteps,
+			fmt.Sprintf("Apply '%s' with 'gcloud spanner databases ddl update --ddl-file' and add 'cloud.google.com/go/spanner' to go.mod.", filepath.Join("migrations/spanner", data.SnakeCase+".sql")),
+			fmt.Sprintf("Give 'dto.%s' spanner tags matching the '%s' columns, with 'spanner:\"-\"' on its ID, and construct the repository with the '*spanner.Client' of the database.", data.PascalCase, data.Collection()),
+		)
+	}
+	if data.Bolt() {
+		nextSteps = append(nextSteps, "Add 'go.etcd.io/bbolt' to go.mod and construct the repository with the '*bbolt.DB' from 'bbolt.Open'; the file is locked, so only one process can open it at a time.")
+		if len(data.FilterFields()) > 0 {
+			nextSteps = append(nextSteps, fmt.Sprintf("Give 'dto.%s' json tags matching the filtered columns, whose values the index buckets are built from.", data.PascalCase))
+		}
+	}
+	if data.PagesByState() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Page the %s list with the '%s' header of each response, passed back as the 'page_state' query parameter; page numbers and sorting are ignored.", data.LowerCase, "X-Next-Page-State"))
+	}
+	if data.Cockroach() && !opts.Resilience {
+		nextSteps = append(nextSteps, fmt.Sprintf("Wrap the repository with 'repository.New%sRetrying' in 'internal/initializer/app.go' and run the migrations outside explicit transactions, as CockroachDB applies schema changes asynchronously.", data.PascalCase))
+	} else if data.Cockroach() {
+		nextSteps = append(nextSteps, "Run the migrations outside explicit transactions, as CockroachDB applies schema changes asynchronously; the resilience policy retries its serialization failures.")
+	}
+	if data.SQLServer() || data.Oracle() {
+		driver := map[string]string{dbSQLServer: "github.com/microsoft/go-mssqldb", dbOracle: "github.com/sijms/go-ora/v2"}[opts.DB]
+		nextSteps = append(nextSteps,
+			fmt.Sprintf("Run the '%s' migration and add '%s' to go.mod.", filepath.Join("migrations", opts.DB, data.SnakeCase+".up.sql"), driver),
+			fmt.Sprintf("Give 'dto.%s' json tags matching the '%s' columns, which rows are written and read by, and construct the repository with a '*sql.DB' opened with the '%s' driver.", data.PascalCase, data.SnakeCase, opts.DB),
+		)
+	}
+	if partition := data.Entity.Partition; partition != nil {
+		nextSteps = append(nextSteps, fmt.Sprintf("Run the '%s' migration to create the partitioned '%s' table.", filepath.Join("migrations", data.SnakeCase+"_partitioned.up.sql"), data.SnakeCase))
+		if partition.Strategy == partitionRange {
+			nextSteps = append(nextSteps, fmt.Sprintf("Schedule 'job.%sPartitionJob' so partitions exist before rows arrive, and fill in the detach TODO once a retention period is decided.", data.PascalCase))
+		}
+	}
+	if len(data.ConstrainedFields()) > 0 && data.Entity.Partition == nil && data.Postgres() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Run the '%s' migration to apply the spec's defaults and checks to the '%s' table.", filepath.Join("migrations", data.SnakeCase+"_constraints.up.sql"), data.SnakeCase))
+	}
+	if data.LoadsRelations() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Give 'dto.%s' the %s fields the list fills, keeping the loaded ones out of the table mapping, and implement the queries in '%s'.", data.PascalCase, data.relationFields(), filepath.Join("internal/transport/repository/postgres", data.CamelCase+"Relations.go")))
+		if opts.ModuleGroup == "" {
+			nextSteps = append(nextSteps, fmt.Sprintf("Pass 'postgres.New%sRelations' to 'service.New%sService' in 'internal/initializer/app.go'.", data.PascalCase, data.PascalCase))
+		}
+	}
+	if data.GraphQL() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Add '%s' to the gqlgen schema, bind '%s' to 'dto.%s' and 'ID' to the id type in gqlgen.yml, and call 'graphql.%sResolver' from the resolvers gqlgen generates.", filepath.Join("graph", data.SnakeCase+".graphqls"), data.PascalCase, data.PascalCase, data.PascalCase))
+		if len(data.GraphQLLoaders()) > 0 {
+			nextSteps = append(nextSteps, fmt.Sprintf("Add 'github.com/graph-gophers/dataloader/v7' to go.mod and wrap the GraphQL handler with 'graphql.%sLoadersMiddleware(postgres.New%sRelations(...), handler)'; generate %s with --transport %s too, as the schema refers to their types.", data.PascalCase, data.PascalCase, data.relatedEntities(), transportGraphQL))
+		}
+	}
+	if opts.Include {
+		nextSteps = append(nextSteps, fmt.Sprintf("Tag the loaded fields of 'dto.%s' with the json names %s and omitempty, so the responses nest only the relations named in the 'include' query parameter.", data.PascalCase, data.includeNames()))
+	}
+	if len(data.RedactedFields()) > 0 {
+		usedBy := "which logs redact fields by"
+		if len(data.HiddenFields()) > 0 || len(data.MaskedFields()) > 0 {
+			usedBy = "which the responses hide and mask fields by"
+		}
+		nextSteps = append(nextSteps, fmt.Sprintf("Give 'dto.%s' json tags matching the spec field names, %s, and log %s values through 'redact.%s'.", data.PascalCase, usedBy, data.LowerCase, data.PascalCase))
+	}
+	if data.EncryptsFields() {
+		if opts.ModuleGroup == "" {
+			nextSteps = append(nextSteps, fmt.Sprintf("Wrap the repository with 'repository.New%sEncrypting' in 'internal/initializer/app.go', passing an 'encryption.KeyProvider' backed by your KMS.", data.PascalCase))
+		} else {
+			nextSteps = append(nextSteps, fmt.Sprintf("Pass an 'encryption.KeyProvider' backed by your KMS to '%s.New%s', which encrypts through it.", opts.ModuleGroup, data.PascalCase))
+		}
+	}
+	if len(data.PIIFields()) > 0 && data.Postgres() && !opts.Stub {
+		nextSteps = append(nextSteps, fmt.Sprintf("Run the '%s' migration to mark the personal data columns of the '%s' table.", filepath.Join("migrations", data.SnakeCase+"_pii.up.sql"), data.SnakeCase))
+	}
+	if len(data.ForeignKeys()) > 0 && data.Entity.Partition == nil && data.Postgres() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Run the '%s' migration to apply the spec's ON DELETE behaviour to the '%s' foreign keys.", filepath.Join("migrations", data.SnakeCase+"_foreign_keys.up.sql"), data.SnakeCase))
+	}
+	if opts.RLS != "" {
+		if opts.Claims {
+			nextSteps = append(nextSteps, fmt.Sprintf("Run the '%s' migration; the handlers attach the caller's id from the token claims.", filepath.Join("migrations", data.SnakeCase+"_rls.up.sql")))
+		} else {
+			nextSteps = append(nextSteps, fmt.Sprintf("Run the '%s' migration and attach the caller's id in your auth middleware with 'repository.WithRLS(ctx, repository.%s, id)'.", filepath.Join("migrations", data.SnakeCase+"_rls.up.sql"), data.RLSSettingConst()))
+		}
+		if !opts.AppendOnly && !opts.Stub {
+			nextSteps = append(nextSteps, fmt.Sprintf("Run the %s repository's queries in a transaction from 'repository.BeginScoped'; queries outside one see no %s rows.", data.PascalCase, data.SnakeCase))
+		}
+	}
+	if opts.CDC != "" {
+		nextSteps = append(nextSteps, fmt.Sprintf("Give 'dto.%s' json tags matching the '%s' columns so changes decode into it, and implement a 'cdc.%sChangeHandler' for your cache or index.", data.PascalCase, data.SnakeCase, data.PascalCase))
+	}
+	if opts.CDC == cdcNotify {
+		nextSteps = append(nextSteps, fmt.Sprintf("Run the '%s' migration, add 'github.com/lib/pq' to go.mod and start 'cdc.New%sListener' with the database DSN in 'internal/initializer/app.go'.", filepath.Join("migrations", data.SnakeCase+"_notify.up.sql"), data.PascalCase))
+	}
+	if opts.CDC == cdcDebezium {
+		nextSteps = append(nextSteps, fmt.Sprintf("Capture 'public.%s' in the Debezium connector with the JSON converter and call 'cdc.%sDebeziumConsumer.Handle' from the Kafka consumer of its topic.", data.SnakeCase, data.PascalCase))
+	}
+	if opts.Admin {
+		nextSteps = append(nextSteps, fmt.Sprintf("Register the handlers from '%s.NewAdmin' with '%s.RegisterAdminRoutes' on the '/admin/api/v1' router group, passing the admin auth middleware.", data.LowerCase, data.LowerCase))
+	}
+	if opts.InternalAPI {
+		nextSteps = append(nextSteps,
+			fmt.Sprintf("Add the fields safe to expose publicly to 'public%sResponse' in '%s'.", data.PascalCase, filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "response.go")),
+			fmt.Sprintf("Register the handlers from '%s.NewInternal' with '%s.RegisterInternalRoutes' on the '%s' router group, passing the service-to-service auth middleware.", data.LowerCase, data.LowerCase, internalRoutePrefix),
+		)
+	}
+	if opts.Gateway != "" {
+		nextSteps = append(nextSteps, fmt.Sprintf("Check the upstream in '%s' and apply it to the %s gateway.", filepath.Join("deploy/gateway", opts.Gateway, data.KebabCase+".yaml"), opts.Gateway))
+	}
+	if opts.UI == uiTempl {
+		nextSteps = append(nextSteps,
+			fmt.Sprintf("Add the %s fields to the form and pages in '%s', then run 'templ generate'.", data.PascalCase, filepath.Join("internal/transport/http/ui", data.CamelCase)),
+			fmt.Sprintf("Register the handlers from the '%s' UI package under '/admin/ui/%s' behind the admin auth middleware: GET /, GET /new, POST /, GET /:id/edit, PUT /:id and DELETE /:id.", data.LowerCase, data.KebabCase),
+		)
+	}
+	if opts.UI == uiReactAdmin {
+		nextSteps = append(nextSteps, fmt.Sprintf("Add the %s fields to the components in '%s' and register '<Resource {...%sResource} />' in the <Admin> of your app, using 'dataProvider' from '%s'.", data.PascalCase, filepath.Join(config.UI.reactAdminDir(), "resources", data.KebabCase), data.CamelCase, filepath.Join(config.UI.reactAdminDir(), "dataProvider.ts")))
+	}
+	if opts.Adminctl {
+		nextSteps = append(nextSteps, fmt.Sprintf("Try 'go run ./cmd/adminctl %s list --addr http://localhost:8080' against a running service.", data.KebabCase))
+	}
+	if data.UUID() && data.Cockroach() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Make 'dto.%s.ID' a 'uuid.UUID' backed by a 'UUID PRIMARY KEY DEFAULT gen_random_uuid()' column and add 'github.com/google/uuid' to go.mod.", data.PascalCase))
+	} else if data.Firestore() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Make 'dto.%s.ID' a 'uuid.UUID', stored as the document name, and add 'github.com/google/uuid' to go.mod.", data.PascalCase))
+	} else if data.Spanner() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Make 'dto.%s.ID' a 'uuid.UUID', stored as a string in the 'id' column, and add 'github.com/google/uuid' to go.mod.", data.PascalCase))
+	} else if data.UUID() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Make 'dto.%s.ID' a 'uuid.UUID' backed by a UUID primary key column and add 'github.com/google/uuid' to go.mod.", data.PascalCase))
+	} else if data.Cockroach() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Expect '%s' ids from unique_rowid(), which CockroachDB fills SERIAL and identity columns with: unique but not sequential and beyond JavaScript's safe integers; --id-type uuid with 'DEFAULT gen_random_uuid()' avoids both.", data.SnakeCase))
+	}
+	if opts.I18n {
+		nextSteps = append(nextSteps, fmt.Sprintf("Translate the new '%s.*' messages marked TODO in the locale files and make sure the error handler translates error messages by key.", data.SnakeCase))
+	}
+	if opts.Stub {
+		nextSteps = append(nextSteps, fmt.Sprintf("Fill in 'sample%s' in '%s'; once the repository exists, delete that file and re-run without --stub.", data.PascalCase, filepath.Join("internal/service", data.CamelCase+".go")))
+	}
+	if opts.Webhooks {
+		nextSteps = append(nextSteps,
+			fmt.Sprintf("Ensure 'dto.%sWebhookSubscription' implements 'dto.Entity', add 'github.com/lib/pq' to go.mod and run the '%s' migration.", data.PascalCase, filepath.Join("migrations", data.SnakeCase+"_webhook_subscription.up.sql")),
+			"Wire the webhook repository and dispatcher into the initializers and register the webhook routes behind the admin auth middleware.",
+		)
+	}
+	if opts.Worker {
+		nextSteps = append(nextSteps,
+			fmt.Sprintf("Implement 'worker.%sQueue' on top of your message broker and fill in the job handlers in '%s'.", data.PascalCase, filepath.Join("internal/worker", data.SnakeCase+"_worker.go")),
+			"Start the worker in 'internal/initializer/app.go' and call its Shutdown method when the application receives a termination signal.",
+		)
+	}
+	if opts.GDPR != "" {
+		nextSteps = append(nextSteps, fmt.Sprintf("Implement the queries in '%s'.", filepath.Join("internal/transport/repository/postgres", data.CamelCase+"DataSubject.go")))
+		if opts.ModuleGroup == "" {
+			nextSteps = append(nextSteps, fmt.Sprintf("Build 'service.New%sDataSubjectService' on 'postgres.New%sDataSubject'%s in 'internal/initializer/app.go', register it with your 'gdpr.Registry' under \"%s\" and register its export route with '%s.RegisterExportRoutes' behind auth that only lets the user or support staff through.", data.PascalCase, data.PascalCase, data.dataSubjectDecorator(), data.PascalCase, data.LowerCase))
+		} else {
+			nextSteps = append(nextSteps, fmt.Sprintf("Pass your 'gdpr.Registry' to '%s.New%s', which registers the %s data subject service with it, and register its export route with '%s.RegisterExportRoutes' behind auth that only lets the user or support staff through.", opts.ModuleGroup, data.PascalCase, data.LowerCase, data.LowerCase))
+		}
 	}
-
-	filesToGenerate := map[string]string{
-		filepath.Join("internal/transport/repository/postgres", data.CamelCase+".go"):                repositoryTemplate,
-		filepath.Join("internal/service", data.CamelCase+".go"):                                      serviceTemplate,
-		filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "controller.go"): controllerTemplate,
-		filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "request.go"):    requestTemplate,
+	if opts.Clone && len(data.CloneResetFields()) == 0 {
+		nextSteps = append(nextSteps, fmt.Sprintf("Reset the fields a copy must not share in 'Clone%s' of '%s', or mark them reset_on_clone in the spec.", data.PascalCase, filepath.Join("internal/service", data.CamelCase+"Clone.go")))
 	}
-
-	for path, tmplStr := range filesToGenerate {
-
-		if _, err := os.Stat(path); err == nil {
-			fmt.Printf("Skipping existing file: %s.\n", path)
-			continue
-		} else if !os.IsNotExist(err) {
-			fmt.Printf("Error checking file status for %s: %v\n", path, err)
-			return
+	if opts.SoftDelete {
+		nextSteps = append(nextSteps, fmt.Sprintf("Add a nullable 'deleted_at' column to the '%s' table, have deletes set it and reads skip the rows it is set on, and implement the trash queries in '%s'.", data.SnakeCase, filepath.Join("internal/transport/repository/postgres", data.CamelCase+"Trash.go")))
+		if opts.ModuleGroup == "" {
+			nextSteps = append(nextSteps, fmt.Sprintf("Pass 'postgres.New%sTrash' to 'service.New%sService' in 'internal/initializer/app.go'.", data.PascalCase, data.PascalCase))
+		}
+	}
+	if opts.History {
+		nextSteps = append(nextSteps, fmt.Sprintf("Run the '%s' migration to record the previous versions of '%s' rows, and implement the history query in '%s'.", filepath.Join("migrations", data.SnakeCase+"_history.up.sql"), data.SnakeCase, filepath.Join("internal/transport/repository/postgres", data.CamelCase+"History.go")))
+		if opts.ModuleGroup == "" {
+			nextSteps = append(nextSteps, fmt.Sprintf("Pass 'postgres.New%sHistory' to 'service.New%sService' in 'internal/initializer/app.go'.", data.PascalCase, data.PascalCase))
+		}
+		if !data.DiffsVersions() {
+			nextSteps = append(nextSteps, fmt.Sprintf("Declare the fields of %s in --spec to also generate the endpoint diffing two of its versions.", data.PascalCase))
+		}
+	}
+	if opts.Approval {
+		nextSteps = append(nextSteps, fmt.Sprintf("Run the '%s' migration, implement the queries in '%s' and map the submitted request in 'Submit%sChange' of '%s'.", filepath.Join("migrations", data.SnakeCase+"_pending_change.up.sql"), filepath.Join("internal/transport/repository/postgres", data.CamelCase+"Approvals.go"), data.PascalCase, filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "approvals.go")))
+		if opts.ModuleGroup == "" {
+			nextSteps = append(nextSteps, fmt.Sprintf("Build '%s.NewApprovals' on 'service.New%sApprovalService' with 'postgres.New%sApprovals' and the %s service in 'internal/initializer/app.go', and register its routes with '%s.RegisterApprovalRoutes'.", data.LowerCase, data.PascalCase, data.PascalCase, data.LowerCase, data.LowerCase))
 		} else {
-			fmt.Printf("Generating file: %s\n", path)
+			nextSteps = append(nextSteps, fmt.Sprintf("Register the 'Approvals' controller of '%s.New%s' with '%s.RegisterApprovalRoutes'.", opts.ModuleGroup, data.PascalCase, data.LowerCase))
 		}
-
-		// Create directories if they don't exist
-		dir := filepath.Dir(path)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			fmt.Printf("Error creating directory %s: %v\n", dir, err)
-			return
+		nextSteps = append(nextSteps, fmt.Sprintf("Grant the '%s_reviewer' role to the reviewers, and take the PUT route away from the operators whose changes need a review.", data.SnakeCase))
+	}
+	if opts.Saga {
+		nextSteps = append(nextSteps, fmt.Sprintf("Run the '%s' migration, implement 'Save' in '%s' and add the steps creating the %s on other services, with their compensations, to '%s'.", filepath.Join("migrations", "saga_state.up.sql"), filepath.Join("internal/saga", "postgres.go"), data.LowerCase, filepath.Join("internal/service", data.CamelCase+"Saga.go")))
+		if opts.ModuleGroup == "" {
+			nextSteps = append(nextSteps, fmt.Sprintf("Pass 'saga.New(saga.NewPostgresStore(db, log), log)' to 'service.New%sService' in 'internal/initializer/app.go'.", data.PascalCase))
 		}
-
-		file, err := os.Create(path)
-		if err != nil {
-			fmt.Printf("Error creating file %s: %v\n", path, err)
-			return
+		nextSteps = append(nextSteps, "Watch the 'saga_state' rows left running, compensating or failed, whose sagas were interrupted or could not undo their steps.")
+	}
+	if data.Entity.Source != nil {
+		aclDir := filepath.Join("internal/acl", data.SourcePackage())
+		nextSteps = append(nextSteps, fmt.Sprintf("Point 'Get%s' in '%s' at the %s API and map the fields left to do in '%s'.", data.PascalCase, filepath.Join(aclDir, data.CamelCase+"Source.go"), data.SourceSystem(), filepath.Join(aclDir, data.CamelCase+"Payload.go")))
+		nextSteps = append(nextSteps, fmt.Sprintf("Build '%s.New%sSource' in 'internal/initializer/app.go' and inject it as 'client.%sSource' where %ss are imported from %s.", data.SourcePackage(), data.PascalCase, data.PascalCase, data.LowerCase, data.SourceSystem()))
+	}
+	if clients := data.Clients(); len(clients) > 0 {
+		nextSteps = append(nextSteps, fmt.Sprintf("Declare the calls on %s in the ports under '%s', implement them in the %s adapters and fill in the fields they own in '%s'.", data.ClientNames(), "internal/transport/client", opts.ClientTransport, filepath.Join("internal/service", data.CamelCase+"Clients.go")))
+		if opts.ModuleGroup == "" {
+			nextSteps = append(nextSteps, fmt.Sprintf("Build 'service.%sClients' from the '%sclient' adapters in 'internal/initializer/app.go' and pass it to 'service.New%sService'.", data.PascalCase, opts.ClientTransport, data.PascalCase))
+		} else {
+			nextSteps = append(nextSteps, fmt.Sprintf("Build 'service.%sClients' from the '%sclient' adapters and pass it to '%s.New%s'.", data.PascalCase, opts.ClientTransport, opts.ModuleGroup, data.PascalCase))
 		}
-		defer file.Close()
-
-		tmpl, err := template.New(path).Parse(tmplStr)
-		if err != nil {
-			fmt.Printf("Error parsing template for %s: %v\n", path, err)
-			return
+	}
+	if opts.CheckDuplicate {
+		nextSteps = append(nextSteps, fmt.Sprintf("Implement the duplicate query in '%s'.", filepath.Join("internal/transport/repository/postgres", data.CamelCase+"Duplicates.go")))
+		if opts.ModuleGroup == "" {
+			nextSteps = append(nextSteps, fmt.Sprintf("Build '%s.NewDuplicates' on 'service.New%sDuplicateService' and 'postgres.New%sDuplicates' in 'internal/initializer/app.go' and register its route with '%s.RegisterDuplicateRoutes', rate limited, as it reveals which values are taken.", data.LowerCase, data.PascalCase, data.PascalCase, data.LowerCase))
+		} else {
+			nextSteps = append(nextSteps, fmt.Sprintf("Register the 'Duplicates' controller of '%s.New%s' with '%s.RegisterDuplicateRoutes', rate limited, as it reveals which values are taken.", opts.ModuleGroup, data.PascalCase, data.LowerCase))
 		}
-		if err := tmpl.Execute(file, data); err != nil {
-			fmt.Printf("Error executing template for %s: %v\n", path, err)
-			return
+	}
+	if opts.RetentionJob == retentionArchive {
+		nextSteps = append(nextSteps, fmt.Sprintf("Run the '%s' migration to create the archive table.", filepath.Join("migrations", data.SnakeCase+"_archive.up.sql")))
+	}
+	if opts.RetentionJob != "" {
+		nextSteps = append(nextSteps, fmt.Sprintf("Schedule 'job.%sRetentionJob' with the desired retention; it expects a nullable 'deleted_at' column on the '%s' table.", data.PascalCase, data.SnakeCase))
+	}
+	if opts.Resilience {
+		nextSteps = append(nextSteps, fmt.Sprintf("Wrap the repository with 'repository.New%sResilient' in 'internal/initializer/app.go', sharing one 'repository.ResiliencePolicy' per database.", data.PascalCase))
+	}
+	if opts.SlowQuery > 0 {
+		nextSteps = append(nextSteps, fmt.Sprintf("Adjust 'postgres.%sSlowQueryThreshold' from the service's config at startup if %s does not suit every environment.", data.PascalCase, opts.SlowQuery))
+	}
+	if opts.ReadReplicas {
+		if opts.ModuleGroup == "" {
+			nextSteps = append(nextSteps, fmt.Sprintf("Build the repository on the primary's and on the replica pool's 'ports.Database' and combine them with 'repository.New%sReadSplit(primary, replica)' in 'internal/initializer/app.go'.", data.PascalCase))
+		} else {
+			nextSteps = append(nextSteps, fmt.Sprintf("Pass the replica pool's 'ports.Database' to '%s.New%s', which reads through it.", opts.ModuleGroup, data.PascalCase))
 		}
+		nextSteps = append(nextSteps, "Wrap the context with 'repository.ReadFromPrimary' for reads that must see a write just made, such as reading an entity back after creating it.")
 	}
-
-	fmt.Println("--- CRUD for", data.PascalCase, "generated successfully! ---")
-	fmt.Println("Next steps:")
-	fmt.Println("1. Define the 'dto.", data.PascalCase, "' struct in a relevant DTO file and ensure it implements 'dto.Entity'.")
-	fmt.Printf("2. Populate the request structs in '%s'.\n", filepath.Join("internal/transport/http/rest/controller/v1", data.LowerCase, "request.go"))
-	fmt.Println("3. Implement the TODOs in the generated controller to map request structs to your DTO.")
-	fmt.Println("4. Add the new controller, service, and repository to the initializers in 'internal/initializer/app.go'.")
-	fmt.Println("5. Add the new routes to the router in 'internal/transport/http/rest/router/route.go'.")
-	fmt.Println("6. Update the ColumnMapping in the generated controller for filtering and sorting.")
+	if opts.Timeout > 0 {
+		nextSteps = append(nextSteps, fmt.Sprintf("Pass 'service.%sTimeouts' from the application config to the service constructor; zero values fall back to %s.", data.PascalCase, opts.Timeout))
+	}
+	if opts.SparseFields {
+		nextSteps = append(nextSteps, fmt.Sprintf("Give 'dto.%s' json tags matching the spec field names, which the 'fields' query parameter selects.", data.PascalCase))
+	}
+	if opts.InMemory {
+		nextSteps = append(nextSteps, fmt.Sprintf("Implement 'pageBounds' in '%s' once so in-memory repositories honour page and page size.", filepath.Join("internal/transport/repository/inmem", "store.go")))
+	}
+	if opts.MockServer {
+		nextSteps = append(nextSteps, fmt.Sprintf("Fill in 'fake%s' in '%s' and start the mock server with 'go run ./cmd/mockserver'.", data.PascalCase, filepath.Join("cmd/mockserver", data.CamelCase+".go")))
+	}
+	if opts.Pact {
+		nextSteps = append(nextSteps, fmt.Sprintf("Add 'github.com/pact-foundation/pact-go/v2' to go.mod, implement the provider state handler in '%s' and run 'go test -tags pact ./test/pact/...'.", filepath.Join("test/pact", data.SnakeCase+"_provider_test.go")))
+	}
+	if opts.CorrelationID {
+		nextSteps = append(nextSteps, fmt.Sprintf("Allow and expose the '%s' header in the CORS and gateway config, and wrap the shared logger with 'correlation.Logger' so other entities log the ID too.", data.Correlation.Header))
+	}
+	if data.ProblemJSON() {
+		nextSteps = append(nextSteps, "Make 'httpUtils.ProblemStatus' map the project's appErr errors to their HTTP status; routes registered outside 'RegisterRoutes' need wrapping in 'httpUtils.Problems' themselves.")
+	}
+	if opts.ContentNegotiation {
+		nextSteps = append(nextSteps, "Add 'github.com/vmihailenco/msgpack/v5' to go.mod and register any extra media types with 'httpUtils.RegisterEncoder'.")
+	}
+	if opts.CORS {
+		nextSteps = append(nextSteps, fmt.Sprintf("Pass '%s.CORS(allowedOrigins...)' as middleware to '%s.RegisterRoutes' in 'internal/transport/http/rest/router/route.go'.", data.LowerCase, data.LowerCase))
+	}
+	if opts.Auth == authAPIKey {
+		nextSteps = append(nextSteps,
+			fmt.Sprintf("Ensure 'dto.%sAPIKey' implements 'dto.Entity', run the '%s' migration and implement 'FindByHash' in '%s'.", data.PascalCase, filepath.Join("migrations", data.SnakeCase+"_api_key.up.sql"), filepath.Join("internal/transport/repository/postgres", data.CamelCase+"APIKey.go")),
+			fmt.Sprintf("Apply '%s.APIKeyAuth(keys)' to the '/api/v1/%s' route group and register the handlers from '%s.NewAPIKey' on '/admin/api/v1/%s/api-keys' behind the admin auth middleware.", data.LowerCase, data.KebabCase, data.LowerCase, data.KebabCase),
+		)
+	}
+	if opts.Claims {
+		nextSteps = append(nextSteps, fmt.Sprintf("Make sure the JWT middleware stores the verified claims as a map in the '%s' request local and add the role checks to '%s'.", data.Auth.ClaimsLocal, filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "caller.go")))
+	}
+	if opts.FeatureFlag != "" {
+		nextSteps = append(nextSteps, fmt.Sprintf("Implement 'httpUtils.FeatureFlags' on the project's feature-flag client and apply '%s.FeatureGate(flags)' to every '%s' route group; the routes answer 404 until '%s' is enabled.", data.LowerCase, data.KebabCase, opts.FeatureFlag))
+	}
+	if len(data.HealthDependencies()) > 0 {
+		nextSteps = append(nextSteps, fmt.Sprintf("Fill in 'health.%sDependencies' with checks pinging each dependency, pass it to 'health.Register%sChecks' in 'internal/initializer/app.go' and serve 'Registry.Readiness' on the readiness route.", data.PascalCase, data.PascalCase))
+	}
+	if opts.Makefile && opts.writesWorkingTree() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Try 'make migrate-%s', 'make test-%s' and 'make mocks-%s'; they expect psql and mockery on the PATH.", data.KebabCase, data.KebabCase, data.KebabCase))
+	}
+	if len(opts.TestServices()) > 0 && opts.writesWorkingTree() {
+		step := fmt.Sprintf("Start the integration test dependencies with 'docker compose -f %s up -d --wait'.", composeTestPath)
+		if opts.Makefile {
+			step = fmt.Sprintf("Run 'make test-%s'; it starts the dependencies in '%s' and migrates the test database first.", data.KebabCase, composeTestPath)
+		}
+		nextSteps = append(nextSteps, step)
+	}
+	if opts.Fuzz {
+		nextSteps = append(nextSteps, fmt.Sprintf("Set 'fuzzValidation' in '%s' and run 'go test -fuzz=FuzzCreate%sRequest' in that package.", filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase, "request_fuzz_test.go"), data.PascalCase))
+	}
+	if opts.Bench {
+		nextSteps = append(nextSteps, fmt.Sprintf("Implement the TODOs in '%s' and run the benchmarks with TEST_DATABASE_URL set.", filepath.Join("internal/transport/repository/postgres", data.CamelCase+"_bench_test.go")))
+	}
+	for i, step := range nextSteps {
+		fmt.Printf("%d. %s\n", i+1, data.groupStep(filepath.ToSlash(step)))
+	}
+	return errors.Join(failed...)
 }
 
 // --- TEMPLATES ---
 
+const (
+	swaggerSwaggo     = "swaggo"
+	swaggerOpenAPIGen = "openapi-gen"
+	swaggerNone       = "none"
+)
+
 const requestTemplate = `package {{.LowerCase}}
+{{- with .RequestImports}}
 
+import (
+{{- range .}}
+	"{{.}}"
+{{- end}}
+)
+{{- end}}
+
+{{if eq .Swagger "openapi-gen" -}}
+// +k8s:openapi-gen=true
+{{end -}}
 type create{{.PascalCase}}Request struct {
+{{- range .RequestFields false}}
+	{{.}}
+{{- else}}
+	// gocrud-gen:begin create-request-fields
 	// TODO: Add fields for creating a new {{.PascalCase}}.
 	// Example:
 	// Name string ` + "`json:\"name\" validate:\"required\"`" + `
+	// gocrud-gen:end create-request-fields
+{{- end}}
 }
+{{- if not .AppendOnly}}
 
+{{if eq .Swagger "openapi-gen" -}}
+// +k8s:openapi-gen=true
+{{end -}}
 type update{{.PascalCase}}Request struct {
+{{- range .RequestFields true}}
+	{{.}}
+{{- else}}
+	// gocrud-gen:begin update-request-fields
 	// TODO: Add fields for updating an existing {{.PascalCase}}.
 	// Example:
 	// Name string ` + "`json:\"name\" validate:\"required\"`" + `
+	// gocrud-gen:end update-request-fields
+{{- end}}
 }
+{{- end}}
 `
 
 const repositoryTemplate = `package postgres
 
-import (
-	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{block "repositoryImports" .}}import (
+{{- if .ListOverride}}
+	"cmp"
+{{- end}}
+{{- if or .ListOverride .GetOverride .ObservesQueries}}
+	"context"
+	"fmt"
+{{- end}}
+{{- if .ObservesQueries}}
+	"time"
+{{- end}}
+{{if or .ListOverride .GetOverride .ObservesQueries}}
+{{end}}	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
 	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
 	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
-)
+{{- if and (or .GetOverride .ObservesQueries) .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+{{- if .SlowQuery}}
+	"go.elastic.co/apm"
+{{- end}}
+){{end}}
+{{- if .Scoped}}
+
+// {{.CamelCase}}DefaultScope is the default scope of the spec, which list and get add
+// unless the request sets the {{.ScopeOverride}} query parameter.
+const {{.CamelCase}}DefaultScope = {{printf "%q" .Entity.DefaultScope.Where}}
+{{- end}}
+{{- if .ListOverride}}
+
+// {{.CamelCase}}DefaultPageSize is the page size of list requests without one.
+const {{.CamelCase}}DefaultPageSize = 20
+{{- end}}
+{{- if .SlowQuery}}
+
+// {{.PascalCase}}SlowQueryThreshold is how long a repository operation may take before
+// it is logged as a slow query.
+var {{.PascalCase}}SlowQueryThreshold = {{.SlowQuery.Milliseconds}} * time.Millisecond
+
+// {{.CamelCase}}Statements describe the SQL of the generic repository's operations
+// for slow query warnings and spans.
+var {{.CamelCase}}Statements = map[string]string{
+	"GetByID": "SELECT * FROM {{.TableIdent | js}} WHERE id = $1",
+	"FindAll": "SELECT * FROM {{.TableIdent | js}} ORDER BY ... LIMIT $1 OFFSET $2",
+	"Create":  "INSERT INTO {{.TableIdent | js}} (...) VALUES (...) RETURNING id",
+	"Update":  "UPDATE {{.TableIdent | js}} SET ... WHERE id = $1",
+	"Delete":  "DELETE FROM {{.TableIdent | js}} WHERE id = $1",
+}
+{{- end}}
 
 type {{.CamelCase}}Repository struct {
 	repository.GenericRepository[dto.{{.PascalCase}}]
@@ -157,105 +1509,620 @@ func New{{.PascalCase}}Repository(db ports.Database, log ports.LoggerWithTraceID
 	return &{{.CamelCase}}Repository{
 		GenericRepository: repository.NewGenericRepository[dto.{{.PascalCase}}](db, log),
 		db:                db,
-		log:               log,
+		log:               {{if .CorrelationID}}correlation.Logger(log){{else}}log{{end}},
+	}
+}
+{{- if or .ListOverride .GetOverride}}
+
+// {{.CamelCase}}Querier is what the overriding reads need of the database: the
+// sqlx methods scanning rows into structs by their db tags.
+type {{.CamelCase}}Querier interface {
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// querier returns r.db as a {{.CamelCase}}Querier, failing when the database is
+// not backed by sqlx.
+func (r *{{.CamelCase}}Repository) querier() ({{.CamelCase}}Querier, error) {
+	db, ok := r.db.({{.CamelCase}}Querier)
+	if !ok {
+		return nil, fmt.Errorf("{{.SnakeCase}} repository: %T cannot run queries", r.db)
+	}
+	return db, nil
+}
+{{- end}}
+{{- if .ObservesQueries}}
+
+func (r *{{.CamelCase}}Repository) GetByID(ctx context.Context, id {{.IDGoType}}) (_ dto.{{.PascalCase}}, err error) {
+{{- if .SlowQuery}}
+	span, ctx := r.startSpan(ctx, "GetByID")
+	defer span.End()
+{{- end}}
+	defer r.logQuery(ctx, "GetByID", time.Now(), &err)
+	return r.{{with .GetOverride}}{{.}}{{else}}GenericRepository.GetByID{{end}}(ctx, id)
+}
+
+func (r *{{.CamelCase}}Repository) FindAll(ctx context.Context, pagination dto.Pagination) (_ []dto.{{.PascalCase}}, _ *dto.Pagination, err error) {
+{{- if .SlowQuery}}
+	span, ctx := r.startSpan(ctx, "FindAll")
+	defer span.End()
+{{- end}}
+	defer r.logQuery(ctx, "FindAll", time.Now(), &err)
+	return r.{{with .ListOverride}}{{.}}{{else}}GenericRepository.FindAll{{end}}(ctx, pagination)
+}
+
+func (r *{{.CamelCase}}Repository) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) (err error) {
+{{- if .SlowQuery}}
+	span, ctx := r.startSpan(ctx, "Create")
+	defer span.End()
+{{- end}}
+	defer r.logQuery(ctx, "Create", time.Now(), &err)
+	return r.GenericRepository.Create(ctx, {{.CamelCase}})
+}
+
+func (r *{{.CamelCase}}Repository) Update(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) (err error) {
+{{- if .SlowQuery}}
+	span, ctx := r.startSpan(ctx, "Update")
+	defer span.End()
+{{- end}}
+	defer r.logQuery(ctx, "Update", time.Now(), &err)
+	return r.GenericRepository.Update(ctx, {{.CamelCase}})
+}
+
+func (r *{{.CamelCase}}Repository) Delete(ctx context.Context, id {{.IDGoType}}) (err error) {
+{{- if .SlowQuery}}
+	span, ctx := r.startSpan(ctx, "Delete")
+	defer span.End()
+{{- end}}
+	defer r.logQuery(ctx, "Delete", time.Now(), &err)
+	return r.GenericRepository.Delete(ctx, id)
+}
+
+{{- if .SlowQuery}}
+
+// startSpan starts the span of a repository operation, carrying its statement.
+func (r *{{.CamelCase}}Repository) startSpan(ctx context.Context, operation string) (*apm.Span, context.Context) {
+	span, ctx := apm.StartSpan(ctx, "{{.PascalCase}}Repository."+operation, "db.postgresql.query")
+	span.Context.SetDatabase(apm.DatabaseSpanContext{Type: "sql", Statement: {{.CamelCase}}Statements[operation]})
+	return span, ctx
+}
+
+// logQuery warns about an operation slower than {{.PascalCase}}SlowQueryThreshold
+// with its statement{{if .LogQueries}}, and logs every other with its duration and error, if
+// any{{end}}. The logger adds the trace ID of the request from ctx.
+func (r *{{.CamelCase}}Repository) logQuery(ctx context.Context, operation string, start time.Time, err *error) {
+	duration := time.Since(start)
+	message := fmt.Sprintf("repository entity={{.SnakeCase}} operation=%s duration=%s", operation, duration)
+	if *err != nil {
+		message = fmt.Sprintf("%s error=%q", message, *err)
+	}
+	if duration >= {{.PascalCase}}SlowQueryThreshold {
+		r.warn(ctx, fmt.Sprintf("slow query: %s statement=%q", message, {{.CamelCase}}Statements[operation]))
+{{- if .LogQueries}}
+		return
+{{- end}}
+	}
+{{- if .LogQueries}}
+	if *err != nil {
+		r.log.Error(ctx, message)
+		return
+	}
+	r.log.Info(ctx, message)
+{{- end}}
+}
+
+// warn logs message as a warning if the logger has that level, else as info.
+func (r *{{.CamelCase}}Repository) warn(ctx context.Context, message string) {
+	if warner, ok := r.log.(interface{ Warn(context.Context, string) }); ok {
+		warner.Warn(ctx, message)
+		return
+	}
+	r.log.Info(ctx, message)
+}
+{{- else}}
+
+// logQuery logs a finished operation with its duration and error, if any. The
+// logger adds the trace ID of the request from ctx.
+func (r *{{.CamelCase}}Repository) logQuery(ctx context.Context, operation string, start time.Time, err *error) {
+	message := fmt.Sprintf("repository entity={{.SnakeCase}} operation=%s duration=%s", operation, time.Since(start))
+	if *err != nil {
+		r.log.Error(ctx, fmt.Sprintf("%s error=%q", message, *err))
+		return
+	}
+	r.log.Info(ctx, message)
+}
+{{- end}}
+{{- end}}
+{{- with .GetOverride}}
+{{range $.GetOverrideDoc}}
+{{.}}
+{{- end}}
+func (r *{{$.CamelCase}}Repository) {{.}}(ctx context.Context, id {{$.IDGoType}}) (dto.{{$.PascalCase}}, error) {
+{{- if $.SparseFields}}
+	columns := repository.SelectList(ctx)
+{{- end}}
+{{- if $.Scoped}}
+	scoped := !dto.Unscoped(ctx)
+{{- end}}
+	if {{$.GetGenericCondition}} {
+		return r.GenericRepository.GetByID(ctx, id)
+	}
+	db, err := r.querier()
+	if err != nil {
+		return dto.{{$.PascalCase}}{}, err
+	}
+{{if and $.Scoped $.SparseFields}}
+	where := "id = $1"
+	if scoped {
+		where = repository.AndScope(where, {{$.CamelCase}}DefaultScope)
+	}
+{{- else if $.Scoped}}
+	where := repository.AndScope("id = $1", {{$.CamelCase}}DefaultScope)
+{{- else}}
+	where := "id = $1"
+{{- end}}
+	query := "SELECT {{if $.SparseFields}}" + columns + "{{else}}*{{end}} FROM {{$.TableIdent | js}} WHERE " + where
+
+	var {{$.CamelCase}} dto.{{$.PascalCase}}
+	if err := db.GetContext(ctx, &{{$.CamelCase}}, query, id); err != nil {
+		return dto.{{$.PascalCase}}{}, err
+	}
+	return {{$.CamelCase}}, nil
+}
+{{- end}}
+{{- with .ListOverride}}
+{{range $.ListOverrideDoc}}
+{{.}}
+{{- end}}
+func (r *{{$.CamelCase}}Repository) {{.}}(ctx context.Context, pagination dto.Pagination) ([]dto.{{$.PascalCase}}, *dto.Pagination, error) {
+{{- if $.FilterFields}}
+	filters := dto.FiltersFromContext(ctx)
+{{- end}}
+{{- if $.SparseFields}}
+	columns := repository.SelectList(ctx)
+{{- end}}
+{{- if $.SortFields}}
+	sorts := dto.SortsFromContext(ctx)
+{{- end}}
+{{- if $.Scoped}}
+	scoped := !dto.Unscoped(ctx)
+{{- end}}
+	if {{$.ListGenericCondition}} {
+		return r.GenericRepository.FindAll(ctx, pagination)
+	}
+	db, err := r.querier()
+	if err != nil {
+		return nil, nil, err
+	}
+{{if $.FilterFields}}
+	where, args := repository.BuildFilterClause(filters, 1)
+{{- else if and $.Scoped (or $.SparseFields $.SortFields)}}
+	where := ""
+{{- else if $.Scoped}}
+	where := repository.AndScope("", {{$.CamelCase}}DefaultScope)
+{{- end}}
+{{- if and $.Scoped (or $.FilterFields $.SparseFields $.SortFields)}}
+	if scoped {
+		where = repository.AndScope(where, {{$.CamelCase}}DefaultScope)
+	}
+{{- end}}
+{{- if $.ListWhereOptional}}
+	from := " FROM {{$.TableIdent | js}}"
+	if where != "" {
+		from += " WHERE " + where
+	}
+{{- else if $.Scoped}}
+	from := " FROM {{$.TableIdent | js}} WHERE " + where
+{{- else}}
+	from := " FROM {{$.TableIdent | js}}"
+{{- end}}
+	if err := db.GetContext(ctx, &pagination.TotalRows, "SELECT COUNT(*)"+from{{if $.FilterFields}}, args...{{end}}); err != nil {
+		return nil, nil, err
+	}
+
+	size := cmp.Or(pagination.PageSize, {{$.CamelCase}}DefaultPageSize)
+	query := {{if $.SparseFields}}"SELECT " + columns{{else}}"SELECT *"{{end}} + from
+{{- if $.FilterFields}}
+	query += fmt.Sprintf(" ORDER BY {{if $.SortFields}}%s{{else}}id{{end}} LIMIT $%d OFFSET $%d", {{if $.SortFields}}repository.BuildOrderBy(sorts), {{end}}len(args)+1, len(args)+2)
+	args = append(args, size, {{$.CamelCase}}PageOffset(pagination, size))
+{{- else}}
+	query += " ORDER BY {{if $.SortFields}}" + repository.BuildOrderBy(sorts) + "{{else}}id{{end}} LIMIT $1 OFFSET $2"
+	args := []any{size, {{$.CamelCase}}PageOffset(pagination, size)}
+{{- end}}
+
+	var {{$.CamelCase}}s []dto.{{$.PascalCase}}
+	if err := db.SelectContext(ctx, &{{$.CamelCase}}s, query, args...); err != nil {
+		return nil, nil, err
 	}
+	return {{$.CamelCase}}s, &pagination, nil
+}
+
+// {{$.CamelCase}}PageOffset is the number of rows before the requested page,
+// which is counted from 1 like in the generic repository.
+func {{$.CamelCase}}PageOffset(pagination dto.Pagination, size int) int {
+	return (max(pagination.Page, 1) - 1) * size
 }
+{{- end}}
 `
 
 const serviceTemplate = `package service
 
-import (
+{{block "serviceImports" .}}import (
 	"context"
-
+{{- if .RestrictedBy}}
+	"errors"
+	"fmt"
+{{- end}}
+{{- if .Timeout}}
+	"time"
+{{- end}}
+{{if .RestrictedBy}}
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+{{- end}}
 	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
 	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+{{- if .Saga}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/saga"
+{{- end}}
 	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
-)
+{{- if .Webhooks}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/webhook"
+{{- end}}
+{{- if .Worker}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/worker"
+{{- end}}
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+){{end}}
 
 type {{.PascalCase}} interface {
-	Get{{.PascalCase}}ByID(ctx context.Context, id int64) (dto.{{.PascalCase}}, error)
+	Get{{.PascalCase}}ByID(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error)
 	Update{{.PascalCase}}(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}) (dto.{{.PascalCase}}, error)
 	Create{{.PascalCase}}(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}) (dto.{{.PascalCase}}, error)
-	Delete{{.PascalCase}}(ctx context.Context, id int64) error
+	Delete{{.PascalCase}}(ctx context.Context, id {{.IDGoType}}) error
 	GetPaginated{{.PascalCase}}s(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error)
+{{- if .Clone}}
+	Clone{{.PascalCase}}(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error)
+{{- end}}
+{{- if .SoftDelete}}
+	GetDeleted{{.PascalCase}}s(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error)
+	Restore{{.PascalCase}}(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error)
+{{- end}}
+{{- if .History}}
+	Get{{.PascalCase}}History(ctx context.Context, id {{.IDGoType}}, pagination dto.Pagination) ([]dto.Version[dto.{{.PascalCase}}], *dto.Pagination, error)
+{{- end}}
+{{- if .DiffsVersions}}
+	Diff{{.PascalCase}}Versions(ctx context.Context, id {{.IDGoType}}, versionA, versionB int64) ([]dto.FieldChange, error)
+{{- end}}
+}
+{{- if .Timeout}}
+
+// {{.PascalCase}}Timeouts bounds how long each operation may run.
+// Zero values fall back to default{{.PascalCase}}Timeout.
+type {{.PascalCase}}Timeouts struct {
+	Get    time.Duration
+	List   time.Duration
+	Create time.Duration
+	Update time.Duration
+	Delete time.Duration
+}
+
+const default{{.PascalCase}}Timeout = {{.Timeout.Milliseconds}} * time.Millisecond
+
+func (t {{.PascalCase}}Timeouts) withDefaults() {{.PascalCase}}Timeouts {
+	for _, timeout := range []*time.Duration{&t.Get, &t.List, &t.Create, &t.Update, &t.Delete} {
+		if *timeout <= 0 {
+			*timeout = default{{.PascalCase}}Timeout
+		}
+	}
+	return t
 }
+{{- end}}
 
 type {{.CamelCase}}Service struct {
 	log              ports.LoggerWithTraceID
 	{{.CamelCase}}Repository repository.{{.PascalCase}}
+{{- if .Webhooks}}
+	webhooks         *webhook.{{.PascalCase}}Dispatcher
+{{- end}}
+{{- if .Worker}}
+	worker           *worker.{{.PascalCase}}Worker
+{{- end}}
+{{- if .Timeout}}
+	timeouts         {{.PascalCase}}Timeouts
+{{- end}}
+{{- if .LoadsRelations}}
+	relations        repository.{{.PascalCase}}Relations
+{{- end}}
+{{- if .SoftDelete}}
+	trash            repository.{{.PascalCase}}Trash
+{{- end}}
+{{- if .History}}
+	history          repository.{{.PascalCase}}History
+{{- end}}
+{{- if .Saga}}
+	sagas            *saga.Orchestrator
+{{- end}}
+{{- if .Clients}}
+	clients          {{.PascalCase}}Clients
+{{- end}}
 }
 
-func New{{.PascalCase}}Service(log ports.LoggerWithTraceID, {{.CamelCase}}Repository repository.{{.PascalCase}}) {{.PascalCase}} {
+func New{{.PascalCase}}Service(log ports.LoggerWithTraceID, {{.CamelCase}}Repository repository.{{.PascalCase}}{{if .Webhooks}}, webhooks *webhook.{{.PascalCase}}Dispatcher{{end}}{{if .Worker}}, {{.CamelCase}}Worker *worker.{{.PascalCase}}Worker{{end}}{{if .Timeout}}, timeouts {{.PascalCase}}Timeouts{{end}}{{if .LoadsRelations}}, relations repository.{{.PascalCase}}Relations{{end}}{{if .SoftDelete}}, trash repository.{{.PascalCase}}Trash{{end}}{{if .History}}, history repository.{{.PascalCase}}History{{end}}{{if .Saga}}, sagas *saga.Orchestrator{{end}}{{if .Clients}}, clients {{.PascalCase}}Clients{{end}}) {{.PascalCase}} {
 	return &{{.CamelCase}}Service{
-		log:              log,
+		log:              {{if .CorrelationID}}correlation.Logger(log){{else}}log{{end}},
 		{{.CamelCase}}Repository: {{.CamelCase}}Repository,
+{{- if .Webhooks}}
+		webhooks:         webhooks,
+{{- end}}
+{{- if .Worker}}
+		worker:           {{.CamelCase}}Worker,
+{{- end}}
+{{- if .Timeout}}
+		timeouts:         timeouts.withDefaults(),
+{{- end}}
+{{- if .LoadsRelations}}
+		relations:        relations,
+{{- end}}
+{{- if .SoftDelete}}
+		trash:            trash,
+{{- end}}
+{{- if .History}}
+		history:          history,
+{{- end}}
+{{- if .Saga}}
+		sagas:            sagas,
+{{- end}}
+{{- if .Clients}}
+		clients:          clients,
+{{- end}}
 	}
 }
 
-func (s *{{.CamelCase}}Service) Get{{.PascalCase}}ByID(ctx context.Context, id int64) (dto.{{.PascalCase}}, error) {
+func (s *{{.CamelCase}}Service) Get{{.PascalCase}}ByID(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error) {
+{{- if .Timeout}}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Get)
+	defer cancel()
+{{end}}
 	{{.CamelCase}}, err := s.{{.CamelCase}}Repository.GetByID(ctx, id)
 	if err != nil {
 		return dto.{{.PascalCase}}{}, err
 	}
+{{- if .Clients}}
+	if err := s.enrich{{.PascalCase}}(ctx, &{{.CamelCase}}); err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+{{- end}}
+{{- if .Include}}
+	{{.CamelCase}}s := []dto.{{.PascalCase}}{ {{- .CamelCase}}}
+	if err := s.loadRelations(ctx, {{.CamelCase}}s); err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	return {{.CamelCase}}s[0], nil
+{{- else}}
 	return {{.CamelCase}}, nil
+{{- end}}
 }
 
 func (s *{{.CamelCase}}Service) Update{{.PascalCase}}(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}) (dto.{{.PascalCase}}, error) {
+{{- if .Timeout}}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Update)
+	defer cancel()
+{{end}}
 	err := s.{{.CamelCase}}Repository.Update(ctx, &{{.CamelCase}})
 	if err != nil {
 		return dto.{{.PascalCase}}{}, err
 	}
+{{- if .Webhooks}}
+	s.webhooks.Dispatch(ctx, webhook.{{.PascalCase}}Updated, {{.CamelCase}})
+{{- end}}
+{{- if .Worker}}
+	s.enqueue(ctx, worker.{{.PascalCase}}UpdatedJob, {{.CamelCase}}.ID)
+{{- end}}
 	return {{.CamelCase}}, nil
 }
 
 func (s *{{.CamelCase}}Service) Create{{.PascalCase}}(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}) (dto.{{.PascalCase}}, error) {
+{{- if .Timeout}}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Create)
+	defer cancel()
+{{end}}
+{{- if .Saga}}
+	err := s.sagas.Run(ctx, create{{.PascalCase}}Saga, s.create{{.PascalCase}}Steps(&{{.CamelCase}})...)
+{{- else}}
 	err := s.{{.CamelCase}}Repository.Create(ctx, &{{.CamelCase}})
+{{- end}}
 	if err != nil {
 		return dto.{{.PascalCase}}{}, err
 	}
+{{- if .Webhooks}}
+	s.webhooks.Dispatch(ctx, webhook.{{.PascalCase}}Created, {{.CamelCase}})
+{{- end}}
+{{- if .Worker}}
+	s.enqueue(ctx, worker.{{.PascalCase}}CreatedJob, {{.CamelCase}}.ID)
+{{- end}}
 	return {{.CamelCase}}, nil
 }
 
-func (s *{{.CamelCase}}Service) Delete{{.PascalCase}}(ctx context.Context, id int64) error {
+func (s *{{.CamelCase}}Service) Delete{{.PascalCase}}(ctx context.Context, id {{.IDGoType}}) error {
+{{- if .Timeout}}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Delete)
+	defer cancel()
+{{end}}
 	err := s.{{.CamelCase}}Repository.Delete(ctx, id)
+{{- if .RestrictedBy}}
+	if {{.CamelCase}}Referenced(err) {
+		return appErr.NewConflictErr(fmt.Errorf("{{.LowerCase}} %v is still referenced by {{.RestrictedByList}}", id))
+	}
+{{- end}}
 	if err != nil {
 		return err
 	}
+{{- if .Webhooks}}
+	s.webhooks.Dispatch(ctx, webhook.{{.PascalCase}}Deleted, map[string]{{.IDGoType}}{"id": id})
+{{- end}}
 	return nil
 }
 
 func (s *{{.CamelCase}}Service) GetPaginated{{.PascalCase}}s(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+{{- if .Timeout}}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.List)
+	defer cancel()
+{{end}}
 	{{.CamelCase}}s, resultPagination, err := s.{{.CamelCase}}Repository.FindAll(ctx, pagination)
 	if err != nil {
 		return nil, nil, err
 	}
+{{- if .LoadsRelations}}
+	if err := s.loadRelations(ctx, {{.CamelCase}}s); err != nil {
+		return nil, nil, err
+	}
+{{- end}}
+{{- if .Clients}}
+	for i := range {{.CamelCase}}s {
+		if err := s.enrich{{.PascalCase}}(ctx, &{{.CamelCase}}s[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+{{- end}}
 	return {{.CamelCase}}s, resultPagination, nil
 }
+{{- if .LoadsRelations}}
+
+// loadRelations fills the related rows of a page of {{.LowerCase}}s with one query
+// per relation rather than one per {{.LowerCase}}.
+{{- if .Include}}
+// Only the relations the request includes are loaded.
+{{- end}}
+func (s *{{.CamelCase}}Service) loadRelations(ctx context.Context, {{.CamelCase}}s []dto.{{.PascalCase}}) error {
+{{- range .RelationLoaders}}
+{{- if $.Include}}
+	if dto.Included(ctx, "{{.JSONName}}") {
+		{{.Var}}, err := s.relations.{{.Method}}(ctx, {{$.CamelCase}}s)
+		if err != nil {
+			return err
+		}
+		for i := range {{$.CamelCase}}s {
+{{- if .Many}}
+			{{$.CamelCase}}s[i].{{.Field}} = {{.Var}}[{{$.CamelCase}}s[i].{{.Key}}]
+{{- else}}
+			if {{.Single}}, ok := {{.Var}}[{{$.CamelCase}}s[i].{{.Key}}]; ok {
+				{{$.CamelCase}}s[i].{{.Field}} = &{{.Single}}
+			}
+{{- end}}
+		}
+	}
+{{- else}}
+	{{.Var}}, err := s.relations.{{.Method}}(ctx, {{$.CamelCase}}s)
+	if err != nil {
+		return err
+	}
+	for i := range {{$.CamelCase}}s {
+{{- if .Many}}
+		{{$.CamelCase}}s[i].{{.Field}} = {{.Var}}[{{$.CamelCase}}s[i].{{.Key}}]
+{{- else}}
+		if {{.Single}}, ok := {{.Var}}[{{$.CamelCase}}s[i].{{.Key}}]; ok {
+			{{$.CamelCase}}s[i].{{.Field}} = &{{.Single}}
+		}
+{{- end}}
+	}
+{{- end}}
+{{- end}}
+	return nil
+}
+{{- end}}
+{{- if .RestrictedBy}}
+
+// {{.CamelCase}}Referenced reports whether err is the foreign key violation of
+// deleting a {{.LowerCase}} that other rows still reference.
+func {{.CamelCase}}Referenced(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23503"
+}
+{{- end}}
+{{- if .Worker}}
+
+// enqueue schedules async post-processing. Failures are logged rather than
+// returned because the write itself has already succeeded.
+func (s *{{.CamelCase}}Service) enqueue(ctx context.Context, jobType string, id {{.IDGoType}}) {
+	if err := s.worker.Enqueue(ctx, worker.{{.PascalCase}}Job{Type: jobType, ID: id}); err != nil {
+		s.log.Error(ctx, err.Error())
+	}
+}
+{{- end}}
 `
 
 const controllerTemplate = `package {{.LowerCase}}
 
-import (
+{{block "controllerImports" .}}import (
 	"errors"
 
 	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
 	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
 	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if not .I18n}}
 	"git.snapp.ninja/snappshop/delivery/harley/internal/consts"
-	"git.snapp.ninja/snappshop/delivery/harley/internal/service"
+{{- end}}
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+	"{{.ServiceImport}}"
 	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
 	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/validator"
 	"git.snapp.ninja/snappshop/delivery/harley/internal/utils"
 	"go.elastic.co/apm"
-)
+){{end}}
 
 type {{.PascalCase}} interface {
 	GetPaginated{{.PascalCase}}s(c *ports.HttpContext) error
+{{- if not .Admin}}
 	Create{{.PascalCase}}(c *ports.HttpContext) error
+{{- end}}
 	Get{{.PascalCase}}ByID(c *ports.HttpContext) error
+{{- if not .Admin}}
 	Update{{.PascalCase}}(c *ports.HttpContext) error
 	Delete{{.PascalCase}}(c *ports.HttpContext) error
+{{- if .Clone}}
+	Clone{{.PascalCase}}(c *ports.HttpContext) error
+{{- end}}
+{{- if .SoftDelete}}
+	GetDeleted{{.PascalCase}}s(c *ports.HttpContext) error
+	Restore{{.PascalCase}}(c *ports.HttpContext) error
+{{- end}}
+{{- if .History}}
+	Get{{.PascalCase}}History(c *ports.HttpContext) error
+{{- end}}
+{{- if .DiffsVersions}}
+	Diff{{.PascalCase}}Versions(c *ports.HttpContext) error
+{{- end}}
+{{- end}}
 }
+{{- if .Admin}}
+
+// {{.PascalCase}}Admin holds the write handlers, served under the admin router group.
+type {{.PascalCase}}Admin interface {
+	Create{{.PascalCase}}(c *ports.HttpContext) error
+	Update{{.PascalCase}}(c *ports.HttpContext) error
+	Delete{{.PascalCase}}(c *ports.HttpContext) error
+{{- if .Clone}}
+	Clone{{.PascalCase}}(c *ports.HttpContext) error
+{{- end}}
+{{- if .SoftDelete}}
+	GetDeleted{{.PascalCase}}s(c *ports.HttpContext) error
+	Restore{{.PascalCase}}(c *ports.HttpContext) error
+{{- end}}
+{{- if .History}}
+	Get{{.PascalCase}}History(c *ports.HttpContext) error
+{{- end}}
+{{- if .DiffsVersions}}
+	Diff{{.PascalCase}}Versions(c *ports.HttpContext) error
+{{- end}}
+}
+{{- end}}
 
 type {{.CamelCase}}Controller struct {
 	{{.CamelCase}}Service    service.{{.PascalCase}}
@@ -263,28 +2130,88 @@ type {{.CamelCase}}Controller struct {
 	log              ports.LoggerWithTraceID
 }
 
+{{- if .Pagination.IsSet}}
+
+// {{.CamelCase}}PaginationDefaults come from the pagination section of the generator config.
+var {{.CamelCase}}PaginationDefaults = httpUtils.PaginationDefaults{
+	DefaultPageSize: {{.Pagination.DefaultPageSize}},
+	MaxPageSize:     {{.Pagination.MaxPageSize}},
+	DefaultSort:     {{printf "%q" .Pagination.DefaultSort}},
+}
+{{- end}}
+{{- if .FilterFields}}
+
+// {{.CamelCase}}Filters whitelists the filter operators of each field, as declared
+// in the spec. Query parameters take the form field[operator]=value.
+var {{.CamelCase}}Filters = map[string]httpUtils.FilterField{
+{{- range .FilterFields}}
+	"{{.Name}}": {Column: "{{.Name}}", Kind: {{.FilterKind}}, Operators: {{.FilterOperators}}},
+{{- end}}
+}
+{{- end}}
+{{- if .SparseFields}}
+
+// {{.CamelCase}}Fields whitelists the fields the fields query parameter may select,
+// with the columns each is read from. The id is always returned.
+var {{.CamelCase}}Fields = map[string][]string{
+{{- range .SparseFieldColumns}}
+	{{.}}
+{{- end}}
+}
+{{- end}}
+{{- if .Include}}
+
+// {{.CamelCase}}Includes are the relations the include query parameter may nest in
+// the response.
+var {{.CamelCase}}Includes = {{.IncludeNames}}
+{{- end}}
+
 func New(log ports.LoggerWithTraceID, {{.CamelCase}}Service service.{{.PascalCase}}, customValidation validator.CustomValidation) {{.PascalCase}} {
 	return &{{.CamelCase}}Controller{
 		{{.CamelCase}}Service:    {{.CamelCase}}Service,
 		customValidation: customValidation,
-		log:              log,
+		log:              {{if .CorrelationID}}correlation.Logger(log){{else}}log{{end}},
+	}
+}
+{{- if .Admin}}
+
+func NewAdmin(log ports.LoggerWithTraceID, {{.CamelCase}}Service service.{{.PascalCase}}, customValidation validator.CustomValidation) {{.PascalCase}}Admin {
+	return &{{.CamelCase}}Controller{
+		{{.CamelCase}}Service:    {{.CamelCase}}Service,
+		customValidation: customValidation,
+		log:              {{if .CorrelationID}}correlation.Logger(log){{else}}log{{end}},
 	}
 }
+{{- end}}
 
+{{if eq .Swagger "swaggo" -}}
 // @Summary		Create a {{.PascalCase}}
 // @Description	This route will create a {{.LowerCase}}
 // @Tags			{{.PascalCase}}
 // @Accept			json
 // @Produce		json
 // @Param			body	body		create{{.PascalCase}}Request 	true	"Create {{.PascalCase}} request"
-// @Success		201		{object}	ports.Response{data=dto.{{.PascalCase}}}
-// @Failure		400		{object}	ports.ErrorDetails
-// @Failure		422		{object}	ports.ErrorDetails
-// @Failure		500		{object}	ports.ErrorDetails
-// @Router			/api/v1/{{.KebabCase}}/ [post]
+// @Success		201		{{.SwagResponse .ResponseType}}
+// @Failure		400		{object}	{{.ErrorSchema}}
+// @Failure		422		{object}	{{.ErrorSchema}}
+// @Failure		500		{object}	{{.ErrorSchema}}
+// @Router			{{.WriteRoutePrefix}}/{{.KebabCase}}/ [post]
+{{else -}}
+// Create{{.PascalCase}} handles POST {{.WriteRoutePrefix}}/{{.KebabCase}}/.
+{{end -}}
 func (ctrl *{{.CamelCase}}Controller) Create{{.PascalCase}}(c *ports.HttpContext) error {
 	span, ctx := apm.StartSpan(c.Context(), "Create{{.PascalCase}}", "controller")
 	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
+
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return err
+	}
+{{- end}}
 
 	var inputRequest create{{.PascalCase}}Request
 	if err := c.BodyParser(&inputRequest); err != nil {
@@ -295,80 +2222,152 @@ func (ctrl *{{.CamelCase}}Controller) Create{{.PascalCase}}(c *ports.HttpContext
 	validationErrs := ctrl.customValidation.ValidateStruct(inputRequest)
 	if validationErrs != nil {
 		return utils.WithFieldErrors(
-			appErr.NewBadRequestErr(errors.New(consts.ErrValidationFailedMsg)),
+			appErr.NewBadRequestErr(errors.New({{if .I18n}}msg{{.PascalCase}}ValidationFailed{{else}}consts.ErrValidationFailedMsg{{end}})),
 			validationErrs...,
 		)
 	}
 	
+	// gocrud-gen:begin create-mapping
 	// TODO: Map inputRequest to a dto.{{.PascalCase}} struct.
 	// Example:
 	// entityDto := dto.{{.PascalCase}}{
 	// 	Name: inputRequest.Name,
 	// }
 	var entityDto dto.{{.PascalCase}}
+	// gocrud-gen:end create-mapping
 
 
 	createdEntity, err := ctrl.{{.CamelCase}}Service.Create{{.PascalCase}}(ctx, entityDto)
 	if err != nil {
-		return err
+		return {{block "controllerServiceError" .}}{{if .I18n}}localize{{.PascalCase}}Error(err){{else}}err{{end}}{{end}}
 	}
 
-	return c.Status(201).JSON(ports.Response{
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 201, {{else}}c.Status(201).JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
 		Status: true,
-		Data:   createdEntity,
-	})
+		Data:   {{with .ResponseMapper}}{{.}}(createdEntity){{else}}createdEntity{{end}},
+	}{{if .Envelope.IsSet}}){{end}})
 }
 
+{{if eq .Swagger "swaggo" -}}
 // @Summary		Get {{.PascalCase}} by ID
 // @Description	This route will fetch a specific {{.LowerCase}} by its ID
 // @Tags			{{.PascalCase}}
 // @Accept			json
 // @Produce		json
-// @Param			id	path		int	true	"{{.PascalCase}} ID"
-// @Success		200	{object}	ports.Response{data=dto.{{.PascalCase}}}
-// @Failure		400	{object}	ports.ErrorDetails
-// @Failure		404	{object}	ports.ErrorDetails
-// @Failure		500	{object}	ports.ErrorDetails
+// @Param			id	path		{{.IDParam}}	true	"{{.PascalCase}} ID"{{if .UUID}}	format(uuid){{end}}
+{{- if .SparseFields}}
+// @Param			fields	query	string	false	"Comma-separated fields to return, e.g. id,name; all by default"
+{{- end}}
+{{- if .Include}}
+// @Param			include	query	string	false	"Comma-separated relations to nest in the response"
+{{- end}}
+{{- if .Scoped}}
+// @Param			{{.ScopeOverride}}	query	bool	false	"Include the {{.LowerCase}}s outside the default scope"
+{{- end}}
+// @Success		200	{{.SwagResponse .ResponseType}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		404	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
 // @Router			/api/v1/{{.KebabCase}}/{id} [get]
+{{else -}}
+// Get{{.PascalCase}}ByID handles GET /api/v1/{{.KebabCase}}/{id}.
+{{end -}}
 func (ctrl *{{.CamelCase}}Controller) Get{{.PascalCase}}ByID(c *ports.HttpContext) error {
 	span, ctx := apm.StartSpan(c.Context(), "Get{{.PascalCase}}ByID", "controller")
 	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
 
-	id, err := c.ParamsInt("id")
+	ctx, err := withCaller(ctx, c)
 	if err != nil {
-		return appErr.NewBadRequestErr(err)
+		return err
 	}
+{{- end}}
 
-	entity, err := ctrl.{{.CamelCase}}Service.Get{{.PascalCase}}ByID(ctx, int64(id))
+	id, err := {{if .UUID}}parse{{.PascalCase}}ID(c){{else}}c.ParamsInt("id"){{end}}
+	if err != nil {
+		return {{if .UUID}}err{{else}}appErr.NewBadRequestErr(err){{end}}
+	}
+{{- if .SparseFields}}
+
+	fields, err := httpUtils.ParseFields(c, {{.CamelCase}}Fields)
+	if err != nil {
+		return err
+	}
+	ctx = dto.WithFields(ctx, fields)
+{{- end}}
+{{- if .Include}}
+
+	include, err := httpUtils.ParseInclude(c, {{.CamelCase}}Includes)
+	if err != nil {
+		return err
+	}
+	ctx = dto.WithInclude(ctx, include)
+{{- end}}
+{{- if .Scoped}}
+
+	unscoped, err := httpUtils.ParseUnscoped(c, "{{.ScopeOverride}}")
+	if err != nil {
+		return err
+	}
+	if unscoped {
+		ctx = dto.WithUnscoped(ctx)
+	}
+{{- end}}
+
+	entity, err := ctrl.{{.CamelCase}}Service.Get{{.PascalCase}}ByID(ctx, {{.IDArg}})
+	if err != nil {
+		return {{template "controllerServiceError" .}}
+	}
+{{- if .SparseFields}}
+
+	data, err := httpUtils.SelectFields({{with .ResponseMapper}}{{.}}(entity){{else}}entity{{end}}, fields)
 	if err != nil {
 		return err
 	}
+{{- end}}
 
-	return c.JSON(ports.Response{
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, {{else}}c.JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
 		Status: true,
-		Data:   entity,
-	})
+		Data:   {{if .SparseFields}}data{{else}}{{with .ResponseMapper}}{{.}}(entity){{else}}entity{{end}}{{end}},
+	}{{if .Envelope.IsSet}}){{end}})
 }
 
+{{if eq .Swagger "swaggo" -}}
 // @Summary		Update a {{.PascalCase}}
 // @Description	This route will update a {{.LowerCase}}
 // @Tags			{{.PascalCase}}
 // @Accept			json
 // @Produce		json
-// @Param			id		path		int	true	"{{.PascalCase}} ID"
+// @Param			id		path		{{.IDParam}}	true	"{{.PascalCase}} ID"{{if .UUID}}	format(uuid){{end}}
 // @Param			body	body		update{{.PascalCase}}Request 	true	"Update {{.PascalCase}} request"
-// @Success		200		{object}	ports.Response{data=dto.{{.PascalCase}}}
-// @Failure		400		{object}	ports.ErrorDetails
-// @Failure		422		{object}	ports.ErrorDetails
-// @Failure		500		{object}	ports.ErrorDetails
-// @Router			/api/v1/{{.KebabCase}}/{id} [put]
+// @Success		200		{{.SwagResponse .ResponseType}}
+// @Failure		400		{object}	{{.ErrorSchema}}
+// @Failure		422		{object}	{{.ErrorSchema}}
+// @Failure		500		{object}	{{.ErrorSchema}}
+// @Router			{{.WriteRoutePrefix}}/{{.KebabCase}}/{id} [put]
+{{else -}}
+// Update{{.PascalCase}} handles PUT {{.WriteRoutePrefix}}/{{.KebabCase}}/{id}.
+{{end -}}
 func (ctrl *{{.CamelCase}}Controller) Update{{.PascalCase}}(c *ports.HttpContext) error {
 	span, ctx := apm.StartSpan(c.Context(), "Update{{.PascalCase}}", "controller")
 	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
 
-	id, err := c.ParamsInt("id")
+	ctx, err := withCaller(ctx, c)
 	if err != nil {
-		return appErr.NewBadRequestErr(err)
+		return err
+	}
+{{- end}}
+
+	id, err := {{if .UUID}}parse{{.PascalCase}}ID(c){{else}}c.ParamsInt("id"){{end}}
+	if err != nil {
+		return {{if .UUID}}err{{else}}appErr.NewBadRequestErr(err){{end}}
 	}
 
 	var inputRequest update{{.PascalCase}}Request
@@ -380,94 +2379,215 @@ func (ctrl *{{.CamelCase}}Controller) Update{{.PascalCase}}(c *ports.HttpContext
 	validationErrs := ctrl.customValidation.ValidateStruct(inputRequest)
 	if validationErrs != nil {
 		return utils.WithFieldErrors(
-			appErr.NewBadRequestErr(errors.New(consts.ErrValidationFailedMsg)),
+			appErr.NewBadRequestErr(errors.New({{if .I18n}}msg{{.PascalCase}}ValidationFailed{{else}}consts.ErrValidationFailedMsg{{end}})),
 			validationErrs...,
 		)
 	}
 	
+	// gocrud-gen:begin update-mapping
 	// TODO: Map inputRequest to a dto.{{.PascalCase}} struct.
 	// Example:
 	// entityDto := dto.{{.PascalCase}}{
 	// 	Name: inputRequest.Name,
 	// }
 	var entityDto dto.{{.PascalCase}}
-	entityDto.ID = int64(id) // Set ID from path
+	// gocrud-gen:end update-mapping
+	entityDto.ID = {{.IDArg}} // Set ID from path
 
 	result, err := ctrl.{{.CamelCase}}Service.Update{{.PascalCase}}(ctx, entityDto)
 	if err != nil {
-		return err
+		return {{template "controllerServiceError" .}}
 	}
 
-	return c.JSON(ports.Response{
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, {{else}}c.JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
 		Status: true,
-		Data:   result,
-	})
+		Data:   {{with .ResponseMapper}}{{.}}(result){{else}}result{{end}},
+	}{{if .Envelope.IsSet}}){{end}})
 }
 
+{{if eq .Swagger "swaggo" -}}
 // @Summary		Delete a {{.PascalCase}}
 // @Description	This route will delete a {{.LowerCase}}
 // @Tags			{{.PascalCase}}
 // @Accept			json
 // @Produce		json
-// @Param			id	path		int	true	"{{.PascalCase}} ID"
+// @Param			id	path		{{.IDParam}}	true	"{{.PascalCase}} ID"{{if .UUID}}	format(uuid){{end}}
 // @Success		204
-// @Failure		400	{object}	ports.ErrorDetails
-// @Failure		500	{object}	ports.ErrorDetails
-// @Router			/api/v1/{{.KebabCase}}/{id} [delete]
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			{{.WriteRoutePrefix}}/{{.KebabCase}}/{id} [delete]
+{{else -}}
+// Delete{{.PascalCase}} handles DELETE {{.WriteRoutePrefix}}/{{.KebabCase}}/{id}.
+{{end -}}
 func (ctrl *{{.CamelCase}}Controller) Delete{{.PascalCase}}(c *ports.HttpContext) error {
 	span, ctx := apm.StartSpan(c.Context(), "Delete{{.PascalCase}}", "controller")
 	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
 
-	id, err := c.ParamsInt("id")
+	ctx, err := withCaller(ctx, c)
 	if err != nil {
-		return appErr.NewBadRequestErr(err)
+		return err
 	}
+{{- end}}
 
-	err = ctrl.{{.CamelCase}}Service.Delete{{.PascalCase}}(ctx, int64(id))
+	id, err := {{if .UUID}}parse{{.PascalCase}}ID(c){{else}}c.ParamsInt("id"){{end}}
 	if err != nil {
-		return err
+		return {{if .UUID}}err{{else}}appErr.NewBadRequestErr(err){{end}}
+	}
+
+	err = ctrl.{{.CamelCase}}Service.Delete{{.PascalCase}}(ctx, {{.IDArg}})
+	if err != nil {
+		return {{template "controllerServiceError" .}}
 	}
 
 	return c.SendStatus(204)
 }
 
+{{if eq .Swagger "swaggo" -}}
 // @Summary		Get All {{.PascalCase}}s
 // @Description	Get all paginated {{.LowerCase}}s
 // @Tags			{{.PascalCase}}
 // @Accept			json
 // @Produce		json
 // @Param			params	query		httpUtils.ListRequest	false	"Pagination and filter parameters"
-// @Success		200		{object}	ports.Response{data=[]dto.{{.PascalCase}}}
-// @Failure		400	{object}	ports.ErrorDetails
-// @Failure		500	{object}	ports.ErrorDetails
+{{- if .PagesByState}}
+// @Param			page_state	query	string	false	"Paging state of the page, from the X-Next-Page-State header of the previous response"
+{{- end}}
+{{- if .SparseFields}}
+// @Param			fields	query	string	false	"Comma-separated fields to return, e.g. id,name; all by default"
+{{- end}}
+{{- if .Include}}
+// @Param			include	query	string	false	"Comma-separated relations to nest in the response"
+{{- end}}
+{{- if .Scoped}}
+// @Param			{{.ScopeOverride}}	query	bool	false	"Include the {{.LowerCase}}s outside the default scope"
+{{- end}}
+// @Success		200		{{.SwagResponse (print "[]" .ResponseType)}}
+{{- if .PagesByState}}
+// @Header			200		{string}	X-Next-Page-State	"Paging state of the next page"
+{{- end}}
+{{- if .PaginationHeaders}}
+// @Header			200		{integer}	X-Total-Count	"Number of matching {{.LowerCase}}s"
+// @Header			200		{string}	Link	"URLs of the previous and next pages"
+{{- end}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
 // @Router			/api/v1/{{.KebabCase}}/ [get]
+{{else -}}
+// GetPaginated{{.PascalCase}}s handles GET /api/v1/{{.KebabCase}}/.
+{{end -}}
 func (ctrl *{{.CamelCase}}Controller) GetPaginated{{.PascalCase}}s(c *ports.HttpContext) error {
 	span, ctx := apm.StartSpan(c.Context(), "GetPaginated{{.PascalCase}}s", "controller")
 	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
+
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return err
+	}
+{{- end}}
 	
 	// IMPORTANT: Define your filterable and sortable columns here
 	columnMapping := map[string]string{
+{{- range .SortColumnMapping}}
+		{{.}}
+{{- else}}
+		// gocrud-gen:begin column-mapping
 		// "fieldNameInQuery": "db_column_name",
 		// "name": "title",
+		// gocrud-gen:end column-mapping
+{{- end}}
 	}
 
 	pagination, err := httpUtils.ParseAndValidatePagination(ctx, c, ctrl.customValidation, ctrl.log, columnMapping)
 	if err != nil {
 		return err
 	}
+{{- if .Pagination.IsSet}}
+	pagination = httpUtils.ApplyPaginationDefaults(c, pagination, {{.CamelCase}}PaginationDefaults)
+{{- end}}
+{{- if .FilterFields}}
+
+	filters, err := httpUtils.ParseFilters(c, {{.CamelCase}}Filters)
+	if err != nil {
+		return err
+	}
+	ctx = dto.WithFilters(ctx, filters)
+{{- end}}
+{{- if .SortFields}}
+
+	sorts, err := httpUtils.ParseSort(c, columnMapping)
+	if err != nil {
+		return err
+	}
+	ctx = dto.WithSorts(ctx, sorts)
+{{- end}}
+{{- if .SparseFields}}
+
+	fields, err := httpUtils.ParseFields(c, {{.CamelCase}}Fields)
+	if err != nil {
+		return err
+	}
+	ctx = dto.WithFields(ctx, fields)
+{{- end}}
+{{- if .Include}}
+
+	include, err := httpUtils.ParseInclude(c, {{.CamelCase}}Includes)
+	if err != nil {
+		return err
+	}
+	ctx = dto.WithInclude(ctx, include)
+{{- end}}
+{{- if .Scoped}}
+
+	unscoped, err := httpUtils.ParseUnscoped(c, "{{.ScopeOverride}}")
+	if err != nil {
+		return err
+	}
+	if unscoped {
+		ctx = dto.WithUnscoped(ctx)
+	}
+{{- end}}
+{{- if .PagesByState}}
+
+	pageState, err := httpUtils.ParsePageState(c)
+	if err != nil {
+		return err
+	}
+	ctx = dto.WithPageState(ctx, pageState)
+{{- end}}
 
 	paginatedResult, resultPagination, err := ctrl.{{.CamelCase}}Service.GetPaginated{{.PascalCase}}s(ctx, pagination)
 	if err != nil {
 		return err
 	}
+{{- if .PagesByState}}
+	httpUtils.SetNextPageState(c, pageState)
+{{- end}}
+{{- if .PaginationHeaders}}
+	httpUtils.SetPaginationHeaders(c, resultPagination)
+{{- end}}
+{{- if .SparseFields}}
+
+	data, err := httpUtils.SelectFields({{with .ResponseMapper}}{{.}}s(paginatedResult){{else}}paginatedResult{{end}}, fields)
+	if err != nil {
+		return err
+	}
+{{- end}}
 
-	resp := ports.Response{
-		Data: paginatedResult,
+	resp := {{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Data: {{if .SparseFields}}data{{else}}{{with .ResponseMapper}}{{.}}s(paginatedResult){{else}}paginatedResult{{end}}{{end}},
 		Meta: &ports.Meta{
 			Pagination: &resultPagination.Pagination,
 		},
-	}
+	}{{if .Envelope.IsSet}}){{end}}
 
-	return c.JSON(resp)
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, resp){{else}}c.JSON(resp){{end}}
 }
 `
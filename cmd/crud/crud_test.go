@@ -0,0 +1,272 @@
+package crud
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerateCrud(t *testing.T) {
+	tests := []struct {
+		name string
+		opts func(*Options)
+		// files maps the paths the run must generate to a snippet each must
+		// contain.
+		files map[string]string
+	}{
+		{
+			name: "default",
+			opts: func(*Options) {},
+			files: map[string]string{
+				"internal/transport/repository/postgres/widget.go":                "func NewWidgetRepository(",
+				"internal/service/widget.go":                                      "func NewWidgetService(",
+				"internal/transport/http/rest/controller/v1/widget/controller.go": "package widget",
+				"internal/transport/http/rest/controller/v1/widget/request.go":    "package widget",
+				"internal/transport/http/rest/controller/v1/widget/routes.go":     "package widget",
+			},
+		},
+		{
+			name: "graphql",
+			opts: func(o *Options) { o.Transport = transportGraphQL },
+			files: map[string]string{
+				"graph/widget.graphqls":                "widgets(page: Int! = 1, pageSize: Int! = 10): WidgetPage!",
+				"graph/scalars.graphqls":               "scalar Time",
+				"internal/transport/graphql/widget.go": "func (r *WidgetResolver) Widgets(ctx context.Context, page, pageSize int) (*WidgetPage, error) {",
+			},
+		},
+		{
+			name: "soft delete",
+			opts: func(o *Options) { o.SoftDelete = true },
+			files: map[string]string{
+				"internal/service/widgetTrash.go":                            "package service",
+				"internal/transport/http/rest/controller/v1/widget/trash.go": "package widget",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Swagger: swaggerSwaggo, Errors: errorsDetails, IDType: idTypeInt64, DB: dbPostgres, ClientTransport: clientGRPC, LineEndings: lineEndingsLF, DryRun: true}
+			tt.opts(&opts)
+			writer := newMemoryWriter()
+			if err := generateCrud("Widget", "Widget", opts, writer); err != nil {
+				t.Fatal(err)
+			}
+
+			for path, snippet := range tt.files {
+				content, ok := writer.files[path]
+				if !ok {
+					t.Errorf("%s was not generated; got %v", path, slices.Sorted(maps.Keys(writer.files)))
+					continue
+				}
+				if !bytes.HasPrefix(content, []byte("// Code generated by gocrud-gen ")) {
+					t.Errorf("%s has no generation header: %.80q", path, content)
+				}
+				if !bytes.Contains(content, []byte(snippet)) {
+					t.Errorf("%s does not contain %q", path, snippet)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateCrudSkipsExistingFiles(t *testing.T) {
+	writer := newMemoryWriter()
+	service := "internal/service/widget.go"
+	writer.files[service] = []byte("edited")
+	opts := Options{Swagger: swaggerSwaggo, Errors: errorsDetails, IDType: idTypeInt64, DB: dbPostgres, ClientTransport: clientGRPC, LineEndings: lineEndingsLF, DryRun: true}
+	if err := generateCrud("Widget", "Widget", opts, writer); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(writer.files[service]); got != "edited" {
+		t.Errorf("%s was overwritten: %.80q", service, got)
+	}
+}
+
+func TestGenerateCrudRegeneratesByArtifact(t *testing.T) {
+	previous := config.Overwrite
+	t.Cleanup(func() { config.Overwrite = previous })
+	config.Overwrite = OverwriteConfig{"service": overwriteAlways}
+
+	writer := newMemoryWriter()
+	service, repository := "internal/service/widget.go", "internal/transport/repository/postgres/widget.go"
+	writer.files[service] = []byte("edited")
+	writer.files[repository] = []byte("edited")
+	opts := Options{Swagger: swaggerSwaggo, Errors: errorsDetails, IDType: idTypeInt64, DB: dbPostgres, ClientTransport: clientGRPC, LineEndings: lineEndingsLF, DryRun: true}
+	if err := generateCrud("Widget", "Widget", opts, writer); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(writer.files[service]); got == "edited" {
+		t.Errorf("%s was not regenerated", service)
+	}
+	if got := string(writer.files[repository]); got != "edited" {
+		t.Errorf("%s was overwritten: %.80q", repository, got)
+	}
+}
+
+func TestGenerateCrudGraphQLLoaders(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "spec.yaml")
+	content := "entities:\n  - name: Widget\n    fields:\n      - title:string\n      - secret:string:visibility=internal\n" +
+		"    relations:\n      - kind: belongs_to\n        entity: Customer\n      - kind: has_many\n        entity: Part\n  - name: Customer\n  - name: Part\n"
+	if err := os.WriteFile(spec, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Swagger: swaggerSwaggo, Errors: errorsDetails, IDType: idTypeInt64, DB: dbPostgres, ClientTransport: clientGRPC, LineEndings: lineEndingsLF, DryRun: true, SpecFile: spec, Transport: transportGraphQL}
+	writer := newMemoryWriter()
+	if err := generateCrud("Widget", "Widget", opts, writer); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, snippets := range map[string][]string{
+		"graph/widget.graphqls": {"  title: String!\n  customer: Customer\n  parts: [Part!]!\n}"},
+		"internal/transport/graphql/widgetLoaders.go": {
+			"Customer *dataloader.Loader[int64, *dto.Customer]",
+			"widgets[i].CustomerID = key",
+			"relations.LoadCustomersFor(ctx, widgets)",
+			"Parts *dataloader.Loader[int64, []dto.Part]",
+			"widgets[i].ID = key",
+		},
+		"internal/transport/graphql/widget.go": {
+			"return loaders.Customer.Load(ctx, obj.CustomerID)()",
+			"return loaders.Parts.Load(ctx, obj.ID)()",
+		},
+	} {
+		content := string(writer.files[path])
+		for _, snippet := range snippets {
+			if !strings.Contains(content, snippet) {
+				t.Errorf("%s does not contain %q", path, snippet)
+			}
+		}
+	}
+	if schema := string(writer.files["graph/widget.graphqls"]); strings.Contains(schema, "secret") {
+		t.Errorf("graph/widget.graphqls exposes the internal field secret")
+	}
+}
+
+func TestGenerateCrudFailure(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(spec, []byte("entities:\n  - name: Widget\n    fields:\n      - title:string:filters=eq\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		opts    func(*Options)
+		wantErr string
+	}{
+		{
+			name:    "missing spec",
+			opts:    func(o *Options) { o.SpecFile = filepath.Join(filepath.Dir(spec), "missing.yaml") },
+			wantErr: "loading spec",
+		},
+		{
+			name: "unsupported spec",
+			opts: func(o *Options) {
+				o.SpecFile = spec
+				o.DB = dbCassandra
+			},
+			wantErr: "does not support the filters or partition declared for Widget",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Swagger: swaggerSwaggo, Errors: errorsDetails, IDType: idTypeInt64, DB: dbPostgres, ClientTransport: clientGRPC, LineEndings: lineEndingsLF, DryRun: true}
+			tt.opts(&opts)
+			writer := newMemoryWriter()
+			err := generateCrud("Widget", "Widget", opts, writer)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("generateCrud() = %v, want an error containing %q", err, tt.wantErr)
+			}
+			if len(writer.files) > 0 {
+				t.Errorf("generateCrud() wrote %v", slices.Sorted(maps.Keys(writer.files)))
+			}
+		})
+	}
+}
+
+func TestGenerateCrudListOverridePages(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "spec.yaml")
+	if err := os.WriteFile(spec, []byte("entities:\n  - name: Widget\n    fields:\n      - title:string:filters=eq\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Swagger: swaggerSwaggo, Errors: errorsDetails, IDType: idTypeInt64, DB: dbPostgres, ClientTransport: clientGRPC, LineEndings: lineEndingsLF, DryRun: true, SpecFile: spec}
+	writer := newMemoryWriter()
+	if err := generateCrud("Widget", "Widget", opts, writer); err != nil {
+		t.Fatal(err)
+	}
+
+	path := "internal/transport/repository/postgres/widget.go"
+	content := string(writer.files[path])
+	for _, snippet := range []string{
+		`from := " FROM \"widget\""`,
+		`db.GetContext(ctx, &pagination.TotalRows, "SELECT COUNT(*)"+from, args...)`,
+		`query := "SELECT *" + from`,
+		`query += fmt.Sprintf(" ORDER BY id LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)`,
+		`args = append(args, size, widgetPageOffset(pagination, size))`,
+	} {
+		if !strings.Contains(content, snippet) {
+			t.Errorf("%s does not contain %q", path, snippet)
+		}
+	}
+
+	// Run the generated offset for the second page of 20 rows against a
+	// stand-in for dto.Pagination.
+	offset := funcSource(t, content, "widgetPageOffset")
+	program := "package main\n\nimport \"fmt\"\n\ntype Pagination struct{ Page, PageSize int }\n\n" +
+		strings.ReplaceAll(offset, "dto.Pagination", "Pagination") +
+		"\n\nfunc main() { fmt.Print(widgetPageOffset(Pagination{Page: 2}, 20), widgetPageOffset(Pagination{}, 20)) }\n"
+	if got := runProgram(t, program); got != "20 0" {
+		t.Errorf("offsets of page 2 and of no page = %q, want \"20 0\"", got)
+	}
+}
+
+// funcSource returns the source of the function name declared in src.
+func funcSource(t *testing.T, src, name string) string {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == name {
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, fset, fn); err != nil {
+				t.Fatal(err)
+			}
+			return buf.String()
+		}
+	}
+	t.Fatalf("%s is not declared", name)
+	return ""
+}
+
+// runProgram runs the main package src and returns its output.
+func runProgram(t *testing.T, src string) string {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(goBin, "run", "main.go")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s", err, out)
+	}
+	return string(out)
+}
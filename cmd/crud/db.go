@@ -0,0 +1,77 @@
+package crud
+
+// The databases --db generates the entity's repository for.
+const (
+	dbPostgres   = "postgres"
+	dbClickHouse = "clickhouse"
+	dbCassandra  = "cassandra"
+	dbSQLServer  = "sqlserver"
+	dbOracle     = "oracle"
+	dbCockroach  = "cockroach"
+	dbFirestore  = "firestore"
+	dbSpanner    = "spanner"
+	dbBolt       = "bolt"
+)
+
+// Postgres reports whether the entity is stored in PostgreSQL, which an empty
+// DB defaults to, or in CockroachDB, which shares its repository.
+func (d TemplateData) Postgres() bool {
+	return d.DB == "" || d.DB == dbPostgres || d.DB == dbCockroach
+}
+
+// ClickHouse reports whether the entity is stored in ClickHouse.
+func (d TemplateData) ClickHouse() bool {
+	return d.DB == dbClickHouse
+}
+
+// Cassandra reports whether the entity is stored in Cassandra or ScyllaDB.
+func (d TemplateData) Cassandra() bool {
+	return d.DB == dbCassandra
+}
+
+// Firestore reports whether the entity is stored in Cloud Firestore.
+func (d TemplateData) Firestore() bool {
+	return d.DB == dbFirestore
+}
+
+// Spanner reports whether the entity is stored in Cloud Spanner.
+func (d TemplateData) Spanner() bool {
+	return d.DB == dbSpanner
+}
+
+// PagesByState reports whether the list endpoint pages with the opaque
+// page_state cursor instead of page numbers.
+func (d TemplateData) PagesByState() bool {
+	return d.Cassandra() || d.Firestore() || d.Spanner() || d.Bolt()
+}
+
+// SQLServer reports whether the entity is stored in Microsoft SQL Server.
+func (d TemplateData) SQLServer() bool {
+	return d.DB == dbSQLServer
+}
+
+// Oracle reports whether the entity is stored in Oracle Database.
+func (d TemplateData) Oracle() bool {
+	return d.DB == dbOracle
+}
+
+// Bolt reports whether the entity is stored in an embedded bbolt database.
+func (d TemplateData) Bolt() bool {
+	return d.DB == dbBolt
+}
+
+// Cockroach reports whether the entity is stored in CockroachDB.
+func (d TemplateData) Cockroach() bool {
+	return d.DB == dbCockroach
+}
+
+// TableIdent is the entity's table quoted as an SQL identifier, so entities
+// named after reserved words, e.g. Order or User, can be queried. Every
+// database but Spanner, whose tables are the collections, quotes with ".
+func (d TemplateData) TableIdent() string {
+	return quoteIdent(d.SnakeCase)
+}
+
+func quoteIdent(name string) string {
+	return `"` + name + `"`
+}
@@ -0,0 +1,252 @@
+package crud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const deprecationDateLayout = "2006-01-02"
+
+var deprecateOptions struct {
+	Since  string
+	Sunset string
+	Link   string
+}
+
+var deprecateCmd = &cobra.Command{
+	Use:   "deprecate [EntityName]",
+	Short: "Marks the generated endpoints of an entity as deprecated.",
+	Long: `This command updates the controllers generated by crud for the entity in the
+current directory: every handler gets a swagger @Deprecated annotation and sets
+the Deprecation, Sunset and Link headers on its responses, and every call is
+logged so remaining clients can be found before the sunset. Handler code edited
+since generation is kept. Re-run it to move the sunset. For example:
+
+go run . deprecate SbsFee --sunset 2027-01-31 --link https://docs.example.com/migrate-sbs-fee`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deprecation, err := parseDeprecation(deprecateOptions.Since, deprecateOptions.Sunset, deprecateOptions.Link)
+		if err != nil {
+			return err
+		}
+		return deprecateEntity(args[0], generationRun(args[0], cmd.LocalNonPersistentFlags()), deprecation)
+	},
+}
+
+func init() {
+	deprecateCmd.Flags().StringVar(&deprecateOptions.Since, "since", "", "Date the endpoints were deprecated, YYYY-MM-DD (default today)")
+	deprecateCmd.Flags().StringVar(&deprecateOptions.Sunset, "sunset", "", "Date the endpoints stop being served, YYYY-MM-DD")
+	deprecateCmd.Flags().StringVar(&deprecateOptions.Link, "link", "", "URL of the migration guide for clients")
+	rootCmd.AddCommand(deprecateCmd)
+}
+
+// Deprecation describes the lifecycle of deprecated endpoints.
+type Deprecation struct {
+	Since  time.Time
+	Sunset time.Time
+	Link   string
+}
+
+func parseDeprecation(since, sunset, link string) (Deprecation, error) {
+	deprecation := Deprecation{Since: time.Now().UTC().Truncate(24 * time.Hour), Link: link}
+	var err error
+	if since != "" {
+		if deprecation.Since, err = time.Parse(deprecationDateLayout, since); err != nil {
+			return Deprecation{}, fmt.Errorf("invalid --since %q: must be a date like 2027-01-31", since)
+		}
+	}
+	if sunset != "" {
+		if deprecation.Sunset, err = time.Parse(deprecationDateLayout, sunset); err != nil {
+			return Deprecation{}, fmt.Errorf("invalid --sunset %q: must be a date like 2027-01-31", sunset)
+		}
+		if !deprecation.Sunset.After(deprecation.Since) {
+			return Deprecation{}, fmt.Errorf("invalid --sunset %s: must be after the deprecation date %s", sunset, deprecation.Since.Format(deprecationDateLayout))
+		}
+	}
+	if link != "" && !strings.HasPrefix(link, "https://") && !strings.HasPrefix(link, "http://") {
+		return Deprecation{}, fmt.Errorf("invalid --link %q: must be an http(s) URL", link)
+	}
+	return deprecation, nil
+}
+
+// Header is the value of the Deprecation header (RFC 9745).
+func (d Deprecation) Header() string {
+	return fmt.Sprintf("@%d", d.Since.Unix())
+}
+
+// SunsetHeader is the value of the Sunset header (RFC 8594), empty without a sunset.
+func (d Deprecation) SunsetHeader() string {
+	if d.Sunset.IsZero() {
+		return ""
+	}
+	return d.Sunset.Format(http.TimeFormat)
+}
+
+// LinkHeader is the value of the Link header pointing at the migration guide.
+func (d Deprecation) LinkHeader() string {
+	if d.Link == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, d.Link)
+}
+
+// deprecatedHandlerFiles are the controller files whose handlers are marked.
+var deprecatedHandlerFiles = []string{"controller.go", "internal.go"}
+
+func deprecateEntity(namePascal, run string, deprecation Deprecation) error {
+	data := newTemplateData(namePascal, Options{})
+	data.Deprecation = deprecation
+	dir := filepath.Join("internal/transport/http/rest/controller/v1", data.CamelCase)
+
+	marked := 0
+	for _, name := range deprecatedHandlerFiles {
+		path := filepath.Join(dir, name)
+		changed, err := markHandlersDeprecated(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if changed {
+			fmt.Printf("Marked handlers deprecated: %s\n", path)
+		}
+		marked++
+	}
+	if marked == 0 {
+		return fmt.Errorf("no generated controller for %s found in %s", namePascal, dir)
+	}
+
+	path := filepath.Join(dir, "deprecation.go")
+	if content, err := os.ReadFile(path); err == nil {
+		header, body, ok := parseHeader(content)
+		if !ok || !header.matches(path, body) {
+			return fmt.Errorf("%s was edited after generation; delete it to regenerate", path)
+		}
+	}
+
+	tmpl, err := template.New(path).Parse(deprecationTemplate)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return err
+	}
+	banner, err := renderBanner(path, config.FileHeader, data)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(banner, withHeader(path, newFileHeader("", run), body.Bytes())...), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Generating file: %s\n", path)
+
+	fmt.Println("--- Endpoints of", data.PascalCase, "marked deprecated ---")
+	fmt.Println("Next steps:")
+	nextSteps := []string{
+		"Regenerate the swagger docs so the operations show as deprecated.",
+		fmt.Sprintf("Announce the deprecation to the clients found in the '%s endpoint is deprecated' logs.", data.LowerCase),
+	}
+	if !deprecation.Sunset.IsZero() {
+		nextSteps = append(nextSteps, fmt.Sprintf("Remove the %s routes after %s.", data.PascalCase, deprecation.Sunset.Format(deprecationDateLayout)))
+	}
+	for i, step := range nextSteps {
+		fmt.Printf("%d. %s\n", i+1, filepath.ToSlash(step))
+	}
+	return nil
+}
+
+// markHandlersDeprecated adds a swagger @Deprecated annotation after every
+// @Summary and a markDeprecated call after every handler's span, leaving
+// handlers that already have them alone. A file that had not drifted gets a
+// fresh hash so it still passes the drift check.
+func markHandlersDeprecated(path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	crlf := bytes.Contains(content, []byte("\r\n"))
+	content = normalizeLineEndings(content)
+	header, body, generated := parseHeader(content)
+	if !generated {
+		return false, fmt.Errorf("%s has no gocrud-gen header", path)
+	}
+	prefix := content[:len(content)-len(body)]
+
+	lines := strings.Split(string(body), "\n")
+	var marked []string
+	for i, line := range lines {
+		marked = append(marked, line)
+		next := ""
+		if i+1 < len(lines) {
+			next = lines[i+1]
+		}
+		switch {
+		case strings.HasPrefix(line, "// @Summary") && next != "// @Deprecated":
+			marked = append(marked, "// @Deprecated")
+		case line == "\tdefer span.End()" && next != "\tmarkDeprecated(ctx, c, ctrl.log)":
+			marked = append(marked, "\tmarkDeprecated(ctx, c, ctrl.log)")
+		}
+	}
+	if len(marked) == len(lines) {
+		return false, nil
+	}
+
+	newBody := []byte(strings.Join(marked, "\n"))
+	if header.matches(path, body) {
+		// The hash is the last on the header line, after the spec's.
+		i := bytes.LastIndex(prefix, []byte(header.Hash))
+		prefix = slices.Concat(prefix[:i], []byte(bodyHash(path, newBody)), prefix[i+len(header.Hash):])
+	}
+	return true, os.WriteFile(path, withLineEndings(append(prefix, newBody...), crlf), 0644)
+}
+
+// --- DEPRECATION TEMPLATES ---
+
+const deprecationTemplate = `package {{.LowerCase}}
+
+import (
+	"context"
+	"fmt"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+// {{.CamelCase}}Deprecation is when the {{.PascalCase}} endpoints were deprecated.
+const {{.CamelCase}}Deprecation = "{{.Deprecation.Header}}"
+{{- with .Deprecation.SunsetHeader}}
+
+// {{$.CamelCase}}Sunset is when the {{$.PascalCase}} endpoints stop being served.
+const {{$.CamelCase}}Sunset = "{{.}}"
+{{- end}}
+{{- with .Deprecation.LinkHeader}}
+
+// {{$.CamelCase}}DeprecationLink points clients at the migration guide.
+const {{$.CamelCase}}DeprecationLink = {{printf "%q" .}}
+{{- end}}
+
+// markDeprecated tells the client that the endpoint is deprecated and logs the
+// call, so the remaining clients can be found before the endpoints go away.
+func markDeprecated(ctx context.Context, c *ports.HttpContext, log ports.LoggerWithTraceID) {
+	c.Set("Deprecation", {{.CamelCase}}Deprecation)
+{{- if .Deprecation.SunsetHeader}}
+	c.Set("Sunset", {{.CamelCase}}Sunset)
+{{- end}}
+{{- if .Deprecation.LinkHeader}}
+	c.Set("Link", {{.CamelCase}}DeprecationLink)
+{{- end}}
+	log.Info(ctx, fmt.Sprintf("{{.LowerCase}} endpoint is deprecated: %s %s called by %q", c.Method(), c.Path(), c.Get("User-Agent")))
+}
+`
@@ -0,0 +1,136 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"slices"
+)
+
+// DerivedSpec is a response field computed from other fields of the entity,
+// e.g. total = price * quantity. It is neither requested nor stored.
+type DerivedSpec struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// Expr is the Go expression computing the field from the entity's fields,
+	// referred to by their spec names, e.g. price * quantity.
+	Expr string `yaml:"expr"`
+}
+
+// derivedGoTypes are the Go types a derived field may have.
+var derivedGoTypes = []string{"string", "int", "int64", "float64", "bool"}
+
+// numericGoTypes are the Go types the operands of a derived field are converted
+// between.
+var numericGoTypes = []string{"int", "int64", "float64"}
+
+func (d DerivedSpec) validate(entity EntitySpec, columns map[string]bool) error {
+	if !fieldNamePattern.MatchString(d.Name) {
+		return fmt.Errorf("%s: derived field name %q must be snake_case", entity.Name, d.Name)
+	}
+	if columns[d.Name] {
+		return fmt.Errorf("%s: derived field %s collides with a field", entity.Name, d.Name)
+	}
+	if fieldType, ok := fieldTypes[d.Type]; !ok || !slices.Contains(derivedGoTypes, fieldType.Go) {
+		return fmt.Errorf("%s.%s: derived field type %q must be a number, string or bool", entity.Name, d.Name, d.Type)
+	}
+	expr, err := parser.ParseExpr(d.Expr)
+	if err != nil {
+		return fmt.Errorf("%s.%s: parsing expr %q: %w", entity.Name, d.Name, d.Expr, err)
+	}
+
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch node := node.(type) {
+		case nil, *ast.BinaryExpr, *ast.UnaryExpr, *ast.ParenExpr, *ast.BasicLit:
+		case *ast.Ident:
+			field, ok := entity.field(node.Name)
+			switch {
+			case node.Name == "true" || node.Name == "false":
+			case !ok:
+				err = fmt.Errorf("%s.%s: %s in expr is not a field", entity.Name, d.Name, node.Name)
+			case field.Nullable:
+				err = fmt.Errorf("%s.%s: expr uses the nullable field %s", entity.Name, d.Name, node.Name)
+			case field.Visibility != "":
+				err = fmt.Errorf("%s.%s: expr uses the %s field %s, which the response would reveal", entity.Name, d.Name, field.Visibility, node.Name)
+			case !slices.Contains(derivedGoTypes, fieldTypes[field.Type].Go):
+				err = fmt.Errorf("%s.%s: expr uses the %s field %s, only numbers, strings and bools can be derived from", entity.Name, d.Name, field.Type, node.Name)
+			}
+		default:
+			err = fmt.Errorf("%s.%s: expr %q may only combine fields and literals with operators", entity.Name, d.Name, d.Expr)
+		}
+		return true
+	})
+	return err
+}
+
+// field returns the field of the entity with the given spec name.
+func (e EntitySpec) field(name string) (FieldSpec, bool) {
+	for _, field := range e.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return FieldSpec{}, false
+}
+
+// GoName is the exported Go name of the derived field, e.g. LineTotal.
+func (d DerivedSpec) GoName() string {
+	return FieldSpec{Name: d.Name}.GoName()
+}
+
+// GoType is the Go type of the derived field.
+func (d DerivedSpec) GoType() string {
+	return fieldTypes[d.Type].Go
+}
+
+// derivedGo renders the expression of a derived field as Go code reading the
+// fields of entity. Numeric operands are converted to the type of the derived
+// field, so price * quantity works for a float price and an int quantity.
+func (d TemplateData) derivedGo(derived DerivedSpec) string {
+	expr, err := parser.ParseExpr(derived.Expr)
+	if err != nil {
+		// The spec was validated when it was loaded.
+		return derived.Expr
+	}
+	expr = replaceIdents(expr, func(ident *ast.Ident) ast.Expr {
+		field, ok := d.Entity.field(ident.Name)
+		if !ok {
+			return ident
+		}
+		var operand ast.Expr = &ast.SelectorExpr{X: ast.NewIdent("entity"), Sel: ast.NewIdent(field.GoName())}
+		goType := fieldTypes[field.Type].Go
+		if goType != derived.GoType() && slices.Contains(numericGoTypes, goType) && slices.Contains(numericGoTypes, derived.GoType()) {
+			operand = &ast.CallExpr{Fun: ast.NewIdent(derived.GoType()), Args: []ast.Expr{operand}}
+		}
+		return operand
+	})
+	var out bytes.Buffer
+	if err := format.Node(&out, token.NewFileSet(), expr); err != nil {
+		return derived.Expr
+	}
+	return out.String()
+}
+
+// replaceIdents returns expr with each identifier replaced by what
+// replace returns for it. Derived expressions only hold the node types
+// DerivedSpec.validate accepts.
+func replaceIdents(expr ast.Expr, replace func(*ast.Ident) ast.Expr) ast.Expr {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return replace(expr)
+	case *ast.BinaryExpr:
+		expr.X = replaceIdents(expr.X, replace)
+		expr.Y = replaceIdents(expr.Y, replace)
+	case *ast.UnaryExpr:
+		expr.X = replaceIdents(expr.X, replace)
+	case *ast.ParenExpr:
+		expr.X = replaceIdents(expr.X, replace)
+	}
+	return expr
+}
@@ -0,0 +1,142 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	diagramMermaid  = "mermaid"
+	diagramPlantUML = "plantuml"
+)
+
+var diagramOptions struct {
+	Spec   string
+	Format string
+	Output string
+}
+
+var diagramCmd = &cobra.Command{
+	Use:   "diagram",
+	Short: "Generates an ER diagram of the entities defined in a spec.",
+	Long: `This command renders the entities, fields and relations of a spec file as a
+Mermaid or PlantUML ER diagram. Re-run it whenever the spec changes; the
+diagram is rewritten in place. For example:
+
+go run . diagram --spec entities.yaml --format plantuml`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return generateDiagram(generationRun(cmd.Name(), cmd.LocalNonPersistentFlags()))
+	},
+}
+
+func init() {
+	diagramCmd.Flags().StringVar(&diagramOptions.Spec, "spec", "", "Path to the entities spec file, or '-' to read it from stdin")
+	diagramCmd.Flags().StringVar(&diagramOptions.Format, "format", diagramMermaid, "Diagram syntax: 'mermaid' or 'plantuml'")
+	diagramCmd.Flags().StringVarP(&diagramOptions.Output, "output", "o", "", "File to write, or '-' for stdout (default docs/erd.mmd or docs/erd.puml)")
+	diagramCmd.MarkFlagRequired("spec")
+	rootCmd.AddCommand(diagramCmd)
+}
+
+func generateDiagram(run string) error {
+	var tmplStr, output string
+	switch diagramOptions.Format {
+	case diagramMermaid:
+		tmplStr, output = mermaidDiagramTemplate, filepath.Join("docs", "erd.mmd")
+	case diagramPlantUML:
+		tmplStr, output = plantUMLDiagramTemplate, filepath.Join("docs", "erd.puml")
+	default:
+		return fmt.Errorf("invalid --format %q: must be %q or %q", diagramOptions.Format, diagramMermaid, diagramPlantUML)
+	}
+	if diagramOptions.Output != "" {
+		output = diagramOptions.Output
+	}
+
+	spec, err := loadSpec(diagramOptions.Spec)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New(diagramOptions.Format).Parse(tmplStr)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, spec); err != nil {
+		return err
+	}
+
+	if output == "-" {
+		_, err := os.Stdout.Write(body.Bytes())
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, withHeader(output, newFileHeader(diagramOptions.Spec, run), body.Bytes()), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Diagram of %d entities written to %s\n", len(spec.Entities), output)
+	return nil
+}
+
+// Cardinality is the crow's foot notation of the relation, read from the
+// declaring entity to the related one. Mermaid and PlantUML share it.
+func (r RelationSpec) Cardinality() string {
+	switch r.Kind {
+	case relationBelongsTo:
+		return "}o--||"
+	case relationHasOne:
+		return "||--o|"
+	case relationHasMany:
+		return "||--o{"
+	default:
+		return "}o--o{"
+	}
+}
+
+// --- DIAGRAM TEMPLATES ---
+
+const mermaidDiagramTemplate = `erDiagram
+{{- range .Entities}}
+    {{.Name}} {
+        int64 id PK
+{{- range .Fields}}
+        {{.Type}} {{.Name}}{{if .Unique}} UK{{end}}{{if .Nullable}} "nullable"{{end}}
+{{- end}}
+{{- range .Relations}}{{if eq .Kind "belongs_to"}}
+        int64 {{.ForeignKey}} FK
+{{- end}}{{end}}
+    }
+{{- end}}
+{{- range $entity := .Entities}}{{range .Relations}}
+    {{$entity.Name}} {{.Cardinality}} {{.Entity}} : "{{.Kind}}"
+{{- end}}{{end}}
+`
+
+const plantUMLDiagramTemplate = `@startuml
+hide circle
+{{- range .Entities}}
+
+entity {{.Name}} {
+  * id : int64 <<PK>>
+  --
+{{- range .Fields}}
+  {{if not .Nullable}}* {{end}}{{.Name}} : {{.Type}}{{if .Unique}} <<unique>>{{end}}
+{{- end}}
+{{- range .Relations}}{{if eq .Kind "belongs_to"}}
+  * {{.ForeignKey}} : int64 <<FK>>
+{{- end}}{{end}}
+}
+{{- end}}
+{{range $entity := .Entities}}{{range .Relations}}
+{{$entity.Name}} {{.Cardinality}} {{.Entity}} : {{.Kind}}
+{{- end}}{{end}}
+@enduml
+`
@@ -0,0 +1,553 @@
+package crud
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// sqlServerTypes maps a spec field type to its SQL Server column type.
+var sqlServerTypes = map[string]string{
+	"string": "NVARCHAR(255)",
+	"text":   "NVARCHAR(MAX)",
+	"int":    "INT",
+	"int64":  "BIGINT",
+	"float":  "FLOAT",
+	"bool":   "BIT",
+	"time":   "DATETIMEOFFSET",
+	"uuid":   "UNIQUEIDENTIFIER",
+	"json":   "NVARCHAR(MAX)",
+}
+
+// oracleTypes maps a spec field type to its Oracle column type. Oracle has no
+// boolean columns, so booleans are stored as 0 or 1.
+var oracleTypes = map[string]string{
+	"string": "VARCHAR2(255 CHAR)",
+	"text":   "CLOB",
+	"int":    "NUMBER(10)",
+	"int64":  "NUMBER(19)",
+	"float":  "BINARY_DOUBLE",
+	"bool":   "NUMBER(1)",
+	"time":   "TIMESTAMP WITH TIME ZONE",
+	"uuid":   "VARCHAR2(36)",
+	"json":   "CLOB",
+}
+
+// dataColumns lists the columns of the entity's table besides id: the fields
+// of the spec and the foreign keys of belongs_to relations, typed like the id.
+func (d TemplateData) dataColumns() []FieldSpec {
+	columns := slices.Clone(d.Entity.Fields)
+	foreignKeyType := "int64"
+	if d.UUID() {
+		foreignKeyType = "uuid"
+	}
+	for _, relation := range d.Entity.Relations {
+		if relation.Kind == relationBelongsTo {
+			columns = append(columns, FieldSpec{Name: relation.ForeignKey(), Type: foreignKeyType, Nullable: relation.OnDelete == onDeleteSetNull})
+		}
+	}
+	return columns
+}
+
+// dialectOnDelete is the ON DELETE clause of a foreign key in SQL Server and
+// Oracle, which have no RESTRICT but refuse the delete by default.
+func dialectOnDelete(relation RelationSpec) string {
+	if relation.OnDelete == onDeleteRestrict {
+		return ""
+	}
+	return relation.OnDeleteClause()
+}
+
+// DialectColumns lists the columns of the entity's table besides id, which
+// the SQL Server and Oracle repositories write.
+func (d TemplateData) DialectColumns() []string {
+	var names []string
+	for _, column := range d.dataColumns() {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+// DialectColumnList is the comma separated DialectColumns.
+func (d TemplateData) DialectColumnList() string {
+	return strings.Join(d.DialectColumns(), ", ")
+}
+
+// SQLServerColumns renders the column definitions of the entity's SQL Server
+// table from the spec, aligned on the type: the id, the fields and the foreign
+// keys of belongs_to relations.
+func (d TemplateData) SQLServerColumns() []string {
+	type column struct{ name, definition string }
+	columns := []column{{"id", "BIGINT IDENTITY(1, 1) PRIMARY KEY"}}
+	if d.UUID() {
+		columns[0].definition = "UNIQUEIDENTIFIER PRIMARY KEY"
+	}
+	for _, field := range d.Entity.Fields {
+		definition := sqlServerTypes[field.Type]
+		if !field.Nullable {
+			definition += " NOT NULL"
+		}
+		if field.Unique {
+			definition += " UNIQUE"
+		}
+		if field.Default != "" {
+			definition += " DEFAULT " + field.Default
+		}
+		if field.Check != "" {
+			definition += " CHECK (" + field.Check + ")"
+		}
+		if field.Type == "json" {
+			definition += " CHECK (ISJSON(" + field.Name + ") = 1)"
+		}
+		columns = append(columns, column{field.Name, definition})
+	}
+	for _, relation := range d.Entity.Relations {
+		if relation.Kind == relationBelongsTo {
+			definition := "BIGINT NOT NULL REFERENCES "
+			if relation.OnDelete == onDeleteSetNull {
+				definition = "BIGINT REFERENCES "
+			}
+			columns = append(columns, column{relation.ForeignKey(), definition + relation.TableIdent() + " (id)" + dialectOnDelete(relation)})
+		}
+	}
+
+	width := 0
+	for _, c := range columns {
+		width = max(width, len(c.name))
+	}
+	rendered := make([]string, 0, len(columns))
+	for _, c := range columns {
+		rendered = append(rendered, fmt.Sprintf("%-*s %s", width, c.name, c.definition))
+	}
+	return rendered
+}
+
+// SQLServerJSONColumns renders the OPENJSON WITH clause reading a JSON encoded
+// row, aligned on the type. The update reads the id along with the columns.
+func (d TemplateData) SQLServerJSONColumns(withID bool) []string {
+	type column struct{ name, definition string }
+	var columns []column
+	if withID {
+		columns = append(columns, column{"id", "BIGINT '$.id'"})
+		if d.UUID() {
+			columns[0].definition = "UNIQUEIDENTIFIER '$.id'"
+		}
+	}
+	for _, field := range d.dataColumns() {
+		definition := sqlServerTypes[field.Type] + " '$." + field.Name + "'"
+		if field.Type == "json" {
+			definition += " AS JSON"
+		}
+		columns = append(columns, column{field.Name, definition})
+	}
+
+	width := 0
+	for _, c := range columns {
+		width = max(width, len(c.name))
+	}
+	rendered := make([]string, 0, len(columns))
+	for _, c := range columns {
+		rendered = append(rendered, fmt.Sprintf("%-*s %s", width, c.name, c.definition))
+	}
+	return rendered
+}
+
+// SQLServerSelectList is the select list encoding a row as JSON with FOR JSON.
+// JSON columns are embedded as JSON instead of strings.
+func (d TemplateData) SQLServerSelectList() string {
+	list := []string{"id"}
+	for _, field := range d.dataColumns() {
+		if field.Type == "json" {
+			list = append(list, "JSON_QUERY("+field.Name+") AS "+field.Name)
+			continue
+		}
+		list = append(list, field.Name)
+	}
+	return strings.Join(list, ", ")
+}
+
+// OracleColumns renders the column definitions of the entity's Oracle table
+// from the spec, aligned on the type: the id, the fields and the foreign keys
+// of belongs_to relations. Oracle wants defaults before the constraints.
+func (d TemplateData) OracleColumns() []string {
+	type column struct{ name, definition string }
+	columns := []column{{"id", "NUMBER(19) DEFAULT " + d.SnakeCase + "_seq.NEXTVAL PRIMARY KEY"}}
+	if d.UUID() {
+		columns[0].definition = "VARCHAR2(36) PRIMARY KEY"
+	}
+	for _, field := range d.Entity.Fields {
+		definition := oracleTypes[field.Type]
+		if field.Default != "" {
+			definition += " DEFAULT " + field.Default
+		}
+		if !field.Nullable {
+			definition += " NOT NULL"
+		}
+		if field.Unique {
+			definition += " UNIQUE"
+		}
+		if field.Check != "" {
+			definition += " CHECK (" + field.Check + ")"
+		}
+		switch field.Type {
+		case "bool":
+			definition += " CHECK (" + field.Name + " IN (0, 1))"
+		case "json":
+			definition += " CHECK (" + field.Name + " IS JSON)"
+		}
+		columns = append(columns, column{field.Name, definition})
+	}
+	for _, relation := range d.Entity.Relations {
+		if relation.Kind == relationBelongsTo {
+			definition := "NUMBER(19) NOT NULL REFERENCES "
+			if relation.OnDelete == onDeleteSetNull {
+				definition = "NUMBER(19) REFERENCES "
+			}
+			columns = append(columns, column{relation.ForeignKey(), definition + relation.TableIdent() + " (id)" + dialectOnDelete(relation)})
+		}
+	}
+
+	width := 0
+	for _, c := range columns {
+		width = max(width, len(c.name))
+	}
+	rendered := make([]string, 0, len(columns))
+	for _, c := range columns {
+		rendered = append(rendered, fmt.Sprintf("%-*s %s", width, c.name, c.definition))
+	}
+	return rendered
+}
+
+// OracleJSONColumns renders the JSON_TABLE COLUMNS clause reading a JSON
+// encoded row, aligned on the type. Booleans are read as text and converted
+// by OracleValues.
+func (d TemplateData) OracleJSONColumns() []string {
+	type column struct{ name, definition string }
+	var columns []column
+	for _, field := range d.dataColumns() {
+		var definition string
+		switch field.Type {
+		case "bool":
+			definition = "VARCHAR2(5)"
+		case "json":
+			definition = "CLOB FORMAT JSON"
+		default:
+			definition = oracleTypes[field.Type]
+		}
+		columns = append(columns, column{field.Name, definition + " PATH '$." + field.Name + "'"})
+	}
+
+	width := 0
+	for _, c := range columns {
+		width = max(width, len(c.name))
+	}
+	rendered := make([]string, 0, len(columns))
+	for _, c := range columns {
+		rendered = append(rendered, fmt.Sprintf("%-*s %s", width, c.name, c.definition))
+	}
+	return rendered
+}
+
+// OracleValues is the comma separated values inserted from the JSON_TABLE
+// aliased j, in the order of DialectColumnList.
+func (d TemplateData) OracleValues() string {
+	var values []string
+	for _, field := range d.dataColumns() {
+		if field.Type == "bool" {
+			values = append(values, "DECODE(j."+field.Name+", 'true', 1, 'false', 0)")
+			continue
+		}
+		values = append(values, "j."+field.Name)
+	}
+	return strings.Join(values, ", ")
+}
+
+// OracleJSONObject renders the JSON_OBJECT entries encoding a row as JSON, keyed
+// like the json tags of the DTO. Booleans and JSON columns are embedded as JSON
+// and times are written in UTC as RFC 3339.
+func (d TemplateData) OracleJSONObject() []string {
+	entries := []string{"'id' VALUE id"}
+	for _, field := range d.dataColumns() {
+		var value string
+		switch field.Type {
+		case "bool":
+			value = "DECODE(" + field.Name + ", 1, 'true', 0, 'false') FORMAT JSON"
+		case "json":
+			value = field.Name + " FORMAT JSON"
+		case "time":
+			value = "TO_CHAR(" + field.Name + ` AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.FF6"Z"')`
+		default:
+			value = field.Name
+		}
+		entries = append(entries, "'"+field.Name+"' VALUE "+value)
+	}
+	return entries
+}
+
+// --- SQL SERVER AND ORACLE TEMPLATES ---
+
+// The SQL Server and Oracle templates store the entity with database/sql. Rows
+// are written and read as JSON, so the columns follow the json tags of the
+// DTO, and both databases page with OFFSET ... FETCH.
+
+const dialectRepositoryTemplate = `package {{.DB}}
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+{{- if .SQLServer}}
+	// Registers the "sqlserver" database/sql driver.
+	_ "github.com/microsoft/go-mssqldb"
+{{- else}}
+	// Registers the "oracle" database/sql driver.
+	_ "github.com/sijms/go-ora/v2"
+{{- end}}
+)
+
+// {{.CamelCase}}DefaultPageSize is the page size of list requests without one.
+const {{.CamelCase}}DefaultPageSize = 20
+{{- if .SQLServer}}
+
+const (
+	{{.CamelCase}}SelectQuery = ` + "`" + `
+SELECT (SELECT {{.SQLServerSelectList}} FOR JSON PATH, WITHOUT_ARRAY_WRAPPER, INCLUDE_NULL_VALUES)
+FROM {{.TableIdent}}
+WHERE id = @p1` + "`" + `
+	// {{.CamelCase}}InsertQuery inserts the JSON encoding of a dto.{{.PascalCase}}{{if not .UUID}} and
+	// returns the id the identity column assigned it{{end}}. Columns missing from the
+	// JSON are inserted as NULL, not as their defaults.
+	{{.CamelCase}}InsertQuery = ` + "`" + `
+INSERT INTO {{.TableIdent}} ({{if .UUID}}id, {{end}}{{.DialectColumnList}})
+{{- if not .UUID}}
+OUTPUT INSERTED.id
+{{- end}}
+SELECT {{if .UUID}}@p1, {{end}}{{.DialectColumnList}} FROM OPENJSON(@p{{if .UUID}}2{{else}}1{{end}}) WITH (
+{{- range $i, $column := .SQLServerJSONColumns false}}{{if $i}},{{end}}
+    {{$column}}
+{{- end}}
+)` + "`" + `
+	// {{.CamelCase}}UpdateQuery overwrites the row with the id of the JSON encoding
+	// of a dto.{{.PascalCase}}.
+	{{.CamelCase}}UpdateQuery = ` + "`" + `
+UPDATE target SET
+{{- range $i, $column := .DialectColumns}}{{if $i}},{{end}}
+    {{$column}} = j.{{$column}}
+{{- end}}
+FROM {{.TableIdent}} AS target CROSS APPLY OPENJSON(@p1) WITH (
+{{- range $i, $column := .SQLServerJSONColumns true}}{{if $i}},{{end}}
+    {{$column}}
+{{- end}}
+) AS j
+WHERE target.id = j.id` + "`" + `
+	{{.CamelCase}}DeleteQuery = ` + "`" + `DELETE FROM {{.TableIdent}} WHERE id = @p1` + "`" + `
+	{{.CamelCase}}CountQuery  = ` + "`" + `SELECT COUNT(*) FROM {{.TableIdent}}` + "`" + `
+	// {{.CamelCase}}ListQuery pages through the rows in id order. OFFSET ... FETCH,
+	// unlike TOP, skips the rows of the earlier pages.
+	{{.CamelCase}}ListQuery = ` + "`" + `
+SELECT (SELECT {{.SQLServerSelectList}} FOR JSON PATH, WITHOUT_ARRAY_WRAPPER, INCLUDE_NULL_VALUES)
+FROM {{.TableIdent}}
+ORDER BY id
+OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY` + "`" + `
+)
+{{- else}}
+
+const (
+	{{.CamelCase}}SelectQuery = ` + "`" + `
+SELECT JSON_OBJECT(
+{{- range $i, $entry := .OracleJSONObject}}{{if $i}},{{end}}
+    {{$entry}}
+{{- end}}
+    RETURNING CLOB)
+FROM {{.TableIdent}}
+WHERE id = :1` + "`" + `
+{{- if not .UUID}}
+	// {{.CamelCase}}NextIDQuery draws the id of a new row from the sequence of the table.
+	{{.CamelCase}}NextIDQuery = ` + "`" + `SELECT {{.SnakeCase}}_seq.NEXTVAL FROM dual` + "`" + `
+{{- end}}
+	// {{.CamelCase}}InsertQuery inserts the JSON encoding of a dto.{{.PascalCase}}, :2,
+	// under the id :1. Columns missing from the JSON are inserted as NULL, not
+	// as their defaults.
+	{{.CamelCase}}InsertQuery = ` + "`" + `
+INSERT INTO {{.TableIdent}} (id, {{.DialectColumnList}})
+SELECT :1, {{.OracleValues}} FROM JSON_TABLE(:2, '$' COLUMNS (
+{{- range $i, $column := .OracleJSONColumns}}{{if $i}},{{end}}
+    {{$column}}
+{{- end}}
+)) j` + "`" + `
+	// {{.CamelCase}}UpdateQuery overwrites the row with the id of the JSON encoding
+	// of a dto.{{.PascalCase}}, which is bound twice.
+	{{.CamelCase}}UpdateQuery = ` + "`" + `
+UPDATE {{.TableIdent}} SET ({{.DialectColumnList}}) = (
+    SELECT {{.OracleValues}} FROM JSON_TABLE(:1, '$' COLUMNS (
+{{- range $i, $column := .OracleJSONColumns}}{{if $i}},{{end}}
+        {{$column}}
+{{- end}}
+    )) j
+)
+WHERE id = JSON_VALUE(:2, '$.id' RETURNING {{if .UUID}}VARCHAR2(36){{else}}NUMBER{{end}})` + "`" + `
+	{{.CamelCase}}DeleteQuery = ` + "`" + `DELETE FROM {{.TableIdent}} WHERE id = :1` + "`" + `
+	{{.CamelCase}}CountQuery  = ` + "`" + `SELECT COUNT(*) FROM {{.TableIdent}}` + "`" + `
+	// {{.CamelCase}}ListQuery pages through the rows in id order.
+	{{.CamelCase}}ListQuery = ` + "`" + `
+SELECT JSON_OBJECT(
+{{- range $i, $entry := .OracleJSONObject}}{{if $i}},{{end}}
+    {{$entry}}
+{{- end}}
+    RETURNING CLOB)
+FROM {{.TableIdent}}
+ORDER BY id
+OFFSET :1 ROWS FETCH NEXT :2 ROWS ONLY` + "`" + `
+)
+{{- end}}
+
+type {{.CamelCase}}Repository struct {
+	db *sql.DB
+}
+
+// New{{.PascalCase}}Repository returns a repository on db, opened with the "{{.DB}}"
+// driver. Unknown ids are reported as sql.ErrNoRows.
+func New{{.PascalCase}}Repository(db *sql.DB) repository.{{.PascalCase}} {
+	return &{{.CamelCase}}Repository{db: db}
+}
+
+func (r *{{.CamelCase}}Repository) GetByID(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error) {
+	var {{.CamelCase}} dto.{{.PascalCase}}
+	var row string
+	if err := r.db.QueryRowContext(ctx, {{.CamelCase}}SelectQuery, id).Scan(&row); err != nil {
+		return {{.CamelCase}}, err
+	}
+	return {{.CamelCase}}, json.Unmarshal([]byte(row), &{{.CamelCase}})
+}
+
+// Create inserts {{.CamelCase}}{{if .UUID}} under a random id{{end}} and reads it back, so it
+// carries its id and the values the database filled in.
+func (r *{{.CamelCase}}Repository) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	row, err := json.Marshal({{.CamelCase}})
+	if err != nil {
+		return err
+	}
+{{- if .UUID}}
+	id := uuid.New()
+	if _, err := r.db.ExecContext(ctx, {{.CamelCase}}InsertQuery, id, string(row)); err != nil {
+		return err
+	}
+{{- else if .SQLServer}}
+	var id int64
+	if err := r.db.QueryRowContext(ctx, {{.CamelCase}}InsertQuery, string(row)).Scan(&id); err != nil {
+		return err
+	}
+{{- else}}
+	var id int64
+	if err := r.db.QueryRowContext(ctx, {{.CamelCase}}NextIDQuery).Scan(&id); err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, {{.CamelCase}}InsertQuery, id, string(row)); err != nil {
+		return err
+	}
+{{- end}}
+	created, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	*{{.CamelCase}} = created
+	return nil
+}
+
+// Update overwrites the columns of the row with the id of {{.CamelCase}}.
+func (r *{{.CamelCase}}Repository) Update(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	row, err := json.Marshal({{.CamelCase}})
+	if err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, {{.CamelCase}}UpdateQuery, string(row){{if .Oracle}}, string(row){{end}})
+	if err != nil {
+		return err
+	}
+	return {{.CamelCase}}Affected(result)
+}
+
+func (r *{{.CamelCase}}Repository) Delete(ctx context.Context, id {{.IDGoType}}) error {
+	result, err := r.db.ExecContext(ctx, {{.CamelCase}}DeleteQuery, id)
+	if err != nil {
+		return err
+	}
+	return {{.CamelCase}}Affected(result)
+}
+
+// FindAll returns one page of rows in id order; sorting is ignored.
+func (r *{{.CamelCase}}Repository) FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+	if err := r.db.QueryRowContext(ctx, {{.CamelCase}}CountQuery).Scan(&pagination.TotalRows); err != nil {
+		return nil, nil, err
+	}
+	size := cmp.Or(pagination.PageSize, {{.CamelCase}}DefaultPageSize)
+	rows, err := r.db.QueryContext(ctx, {{.CamelCase}}ListQuery, {{.CamelCase}}PageOffset(pagination, size), size)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var {{.CamelCase}}s []dto.{{.PascalCase}}
+	for rows.Next() {
+		var row string
+		var {{.CamelCase}} dto.{{.PascalCase}}
+		if err := rows.Scan(&row); err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal([]byte(row), &{{.CamelCase}}); err != nil {
+			return nil, nil, err
+		}
+		{{.CamelCase}}s = append({{.CamelCase}}s, {{.CamelCase}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return {{.CamelCase}}s, &pagination, nil
+}
+
+// {{.CamelCase}}PageOffset is the number of rows before the requested page,
+// which is counted from 1 like in the generic repository.
+func {{.CamelCase}}PageOffset(pagination dto.Pagination, size int) int {
+	return (max(pagination.Page, 1) - 1) * size
+}
+
+// {{.CamelCase}}Affected reports sql.ErrNoRows when a write matched no row.
+func {{.CamelCase}}Affected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+`
+
+const sqlServerTableMigrationTemplate = `IF OBJECT_ID(N'{{.SnakeCase}}', N'U') IS NULL
+CREATE TABLE {{.TableIdent}} (
+{{- range $i, $column := .SQLServerColumns}}{{if $i}},{{end}}
+    {{$column}}
+{{- end}}
+);
+`
+
+const oracleTableMigrationTemplate = `{{if not .UUID -}}
+-- {{.SnakeCase}}_seq numbers the rows of {{.SnakeCase}}; the repository draws ids from it
+-- before inserting.
+CREATE SEQUENCE {{.SnakeCase}}_seq;
+
+{{end -}}
+CREATE TABLE {{.TableIdent}} (
+{{- range $i, $column := .OracleColumns}}{{if $i}},{{end}}
+    {{$column}}
+{{- end}}
+);
+`
@@ -0,0 +1,104 @@
+package crud
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var driftCmd = &cobra.Command{
+	Use:   "drift [path]",
+	Short: "Reports generated files that were edited after generation.",
+	Long: `This command scans the given directory (the current one by default) for files
+carrying a gocrud-gen header and compares each file's content with the hash
+recorded at generation time. Go files are compared as gofmt formats them, so
+formatting on save is not reported, and edits inside the marked regions, such
+as filled in TODOs, are the user's and never drift. A file generated from a
+spec whose content has changed since is stale, as regenerating it would change
+it. The command exits with an error when any file has drifted or is stale, so
+it can be used as a CI check. Run it from the directory crud ran in, which the
+spec paths are relative to.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root := "."
+		if len(args) == 1 {
+			root = args[0]
+		}
+		return checkDrift(root)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+}
+
+func checkDrift(root string) error {
+	drifted, stale := 0, 0
+	// specHashes caches the current hash of every spec, empty for the specs
+	// that are gone.
+	specHashes := make(map[string]string)
+	err := walkGenerated(root, func(path string, header fileHeader, body []byte) error {
+		if !header.matches(path, body) {
+			drifted++
+			fmt.Printf("Drifted: %s (generated by gocrud-gen %s by %q)\n", path, header.Version, header.Run)
+			return nil
+		}
+		if header.Spec == "" || header.Spec == stdinSpecPath {
+			return nil
+		}
+		hash, ok := specHashes[header.Spec]
+		if !ok {
+			hash = currentHash(header.Spec)
+			specHashes[header.Spec] = hash
+		}
+		switch hash {
+		case header.SpecHash:
+		case "":
+			fmt.Printf("Unchecked: %s (spec %s not found)\n", path, header.Spec)
+		default:
+			stale++
+			fmt.Printf("Stale: %s (spec %s changed since generation)\n", path, header.Spec)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if drifted > 0 || stale > 0 {
+		return fmt.Errorf("%d generated file(s) have drifted and %d are stale", drifted, stale)
+	}
+	fmt.Println("No drift detected.")
+	return nil
+}
+
+// walkGenerated calls fn for every file under root that carries a gocrud-gen
+// header. Hidden directories and vendored dependencies are skipped.
+func walkGenerated(root string, fn func(path string, header fileHeader, body []byte) error) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "vendor" || entry.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		header, body, ok := parseHeader(content)
+		if !ok {
+			return nil
+		}
+		return fn(path, header, body)
+	})
+}
@@ -0,0 +1,256 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// DuplicateFields lists the unique fields the duplicate check covers. Hidden
+// fields are left out, as the check would reveal their values, and so are
+// json fields, which are not compared as a whole.
+func (d TemplateData) DuplicateFields() []FieldSpec {
+	return d.fieldsWith(func(field FieldSpec) bool { return field.Unique && !field.Hidden() && field.Type != "json" })
+}
+
+// DuplicateRequestFields renders the fields of the duplicate check request
+// struct, aligned like gofmt would. Each is a pointer, as a form may be
+// checked before all of its unique fields are filled in.
+func (d TemplateData) DuplicateRequestFields() []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	for _, field := range d.DuplicateFields() {
+		fmt.Fprintf(w, "%s\t*%s\t`json:\"%s\"`\n", field.GoName(), fieldTypes[field.Type].Go, field.Name)
+	}
+	w.Flush()
+	return splitLines(buf.String())
+}
+
+// DuplicateImports lists the packages the duplicate check request needs for
+// its fields.
+func (d TemplateData) DuplicateImports() []string {
+	return fieldImports(d.DuplicateFields())
+}
+
+// DuplicateFieldNames lists the names of the fields the duplicate check covers,
+// for its error message.
+func (d TemplateData) DuplicateFieldNames() string {
+	var names []string
+	for _, field := range d.DuplicateFields() {
+		names = append(names, field.Name)
+	}
+	if len(names) <= 1 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// --- DUPLICATE CHECK TEMPLATES ---
+
+// duplicatesDTOTemplate is shared by every entity, so it is generated once and
+// left alone on later runs.
+const duplicatesDTOTemplate = `package dto
+
+// Duplicates is the result of a duplicate check: the unique fields whose
+// values an existing row already holds, in alphabetical order.
+type Duplicates struct {
+	Duplicate bool     ` + "`json:\"duplicate\"`" + `
+	Fields    []string ` + "`json:\"fields\"`" + `
+}
+`
+
+const duplicateInterfaceTemplate = `package repository
+
+import "context"
+
+// {{.PascalCase}}Duplicates finds the existing {{.LowerCase}}s a new one would duplicate.
+type {{.PascalCase}}Duplicates interface {
+	// FindDuplicates returns the columns of values, which maps columns to the
+	// values of a new {{.LowerCase}}, that an existing row already holds.
+	FindDuplicates(ctx context.Context, values map[string]any) ([]string, error)
+}
+`
+
+const duplicateRepositoryTemplate = `package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+)
+
+type {{.CamelCase}}Duplicates struct {
+	db  ports.Database
+	log ports.LoggerWithTraceID
+}
+
+func New{{.PascalCase}}Duplicates(db ports.Database, log ports.LoggerWithTraceID) repository.{{.PascalCase}}Duplicates {
+	return &{{.CamelCase}}Duplicates{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *{{.CamelCase}}Duplicates) FindDuplicates(ctx context.Context, values map[string]any) ([]string, error) {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	// TODO: Check each column on r.db and return those an existing row holds
+	// the value of. Columns come from the controller's request struct and are
+	// quoted, never taken from user input.
+	// Example, for each column:
+	// SELECT EXISTS (SELECT 1 FROM {{.TableIdent}} WHERE "<column>" = $1)
+	return nil, fmt.Errorf("{{.SnakeCase}} duplicate checks are not implemented: %s", strings.Join(columns, ", "))
+}
+`
+
+const duplicateServiceTemplate = `package service
+
+import (
+	"context"
+	"sort"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+)
+
+// {{.PascalCase}}Duplicates checks whether a {{.LowerCase}} about to be created would
+// duplicate the unique fields of an existing one.
+type {{.PascalCase}}Duplicates interface {
+	CheckDuplicate(ctx context.Context, values map[string]any) (dto.Duplicates, error)
+}
+
+type {{.CamelCase}}DuplicateService struct {
+	log        ports.LoggerWithTraceID
+	repository repository.{{.PascalCase}}Duplicates
+}
+
+func New{{.PascalCase}}DuplicateService(log ports.LoggerWithTraceID, repository repository.{{.PascalCase}}Duplicates) {{.PascalCase}}Duplicates {
+	return &{{.CamelCase}}DuplicateService{
+		log:        log,
+		repository: repository,
+	}
+}
+
+// CheckDuplicate only reports what is taken now; the unique constraints still
+// decide when the {{.LowerCase}} is created.
+func (s *{{.CamelCase}}DuplicateService) CheckDuplicate(ctx context.Context, values map[string]any) (dto.Duplicates, error) {
+	fields, err := s.repository.FindDuplicates(ctx, values)
+	if err != nil {
+		return dto.Duplicates{}, err
+	}
+	duplicates := dto.Duplicates{Fields: append([]string{}, fields...)}
+	sort.Strings(duplicates.Fields)
+	duplicates.Duplicate = len(duplicates.Fields) > 0
+	return duplicates, nil
+}
+`
+
+const duplicateControllerTemplate = `package {{.LowerCase}}
+
+import (
+	"errors"
+{{- range .DuplicateImports}}
+	"{{.}}"
+{{- end}}
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+	"{{.ServiceImport}}"
+{{- if or .ContentNegotiation .Envelope.IsSet}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+{{- end}}
+	"go.elastic.co/apm"
+)
+
+// {{.PascalCase}}Duplicates serves the duplicate check of new {{.LowerCase}}s, which forms
+// call for inline feedback before they are submitted.
+type {{.PascalCase}}Duplicates interface {
+	CheckDuplicate{{.PascalCase}}(c *ports.HttpContext) error
+}
+
+type {{.CamelCase}}DuplicatesController struct {
+	duplicates service.{{.PascalCase}}Duplicates
+	log        ports.LoggerWithTraceID
+}
+
+func NewDuplicates(log ports.LoggerWithTraceID, duplicates service.{{.PascalCase}}Duplicates) {{.PascalCase}}Duplicates {
+	return &{{.CamelCase}}DuplicatesController{
+		duplicates: duplicates,
+		log:        log,
+	}
+}
+
+// check{{.PascalCase}}DuplicateRequest holds the unique fields of a {{.LowerCase}} about to be
+// created; those left out are not checked.
+type check{{.PascalCase}}DuplicateRequest struct {
+{{- range .DuplicateRequestFields}}
+	{{.}}
+{{- end}}
+}
+
+// values maps the columns of the fields set in the request to their values.
+func (r check{{.PascalCase}}DuplicateRequest) values() map[string]any {
+	values := make(map[string]any)
+{{- range .DuplicateFields}}
+	if r.{{.GoName}} != nil {
+		values["{{.Name}}"] = *r.{{.GoName}}
+	}
+{{- end}}
+	return values
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Check a new {{.PascalCase}} for duplicates
+// @Description	This route will report which unique fields of a {{.LowerCase}} about to be created an existing one already holds
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			body	body		check{{.PascalCase}}DuplicateRequest	true	"Unique fields to check"
+// @Success		200		{{.SwagResponse "dto.Duplicates"}}
+// @Failure		400		{object}	{{.ErrorSchema}}
+// @Failure		500		{object}	{{.ErrorSchema}}
+// @Router			/api/v1/{{.KebabCase}}/check-duplicate [post]
+{{else -}}
+// CheckDuplicate{{.PascalCase}} handles POST /api/v1/{{.KebabCase}}/check-duplicate.
+{{end -}}
+func (ctrl *{{.CamelCase}}DuplicatesController) CheckDuplicate{{.PascalCase}}(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "CheckDuplicate{{.PascalCase}}", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	var inputRequest check{{.PascalCase}}DuplicateRequest
+	if err := c.BodyParser(&inputRequest); err != nil {
+		ctrl.log.Error(ctx, err.Error())
+		return appErr.NewBadRequestErr(err)
+	}
+	values := inputRequest.values()
+	if len(values) == 0 {
+		return appErr.NewBadRequestErr(errors.New("set at least one of {{.DuplicateFieldNames}} to check"))
+	}
+
+	duplicates, err := ctrl.duplicates.CheckDuplicate(ctx, values)
+	if err != nil {
+		return err
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, {{else}}c.JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   duplicates,
+	}{{if .Envelope.IsSet}}){{end}})
+}
+`
@@ -0,0 +1,306 @@
+package crud
+
+import "fmt"
+
+func (f FieldSpec) validateEncryption() error {
+	if !f.Encrypted {
+		return nil
+	}
+	switch {
+	case fieldTypes[f.Type].Go != "string" || f.Nullable:
+		return fmt.Errorf("only non-nullable string fields can be encrypted")
+	case f.Unique || len(f.Filters) > 0 || f.Sortable || f.Default != "" || f.Check != "":
+		return fmt.Errorf("encrypted fields cannot be unique, filtered, sortable, defaulted or checked, as the database only sees ciphertext")
+	}
+	return nil
+}
+
+// PIIFields lists the fields holding personal data, which includes the
+// encrypted ones.
+func (d TemplateData) PIIFields() []FieldSpec {
+	return d.fieldsWith(func(field FieldSpec) bool { return field.PII || field.Encrypted })
+}
+
+// EncryptedFields lists the fields encrypted at rest.
+func (d TemplateData) EncryptedFields() []FieldSpec {
+	return d.fieldsWith(func(field FieldSpec) bool { return field.Encrypted })
+}
+
+// EncryptsFields reports whether a repository decorator encrypts the entity's
+// encrypted fields, which needs a repository to decorate.
+func (d TemplateData) EncryptsFields() bool {
+	return len(d.EncryptedFields()) > 0 && !d.AppendOnly && !d.Stub
+}
+
+// --- ENCRYPTION TEMPLATES ---
+
+// envelopeEncryptionTemplate is shared by every entity, so it is generated
+// once and left alone on later runs.
+const envelopeEncryptionTemplate = `package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyProvider issues and unwraps the data keys values are encrypted with.
+// Back it with a KMS such as AWS KMS, Cloud KMS or Vault transit, which keeps
+// the key encryption key; it may cache data keys to save round trips.
+type KeyProvider interface {
+	// DataKey returns a new 256-bit data key and the same key wrapped by the
+	// key encryption key.
+	DataKey(ctx context.Context) (key, wrapped []byte, err error)
+	// Unwrap returns the data key of a wrapped key returned by DataKey.
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// prefix marks the values Encrypt produces, so that Decrypt can tell them from
+// plaintext written before the field was encrypted.
+const prefix = "enc:v1:"
+
+// Encrypt seals plaintext with AES-GCM under a new data key and returns the
+// wrapped key, nonce and ciphertext as one printable value.
+func Encrypt(ctx context.Context, keys KeyProvider, plaintext string) (string, error) {
+	key, wrapped, err := keys.DataKey(ctx)
+	if err != nil {
+		return "", fmt.Errorf("encryption: data key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("encryption: nonce: %w", err)
+	}
+
+	sealed := binary.BigEndian.AppendUint16(nil, uint16(len(wrapped)))
+	sealed = append(sealed, wrapped...)
+	sealed = append(sealed, nonce...)
+	sealed = aead.Seal(sealed, nonce, []byte(plaintext), nil)
+	return prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt. Values without its prefix are
+// returned as they are, so rows written before encryption stay readable until
+// they are rewritten.
+func Decrypt(ctx context.Context, keys KeyProvider, value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < 2 {
+		return "", errors.New("encryption: malformed value")
+	}
+	wrappedLen := int(binary.BigEndian.Uint16(sealed))
+	if len(sealed) < 2+wrappedLen {
+		return "", errors.New("encryption: malformed value")
+	}
+	key, err := keys.Unwrap(ctx, sealed[2:2+wrappedLen])
+	if err != nil {
+		return "", fmt.Errorf("encryption: unwrapping data key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	rest := sealed[2+wrappedLen:]
+	if len(rest) < aead.NonceSize() {
+		return "", errors.New("encryption: malformed value")
+	}
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("encryption: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// localKeyProvider wraps data keys with a key encryption key held in memory.
+type localKeyProvider struct {
+	kek cipher.AEAD
+}
+
+// NewLocalKeyProvider returns a KeyProvider wrapping data keys with the given
+// 256-bit key encryption key. It suits development and tests; in production
+// the key encryption key belongs in a KMS.
+func NewLocalKeyProvider(kek []byte) (KeyProvider, error) {
+	if len(kek) != 32 {
+		return nil, errors.New("encryption: the key encryption key must be 32 bytes")
+	}
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	return &localKeyProvider{kek: aead}, nil
+}
+
+func (p *localKeyProvider) DataKey(ctx context.Context) ([]byte, []byte, error) {
+	key := make([]byte, 32)
+	nonce := make([]byte, p.kek.NonceSize())
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return key, p.kek.Seal(nonce, nonce, key, nil), nil
+}
+
+func (p *localKeyProvider) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < p.kek.NonceSize() {
+		return nil, errors.New("encryption: malformed wrapped key")
+	}
+	return p.kek.Open(nil, wrapped[:p.kek.NonceSize()], wrapped[p.kek.NonceSize():], nil)
+}
+`
+
+const encryptingRepositoryTemplate = `package repository
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/encryption"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// {{.CamelCase}}Encrypting encrypts the {{range $i, $field := .EncryptedFields}}{{if $i}}, {{end}}{{$field.Name}}{{end}} field{{if gt (len .EncryptedFields) 1}}s{{end}} of a {{.PascalCase}}
+// before they reach the repository and decrypts them on the way back, so only
+// ciphertext is stored. Methods it does not override pass straight through.
+type {{.CamelCase}}Encrypting struct {
+	{{.PascalCase}}
+	keys encryption.KeyProvider
+}
+
+func New{{.PascalCase}}Encrypting(next {{.PascalCase}}, keys encryption.KeyProvider) {{.PascalCase}} {
+	return &{{.CamelCase}}Encrypting{ {{- .PascalCase}}: next, keys: keys}
+}
+
+func (r *{{.CamelCase}}Encrypting) GetByID(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error) {
+	{{.CamelCase}}, err := r.{{.PascalCase}}.GetByID(ctx, id)
+	if err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	if err := r.decrypt(ctx, &{{.CamelCase}}); err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	return {{.CamelCase}}, nil
+}
+
+func (r *{{.CamelCase}}Encrypting) FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+	{{.CamelCase}}s, resultPagination, err := r.{{.PascalCase}}.FindAll(ctx, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range {{.CamelCase}}s {
+		if err := r.decrypt(ctx, &{{.CamelCase}}s[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return {{.CamelCase}}s, resultPagination, nil
+}
+
+func (r *{{.CamelCase}}Encrypting) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	plain := *{{.CamelCase}}
+	if err := r.encrypt(ctx, {{.CamelCase}}); err != nil {
+		return err
+	}
+	err := r.{{.PascalCase}}.Create(ctx, {{.CamelCase}})
+	restore{{.PascalCase}}Plaintext({{.CamelCase}}, plain)
+	return err
+}
+
+func (r *{{.CamelCase}}Encrypting) Update(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	plain := *{{.CamelCase}}
+	if err := r.encrypt(ctx, {{.CamelCase}}); err != nil {
+		return err
+	}
+	err := r.{{.PascalCase}}.Update(ctx, {{.CamelCase}})
+	restore{{.PascalCase}}Plaintext({{.CamelCase}}, plain)
+	return err
+}
+
+func (r *{{.CamelCase}}Encrypting) encrypt(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	var err error
+{{- range .EncryptedFields}}
+	if {{$.CamelCase}}.{{.GoName}}, err = encryption.Encrypt(ctx, r.keys, {{$.CamelCase}}.{{.GoName}}); err != nil {
+		return err
+	}
+{{- end}}
+	return nil
+}
+
+func (r *{{.CamelCase}}Encrypting) decrypt(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	var err error
+{{- range .EncryptedFields}}
+	if {{$.CamelCase}}.{{.GoName}}, err = encryption.Decrypt(ctx, r.keys, {{$.CamelCase}}.{{.GoName}}); err != nil {
+		return err
+	}
+{{- end}}
+	return nil
+}
+
+// restore{{.PascalCase}}Plaintext puts the plaintext of the encrypted fields back, so the
+// caller keeps working with the {{.LowerCase}} it passed in.
+func restore{{.PascalCase}}Plaintext({{.CamelCase}} *dto.{{.PascalCase}}, plain dto.{{.PascalCase}}) {
+{{- range .EncryptedFields}}
+	{{$.CamelCase}}.{{.GoName}} = plain.{{.GoName}}
+{{- end}}
+}
+{{- if .GDPR}}
+
+// {{.CamelCase}}DataSubjectEncrypting decrypts the {{.LowerCase}}s a data subject export
+// reads. Erasing needs no keys and passes straight through.
+type {{.CamelCase}}DataSubjectEncrypting struct {
+	{{.PascalCase}}DataSubject
+	rows {{.CamelCase}}Encrypting
+}
+
+func New{{.PascalCase}}DataSubjectEncrypting(next {{.PascalCase}}DataSubject, keys encryption.KeyProvider) {{.PascalCase}}DataSubject {
+	return &{{.CamelCase}}DataSubjectEncrypting{ {{- .PascalCase}}DataSubject: next, rows: {{.CamelCase}}Encrypting{keys: keys}}
+}
+
+func (r *{{.CamelCase}}DataSubjectEncrypting) FindByUser(ctx context.Context, userID {{.UserIDGoType}}) ([]dto.{{.PascalCase}}, error) {
+	{{.CamelCase}}s, err := r.{{.PascalCase}}DataSubject.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range {{.CamelCase}}s {
+		if err := r.rows.decrypt(ctx, &{{.CamelCase}}s[i]); err != nil {
+			return nil, err
+		}
+	}
+	return {{.CamelCase}}s, nil
+}
+{{- end}}
+`
+
+// piiMigrationTemplate documents the personal data columns in the schema and
+// widens the encrypted ones to TEXT, which their ciphertext needs.
+const piiMigrationTemplate = `{{range .PIIFields -}}
+{{if .Encrypted -}}
+ALTER TABLE {{$.TableIdent}} ALTER COLUMN {{.Name}} TYPE TEXT;
+COMMENT ON COLUMN {{$.TableIdent}}.{{.Name}} IS 'PII, envelope-encrypted by the application; holds ciphertext only';
+{{else -}}
+COMMENT ON COLUMN {{$.TableIdent}}.{{.Name}} IS 'PII, redacted from logs';
+{{end -}}
+{{end -}}
+`
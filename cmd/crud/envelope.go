@@ -0,0 +1,136 @@
+package crud
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// envelopeFieldName is a JSON field name the envelope may use.
+var envelopeFieldName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// EnvelopeConfig describes the body the generated handlers wrap their
+// responses in, e.g.
+//
+//	envelope:
+//	  data: result
+//	  meta: meta
+//	  errors: errors
+//
+// Names left empty default to data, meta and errors. None returns the data
+// bare, with the pagination in the X-Pagination header. Without either the
+// framework's ports.Response is used.
+type EnvelopeConfig struct {
+	None   bool   `yaml:"none"`
+	Data   string `yaml:"data"`
+	Meta   string `yaml:"meta"`
+	Errors string `yaml:"errors"`
+}
+
+// IsSet reports whether the config replaces ports.Response.
+func (c EnvelopeConfig) IsSet() bool {
+	return c != EnvelopeConfig{}
+}
+
+func (c EnvelopeConfig) validate() error {
+	if c.None && (c.Data != "" || c.Meta != "" || c.Errors != "") {
+		return fmt.Errorf("envelope: none cannot be combined with field names")
+	}
+	seen := map[string]string{}
+	for _, field := range [][2]string{{"data", c.DataField()}, {"meta", c.MetaField()}, {"errors", c.ErrorsField()}} {
+		if !envelopeFieldName.MatchString(field[1]) {
+			return fmt.Errorf("envelope.%s: %q is not a valid field name", field[0], field[1])
+		}
+		if other, ok := seen[field[1]]; ok {
+			return fmt.Errorf("envelope: %s and %s are both named %q", other, field[0], field[1])
+		}
+		seen[field[1]] = field[0]
+	}
+	return nil
+}
+
+// DataField, MetaField and ErrorsField are the JSON names of the envelope's
+// fields.
+func (c EnvelopeConfig) DataField() string {
+	return cmp.Or(c.Data, "data")
+}
+
+func (c EnvelopeConfig) MetaField() string {
+	return cmp.Or(c.Meta, "meta")
+}
+
+func (c EnvelopeConfig) ErrorsField() string {
+	return cmp.Or(c.Errors, "errors")
+}
+
+// SwagResponse is the swaggo type of a success response carrying data of
+// type, e.g. {{.SwagResponse "[]dto.Order"}}.
+func (d TemplateData) SwagResponse(dataType string) string {
+	switch {
+	case d.Envelope.None:
+		if elem, ok := strings.CutPrefix(dataType, "[]"); ok {
+			return "{array}\t" + elem
+		}
+		return "{object}\t" + dataType
+	case d.Envelope.IsSet():
+		return "{object}\thttpUtils.EnvelopeBody{" + d.Envelope.DataField() + "=" + dataType + "}"
+	}
+	return "{object}\tports.Response{data=" + dataType + "}"
+}
+
+// --- ENVELOPE TEMPLATES ---
+
+// envelopeTemplate is shared by every entity, so it is generated once and left
+// alone on later runs.
+const envelopeTemplate = `package httpUtils
+
+{{if .Envelope.None -}}
+import (
+	"encoding/json"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+// PaginationHeader carries the pagination of a list response, which has no
+// envelope to hold it.
+const PaginationHeader = "X-Pagination"
+
+// Envelope returns the data of resp bare, as the public API style guide asks,
+// moving its pagination into the PaginationHeader.
+func Envelope(c *ports.HttpContext, resp ports.Response) any {
+	if resp.Meta != nil && resp.Meta.Pagination != nil {
+		if pagination, err := json.Marshal(resp.Meta.Pagination); err == nil {
+			c.Set(PaginationHeader, string(pagination))
+		}
+	}
+	return resp.Data
+}
+
+// EnvelopeErrors is the body of an error response, for the application's
+// error handler.
+func EnvelopeErrors(errs any) any {
+	return errs
+}
+{{- else -}}
+import "git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+
+// EnvelopeBody is the response body of the public API style guide.
+type EnvelopeBody struct {
+	Data   any         ` + "`" + `json:"{{.Envelope.DataField}},omitempty"` + "`" + `
+	Meta   *ports.Meta ` + "`" + `json:"{{.Envelope.MetaField}},omitempty"` + "`" + `
+	Errors any         ` + "`" + `json:"{{.Envelope.ErrorsField}},omitempty"` + "`" + `
+}
+
+// Envelope moves the data and meta of resp into an EnvelopeBody.
+func Envelope(c *ports.HttpContext, resp ports.Response) any {
+	return EnvelopeBody{Data: resp.Data, Meta: resp.Meta}
+}
+
+// EnvelopeErrors is the body of an error response, for the application's
+// error handler.
+func EnvelopeErrors(errs any) any {
+	return EnvelopeBody{Errors: errs}
+}
+{{- end}}
+`
@@ -0,0 +1,167 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validateExample checks the field's example parses as a value of its type.
+func (f FieldSpec) validateExample() error {
+	if f.Example == "" {
+		return nil
+	}
+	ok := true
+	switch f.Type {
+	case "int", "int64":
+		_, err := strconv.ParseInt(f.Example, 10, 64)
+		ok = err == nil
+	case "float":
+		_, err := strconv.ParseFloat(f.Example, 64)
+		ok = err == nil
+	case "bool":
+		_, err := strconv.ParseBool(f.Example)
+		ok = err == nil
+	case "time":
+		_, err := time.Parse(time.RFC3339, f.Example)
+		ok = err == nil
+	case "uuid":
+		ok = uuidPattern.MatchString(f.Example)
+	case "json":
+		ok = json.Valid([]byte(f.Example))
+	}
+	if !ok {
+		return fmt.Errorf("example %q is not a valid %s value", f.Example, f.Type)
+	}
+	return nil
+}
+
+// example is the field's example, or a value of its type that passes the
+// field's validation when the spec gives none.
+func (f FieldSpec) example() string {
+	if f.Example != "" {
+		return f.Example
+	}
+	rules, _ := f.CheckRules()
+	for _, rule := range rules {
+		if values, ok := strings.CutPrefix(rule, "oneof="); ok {
+			return strings.Fields(values)[0]
+		}
+	}
+	switch f.Type {
+	case "int", "int64", "float":
+		for _, rule := range rules {
+			if value, ok := strings.CutPrefix(rule, "gte="); ok {
+				return value
+			}
+			if value, ok := strings.CutPrefix(rule, "gt="); ok {
+				bound, _ := strconv.ParseFloat(value, 64)
+				return strconv.FormatFloat(bound+1, 'f', -1, 64)
+			}
+		}
+		return "1"
+	case "bool":
+		return "true"
+	case "time":
+		return "2006-01-02T15:04:05Z"
+	case "uuid":
+		return "3f2b6c1e-8a4d-4e2f-9b7a-1c5d0e6f7a81"
+	case "json":
+		return "{}"
+	}
+	return "example " + strings.ReplaceAll(f.Name, "_", " ")
+}
+
+// quoted reports whether the field's values are JSON strings.
+func (f FieldSpec) quoted() bool {
+	switch f.Type {
+	case "int", "int64", "float", "bool", "json":
+		return false
+	}
+	return true
+}
+
+// ExampleJSON is the field's example as a JSON value.
+func (f FieldSpec) ExampleJSON() string {
+	if f.quoted() {
+		encoded, _ := json.Marshal(f.example())
+		return string(encoded)
+	}
+	if f.Type == "json" {
+		var compact bytes.Buffer
+		json.Compact(&compact, []byte(f.example()))
+		return compact.String()
+	}
+	return f.example()
+}
+
+// ExampleGo is the field's example as a Go expression of a JSON-encodable value.
+func (f FieldSpec) ExampleGo() string {
+	switch {
+	case f.quoted():
+		return strconv.Quote(f.example())
+	case f.Type == "json":
+		return "json.RawMessage(" + strconv.Quote(f.ExampleJSON()) + ")"
+	}
+	return f.example()
+}
+
+// exampleTag is the swaggo struct tag of the field's example, if it has one.
+func (d TemplateData) exampleTag(f FieldSpec) string {
+	if d.Swagger != swaggerSwaggo || f.Example == "" || strings.Contains(f.Example, "`") {
+		return ""
+	}
+	return " example:" + strconv.Quote(f.Example)
+}
+
+// ExampleBody is the JSON request body of the spec fields' examples, indented
+// for .http files, or {} without a spec.
+func (d TemplateData) ExampleBody() string {
+	if len(d.Entity.Fields) == 0 {
+		return "{}"
+	}
+	lines := make([]string, 0, len(d.Entity.Fields))
+	for _, field := range d.Entity.Fields {
+		lines = append(lines, fmt.Sprintf("  %q: %s", field.Name, field.ExampleJSON()))
+	}
+	return "{\n" + strings.Join(lines, ",\n") + "\n}"
+}
+
+// ExampleSeed is the compact example body as a quoted Go string, for fuzz
+// corpora.
+func (d TemplateData) ExampleSeed() string {
+	var compact bytes.Buffer
+	json.Compact(&compact, []byte(d.ExampleBody()))
+	return strconv.Quote(compact.String())
+}
+
+// MockFields renders the entries of a mock record, the id followed by the
+// spec fields' examples, aligned like gofmt would.
+func (d TemplateData) MockFields() []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	fmt.Fprintln(w, "\"id\":\tid,")
+	for _, field := range d.Entity.Fields {
+		fmt.Fprintf(w, "%q:\t%s,\n", field.Name, field.ExampleGo())
+	}
+	w.Flush()
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+// HasJSONField reports whether a spec field has the json type, whose mock
+// values need encoding/json.
+func (d TemplateData) HasJSONField() bool {
+	for _, field := range d.Entity.Fields {
+		if field.Type == "json" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package crud
+
+import "regexp"
+
+// featureFlagName matches the flag names accepted by --feature-flag.
+var featureFlagName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+
+// --- FEATURE FLAG TEMPLATES ---
+
+// featureGateTemplate is shared by every entity, so it is generated once and
+// left alone on later runs.
+const featureGateTemplate = `package httpUtils
+
+import (
+	"context"
+	"errors"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+// FeatureFlags is implemented by the project's feature-flag client.
+type FeatureFlags interface {
+	IsEnabled(ctx context.Context, flag string) bool
+}
+
+// FeatureGate returns a middleware that answers 404 while flag is disabled, so
+// dark-launched routes look like they do not exist yet.
+func FeatureGate(flags FeatureFlags, flag string) func(c *ports.HttpContext) error {
+	return func(c *ports.HttpContext) error {
+		if !flags.IsEnabled(c.Context(), flag) {
+			return appErr.NewNotFoundErr(errors.New("resource not found"))
+		}
+		return c.Next()
+	}
+}
+`
+
+const featureFlagTemplate = `package {{.LowerCase}}
+
+import (
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+)
+
+// {{.PascalCase}}FeatureFlag gates every {{.PascalCase}} route.
+const {{.PascalCase}}FeatureFlag = "{{.FeatureFlag}}"
+
+// FeatureGate returns the middleware for the {{.PascalCase}} route groups. Until
+// {{.PascalCase}}FeatureFlag is enabled the routes answer 404.
+func FeatureGate(flags httpUtils.FeatureFlags) func(c *ports.HttpContext) error {
+	return httpUtils.FeatureGate(flags, {{.PascalCase}}FeatureFlag)
+}
+`
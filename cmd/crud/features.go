@@ -0,0 +1,43 @@
+package crud
+
+// FeatureConfig switches cross-cutting behaviour on or off for the templates.
+// It is set for the project in the generator config and per entity in the
+// spec, which wins; toggles left unset keep their defaults.
+type FeatureConfig struct {
+	SoftDelete *bool `yaml:"soft_delete"`
+	Tracing    *bool `yaml:"tracing"`
+	Caching    *bool `yaml:"caching"`
+	Audit      *bool `yaml:"audit"`
+}
+
+// Features are the resolved toggles, available to templates as .Features so
+// a custom template can branch on {{if .Features.SoftDelete}} instead of
+// existing in several variants.
+type Features struct {
+	// SoftDelete is on with --soft-delete and --retention-job, which need a
+	// deleted_at column.
+	SoftDelete bool
+	// Tracing is on by default; the built-in handlers open APM spans.
+	Tracing bool
+	Caching bool
+	Audit   bool
+}
+
+// resolveFeatures applies the feature configs in order of precedence, lowest
+// first, on top of the defaults implied by opts.
+func resolveFeatures(opts Options, configs ...FeatureConfig) Features {
+	features := Features{SoftDelete: opts.SoftDelete || opts.RetentionJob != "", Tracing: true}
+	for _, cfg := range configs {
+		overrideToggle(&features.SoftDelete, cfg.SoftDelete)
+		overrideToggle(&features.Tracing, cfg.Tracing)
+		overrideToggle(&features.Caching, cfg.Caching)
+		overrideToggle(&features.Audit, cfg.Audit)
+	}
+	return features
+}
+
+func overrideToggle(toggle *bool, value *bool) {
+	if value != nil {
+		*toggle = *value
+	}
+}
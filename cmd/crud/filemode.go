@@ -0,0 +1,93 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	defaultFileMode os.FileMode = 0644
+	defaultDirMode  os.FileMode = 0755
+)
+
+// fileModes are the permissions generated files and directories are created
+// with. Exact is set when they were configured, in which case they are applied
+// as given instead of being narrowed by the umask.
+type fileModes struct {
+	File  os.FileMode
+	Dir   os.FileMode
+	Exact bool
+}
+
+// parseFileMode parses an octal permission such as "0640" given for name.
+func parseFileMode(name, value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid %s %q: must be an octal permission such as 0644", name, value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// resolveFileModes picks the modes of the run: the flags win over the config,
+// which wins over the defaults. Both were validated when they were read.
+func resolveFileModes(opts Options, cfg Config) fileModes {
+	modes := fileModes{File: defaultFileMode, Dir: defaultDirMode}
+	for _, setting := range []struct {
+		value string
+		mode  *os.FileMode
+	}{
+		{cfg.FileMode, &modes.File},
+		{cfg.DirMode, &modes.Dir},
+		{opts.FileMode, &modes.File},
+		{opts.DirMode, &modes.Dir},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		if mode, err := parseFileMode("mode", setting.value); err == nil {
+			*setting.mode = mode
+			modes.Exact = true
+		}
+	}
+	return modes
+}
+
+// mkdirAll creates dir and its missing parents with the directory mode.
+func (m fileModes) mkdirAll(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if parent := filepath.Dir(dir); parent != dir {
+		if err := m.mkdirAll(parent); err != nil {
+			return err
+		}
+	}
+	if err := os.Mkdir(dir, m.Dir); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil
+		}
+		return err
+	}
+	if m.Exact {
+		return os.Chmod(dir, m.Dir)
+	}
+	return nil
+}
+
+// create creates the file at path with the file mode.
+func (m fileModes) create(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, m.File)
+	if err != nil {
+		return nil, err
+	}
+	if m.Exact {
+		if err := file.Chmod(m.File); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return file, nil
+}
@@ -0,0 +1,205 @@
+package crud
+
+import "strings"
+
+const (
+	filterEq      = "eq"
+	filterNeq     = "neq"
+	filterGt      = "gt"
+	filterGte     = "gte"
+	filterIn      = "in"
+	filterLike    = "like"
+	filterBetween = "between"
+)
+
+// FilterFields lists the spec fields that declare filter operators.
+func (d TemplateData) FilterFields() []FieldSpec {
+	var fields []FieldSpec
+	for _, field := range d.Entity.Fields {
+		if len(field.Filters) > 0 {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// FilterOperators renders the field's operators as a Go string slice literal.
+func (f FieldSpec) FilterOperators() string {
+	quoted := make([]string, len(f.Filters))
+	for i, operator := range f.Filters {
+		quoted[i] = `"` + operator + `"`
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+// FilterKind is how httpUtils.ParseFilters converts the field's query values.
+func (f FieldSpec) FilterKind() string {
+	switch f.Type {
+	case "int", "int64":
+		return "httpUtils.FilterInt"
+	case "float":
+		return "httpUtils.FilterFloat"
+	case "bool":
+		return "httpUtils.FilterBool"
+	case "time":
+		return "httpUtils.FilterTime"
+	default:
+		return "httpUtils.FilterString"
+	}
+}
+
+// --- FILTER TEMPLATES ---
+
+// filterDTOTemplate, filterParserTemplate and filterClauseTemplate are shared
+// by every entity, so they are generated once and left alone on later runs.
+const filterDTOTemplate = `package dto
+
+import "context"
+
+// Filter is one whitelisted condition of a list request, carried from the
+// controller to the repository on the request context.
+type Filter struct {
+	Column   string
+	Operator string
+	Values   []any
+}
+
+type filtersKey struct{}
+
+// WithFilters returns a copy of ctx carrying filters.
+func WithFilters(ctx context.Context, filters []Filter) context.Context {
+	return context.WithValue(ctx, filtersKey{}, filters)
+}
+
+// FiltersFromContext returns the filters attached by WithFilters, if any.
+func FiltersFromContext(ctx context.Context) []Filter {
+	filters, _ := ctx.Value(filtersKey{}).([]Filter)
+	return filters
+}
+`
+
+const filterParserTemplate = `package httpUtils
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// FilterKind converts a query value to the type of its column.
+type FilterKind func(value string) (any, error)
+
+var (
+	FilterString FilterKind = func(value string) (any, error) { return value, nil }
+	FilterInt    FilterKind = func(value string) (any, error) { return strconv.ParseInt(value, 10, 64) }
+	FilterFloat  FilterKind = func(value string) (any, error) { return strconv.ParseFloat(value, 64) }
+	FilterBool   FilterKind = func(value string) (any, error) { return strconv.ParseBool(value) }
+	FilterTime   FilterKind = func(value string) (any, error) { return time.Parse(time.RFC3339, value) }
+)
+
+// FilterField whitelists the operators a list endpoint accepts for one field.
+type FilterField struct {
+	Column    string
+	Kind      FilterKind
+	Operators []string
+}
+
+// filterOperators are all operators ParseFilters understands. "in" takes a
+// comma-separated list and "between" exactly two comma-separated bounds.
+var filterOperators = []string{"eq", "neq", "gt", "gte", "in", "like", "between"}
+
+// ParseFilters reads field[operator]=value query parameters for the given
+// fields; field=value is short for field[eq]=value. Operators that are not
+// whitelisted for a field are rejected with a bad request error.
+func ParseFilters(c *ports.HttpContext, fields map[string]FilterField) ([]dto.Filter, error) {
+	var filters []dto.Filter
+	for name, field := range fields {
+		for _, operator := range filterOperators {
+			raw := c.Query(name + "[" + operator + "]")
+			if raw == "" && operator == "eq" {
+				raw = c.Query(name)
+			}
+			if raw == "" {
+				continue
+			}
+			if !slices.Contains(field.Operators, operator) {
+				return nil, appErr.NewBadRequestErr(fmt.Errorf("filter operator %q is not allowed on %s", operator, name))
+			}
+
+			parts := []string{raw}
+			if operator == "in" || operator == "between" {
+				parts = strings.Split(raw, ",")
+			}
+			if operator == "between" && len(parts) != 2 {
+				return nil, appErr.NewBadRequestErr(fmt.Errorf("filter %s[between] needs two comma-separated values", name))
+			}
+
+			values := make([]any, len(parts))
+			for i, part := range parts {
+				value, err := field.Kind(strings.TrimSpace(part))
+				if err != nil {
+					return nil, appErr.NewBadRequestErr(fmt.Errorf("invalid value %q for filter %s: %w", part, name, err))
+				}
+				values[i] = value
+			}
+			filters = append(filters, dto.Filter{Column: field.Column, Operator: operator, Values: values})
+		}
+	}
+	return filters, nil
+}
+`
+
+const filterClauseTemplate = `package repository
+
+import (
+	"strconv"
+	"strings"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// BuildFilterClause turns filters into a SQL condition joined with AND and its
+// arguments, numbering placeholders from firstPlaceholder. Columns come from
+// the controllers' whitelists and are quoted, never taken from user input.
+func BuildFilterClause(filters []dto.Filter, firstPlaceholder int) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+	placeholder := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(firstPlaceholder+len(args)-1)
+	}
+
+	for _, filter := range filters {
+		column := "\"" + filter.Column + "\""
+		switch filter.Operator {
+		case "eq":
+			conditions = append(conditions, column+" = "+placeholder(filter.Values[0]))
+		case "neq":
+			conditions = append(conditions, column+" <> "+placeholder(filter.Values[0]))
+		case "gt":
+			conditions = append(conditions, column+" > "+placeholder(filter.Values[0]))
+		case "gte":
+			conditions = append(conditions, column+" >= "+placeholder(filter.Values[0]))
+		case "like":
+			conditions = append(conditions, column+" LIKE "+placeholder(filter.Values[0]))
+		case "in":
+			list := make([]string, len(filter.Values))
+			for i, value := range filter.Values {
+				list[i] = placeholder(value)
+			}
+			conditions = append(conditions, column+" IN ("+strings.Join(list, ", ")+")")
+		case "between":
+			conditions = append(conditions, column+" BETWEEN "+placeholder(filter.Values[0])+" AND "+placeholder(filter.Values[1]))
+		}
+	}
+	return strings.Join(conditions, " AND "), args
+}
+`
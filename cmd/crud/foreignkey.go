@@ -0,0 +1,89 @@
+package crud
+
+import "strings"
+
+const (
+	onDeleteCascade  = "cascade"
+	onDeleteRestrict = "restrict"
+	onDeleteSetNull  = "set_null"
+)
+
+// OnDeleteClause is the ON DELETE clause of a belongs_to foreign key, empty
+// when the spec leaves the database default (NO ACTION) in place.
+func (r RelationSpec) OnDeleteClause() string {
+	if r.OnDelete == "" {
+		return ""
+	}
+	return " ON DELETE " + strings.ToUpper(strings.ReplaceAll(r.OnDelete, "_", " "))
+}
+
+// Table is the table of the related entity.
+func (r RelationSpec) Table() string {
+	return toSnakeCase(r.Entity)
+}
+
+// TableIdent is the table of the related entity quoted as an SQL identifier.
+func (r RelationSpec) TableIdent() string {
+	return quoteIdent(r.Table())
+}
+
+// ForeignKeys lists the entity's belongs_to relations that choose an ON DELETE
+// behaviour.
+func (d TemplateData) ForeignKeys() []RelationSpec {
+	var relations []RelationSpec
+	for _, relation := range d.Entity.Relations {
+		if relation.Kind == relationBelongsTo && relation.OnDelete != "" {
+			relations = append(relations, relation)
+		}
+	}
+	return relations
+}
+
+// RestrictedByList joins RestrictedBy for messages, e.g. "Order or Invoice".
+func (d TemplateData) RestrictedByList() string {
+	return strings.Join(d.RestrictedBy, " or ")
+}
+
+// restrictedBy lists the entities whose belongs_to foreign key keeps a row of
+// the named entity from being deleted while they reference it.
+func (s Spec) restrictedBy(name string) []string {
+	var entities []string
+	for _, entity := range s.Entities {
+		for _, relation := range entity.Relations {
+			if relation.Kind == relationBelongsTo && relation.Entity == name && relation.OnDelete != onDeleteCascade && relation.OnDelete != onDeleteSetNull {
+				entities = append(entities, entity.Name)
+				break
+			}
+		}
+	}
+	return entities
+}
+
+// --- FOREIGN KEY TEMPLATES ---
+
+// foreignKeysMigrationTemplate applies the spec's ON DELETE behaviour to the
+// foreign keys of an existing table; partitioned tables get them in their
+// CREATE TABLE instead.
+const foreignKeysMigrationTemplate = `{{if .Cockroach -}}
+-- CockroachDB cannot drop and re-add a constraint of the same name in one
+-- transaction, so the foreign keys with the spec's ON DELETE behaviour are
+-- added under names of their own before the default ones are dropped.
+{{end -}}
+{{range .ForeignKeys -}}
+{{if $.Cockroach -}}
+{{if eq .OnDelete "set_null" -}}
+ALTER TABLE {{$.TableIdent}} ALTER COLUMN {{.ForeignKey}} DROP NOT NULL;
+{{end -}}
+ALTER TABLE {{$.TableIdent}} ADD CONSTRAINT IF NOT EXISTS {{$.SnakeCase}}_{{.ForeignKey}}_{{.OnDelete}}_fkey
+    FOREIGN KEY ({{.ForeignKey}}) REFERENCES {{.TableIdent}} (id){{.OnDeleteClause}};
+ALTER TABLE {{$.TableIdent}} DROP CONSTRAINT IF EXISTS {{$.SnakeCase}}_{{.ForeignKey}}_fkey;
+{{else -}}
+ALTER TABLE {{$.TableIdent}} DROP CONSTRAINT IF EXISTS {{$.SnakeCase}}_{{.ForeignKey}}_fkey;
+{{if eq .OnDelete "set_null" -}}
+ALTER TABLE {{$.TableIdent}} ALTER COLUMN {{.ForeignKey}} DROP NOT NULL;
+{{end -}}
+ALTER TABLE {{$.TableIdent}} ADD CONSTRAINT {{$.SnakeCase}}_{{.ForeignKey}}_fkey
+    FOREIGN KEY ({{.ForeignKey}}) REFERENCES {{.TableIdent}} (id){{.OnDeleteClause}};
+{{end -}}
+{{end -}}
+`
@@ -0,0 +1,61 @@
+package crud
+
+// --- FUZZ TEST TEMPLATES ---
+
+const requestFuzzTemplate = `package {{.LowerCase}}
+
+import (
+	"encoding/json"
+	"testing"
+
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/validator"
+)
+
+// fuzzValidation is the validator the fuzz targets run decoded requests through.
+// TODO: Construct it the same way 'internal/initializer/app.go' does; until then
+// only the decoding path is exercised.
+var fuzzValidation validator.CustomValidation
+
+var fuzzSeeds = [][]byte{
+	[]byte("{}"),
+	[]byte("[]"),
+	[]byte("null"),
+	[]byte(` + "`" + `{"id": -1}` + "`" + `),
+	[]byte(` + "`" + `{"name": "\u0000", "extra": {"nested": [1, 2, 3]}}` + "`" + `),
+{{- if .Entity.Fields}}
+	[]byte({{.ExampleSeed}}),
+{{- end}}
+}
+
+func FuzzCreate{{.PascalCase}}Request(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		var inputRequest create{{.PascalCase}}Request
+		if err := json.Unmarshal(body, &inputRequest); err != nil {
+			return
+		}
+		if fuzzValidation != nil {
+			fuzzValidation.ValidateStruct(inputRequest)
+		}
+	})
+}
+{{- if not .AppendOnly}}
+
+func FuzzUpdate{{.PascalCase}}Request(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		var inputRequest update{{.PascalCase}}Request
+		if err := json.Unmarshal(body, &inputRequest); err != nil {
+			return
+		}
+		if fuzzValidation != nil {
+			fuzzValidation.ValidateStruct(inputRequest)
+		}
+	})
+}
+{{- end}}
+`
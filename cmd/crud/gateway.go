@@ -0,0 +1,67 @@
+package crud
+
+// --- GATEWAY ROUTE TEMPLATES ---
+
+const (
+	gatewayKong    = "kong"
+	gatewayTraefik = "traefik"
+	gatewayEnvoy   = "envoy"
+)
+
+// gatewayArtifacts maps each supported --gateway value to the built-in
+// template of its route fragment.
+var gatewayArtifacts = map[string]string{
+	gatewayKong:    "kongRoutes",
+	gatewayTraefik: "traefikRoutes",
+	gatewayEnvoy:   "envoyRoutes",
+}
+
+// GatewayPrefixes lists the path prefixes the gateway forwards to the service.
+// Internal routes are deliberately left out, since they are not meant to be
+// reachable from outside the cluster.
+func (d TemplateData) GatewayPrefixes() []string {
+	prefixes := []string{"/api/v1/" + d.KebabCase}
+	if d.Admin {
+		prefixes = append(prefixes, d.WriteRoutePrefix()+"/"+d.KebabCase)
+	}
+	return prefixes
+}
+
+const kongRoutesTemplate = `# Kong declarative config for the {{.PascalCase}} routes; merge it with deck.
+_format_version: "3.0"
+services:
+  - name: harley-{{.KebabCase}}
+    # TODO: Point this at the service's address in your environment.
+    url: http://harley:8080
+    routes:
+      - name: {{.KebabCase}}
+        strip_path: false
+        paths:
+{{- range .GatewayPrefixes}}
+          - {{.}}
+{{- end}}
+`
+
+const traefikRoutesTemplate = `# Traefik dynamic config for the {{.PascalCase}} routes, loaded by the file provider.
+http:
+  routers:
+    harley-{{.KebabCase}}:
+      rule: "{{range $i, $prefix := .GatewayPrefixes}}{{if $i}} || {{end}}PathPrefix(` + "`" + `{{$prefix}}` + "`" + `){{end}}"
+      service: harley-{{.KebabCase}}
+  services:
+    harley-{{.KebabCase}}:
+      loadBalancer:
+        servers:
+          # TODO: Point this at the service's address in your environment.
+          - url: http://harley:8080
+`
+
+const envoyRoutesTemplate = `# Envoy routes for {{.PascalCase}}; add them to the virtual host that fronts the service.
+{{- range .GatewayPrefixes}}
+- match:
+    prefix: {{.}}
+  route:
+    # TODO: Use the cluster name of the service in your Envoy config.
+    cluster: harley
+{{- end}}
+`
@@ -0,0 +1,405 @@
+package crud
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var collectionNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// Collection is the Firestore collection or Spanner table of the entity: the
+// spec's collection, or else the plural of the entity's name in the usual
+// style of the database, orderItems in Firestore and OrderItems in Spanner.
+func (d TemplateData) Collection() string {
+	if d.Entity.Collection != "" {
+		return d.Entity.Collection
+	}
+	if d.Spanner() {
+		return d.PascalCase + "s"
+	}
+	return d.CamelCase + "s"
+}
+
+// spannerTypes maps a spec field type to its Spanner column type.
+var spannerTypes = map[string]string{
+	"string": "STRING(255)",
+	"text":   "STRING(MAX)",
+	"int":    "INT64",
+	"int64":  "INT64",
+	"float":  "FLOAT64",
+	"bool":   "BOOL",
+	"time":   "TIMESTAMP",
+	"uuid":   "STRING(36)",
+	"json":   "JSON",
+}
+
+// SpannerColumns renders the column definitions of the entity's Spanner table
+// from the spec, aligned on the type: the id, the fields and the foreign keys
+// of belongs_to relations, followed by the fields' checks. Foreign keys are
+// not enforced, as the related tables are keyed by UUIDs.
+func (d TemplateData) SpannerColumns() []string {
+	type column struct{ name, definition string }
+	columns := []column{{"id", "STRING(36) NOT NULL"}}
+	var checks []string
+	for _, field := range d.dataColumns() {
+		definition := spannerTypes[field.Type]
+		if !field.Nullable {
+			definition += " NOT NULL"
+		}
+		if field.Default != "" {
+			definition += " DEFAULT (" + field.Default + ")"
+		}
+		columns = append(columns, column{field.Name, definition})
+		if field.Check != "" {
+			checks = append(checks, fmt.Sprintf("CONSTRAINT %s_%s_check CHECK (%s)", d.SnakeCase, field.Name, field.Check))
+		}
+	}
+
+	width := 0
+	for _, c := range columns {
+		width = max(width, len(c.name))
+	}
+	rendered := make([]string, 0, len(columns)+len(checks))
+	for _, c := range columns {
+		rendered = append(rendered, fmt.Sprintf("%-*s %s", width, c.name, c.definition))
+	}
+	return append(rendered, checks...)
+}
+
+// SpannerColumnList is the comma separated columns of the entity's Spanner
+// table, id first.
+func (d TemplateData) SpannerColumnList() string {
+	return strings.Join(append([]string{"id"}, d.DialectColumns()...), ", ")
+}
+
+// --- FIRESTORE AND SPANNER TEMPLATES ---
+
+// The Firestore and Spanner templates store the entity with the Google Cloud
+// clients, encoded by the firestore or spanner tags of the DTO. Ids are random
+// UUIDs, which spread writes across servers, and the list endpoint pages with
+// a cursor on the id instead of page numbers.
+
+const firestoreRepositoryTemplate = `package firestore
+
+import (
+	"cmp"
+	"context"
+	"errors"
+
+	"cloud.google.com/go/firestore"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+	"github.com/google/uuid"
+)
+
+// {{.PascalCase}}Collection holds a document per {{.LowerCase}}, named by its id.
+const {{.PascalCase}}Collection = "{{.Collection}}"
+
+// {{.CamelCase}}DefaultPageSize is the page size of list requests without one.
+const {{.CamelCase}}DefaultPageSize = 20
+
+var _ repository.{{.PascalCase}} = (*{{.PascalCase}}Repository)(nil)
+
+// {{.PascalCase}}Repository stores {{.LowerCase}}s as documents encoded by the firestore
+// tags of dto.{{.PascalCase}}, whose ID is the document name and tagged firestore:"-".
+// Unknown ids are reported as errors with the gRPC code NotFound.
+type {{.PascalCase}}Repository struct {
+	client *firestore.Client
+}
+
+func New{{.PascalCase}}Repository(client *firestore.Client) *{{.PascalCase}}Repository {
+	return &{{.PascalCase}}Repository{client: client}
+}
+
+func (r *{{.PascalCase}}Repository) GetByID(ctx context.Context, id uuid.UUID) (dto.{{.PascalCase}}, error) {
+	snapshot, err := r.client.Collection({{.PascalCase}}Collection).Doc(id.String()).Get(ctx)
+	if err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	return decode{{.PascalCase}}(snapshot)
+}
+
+// Create stores {{.CamelCase}} under a random id.
+func (r *{{.PascalCase}}Repository) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	id := uuid.New()
+	if _, err := r.client.Collection({{.PascalCase}}Collection).Doc(id.String()).Create(ctx, {{.CamelCase}}); err != nil {
+		return err
+	}
+	{{.CamelCase}}.ID = id
+	return nil
+}
+
+// CreateAll stores {{.CamelCase}}s under random ids through a BulkWriter, which
+// batches the writes. The {{.LowerCase}}s that failed are reported together; the
+// others are stored and carry their ids.
+func (r *{{.PascalCase}}Repository) CreateAll(ctx context.Context, {{.CamelCase}}s []*dto.{{.PascalCase}}) error {
+	writer := r.client.BulkWriter(ctx)
+	ids := make([]uuid.UUID, len({{.CamelCase}}s))
+	jobs := make([]*firestore.BulkWriterJob, len({{.CamelCase}}s))
+	for i, {{.CamelCase}} := range {{.CamelCase}}s {
+		ids[i] = uuid.New()
+		job, err := writer.Create(r.client.Collection({{.PascalCase}}Collection).Doc(ids[i].String()), {{.CamelCase}})
+		if err != nil {
+			writer.End()
+			return err
+		}
+		jobs[i] = job
+	}
+	writer.End()
+
+	var errs []error
+	for i, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		{{.CamelCase}}s[i].ID = ids[i]
+	}
+	return errors.Join(errs...)
+}
+
+// Update overwrites the document of {{.CamelCase}}, which must exist.
+func (r *{{.PascalCase}}Repository) Update(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	doc := r.client.Collection({{.PascalCase}}Collection).Doc({{.CamelCase}}.ID.String())
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := tx.Get(doc); err != nil {
+			return err
+		}
+		return tx.Set(doc, {{.CamelCase}})
+	})
+}
+
+func (r *{{.PascalCase}}Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.client.Collection({{.PascalCase}}Collection).Doc(id.String()).Delete(ctx, firestore.Exists)
+	return err
+}
+
+// FindAll returns one page of {{.LowerCase}}s in id order. It starts after the cursor
+// attached to ctx with dto.WithPageState and sets the cursor of the next page
+// on it; page numbers and sorting are ignored.
+func (r *{{.PascalCase}}Repository) FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+	size := cmp.Or(pagination.PageSize, {{.CamelCase}}DefaultPageSize)
+	query := r.client.Collection({{.PascalCase}}Collection).OrderBy(firestore.DocumentID, firestore.Asc).Limit(size)
+	state := dto.PageStateFromContext(ctx)
+	if state != nil && len(state.Current) > 0 {
+		query = query.StartAfter(string(state.Current))
+	}
+
+	snapshots, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	{{.CamelCase}}s := make([]dto.{{.PascalCase}}, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		{{.CamelCase}}, err := decode{{.PascalCase}}(snapshot)
+		if err != nil {
+			return nil, nil, err
+		}
+		{{.CamelCase}}s = append({{.CamelCase}}s, {{.CamelCase}})
+	}
+	if state != nil && len(snapshots) == size {
+		state.Next = []byte(snapshots[len(snapshots)-1].Ref.ID)
+	}
+	return {{.CamelCase}}s, &pagination, nil
+}
+
+func decode{{.PascalCase}}(snapshot *firestore.DocumentSnapshot) (dto.{{.PascalCase}}, error) {
+	var {{.CamelCase}} dto.{{.PascalCase}}
+	if err := snapshot.DataTo(&{{.CamelCase}}); err != nil {
+		return {{.CamelCase}}, err
+	}
+	id, err := uuid.Parse(snapshot.Ref.ID)
+	if err != nil {
+		return {{.CamelCase}}, err
+	}
+	{{.CamelCase}}.ID = id
+	return {{.CamelCase}}, nil
+}
+`
+
+const spannerRepositoryTemplate = `package spanner
+
+import (
+	"cmp"
+	"context"
+
+	"cloud.google.com/go/spanner"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// {{.PascalCase}}Table holds a row per {{.LowerCase}}, keyed by its id.
+const {{.PascalCase}}Table = "{{.Collection}}"
+
+const (
+	// {{.CamelCase}}DefaultPageSize is the page size of list requests without one.
+	{{.CamelCase}}DefaultPageSize = 20
+	// {{.CamelCase}}MutationBatchSize is how many rows CreateAll commits at once,
+	// well below Spanner's limit of 80,000 mutated cells per commit.
+	{{.CamelCase}}MutationBatchSize = 500
+)
+
+// {{.CamelCase}}Columns are the columns of {{.PascalCase}}Table, read into {{.CamelCase}}Row.
+var {{.CamelCase}}Columns = []string{"id"{{range .DialectColumns}}, "{{.}}"{{end}}}
+
+// {{.CamelCase}}ListQuery reads the page of {{.LowerCase}}s after a cursor on the id.
+const {{.CamelCase}}ListQuery = ` + "`" + `SELECT {{.SpannerColumnList}} FROM {{.Collection}}
+WHERE id > @after ORDER BY id LIMIT @limit` + "`" + `
+
+// {{.CamelCase}}Row is a row of {{.PascalCase}}Table: the id, and the columns encoded by
+// the spanner tags of dto.{{.PascalCase}}, whose ID is tagged spanner:"-".
+type {{.CamelCase}}Row struct {
+	ID string ` + "`" + `spanner:"id"` + "`" + `
+	dto.{{.PascalCase}}
+}
+
+func (row {{.CamelCase}}Row) decode() (dto.{{.PascalCase}}, error) {
+	{{.CamelCase}} := row.{{.PascalCase}}
+	id, err := uuid.Parse(row.ID)
+	if err != nil {
+		return {{.CamelCase}}, err
+	}
+	{{.CamelCase}}.ID = id
+	return {{.CamelCase}}, nil
+}
+
+var _ repository.{{.PascalCase}} = (*{{.PascalCase}}Repository)(nil)
+
+// {{.PascalCase}}Repository stores {{.LowerCase}}s in {{.PascalCase}}Table. Unknown ids are
+// reported as errors with the gRPC code NotFound.
+type {{.PascalCase}}Repository struct {
+	client *spanner.Client
+}
+
+func New{{.PascalCase}}Repository(client *spanner.Client) *{{.PascalCase}}Repository {
+	return &{{.PascalCase}}Repository{client: client}
+}
+
+func (r *{{.PascalCase}}Repository) GetByID(ctx context.Context, id uuid.UUID) (dto.{{.PascalCase}}, error) {
+	row, err := r.client.Single().ReadRow(ctx, {{.PascalCase}}Table, spanner.Key{id.String()}, {{.CamelCase}}Columns)
+	if err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	var scanned {{.CamelCase}}Row
+	if err := row.ToStruct(&scanned); err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	return scanned.decode()
+}
+
+// Create stores {{.CamelCase}} under a random id.
+func (r *{{.PascalCase}}Repository) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	return r.CreateAll(ctx, []*dto.{{.PascalCase}}{ {{- .CamelCase}}})
+}
+
+// CreateAll stores {{.CamelCase}}s under random ids, committing {{.CamelCase}}MutationBatchSize
+// of them at a time. The batches committed before an error stay stored and
+// their {{.LowerCase}}s carry their ids.
+func (r *{{.PascalCase}}Repository) CreateAll(ctx context.Context, {{.CamelCase}}s []*dto.{{.PascalCase}}) error {
+	for start := 0; start < len({{.CamelCase}}s); start += {{.CamelCase}}MutationBatchSize {
+		batch := {{.CamelCase}}s[start:min(start+{{.CamelCase}}MutationBatchSize, len({{.CamelCase}}s))]
+		ids := make([]uuid.UUID, len(batch))
+		mutations := make([]*spanner.Mutation, len(batch))
+		for i, {{.CamelCase}} := range batch {
+			ids[i] = uuid.New()
+			mutation, err := spanner.InsertStruct({{.PascalCase}}Table, {{.CamelCase}}Row{ID: ids[i].String(), {{.PascalCase}}: *{{.CamelCase}}})
+			if err != nil {
+				return err
+			}
+			mutations[i] = mutation
+		}
+		if _, err := r.client.Apply(ctx, mutations); err != nil {
+			return err
+		}
+		for i, {{.CamelCase}} := range batch {
+			{{.CamelCase}}.ID = ids[i]
+		}
+	}
+	return nil
+}
+
+// Update overwrites the row of {{.CamelCase}}, which must exist.
+func (r *{{.PascalCase}}Repository) Update(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	mutation, err := spanner.UpdateStruct({{.PascalCase}}Table, {{.CamelCase}}Row{ID: {{.CamelCase}}.ID.String(), {{.PascalCase}}: *{{.CamelCase}}})
+	if err != nil {
+		return err
+	}
+	_, err = r.client.Apply(ctx, []*spanner.Mutation{mutation})
+	return err
+}
+
+// Delete removes the row of id with DML, as a delete mutation cannot tell
+// whether the row existed.
+func (r *{{.PascalCase}}Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+		deleted, err := tx.Update(ctx, spanner.Statement{
+			SQL:    "DELETE FROM {{.Collection}} WHERE id = @id",
+			Params: map[string]any{"id": id.String()},
+		})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return status.Errorf(codes.NotFound, "{{.LowerCase}} %s not found", id)
+		}
+		return nil
+	})
+	return err
+}
+
+// FindAll returns one page of {{.LowerCase}}s in id order. It starts after the cursor
+// attached to ctx with dto.WithPageState and sets the cursor of the next page
+// on it; page numbers and sorting are ignored.
+func (r *{{.PascalCase}}Repository) FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+	size := cmp.Or(pagination.PageSize, {{.CamelCase}}DefaultPageSize)
+	state := dto.PageStateFromContext(ctx)
+	after := ""
+	if state != nil {
+		after = string(state.Current)
+	}
+
+	var {{.CamelCase}}s []dto.{{.PascalCase}}
+	var last string
+	rows := r.client.Single().Query(ctx, spanner.Statement{
+		SQL:    {{.CamelCase}}ListQuery,
+		Params: map[string]any{"after": after, "limit": int64(size)},
+	})
+	err := rows.Do(func(row *spanner.Row) error {
+		var scanned {{.CamelCase}}Row
+		if err := row.ToStruct(&scanned); err != nil {
+			return err
+		}
+		{{.CamelCase}}, err := scanned.decode()
+		if err != nil {
+			return err
+		}
+		{{.CamelCase}}s = append({{.CamelCase}}s, {{.CamelCase}})
+		last = scanned.ID
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if state != nil && len({{.CamelCase}}s) == size {
+		state.Next = []byte(last)
+	}
+	return {{.CamelCase}}s, &pagination, nil
+}
+`
+
+const spannerTableMigrationTemplate = `CREATE TABLE {{.Collection}} (
+{{- range $i, $column := .SpannerColumns}}{{if $i}},{{end}}
+    {{$column}}
+{{- end}}
+) PRIMARY KEY (id);
+{{- range .Entity.Fields}}
+{{- if .Unique}}
+
+CREATE UNIQUE INDEX {{$.Collection}}_{{.Name}}_key ON {{$.Collection}} ({{.Name}});
+{{- end}}
+{{- end}}
+`
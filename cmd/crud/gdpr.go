@@ -0,0 +1,419 @@
+package crud
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// gdprAnonymize erases a user's data by overwriting the personal data
+	// fields of their rows, which are kept.
+	gdprAnonymize = "anonymize"
+	// gdprDelete erases a user's data by deleting their rows.
+	gdprDelete = "delete"
+)
+
+// gdprSubjectColumn is the column holding the id of the user, the data
+// subject, a row belongs to.
+const gdprSubjectColumn = "user_id"
+
+// UserIDGoType is the Go type of the user_id column: that of the field or
+// belongs_to User relation in the spec, or IDGoType without one.
+func (d TemplateData) UserIDGoType() string {
+	if field, ok := d.Entity.field(gdprSubjectColumn); ok {
+		return fieldTypes[field.Type].Go
+	}
+	return d.IDGoType()
+}
+
+// hasUserID reports whether the spec gives the entity a user_id column.
+func (d TemplateData) hasUserID() bool {
+	if _, ok := d.Entity.field(gdprSubjectColumn); ok {
+		return true
+	}
+	for _, relation := range d.Entity.Relations {
+		if relation.Kind == relationBelongsTo && relation.ForeignKey() == gdprSubjectColumn {
+			return true
+		}
+	}
+	return false
+}
+
+// anonymizedSQL is the SQL value anonymizing overwrites the field with: NULL,
+// or a string unique to the row so unique constraints still hold. ok is false
+// for fields that can take neither.
+func (f FieldSpec) anonymizedSQL() (value string, ok bool) {
+	switch {
+	case f.Nullable:
+		return "NULL", true
+	case fieldTypes[f.Type].Go == "string" && f.Type != "uuid":
+		return "'erased:' || id", true
+	}
+	return "", false
+}
+
+// AnonymizeSQL is the SET list of the statement anonymizing a user's rows.
+func (d TemplateData) AnonymizeSQL() string {
+	var assignments []string
+	for _, field := range d.PIIFields() {
+		value, _ := field.anonymizedSQL()
+		assignments = append(assignments, field.Name+" = "+value)
+	}
+	return strings.Join(assignments, ", ")
+}
+
+// dataSubjectDecorator describes the decorator the data subject repository
+// needs, for the next steps.
+func (d TemplateData) dataSubjectDecorator() string {
+	if d.EncryptsFields() {
+		return fmt.Sprintf(" wrapped with 'repository.New%sDataSubjectEncrypting'", d.PascalCase)
+	}
+	return ""
+}
+
+// validateGDPR checks that the spec lets the entity serve data subject
+// requests: the rows need a user_id and, to be anonymized, personal data
+// fields that can be overwritten.
+func (d TemplateData) validateGDPR() error {
+	if d.GDPR == "" || d.Options.SpecFile == "" {
+		return nil
+	}
+	if !d.hasUserID() {
+		return fmt.Errorf("--gdpr needs a %s field or a belongs_to User relation on %s in the spec", gdprSubjectColumn, d.PascalCase)
+	}
+	switch goType := d.UserIDGoType(); goType {
+	case "int", "int64", "string", "uuid.UUID":
+	default:
+		return fmt.Errorf("--gdpr needs an integer, string or uuid %s on %s, not %s", gdprSubjectColumn, d.PascalCase, goType)
+	}
+	if d.GDPR != gdprAnonymize {
+		return nil
+	}
+	if len(d.PIIFields()) == 0 {
+		return fmt.Errorf("--gdpr %s needs pii or encrypted fields on %s in the spec to overwrite; use --gdpr %s to delete the rows instead", gdprAnonymize, d.PascalCase, gdprDelete)
+	}
+	for _, field := range d.PIIFields() {
+		if _, ok := field.anonymizedSQL(); !ok {
+			return fmt.Errorf("--gdpr %s cannot overwrite %s.%s, which is neither nullable nor text; make it nullable or use --gdpr %s", gdprAnonymize, d.PascalCase, field.Name, gdprDelete)
+		}
+	}
+	return nil
+}
+
+// --- GDPR TEMPLATES ---
+
+// gdprRegistryTemplate is shared by every entity, so it is generated once and
+// left alone on later runs.
+const gdprRegistryTemplate = `package gdpr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// ErrInvalidUserID is returned for a user id an entity cannot parse.
+var ErrInvalidUserID = errors.New("gdpr: invalid user id")
+
+// Subject serves data subject requests for the personal data one entity
+// holds.
+type Subject interface {
+	// Export returns the user's rows, ready to be encoded as JSON.
+	Export(ctx context.Context, userID string) (any, error)
+	// Erase anonymizes or deletes the user's rows. Erasing twice is harmless.
+	Erase(ctx context.Context, userID string) error
+}
+
+// Registry is the central registry of data subject requests. Every entity
+// holding personal data registers its Subject, and access and erasure
+// requests are served across all of them.
+type Registry interface {
+	Register(entity string, subject Subject)
+	Export(ctx context.Context, userID string) (map[string]any, error)
+	Erase(ctx context.Context, userID string) error
+}
+
+type registry struct {
+	mu       sync.RWMutex
+	subjects map[string]Subject
+}
+
+func NewRegistry() Registry {
+	return &registry{subjects: make(map[string]Subject)}
+}
+
+// Register adds the Subject of entity, replacing the one registered under the
+// same name before.
+func (r *registry) Register(entity string, subject Subject) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subjects[entity] = subject
+}
+
+// Export collects the user's data from every registered entity, keyed by the
+// entity's name.
+func (r *registry) Export(ctx context.Context, userID string) (map[string]any, error) {
+	data := make(map[string]any)
+	for entity, subject := range r.registered() {
+		rows, err := subject.Export(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("gdpr: exporting %s: %w", entity, err)
+		}
+		data[entity] = rows
+	}
+	return data, nil
+}
+
+// Erase erases the user's data from every registered entity. It goes on past
+// failures, so one entity cannot hold up the others, and returns them joined;
+// the request can then be retried as a whole.
+func (r *registry) Erase(ctx context.Context, userID string) error {
+	var errs []error
+	for entity, subject := range r.registered() {
+		if err := subject.Erase(ctx, userID); err != nil {
+			errs = append(errs, fmt.Errorf("gdpr: erasing %s: %w", entity, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *registry) registered() map[string]Subject {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.subjects)
+}
+`
+
+const dataSubjectInterfaceTemplate = `package repository
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if eq .UserIDGoType "uuid.UUID"}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// {{.PascalCase}}DataSubject reads and erases the {{.LowerCase}}s of a user, those whose
+// user_id is theirs, for data subject requests.
+type {{.PascalCase}}DataSubject interface {
+	FindByUser(ctx context.Context, userID {{.UserIDGoType}}) ([]dto.{{.PascalCase}}, error)
+	// EraseByUser {{if eq .GDPR "anonymize"}}overwrites the personal data fields of{{else}}deletes{{end}} the user's {{.LowerCase}}s and
+	// returns how many it erased.
+	EraseByUser(ctx context.Context, userID {{.UserIDGoType}}) (int64, error)
+}
+`
+
+const dataSubjectRepositoryTemplate = `package postgres
+
+import (
+	"context"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+{{- if eq .UserIDGoType "uuid.UUID"}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+type {{.CamelCase}}DataSubject struct {
+	db  ports.Database
+	log ports.LoggerWithTraceID
+}
+
+func New{{.PascalCase}}DataSubject(db ports.Database, log ports.LoggerWithTraceID) repository.{{.PascalCase}}DataSubject {
+	return &{{.CamelCase}}DataSubject{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *{{.CamelCase}}DataSubject) FindByUser(ctx context.Context, userID {{.UserIDGoType}}) ([]dto.{{.PascalCase}}, error) {
+	{{.CamelCase}}s := []dto.{{.PascalCase}}{}
+	// TODO: Select every {{.SnakeCase}} row of the user into {{.CamelCase}}s.
+	// Example:
+	// SELECT * FROM {{.TableIdent}} WHERE user_id = $1 ORDER BY id
+	return {{.CamelCase}}s, nil
+}
+
+func (r *{{.CamelCase}}DataSubject) EraseByUser(ctx context.Context, userID {{.UserIDGoType}}) (int64, error) {
+{{- if eq .GDPR "anonymize"}}
+	// TODO: Overwrite the personal data of the user's rows and return the
+	// number of rows affected. Clear user_id as well where the column allows,
+	// as rows still linked to the user are only pseudonymous.
+	// Example:
+	// UPDATE {{.TableIdent}} SET {{with .AnonymizeSQL}}{{.}}{{else}}email = NULL{{end}} WHERE user_id = $1
+{{- else}}
+	// TODO: Delete the user's rows and return the number of rows affected.
+	// Example:
+	// DELETE FROM {{.TableIdent}} WHERE user_id = $1
+{{- end}}
+	return 0, nil
+}
+`
+
+const dataSubjectServiceTemplate = `package service
+
+import (
+	"context"
+	"fmt"
+{{- if or (eq .UserIDGoType "int64") (eq .UserIDGoType "int")}}
+	"strconv"
+{{- end}}
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if .WriteOnlyFields}}
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- end}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/gdpr"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+{{- if eq .UserIDGoType "uuid.UUID"}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+type {{.CamelCase}}DataSubjectService struct {
+	log        ports.LoggerWithTraceID
+	repository repository.{{.PascalCase}}DataSubject
+}
+
+// New{{.PascalCase}}DataSubjectService serves data subject requests for the {{.LowerCase}}s
+// of a user. Register it with the gdpr.Registry under "{{.PascalCase}}".
+func New{{.PascalCase}}DataSubjectService(log ports.LoggerWithTraceID, repository repository.{{.PascalCase}}DataSubject) gdpr.Subject {
+	return &{{.CamelCase}}DataSubjectService{
+		log:        log,
+		repository: repository,
+	}
+}
+
+func (s *{{.CamelCase}}DataSubjectService) Export(ctx context.Context, userID string) (any, error) {
+	id, err := parse{{.PascalCase}}UserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	{{.CamelCase}}s, err := s.repository.FindByUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+{{- if .WriteOnlyFields}}
+	// Write-only fields are never returned, not even to their subject.
+	for i := range {{.CamelCase}}s {
+{{- range .WriteOnlyFields}}
+		{{$.CamelCase}}s[i].{{.GoName}} = dto.{{$.PascalCase}}{}.{{.GoName}}
+{{- end}}
+	}
+{{- end}}
+	return {{.CamelCase}}s, nil
+}
+
+// Erase {{if eq .GDPR "anonymize"}}overwrites the personal data fields of{{else}}deletes{{end}} the user's {{.LowerCase}}s. It goes
+// straight to the repository, so the hooks of the {{.PascalCase}} service do not run.
+func (s *{{.CamelCase}}DataSubjectService) Erase(ctx context.Context, userID string) error {
+	id, err := parse{{.PascalCase}}UserID(userID)
+	if err != nil {
+		return err
+	}
+	erased, err := s.repository.EraseByUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.log.Info(ctx, fmt.Sprintf("gdpr: {{if eq .GDPR "anonymize"}}anonymized{{else}}deleted{{end}} %d {{.LowerCase}}s of user %s", erased, userID))
+	return nil
+}
+
+func parse{{.PascalCase}}UserID(userID string) ({{.UserIDGoType}}, error) {
+{{- if eq .UserIDGoType "string"}}
+	if userID == "" {
+		return "", fmt.Errorf("%w: empty", gdpr.ErrInvalidUserID)
+	}
+	return userID, nil
+{{- else}}
+{{- if eq .UserIDGoType "int64"}}
+	id, err := strconv.ParseInt(userID, 10, 64)
+{{- else if eq .UserIDGoType "int"}}
+	id, err := strconv.Atoi(userID)
+{{- else}}
+	id, err := uuid.Parse(userID)
+{{- end}}
+	if err != nil {
+		return id, fmt.Errorf("%w %q", gdpr.ErrInvalidUserID, userID)
+	}
+	return id, nil
+{{- end}}
+}
+`
+
+const dataSubjectControllerTemplate = `package {{.LowerCase}}
+
+import (
+	"errors"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/gdpr"
+{{- if or .ContentNegotiation .Envelope.IsSet}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+{{- end}}
+	"go.elastic.co/apm"
+)
+
+// {{.PascalCase}}Export serves the {{.LowerCase}}s of a user for data subject access
+// requests.
+type {{.PascalCase}}Export interface {
+	Export{{.PascalCase}}s(c *ports.HttpContext) error
+}
+
+type {{.CamelCase}}ExportController struct {
+	subject gdpr.Subject
+	log     ports.LoggerWithTraceID
+}
+
+func NewExport(log ports.LoggerWithTraceID, subject gdpr.Subject) {{.PascalCase}}Export {
+	return &{{.CamelCase}}ExportController{
+		subject: subject,
+		log:     log,
+	}
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Export a user's {{.PascalCase}}s
+// @Description	This route will return every {{.LowerCase}} of a user for a data subject access request
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"User ID"
+// @Success		200	{{.SwagResponse (print "[]dto." .PascalCase)}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			/api/v1/users/{id}/{{.KebabCase}}/export [get]
+{{else -}}
+// Export{{.PascalCase}}s handles GET /api/v1/users/{id}/{{.KebabCase}}/export.
+{{end -}}
+func (ctrl *{{.CamelCase}}ExportController) Export{{.PascalCase}}s(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Export{{.PascalCase}}s", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	{{.CamelCase}}s, err := ctrl.subject.Export(ctx, c.Params("id"))
+	if errors.Is(err, gdpr.ErrInvalidUserID) {
+		return appErr.NewBadRequestErr(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, {{else}}c.JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   {{.CamelCase}}s,
+	}{{if .Envelope.IsSet}}){{end}})
+}
+`
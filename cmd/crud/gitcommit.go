@@ -0,0 +1,62 @@
+package crud
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const gitBranchPrefix = "crudgen/"
+
+// gitBranchName is the branch --git-commit generates the entity on.
+func gitBranchName(data TemplateData) string {
+	return gitBranchPrefix + data.KebabCase
+}
+
+// gitCreateBranch creates the branch and switches to it, carrying over the
+// working tree. It fails if the branch already exists, so a second run never
+// piles onto a branch under review.
+func gitCreateBranch(branch string) error {
+	return runGit("switch", "--create", branch)
+}
+
+// gitCommitGenerated stages and commits paths only, leaving whatever else is
+// staged or modified in the working tree out of the commit.
+func gitCommitGenerated(paths []string, message string) error {
+	if err := runGit(append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+	return runGit(append([]string{"commit", "--quiet", "--message", message, "--"}, paths...)...)
+}
+
+// gitCommitMessage describes the run in the commit, with trailers recording
+// the generator, the crud invocation and the hash of the spec it ran from:
+// the spec file if given, otherwise the invocation itself.
+func gitCommitMessage(data TemplateData, spec string) string {
+	specHash := hashContent([]byte(spec))
+	if data.SpecFile != "" {
+		if content, err := readSpec(data.SpecFile); err == nil {
+			specHash = hashContent(content)
+		}
+	}
+	var message strings.Builder
+	fmt.Fprintf(&message, "Generate CRUD for %s\n\n", data.PascalCase)
+	fmt.Fprintf(&message, "Generated with 'gocrud-gen crud %s'.\n\n", spec)
+	fmt.Fprintf(&message, "Generator: gocrud-gen %s\n", version)
+	if data.SpecFile != "" {
+		fmt.Fprintf(&message, "Spec-File: %s\n", data.SpecFile)
+	}
+	fmt.Fprintf(&message, "Spec-Hash: sha256:%s\n", specHash)
+	return message.String()
+}
+
+func runGit(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running git %s: %w", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package crud
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	graphDOT     = "dot"
+	graphMermaid = "mermaid"
+)
+
+var graphFormat string
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [path]",
+	Short: "Prints the controller, service and repository graph of the generated entities.",
+	Long: `This command scans the given directory (the current one by default) for files
+generated by crud, using their headers as the manifest of generated entities,
+and prints how each entity's layers depend on each other as a Graphviz DOT or
+Mermaid graph. For example:
+
+go run . graph --format mermaid > docs/layers.mmd`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root := "."
+		if len(args) == 1 {
+			root = args[0]
+		}
+		return printLayerGraph(root)
+	},
+}
+
+func init() {
+	graphCmd.Flags().StringVar(&graphFormat, "format", graphDOT, "Graph syntax: 'dot' or 'mermaid'")
+	rootCmd.AddCommand(graphCmd)
+}
+
+// graphLayers are the generated layers in call order, each with the path of
+// its file relative to the project root. The decorators come first; an entity
+// has one of the repositories, depending on its --db.
+var graphLayers = []struct {
+	Name  string
+	Label string
+	Path  func(camel string) string
+}{
+	{"Controller", "controller", func(camel string) string {
+		return filepath.Join("internal/transport/http/rest/controller/v1", camel, "controller.go")
+	}},
+	{"Service", "service", func(camel string) string { return filepath.Join("internal/service", camel+".go") }},
+	{"ResilientRepository", "resilient repository", func(camel string) string { return filepath.Join("internal/transport/repository", camel+"Resilient.go") }},
+	{"EncryptingRepository", "encrypting repository", func(camel string) string {
+		return filepath.Join("internal/transport/repository", camel+"Encrypting.go")
+	}},
+	{"ReadSplitRepository", "read split repository", func(camel string) string {
+		return filepath.Join("internal/transport/repository", camel+"ReadSplit.go")
+	}},
+	{"RetryingRepository", "retrying repository", func(camel string) string {
+		return filepath.Join("internal/transport/repository", camel+"Retrying.go")
+	}},
+	{"Repository", "repository", func(camel string) string { return filepath.Join("internal/transport/repository/postgres", camel+".go") }},
+	{"ClickHouseRepository", "ClickHouse repository", func(camel string) string {
+		return filepath.Join("internal/transport/repository/clickhouse", camel+".go")
+	}},
+	{"CassandraRepository", "Cassandra repository", func(camel string) string {
+		return filepath.Join("internal/transport/repository/cassandra", camel+".go")
+	}},
+	{"SQLServerRepository", "SQL Server repository", func(camel string) string {
+		return filepath.Join("internal/transport/repository", dbSQLServer, camel+".go")
+	}},
+	{"OracleRepository", "Oracle repository", func(camel string) string {
+		return filepath.Join("internal/transport/repository", dbOracle, camel+".go")
+	}},
+	{"FirestoreRepository", "Firestore repository", func(camel string) string {
+		return filepath.Join("internal/transport/repository/firestore", camel+".go")
+	}},
+	{"SpannerRepository", "Spanner repository", func(camel string) string {
+		return filepath.Join("internal/transport/repository/spanner", camel+".go")
+	}},
+	{"BoltRepository", "bolt repository", func(camel string) string { return filepath.Join("internal/transport/repository/bolt", camel+".go") }},
+}
+
+// graphNode is one generated layer of an entity.
+type graphNode struct {
+	ID    string
+	Label string
+}
+
+type graphEdge struct {
+	From, To graphNode
+}
+
+func printLayerGraph(root string) error {
+	var tmplStr string
+	switch graphFormat {
+	case graphDOT:
+		tmplStr = dotGraphTemplate
+	case graphMermaid:
+		tmplStr = mermaidGraphTemplate
+	default:
+		return fmt.Errorf("invalid --format %q: must be %q or %q", graphFormat, graphDOT, graphMermaid)
+	}
+
+	generated := make(map[string]bool)
+	// entities holds the layout each entity was generated with, so the layers
+	// of a --module-group are looked for in its feature directory.
+	entities := make(map[string]TemplateData)
+	err := walkGenerated(root, func(path string, header fileHeader, body []byte) error {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		generated[rel] = true
+		if name, _, _ := strings.Cut(header.Run, " "); entityNamePattern.MatchString(name) {
+			data := entities[name]
+			if group := header.flag("module-group"); group != "" {
+				data.ModuleGroup = group
+			}
+			entities[name] = data
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(entities))
+	for name := range entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var edges []graphEdge
+	for _, name := range names {
+		camel := strings.ToLower(name[:1]) + name[1:]
+		var previous *graphNode
+		for _, layer := range graphLayers {
+			if !generated[entities[name].groupPath(layer.Path(camel))] {
+				continue
+			}
+			node := graphNode{ID: name + layer.Name, Label: name + " " + layer.Label}
+			if previous != nil {
+				edges = append(edges, graphEdge{From: *previous, To: node})
+			}
+			previous = &node
+		}
+	}
+
+	tmpl, err := template.New(graphFormat).Parse(tmplStr)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(os.Stdout, edges)
+}
+
+// --- GRAPH TEMPLATES ---
+
+const dotGraphTemplate = `digraph layers {
+    rankdir=LR;
+    node [shape=box];
+{{- range .}}
+    "{{.From.Label}}" -> "{{.To.Label}}";
+{{- end}}
+}
+`
+
+const mermaidGraphTemplate = `flowchart LR
+{{- range .}}
+    {{.From.ID}}["{{.From.Label}}"] --> {{.To.ID}}["{{.To.Label}}"]
+{{- end}}
+`
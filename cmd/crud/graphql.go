@@ -0,0 +1,310 @@
+package crud
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	transportREST    = "rest"
+	transportGraphQL = "graphql"
+)
+
+// GraphQL reports whether the entity is served over GraphQL too.
+func (d TemplateData) GraphQL() bool {
+	return d.Transport == transportGraphQL
+}
+
+// graphQLName turns a snake_case name into the camelCase GraphQL one, e.g.
+// customerId for customer_id, which gqlgen binds to CustomerID.
+func graphQLName(snake string) string {
+	words := strings.Split(snake, "_")
+	for i, word := range words[1:] {
+		if word != "" {
+			words[i+1] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// GraphQLName is the name of the field in the GraphQL schema.
+func (f FieldSpec) GraphQLName() string {
+	return graphQLName(f.Name)
+}
+
+// GraphQLType is the GraphQL type of the field, non-null unless it is
+// nullable.
+func (f FieldSpec) GraphQLType() string {
+	var name string
+	switch f.Type {
+	case "int", "int64":
+		name = "Int"
+	case "float":
+		name = "Float"
+	case "bool":
+		name = "Boolean"
+	case "time":
+		name = "Time"
+	case "uuid":
+		name = "ID"
+	default:
+		name = "String"
+	}
+	if f.Nullable {
+		return name
+	}
+	return name + "!"
+}
+
+// GraphQLFields lists the fields the GraphQL type exposes: those returned as
+// stored, but for json fields, which no built-in scalar carries.
+func (d TemplateData) GraphQLFields() []FieldSpec {
+	return d.fieldsWith(func(field FieldSpec) bool { return field.Visibility == "" && field.Type != "json" })
+}
+
+// GraphQLName is the name of the relation's field in the GraphQL schema, e.g.
+// customer or orderItems.
+func (l RelationLoader) GraphQLName() string {
+	return graphQLName(l.JSONName())
+}
+
+// GraphQLType is the GraphQL type of the relation's field.
+func (l RelationLoader) GraphQLType() string {
+	if l.Many() {
+		return "[" + l.Entity + "!]!"
+	}
+	return l.Entity
+}
+
+// GraphQLLoaders lists the relations the GraphQL type resolves through
+// dataloaders, which batch the generated relation loaders.
+func (d TemplateData) GraphQLLoaders() []RelationLoader {
+	if !d.LoadsRelations() {
+		return nil
+	}
+	return d.RelationLoaders()
+}
+
+// relatedEntities names the entities of the GraphQL relations, quoted for the
+// next steps.
+func (d TemplateData) relatedEntities() string {
+	var names []string
+	for _, loader := range d.GraphQLLoaders() {
+		if name := "'" + loader.Entity + "'"; !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// --- GRAPHQL TEMPLATES ---
+
+const graphQLSchemaTemplate = `# {{.PascalCase}} is served by the resolvers of
+# internal/transport/graphql/{{.CamelCase}}.go. Fields hidden from responses are left
+# out, as are json fields.
+type {{.PascalCase}} {
+  id: ID!
+{{- range .GraphQLFields}}
+  {{.GraphQLName}}: {{.GraphQLType}}
+{{- end}}
+{{- range .GraphQLLoaders}}
+  {{.GraphQLName}}: {{.GraphQLType}}
+{{- end}}
+}
+
+type {{.PascalCase}}Page {
+  items: [{{.PascalCase}}!]!
+  page: Int!
+  pageSize: Int!
+  totalRows: Int!
+}
+
+extend type Query {
+  {{.CamelCase}}(id: ID!): {{.PascalCase}}
+  {{.CamelCase}}s(page: Int! = 1, pageSize: Int! = {{or .Pagination.DefaultPageSize 10}}): {{.PascalCase}}Page!
+}
+`
+
+// graphQLScalarsTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const graphQLScalarsTemplate = `# Scalars shared by the entity schemas; gqlgen implements Time.
+scalar Time
+`
+
+const graphQLResolverTemplate = `package graphql
+
+import (
+	"context"
+	"fmt"
+{{- if not .UUID}}
+	"strconv"
+{{- end}}
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"{{.ServiceImport}}"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// {{.PascalCase}}Resolver resolves the queries and fields of graph/{{.SnakeCase}}.graphqls
+// with the {{.LowerCase}} service. The resolvers gqlgen generates delegate to it, with
+// {{.PascalCase}} bound to dto.{{.PascalCase}} in gqlgen.yml.
+type {{.PascalCase}}Resolver struct {
+	{{.CamelCase}}Service service.{{.PascalCase}}
+}
+
+func New{{.PascalCase}}Resolver({{.CamelCase}}Service service.{{.PascalCase}}) *{{.PascalCase}}Resolver {
+	return &{{.PascalCase}}Resolver{
+		{{.CamelCase}}Service: {{.CamelCase}}Service,
+	}
+}
+
+// {{.PascalCase}} resolves the {{.CamelCase}} query.
+func (r *{{.PascalCase}}Resolver) {{.PascalCase}}(ctx context.Context, id string) (*dto.{{.PascalCase}}, error) {
+	{{.CamelCase}}ID, err := parse{{.PascalCase}}ID(id)
+	if err != nil {
+		return nil, err
+	}
+	{{.CamelCase}}, err := r.{{.CamelCase}}Service.Get{{.PascalCase}}ByID(ctx, {{.CamelCase}}ID)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.CamelCase}}, nil
+}
+
+// {{.PascalCase}}Page is the result of the {{.CamelCase}}s query.
+type {{.PascalCase}}Page struct {
+	Items     []dto.{{.PascalCase}}
+	Page      int
+	PageSize  int
+	TotalRows int64
+}
+
+// {{.PascalCase}}s resolves the {{.CamelCase}}s query.
+func (r *{{.PascalCase}}Resolver) {{.PascalCase}}s(ctx context.Context, page, pageSize int) (*{{.PascalCase}}Page, error) {
+	if page < 1 || pageSize < 1 {
+		return nil, appErr.NewBadRequestErr(fmt.Errorf("page and pageSize must be positive"))
+	}
+{{- if .Pagination.MaxPageSize}}
+	pageSize = min(pageSize, {{.Pagination.MaxPageSize}})
+{{- end}}
+
+	{{.CamelCase}}s, pagination, err := r.{{.CamelCase}}Service.GetPaginated{{.PascalCase}}s(ctx, dto.Pagination{Page: page, PageSize: pageSize})
+	if err != nil {
+		return nil, err
+	}
+	result := &{{.PascalCase}}Page{Items: {{.CamelCase}}s, Page: page, PageSize: pageSize}
+	if pagination != nil {
+		result.TotalRows = pagination.TotalRows
+	}
+	return result, nil
+}
+{{- range .GraphQLLoaders}}
+
+// {{.Field}} resolves the {{.GraphQLName}} field. Rows the list has loaded already are
+// returned as they are; the others are batched by {{$.PascalCase}}Loaders, so a page of
+// {{$.LowerCase}}s costs one query rather than one per {{$.LowerCase}}.
+func (r *{{$.PascalCase}}Resolver) {{.Field}}(ctx context.Context, obj *dto.{{$.PascalCase}}) ({{if .Many}}[]dto.{{.Entity}}{{else}}*dto.{{.Entity}}{{end}}, error) {
+	if obj.{{.Field}} != nil {
+		return obj.{{.Field}}, nil
+	}
+	loaders, err := {{$.CamelCase}}LoadersFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return loaders.{{.Field}}.Load(ctx, obj.{{.Key}})()
+}
+{{- end}}
+
+func parse{{.PascalCase}}ID(id string) ({{.IDGoType}}, error) {
+{{- if .UUID}}
+	parsed, err := uuid.Parse(id)
+{{- else}}
+	parsed, err := strconv.ParseInt(id, 10, 64)
+{{- end}}
+	if err != nil {
+		return parsed, appErr.NewBadRequestErr(fmt.Errorf("invalid {{.LowerCase}} id %q", id))
+	}
+	return parsed, nil
+}
+`
+
+const graphQLLoadersTemplate = `package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// {{.PascalCase}}Loaders batch the relations the {{.PascalCase}} fields resolve, collecting
+// the keys a query asks for and loading them with one call of the
+// repository.{{.PascalCase}}Relations loader per relation. They cache what they
+// load, so each request gets its own, see {{.PascalCase}}LoadersMiddleware.
+type {{.PascalCase}}Loaders struct {
+{{- range .GraphQLLoaders}}
+	{{.Field}} *dataloader.Loader[{{$.IDGoType}}, {{if .Many}}[]dto.{{.Entity}}{{else}}*dto.{{.Entity}}{{end}}]
+{{- end}}
+}
+
+func New{{.PascalCase}}Loaders(relations repository.{{.PascalCase}}Relations) *{{.PascalCase}}Loaders {
+	return &{{.PascalCase}}Loaders{
+{{- range .GraphQLLoaders}}
+		{{.Field}}: dataloader.NewBatchedLoader(func(ctx context.Context, keys []{{$.IDGoType}}) []*dataloader.Result[{{if .Many}}[]dto.{{.Entity}}{{else}}*dto.{{.Entity}}{{end}}] {
+			// The relation loaders only read the keys of the {{$.LowerCase}}s.
+			{{$.CamelCase}}s := make([]dto.{{$.PascalCase}}, len(keys))
+			for i, key := range keys {
+				{{$.CamelCase}}s[i].{{.Key}} = key
+			}
+			{{.Var}}, err := relations.{{.Method}}(ctx, {{$.CamelCase}}s)
+			results := make([]*dataloader.Result[{{if .Many}}[]dto.{{.Entity}}{{else}}*dto.{{.Entity}}{{end}}], len(keys))
+			for i, key := range keys {
+				result := &dataloader.Result[{{if .Many}}[]dto.{{.Entity}}{{else}}*dto.{{.Entity}}{{end}}]{Error: err}
+{{- if .Many}}
+				if err == nil {
+					result.Data = {{.Var}}[key]
+				}
+{{- else}}
+				if {{.Single}}, ok := {{.Var}}[key]; ok && err == nil {
+					result.Data = &{{.Single}}
+				}
+{{- end}}
+				results[i] = result
+			}
+			return results
+		}),
+{{- end}}
+	}
+}
+
+type {{.CamelCase}}LoadersKey struct{}
+
+// {{.PascalCase}}LoadersMiddleware gives every request to next its own {{.PascalCase}}Loaders.
+// Wrap the GraphQL handler with it.
+func {{.PascalCase}}LoadersMiddleware(relations repository.{{.PascalCase}}Relations, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), {{.CamelCase}}LoadersKey{}, New{{.PascalCase}}Loaders(relations))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func {{.CamelCase}}LoadersFrom(ctx context.Context) (*{{.PascalCase}}Loaders, error) {
+	loaders, ok := ctx.Value({{.CamelCase}}LoadersKey{}).(*{{.PascalCase}}Loaders)
+	if !ok {
+		return nil, errors.New("no {{.PascalCase}}Loaders on the request context; wrap the GraphQL handler with {{.PascalCase}}LoadersMiddleware")
+	}
+	return loaders, nil
+}
+`
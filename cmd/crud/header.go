@@ -0,0 +1,275 @@
+package crud
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/pflag"
+)
+
+// version is stamped into every generated file. Release builds override it with
+// -ldflags "-X github.com/thisPeyman/gocrud-gen/cmd/crud.version=vX.Y.Z".
+var version = "v0.0.0-dev"
+
+// headerWarning ends every header. Regenerating a file replaces all of it but
+// its marked regions, see keepRegions.
+const headerWarning = "EDITS OUTSIDE MARKED REGIONS WILL BE OVERWRITTEN."
+
+var headerPattern = regexp.MustCompile(`^(?://|--|#|%%|') Code generated by gocrud-gen (\S+) from spec ("(?:[^"\\]|\\.)*") \(sha256 ([0-9a-f]{64})\) by ("(?:[^"\\]|\\.)*"); hash ([0-9a-f]{64})\. ` +
+	regexp.QuoteMeta(headerWarning) + `$`)
+
+// regionPattern matches the lines that begin and end a marked region, whose
+// content is the user's: it is left out of the hash and kept when the file is
+// regenerated. The markers are the same as those of the Makefile block.
+var regionPattern = regexp.MustCompile(`^\s*(?://|--|#|%%|')\s*gocrud-gen:(begin|end) (\S+)\s*$`)
+
+// fileHeader is the generation metadata recorded on the first line of every generated file.
+type fileHeader struct {
+	Version string
+	// Spec is the spec file the run read, empty for runs without one, and
+	// SpecHash the hash of its content then, so drift can tell it changed.
+	Spec     string
+	SpecHash string
+	// Run is the equivalent crud invocation, so a header alone is enough to
+	// reproduce the file it sits on.
+	Run  string
+	Hash string
+}
+
+// newFileHeader returns the header of the files a run generates from the spec
+// file specFile, empty without one.
+func newFileHeader(specFile, run string) fileHeader {
+	var content []byte
+	if specFile != "" {
+		// The run has loaded the spec already, so it can be read again; stdin
+		// is cached.
+		content, _ = readSpec(specFile)
+	}
+	return fileHeader{Spec: specFile, SpecHash: hashContent(content), Run: run}
+}
+
+// generationRun describes the inputs of a run as the equivalent crud invocation.
+func generationRun(entityName string, flags *pflag.FlagSet) string {
+	parts := []string{entityName}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			parts = append(parts, fmt.Sprintf("--%s=%s", flag.Name, flag.Value))
+		}
+	})
+	return strings.Join(parts, " ")
+}
+
+// flag returns the value the header's run gives the crud flag name, empty
+// when the run left it unset.
+func (h fileHeader) flag(name string) string {
+	for _, part := range strings.Fields(h.Run) {
+		if value, ok := strings.CutPrefix(part, "--"+name+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func commentPrefix(path string) string {
+	switch filepath.Ext(path) {
+	case ".sql":
+		return "--"
+	case ".http", ".yaml", ".yml":
+		return "#"
+	case ".mmd":
+		return "%%"
+	case ".puml":
+		return "'"
+	default:
+		return "//"
+	}
+}
+
+// isComment reports whether line starts with any marker commentPrefix returns.
+func isComment(line string) bool {
+	for _, prefix := range []string{"//", "--", "#", "%%", "'"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func hashContent(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// bodyHash is the hash recorded in the header of the file at path. Go files
+// are hashed as gofmt formats them, so formatting on save is not an edit, and
+// the content of marked regions is left out.
+func bodyHash(path string, body []byte) string {
+	if filepath.Ext(path) == ".go" {
+		if formatted, err := format.Source(body); err == nil {
+			body = formatted
+		}
+	}
+	return hashContent(withoutRegions(body))
+}
+
+// matches reports whether body, read from the file at path, is the one the
+// header was generated with, edits to its marked regions aside.
+func (h fileHeader) matches(path string, body []byte) bool {
+	return bodyHash(path, body) == h.Hash
+}
+
+// withHeader prefixes body with the generation header and a blank line, which
+// keeps the header from being read as a Go package doc comment.
+func withHeader(path string, header fileHeader, body []byte) []byte {
+	line := fmt.Sprintf("%s Code generated by gocrud-gen %s from spec %q (sha256 %s) by %q; hash %s. %s\n\n",
+		commentPrefix(path), version, header.Spec, header.SpecHash, header.Run, bodyHash(path, body), headerWarning)
+	return append([]byte(line), body...)
+}
+
+// regions returns the content of every marked region of body by name.
+// Regions missing their end are ignored.
+func regions(body []byte) map[string][]byte {
+	found := map[string][]byte{}
+	var name string
+	var start, offset int
+	for _, line := range bytes.SplitAfter(body, []byte("\n")) {
+		offset += len(line)
+		match := regionPattern.FindSubmatch(bytes.TrimSuffix(line, []byte("\n")))
+		switch {
+		case match == nil:
+		case string(match[1]) == "begin":
+			name, start = string(match[2]), offset
+		case name == string(match[2]):
+			found[name] = body[start : offset-len(line)]
+			name = ""
+		}
+	}
+	return found
+}
+
+// withoutRegions returns body with the content of its marked regions removed,
+// keeping their markers.
+func withoutRegions(body []byte) []byte {
+	return replaceRegions(body, func(string, []byte) []byte { return nil })
+}
+
+// keepRegions returns generated with the content of the marked regions that
+// previous, the file it regenerates, has too, so edits inside them survive.
+func keepRegions(previous, generated []byte) []byte {
+	kept := regions(previous)
+	return replaceRegions(generated, func(name string, content []byte) []byte {
+		if previous, ok := kept[name]; ok {
+			return previous
+		}
+		return content
+	})
+}
+
+// replaceRegions returns body with the content of every marked region
+// replaced by what replace returns for it.
+func replaceRegions(body []byte, replace func(name string, content []byte) []byte) []byte {
+	found := regions(body)
+	if len(found) == 0 {
+		return body
+	}
+	var out bytes.Buffer
+	var name string
+	for _, line := range bytes.SplitAfter(body, []byte("\n")) {
+		match := regionPattern.FindSubmatch(bytes.TrimSuffix(line, []byte("\n")))
+		switch {
+		case name != "":
+			if match != nil && string(match[1]) == "end" && string(match[2]) == name {
+				out.Write(line)
+				name = ""
+			}
+			continue
+		case match != nil && string(match[1]) == "begin":
+			if content, ok := found[string(match[2])]; ok {
+				name = string(match[2])
+				out.Write(line)
+				out.Write(replace(name, content))
+				continue
+			}
+		}
+		out.Write(line)
+	}
+	return out.Bytes()
+}
+
+// renderBanner renders the configured file header with the entity data and
+// comments every line in the syntax of the file at path. Lines that already
+// start with the comment marker, such as linter directives, are kept as is.
+func renderBanner(path, bannerTemplate string, data TemplateData) ([]byte, error) {
+	if bannerTemplate == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New("file_header").Parse(bannerTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+
+	prefix := commentPrefix(path)
+	var banner bytes.Buffer
+	for _, line := range strings.Split(strings.TrimRight(rendered.String(), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, prefix):
+			banner.WriteString(line)
+		case line == "":
+			banner.WriteString(prefix)
+		default:
+			banner.WriteString(prefix + " " + line)
+		}
+		banner.WriteString("\n")
+	}
+	banner.WriteString("\n")
+	return banner.Bytes(), nil
+}
+
+// parseHeader splits a generated file into its header and the body the hash was
+// computed over. The header may follow a configured banner, so the whole leading
+// comment block is searched. ok is false for files without a gocrud-gen header.
+// CRLF line endings, as checked out on Windows, are read as LF.
+func parseHeader(content []byte) (header fileHeader, body []byte, ok bool) {
+	rest := normalizeLineEndings(content)
+	for len(rest) > 0 {
+		line, next, _ := bytes.Cut(rest, []byte("\n"))
+		if match := headerPattern.FindSubmatch(line); match != nil {
+			spec, err := strconv.Unquote(string(match[2]))
+			if err != nil {
+				return fileHeader{}, nil, false
+			}
+			run, err := strconv.Unquote(string(match[4]))
+			if err != nil {
+				return fileHeader{}, nil, false
+			}
+			header = fileHeader{
+				Version:  string(match[1]),
+				Spec:     spec,
+				SpecHash: string(match[3]),
+				Run:      run,
+				Hash:     string(match[5]),
+			}
+			return header, bytes.TrimPrefix(next, []byte("\n")), true
+		}
+
+		trimmed := string(bytes.TrimSpace(line))
+		if trimmed != "" && !isComment(trimmed) {
+			break
+		}
+		rest = next
+	}
+	return fileHeader{}, nil, false
+}
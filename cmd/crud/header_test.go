@@ -0,0 +1,171 @@
+package crud
+
+import (
+	"strings"
+	"testing"
+)
+
+const headerTestBody = `package widget
+
+type createWidgetRequest struct {
+	// gocrud-gen:begin create-request-fields
+	// TODO: Add fields for creating a new Widget.
+	// gocrud-gen:end create-request-fields
+}
+`
+
+func TestParseHeader(t *testing.T) {
+	header := fileHeader{Spec: "specs/widget.yaml", SpecHash: hashContent([]byte("entity: Widget\n")), Run: `Widget --db="mysql"`}
+	generated := string(withHeader("widget.go", header, []byte(headerTestBody)))
+	tests := []struct {
+		name    string
+		content string
+		wantOK  bool
+	}{
+		{
+			name:    "header",
+			content: generated,
+			wantOK:  true,
+		},
+		{
+			name:    "after a banner",
+			content: "// Copyright Example Ltd.\n//\n// Licensed under MIT.\n\n" + generated,
+			wantOK:  true,
+		},
+		{
+			name:    "CRLF line endings",
+			content: strings.ReplaceAll(generated, "\n", "\r\n"),
+			wantOK:  true,
+		},
+		{
+			name:    "no header",
+			content: headerTestBody,
+		},
+		{
+			name:    "header after code",
+			content: "package widget\n\n" + generated,
+		},
+		{
+			name:    "other warning",
+			content: strings.Replace(generated, headerWarning, "REGENERATING OVERWRITES EDITS TO THIS FILE.", 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, body, ok := parseHeader([]byte(tt.content))
+			if ok != tt.wantOK {
+				t.Fatalf("parseHeader() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.Version != version || got.Spec != header.Spec || got.SpecHash != header.SpecHash || got.Run != header.Run {
+				t.Errorf("parseHeader() = %+v, want %+v", got, header)
+			}
+			if string(body) != headerTestBody {
+				t.Errorf("parseHeader() body = %q, want %q", body, headerTestBody)
+			}
+		})
+	}
+}
+
+func TestFileHeaderMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		// edit changes the generated body before it is checked.
+		edit func(body string) string
+		want bool
+	}{
+		{
+			name: "unchanged",
+			path: "widget.go",
+			edit: func(body string) string { return body },
+			want: true,
+		},
+		{
+			name: "reformatted Go",
+			path: "widget.go",
+			edit: func(body string) string { return strings.ReplaceAll(body, "\t", "    ") },
+			want: true,
+		},
+		{
+			name: "reformatted other file",
+			path: "widget.http",
+			edit: func(body string) string { return strings.ReplaceAll(body, "\t", "    ") },
+		},
+		{
+			name: "edited inside a region",
+			path: "widget.go",
+			edit: func(body string) string {
+				return strings.Replace(body, "\t// TODO: Add fields for creating a new Widget.\n", "\tName string `json:\"name\"`\n", 1)
+			},
+			want: true,
+		},
+		{
+			name: "edited outside the regions",
+			path: "widget.go",
+			edit: func(body string) string { return strings.Replace(body, "createWidgetRequest", "newWidgetRequest", 1) },
+		},
+		{
+			name: "region marker removed",
+			path: "widget.go",
+			edit: func(body string) string {
+				return strings.Replace(body, "\t// gocrud-gen:end create-request-fields\n", "", 1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, body, ok := parseHeader(withHeader(tt.path, newFileHeader("", "Widget"), []byte(headerTestBody)))
+			if !ok {
+				t.Fatal("parseHeader() found no header")
+			}
+			if got := header.matches(tt.path, []byte(tt.edit(string(body)))); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepRegions(t *testing.T) {
+	const filled = "\tName string `json:\"name\"`\n"
+	tests := []struct {
+		name      string
+		previous  string
+		generated string
+		want      string
+	}{
+		{
+			name:      "keeps the edited region",
+			previous:  strings.Replace(headerTestBody, "\t// TODO: Add fields for creating a new Widget.\n", filled, 1),
+			generated: strings.Replace(headerTestBody, "package widget", "package widgets", 1),
+			want:      strings.Replace(strings.Replace(headerTestBody, "package widget", "package widgets", 1), "\t// TODO: Add fields for creating a new Widget.\n", filled, 1),
+		},
+		{
+			name:      "new regions are generated",
+			previous:  "package widget\n",
+			generated: headerTestBody,
+			want:      headerTestBody,
+		},
+		{
+			name:      "regions gone from the template are dropped",
+			previous:  headerTestBody,
+			generated: "package widget\n",
+			want:      "package widget\n",
+		},
+		{
+			name:      "unterminated regions are not kept",
+			previous:  strings.Replace(headerTestBody, "\t// gocrud-gen:end create-request-fields\n", "", 1),
+			generated: headerTestBody,
+			want:      headerTestBody,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(keepRegions([]byte(tt.previous), []byte(tt.generated))); got != tt.want {
+				t.Errorf("keepRegions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package crud
+
+// healthDependency is infrastructure an entity's options add, whose readiness
+// the entity registers with the health endpoint.
+type healthDependency struct {
+	// Field is the field of the entity's dependencies holding the check.
+	Field string
+	// Name is the check's name in the readiness response, after the entity's.
+	Name string
+	Doc  string
+}
+
+// HealthDependencies lists the infrastructure the entity's options add.
+func (d TemplateData) HealthDependencies() []healthDependency {
+	var deps []healthDependency
+	if d.Worker {
+		deps = append(deps, healthDependency{Field: "Queue", Name: "queue", Doc: "the broker of worker." + d.PascalCase + "Queue"})
+	}
+	if d.Features.Caching {
+		deps = append(deps, healthDependency{Field: "Cache", Name: "cache", Doc: "the cache in front of the " + d.PascalCase + " repository"})
+	}
+	switch d.CDC {
+	case cdcNotify:
+		deps = append(deps, healthDependency{Field: "ChangeListener", Name: "change_listener", Doc: "the LISTEN connection of cdc." + d.PascalCase + "Listener"})
+	case cdcDebezium:
+		deps = append(deps, healthDependency{Field: "ChangeStream", Name: "change_stream", Doc: "the Kafka consumer of the " + d.SnakeCase + " change topic"})
+	}
+	return deps
+}
+
+// --- HEALTH TEMPLATES ---
+
+// healthRegistryTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const healthRegistryTemplate = `package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+// Check reports whether a dependency is ready to serve; nil means it is.
+type Check func(ctx context.Context) error
+
+var errNoCheck = errors.New("no check configured")
+
+// Registry holds the readiness checks of the service's dependencies.
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]Check
+}
+
+func NewRegistry() *Registry {
+	return &Registry{checks: make(map[string]Check)}
+}
+
+// Register adds the named check, replacing any check of the same name. A nil
+// check always fails, so a dependency left unwired shows up as not ready.
+func (r *Registry) Register(name string, check Check) {
+	if check == nil {
+		check = func(context.Context) error { return errNoCheck }
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check
+}
+
+// Check runs every check within timeout and returns the failures by name.
+func (r *Registry) Check(ctx context.Context, timeout time.Duration) map[string]string {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	r.mu.RLock()
+	checks := make(map[string]Check, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.mu.RUnlock()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures = map[string]string{}
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			if err := check(ctx); err != nil {
+				mu.Lock()
+				failures[name] = err.Error()
+				mu.Unlock()
+			}
+		}(name, check)
+	}
+	wg.Wait()
+	return failures
+}
+
+// Readiness answers 200 when every dependency is ready and 503 with the
+// failing checks otherwise.
+func (r *Registry) Readiness(timeout time.Duration) func(c *ports.HttpContext) error {
+	return func(c *ports.HttpContext) error {
+		failures := r.Check(c.Context(), timeout)
+		if len(failures) > 0 {
+			return c.Status(503).JSON(ports.Response{Status: false, Data: failures})
+		}
+		return c.Status(200).JSON(ports.Response{Status: true})
+	}
+}
+`
+
+const healthChecksTemplate = `package health
+
+// {{.PascalCase}}Dependencies holds the readiness checks of the infrastructure
+// the {{.PascalCase}} entity uses.
+type {{.PascalCase}}Dependencies struct {
+{{- range .HealthDependencies}}
+	// {{.Field}} checks {{.Doc}}.
+	{{.Field}} Check
+{{- end}}
+}
+
+// Register{{.PascalCase}}Checks registers the checks of the {{.PascalCase}} dependencies.
+func Register{{.PascalCase}}Checks(r *Registry, deps {{.PascalCase}}Dependencies) {
+{{- range .HealthDependencies}}
+	r.Register("{{$.SnakeCase}}.{{.Name}}", deps.{{.Field}})
+{{- end}}
+}
+`
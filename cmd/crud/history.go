@@ -0,0 +1,397 @@
+package crud
+
+import "fmt"
+
+// HistoryColumns renders the column definitions of the entity's history
+// table, aligned like the hand-written migrations.
+func (d TemplateData) HistoryColumns() []string {
+	idType := "BIGINT"
+	if d.UUID() {
+		idType = "UUID"
+	}
+	return alignColumns([][3]string{
+		{"version", "BIGSERIAL", "PRIMARY KEY"},
+		{d.SnakeCase + "_id", idType, "NOT NULL"},
+		{"snapshot", "JSONB", "NOT NULL"},
+		{"replaced_at", "TIMESTAMPTZ", "NOT NULL DEFAULT now()"},
+	})
+}
+
+// alignColumns renders the name, type and constraints of table columns,
+// separated by commas and aligned like the hand-written migrations.
+func alignColumns(columns [][3]string) []string {
+	nameWidth, typeWidth := 0, 0
+	for _, column := range columns {
+		nameWidth = max(nameWidth, len(column[0]))
+		typeWidth = max(typeWidth, len(column[1]))
+	}
+	rendered := make([]string, 0, len(columns))
+	for i, column := range columns {
+		line := fmt.Sprintf("%-*s %s", nameWidth, column[0], column[1])
+		if column[2] != "" {
+			line = fmt.Sprintf("%-*s %-*s %s", nameWidth, column[0], typeWidth, column[1], column[2])
+		}
+		if i < len(columns)-1 {
+			line += ","
+		}
+		rendered = append(rendered, line)
+	}
+	return rendered
+}
+
+// DiffFields lists the fields the diff of two versions compares. Fields with
+// a visibility are left out, as the diff would reveal the values their
+// responses hide or mask.
+func (d TemplateData) DiffFields() []FieldSpec {
+	return d.fieldsWith(func(field FieldSpec) bool { return field.Visibility == "" })
+}
+
+// DiffsVersions reports whether the history is served with a diff endpoint,
+// which needs the fields of the spec to compare.
+func (d TemplateData) DiffsVersions() bool {
+	return d.History && len(d.DiffFields()) > 0
+}
+
+// --- HISTORY TEMPLATES ---
+
+const historyMigrationTemplate = `-- {{.SnakeCase}}_history keeps a snapshot of every version of a {{.SnakeCase}} row that an
+-- update replaced. Snapshots outlive the row, so deleted rows keep their history.
+CREATE TABLE IF NOT EXISTS {{.SnakeCase}}_history (
+{{- range .HistoryColumns}}
+    {{.}}
+{{- end}}
+);
+
+CREATE INDEX IF NOT EXISTS {{.SnakeCase}}_history_{{.SnakeCase}}_id_idx ON {{.SnakeCase}}_history ({{.SnakeCase}}_id, version DESC);
+
+CREATE OR REPLACE FUNCTION {{.SnakeCase}}_history() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO {{.SnakeCase}}_history ({{.SnakeCase}}_id, snapshot) VALUES (OLD.id, to_jsonb(OLD));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS {{.SnakeCase}}_history ON {{.TableIdent}};
+CREATE TRIGGER {{.SnakeCase}}_history
+    AFTER UPDATE ON {{.TableIdent}}
+    FOR EACH ROW WHEN (OLD IS DISTINCT FROM NEW) EXECUTE FUNCTION {{.SnakeCase}}_history();
+`
+
+// versionDTOTemplate is shared by every entity, so it is generated once and
+// left alone on later runs.
+const versionDTOTemplate = `package dto
+
+import "time"
+
+// Version is a previous version of an entity, the snapshot of its row taken
+// when an update replaced it. Versions are numbered in the order they were
+// replaced.
+type Version[T any] struct {
+	Version    int64     ` + "`json:\"version\"`" + `
+	ReplacedAt time.Time ` + "`json:\"replaced_at\"`" + `
+	Snapshot   T         ` + "`json:\"snapshot\"`" + `
+}
+`
+
+const historyInterfaceTemplate = `package repository
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// {{.PascalCase}}History reads the previous versions of {{.LowerCase}}s, which the
+// {{.SnakeCase}}_history trigger records on every update.
+type {{.PascalCase}}History interface {
+	// FindHistory returns a page of the previous versions of the {{.LowerCase}}
+	// with the given id, newest first.
+	FindHistory(ctx context.Context, id {{.IDGoType}}, pagination dto.Pagination) ([]dto.Version[dto.{{.PascalCase}}], *dto.Pagination, error)
+{{- if .DiffsVersions}}
+	// FindVersion returns the given previous version of the {{.LowerCase}} with the
+	// given id, failing with a not found error when it has no such version.
+	FindVersion(ctx context.Context, id {{.IDGoType}}, version int64) (dto.Version[dto.{{.PascalCase}}], error)
+{{- end}}
+}
+`
+
+const historyRepositoryTemplate = `package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+type {{.CamelCase}}History struct {
+	db  ports.Database
+	log ports.LoggerWithTraceID
+}
+
+func New{{.PascalCase}}History(db ports.Database, log ports.LoggerWithTraceID) repository.{{.PascalCase}}History {
+	return &{{.CamelCase}}History{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *{{.CamelCase}}History) FindHistory(ctx context.Context, id {{.IDGoType}}, pagination dto.Pagination) ([]dto.Version[dto.{{.PascalCase}}], *dto.Pagination, error) {
+	// TODO: Select a page of the versions on r.db, decode each snapshot into a
+	// dto.{{.PascalCase}} with json.Unmarshal and count them for the returned
+	// pagination.
+	// Example:
+	// SELECT version, replaced_at, snapshot FROM {{.SnakeCase}}_history WHERE {{.SnakeCase}}_id = $1 ORDER BY version DESC LIMIT $2 OFFSET $3
+	return nil, nil, fmt.Errorf("{{.SnakeCase}} history is not implemented: %v", id)
+}
+{{- if .DiffsVersions}}
+
+func (r *{{.CamelCase}}History) FindVersion(ctx context.Context, id {{.IDGoType}}, version int64) (dto.Version[dto.{{.PascalCase}}], error) {
+	// TODO: Select the version on r.db and decode its snapshot into a
+	// dto.{{.PascalCase}} with json.Unmarshal, returning a not found error when no
+	// row matches.
+	// Example:
+	// SELECT version, replaced_at, snapshot FROM {{.SnakeCase}}_history WHERE {{.SnakeCase}}_id = $1 AND version = $2
+	return dto.Version[dto.{{.PascalCase}}]{}, fmt.Errorf("{{.SnakeCase}} version %d is not implemented: %v", version, id)
+}
+{{- end}}
+`
+
+const historyServiceTemplate = `package service
+
+import (
+	"context"
+{{- if .DiffsVersions}}
+	"reflect"
+{{- end}}
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// Get{{.PascalCase}}History returns a page of the previous versions of the {{.LowerCase}}
+// with the given id, newest first. Deleted {{.LowerCase}}s keep their history.
+func (s *{{.CamelCase}}Service) Get{{.PascalCase}}History(ctx context.Context, id {{.IDGoType}}, pagination dto.Pagination) ([]dto.Version[dto.{{.PascalCase}}], *dto.Pagination, error) {
+{{- if .Timeout}}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.List)
+	defer cancel()
+{{end}}
+	return s.history.FindHistory(ctx, id, pagination)
+}
+{{- if .DiffsVersions}}
+
+// Diff{{.PascalCase}}Versions compares two previous versions of the {{.LowerCase}} with the
+// given id field by field, listing the fields that changed from versionA to
+// versionB in the order of the spec.
+func (s *{{.CamelCase}}Service) Diff{{.PascalCase}}Versions(ctx context.Context, id {{.IDGoType}}, versionA, versionB int64) ([]dto.FieldChange, error) {
+{{- if .Timeout}}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Get)
+	defer cancel()
+{{end}}
+	from, err := s.history.FindVersion(ctx, id, versionA)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.history.FindVersion(ctx, id, versionB)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []dto.FieldChange{}
+{{- range .DiffFields}}
+	if !reflect.DeepEqual(from.Snapshot.{{.GoName}}, to.Snapshot.{{.GoName}}) {
+		changes = append(changes, dto.FieldChange{Field: "{{.Name}}", From: from.Snapshot.{{.GoName}}, To: to.Snapshot.{{.GoName}}})
+	}
+{{- end}}
+	return changes, nil
+}
+{{- end}}
+`
+
+const historyControllerTemplate = `package {{.LowerCase}}
+
+import (
+{{- if not .UUID}}
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+{{- end}}
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if .ResponseMapper}}
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- end}}
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+	"go.elastic.co/apm"
+)
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Get the history of a {{.PascalCase}}
+// @Description	Get the paginated previous versions of a {{.LowerCase}}, newest first
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			id	path		{{.IDParam}}	true	"ID of the {{.PascalCase}}"{{if .UUID}}	format(uuid){{end}}
+// @Param			params	query		httpUtils.ListRequest	false	"Pagination parameters"
+// @Success		200		{{.SwagResponse (print "[]dto.Version[" .ResponseType "]")}}
+{{- if .PaginationHeaders}}
+// @Header			200		{integer}	X-Total-Count	"Number of previous versions"
+// @Header			200		{string}	Link	"URLs of the previous and next pages"
+{{- end}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			{{.WriteRoutePrefix}}/{{.KebabCase}}/{id}/history [get]
+{{else -}}
+// Get{{.PascalCase}}History handles GET {{.WriteRoutePrefix}}/{{.KebabCase}}/{id}/history.
+{{end -}}
+func (ctrl *{{.CamelCase}}Controller) Get{{.PascalCase}}History(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Get{{.PascalCase}}History", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
+
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return err
+	}
+{{- end}}
+
+	id, err := {{if .UUID}}parse{{.PascalCase}}ID(c){{else}}c.ParamsInt("id"){{end}}
+	if err != nil {
+		return {{if .UUID}}err{{else}}appErr.NewBadRequestErr(err){{end}}
+	}
+
+	// Versions are listed newest first, so no columns are filterable or
+	// sortable.
+	pagination, err := httpUtils.ParseAndValidatePagination(ctx, c, ctrl.customValidation, ctrl.log, nil)
+	if err != nil {
+		return err
+	}
+{{- if .Pagination.IsSet}}
+	pagination = httpUtils.ApplyPaginationDefaults(c, pagination, {{.CamelCase}}PaginationDefaults)
+{{- end}}
+
+	history, resultPagination, err := ctrl.{{.CamelCase}}Service.Get{{.PascalCase}}History(ctx, {{.IDArg}}, pagination)
+	if err != nil {
+		return {{if .I18n}}localize{{.PascalCase}}Error(err){{else}}err{{end}}
+	}
+{{- if .PaginationHeaders}}
+	httpUtils.SetPaginationHeaders(c, resultPagination)
+{{- end}}
+{{- with .ResponseMapper}}
+
+	versions := make([]dto.Version[{{$.ResponseType}}], 0, len(history))
+	for _, version := range history {
+		versions = append(versions, dto.Version[{{$.ResponseType}}]{
+			Version:    version.Version,
+			ReplacedAt: version.ReplacedAt,
+			Snapshot:   {{.}}(version.Snapshot),
+		})
+	}
+{{- end}}
+
+	resp := {{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Data: {{if .ResponseMapper}}versions{{else}}history{{end}},
+		Meta: &ports.Meta{
+			Pagination: &resultPagination.Pagination,
+		},
+	}{{if .Envelope.IsSet}}){{end}}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, resp){{else}}c.JSON(resp){{end}}
+}
+`
+
+// fieldChangeDTOTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const fieldChangeDTOTemplate = `package dto
+
+// FieldChange is a field whose value differs between two versions of an
+// entity.
+type FieldChange struct {
+	Field string ` + "`json:\"field\"`" + `
+	From  any    ` + "`json:\"from\"`" + `
+	To    any    ` + "`json:\"to\"`" + `
+}
+`
+
+const historyDiffControllerTemplate = `package {{.LowerCase}}
+
+import (
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+{{- if or .ContentNegotiation .Envelope.IsSet}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+{{- end}}
+	"go.elastic.co/apm"
+)
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Diff two versions of a {{.PascalCase}}
+// @Description	Get the fields that changed between two previous versions of a {{.LowerCase}}
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			id	path		{{.IDParam}}	true	"ID of the {{.PascalCase}}"{{if .UUID}}	format(uuid){{end}}
+// @Param			versionA	path		int	true	"Version to diff from"
+// @Param			versionB	path		int	true	"Version to diff to"
+// @Success		200	{{.SwagResponse "[]dto.FieldChange"}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		404	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			{{.WriteRoutePrefix}}/{{.KebabCase}}/{id}/history/{versionA}/diff/{versionB} [get]
+{{else -}}
+// Diff{{.PascalCase}}Versions handles GET {{.WriteRoutePrefix}}/{{.KebabCase}}/{id}/history/{versionA}/diff/{versionB}.
+{{end -}}
+func (ctrl *{{.CamelCase}}Controller) Diff{{.PascalCase}}Versions(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Diff{{.PascalCase}}Versions", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
+
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return err
+	}
+{{- end}}
+
+	id, err := {{if .UUID}}parse{{.PascalCase}}ID(c){{else}}c.ParamsInt("id"){{end}}
+	if err != nil {
+		return {{if .UUID}}err{{else}}appErr.NewBadRequestErr(err){{end}}
+	}
+	versionA, err := c.ParamsInt("versionA")
+	if err != nil {
+		return appErr.NewBadRequestErr(err)
+	}
+	versionB, err := c.ParamsInt("versionB")
+	if err != nil {
+		return appErr.NewBadRequestErr(err)
+	}
+
+	changes, err := ctrl.{{.CamelCase}}Service.Diff{{.PascalCase}}Versions(ctx, {{.IDArg}}, int64(versionA), int64(versionB))
+	if err != nil {
+		return {{if .I18n}}localize{{.PascalCase}}Error(err){{else}}err{{end}}
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, {{else}}c.JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   changes,
+	}{{if .Envelope.IsSet}}){{end}})
+}
+`
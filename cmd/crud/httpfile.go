@@ -0,0 +1,74 @@
+package crud
+
+// --- HTTP CLIENT FILE TEMPLATES ---
+
+const httpFileTemplate = `@baseUrl = http://localhost:8080
+{{- if not .AppendOnly}}
+@id = {{.SampleID}}
+{{- end}}
+
+### Create a {{.PascalCase}}
+{{- if not .Entity.Fields}}
+# TODO: Add the create{{.PascalCase}}Request fields to the body.
+{{- end}}
+POST {{"{{"}}baseUrl{{"}}"}}{{.WriteRoutePrefix}}/{{.KebabCase}}/
+Content-Type: application/json
+
+{{.ExampleBody}}
+
+### Get all {{.PascalCase}}s
+{{- if .AppendOnly}}
+GET {{"{{"}}baseUrl{{"}}"}}/api/v1/{{.KebabCase}}/?limit=10
+
+### Get the next page of {{.PascalCase}}s, passing the X-Next-Before header of the previous response
+GET {{"{{"}}baseUrl{{"}}"}}/api/v1/{{.KebabCase}}/?limit=10&before=2006-01-02T15:04:05Z
+{{- if .ClickHouse}}
+
+### Count {{.PascalCase}}s per hour over the last day
+GET {{"{{"}}baseUrl{{"}}"}}/api/v1/{{.KebabCase}}/buckets?interval=1h
+{{- end}}
+{{- else}}
+GET {{"{{"}}baseUrl{{"}}"}}/api/v1/{{.KebabCase}}/?page=1&page_size=10
+
+### Get a {{.PascalCase}} by ID
+GET {{"{{"}}baseUrl{{"}}"}}/api/v1/{{.KebabCase}}/{{"{{"}}id{{"}}"}}
+
+### Update a {{.PascalCase}}
+{{- if not .Entity.Fields}}
+# TODO: Add the update{{.PascalCase}}Request fields to the body.
+{{- end}}
+PUT {{"{{"}}baseUrl{{"}}"}}{{.WriteRoutePrefix}}/{{.KebabCase}}/{{"{{"}}id{{"}}"}}
+Content-Type: application/json
+
+{{.ExampleBody}}
+
+### Delete a {{.PascalCase}}
+DELETE {{"{{"}}baseUrl{{"}}"}}{{.WriteRoutePrefix}}/{{.KebabCase}}/{{"{{"}}id{{"}}"}}
+{{- end}}
+{{- if .InternalAPI}}
+
+### Get all {{.PascalCase}}s (internal)
+GET {{"{{"}}baseUrl{{"}}"}}{{.InternalRoutePrefix}}/{{.KebabCase}}/?page=1&page_size=10
+
+### Get a {{.PascalCase}} by ID (internal)
+GET {{"{{"}}baseUrl{{"}}"}}{{.InternalRoutePrefix}}/{{.KebabCase}}/{{"{{"}}id{{"}}"}}
+{{- end}}
+{{- if .Webhooks}}
+
+### Register a {{.PascalCase}} webhook
+POST {{"{{"}}baseUrl{{"}}"}}/api/v1/{{.KebabCase}}/webhooks
+Content-Type: application/json
+
+{
+  "url": "https://example.com/hooks/{{.KebabCase}}",
+  "secret": "change-me-to-a-long-secret",
+  "events": ["{{.SnakeCase}}.created", "{{.SnakeCase}}.updated", "{{.SnakeCase}}.deleted"]
+}
+
+### Get all {{.PascalCase}} webhooks
+GET {{"{{"}}baseUrl{{"}}"}}/api/v1/{{.KebabCase}}/webhooks
+
+### Delete a {{.PascalCase}} webhook
+DELETE {{"{{"}}baseUrl{{"}}"}}/api/v1/{{.KebabCase}}/webhooks/{{if .UUID}}1{{else}}{{"{{"}}id{{"}}"}}{{end}}
+{{- end}}
+`
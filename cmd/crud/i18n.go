@@ -0,0 +1,172 @@
+package crud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultI18nDir    = "locales"
+	defaultI18nLocale = "en"
+)
+
+// I18nConfig controls where --i18n registers the entity's messages.
+type I18nConfig struct {
+	// Dir holds one <locale>.yaml translation file per locale.
+	Dir string `yaml:"dir"`
+	// Locales are registered in addition to en, which carries the source text.
+	Locales []string `yaml:"locales"`
+}
+
+// i18nMessage is one translation key and its English text.
+type i18nMessage struct {
+	Key  string
+	Text string
+}
+
+// i18nMessages lists the keys registered for the entity: its not-found,
+// conflict and validation messages, plus one validation message per spec field.
+func i18nMessages(data TemplateData) []i18nMessage {
+	messages := []i18nMessage{
+		{data.SnakeCase + ".not_found", data.PascalCase + " not found."},
+		{data.SnakeCase + ".conflict", data.PascalCase + " already exists."},
+		{data.SnakeCase + ".validation_failed", data.PascalCase + " data is invalid."},
+	}
+	for _, field := range data.Entity.Fields {
+		messages = append(messages, i18nMessage{
+			Key:  data.SnakeCase + "." + field.Name + ".invalid",
+			Text: fmt.Sprintf("The %s of the %s is invalid.", field.Name, data.PascalCase),
+		})
+	}
+	return messages
+}
+
+// registerMessages adds the entity's keys to the translation file of every
+// configured locale. Existing keys and their translations are left alone, so
+// it is safe to run again; non-English entries get the English text and a
+// TODO comment until they are translated.
+func registerMessages(cfg I18nConfig, data TemplateData) ([]string, error) {
+	var paths []string
+	for i, path := range localePaths(cfg) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return paths, err
+		}
+		added, err := mergeMessages(path, i18nMessages(data), i > 0)
+		if err != nil {
+			return paths, fmt.Errorf("updating %s: %w", path, err)
+		}
+		fmt.Printf("Registered %d message(s) in %s\n", added, path)
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// localePaths lists the translation files of the configured locales, the
+// default locale's first.
+func localePaths(cfg I18nConfig) []string {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = defaultI18nDir
+	}
+	locales := []string{defaultI18nLocale}
+	for _, locale := range cfg.Locales {
+		if !slices.Contains(locales, locale) {
+			locales = append(locales, locale)
+		}
+	}
+	paths := make([]string, 0, len(locales))
+	for _, locale := range locales {
+		paths = append(paths, filepath.Join(dir, locale+".yaml"))
+	}
+	return paths
+}
+
+// mergeMessages appends the messages missing from the YAML mapping at path,
+// keeping the rest of the file, comments included, as it was.
+func mergeMessages(path string, messages []i18nMessage, untranslated bool) (int, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	if len(bytes.TrimSpace(content)) > 0 {
+		doc = &yaml.Node{}
+		if err := yaml.Unmarshal(content, doc); err != nil {
+			return 0, err
+		}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return 0, fmt.Errorf("expected a mapping of message keys")
+	}
+
+	existing := make(map[string]bool, len(root.Content)/2)
+	for i := 0; i < len(root.Content); i += 2 {
+		existing[root.Content[i].Value] = true
+	}
+
+	added := 0
+	for _, message := range messages {
+		if existing[message.Key] {
+			continue
+		}
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: message.Text}
+		if untranslated {
+			value.LineComment = "TODO: translate"
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: message.Key}, value)
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return 0, err
+	}
+	return added, os.WriteFile(path, out.Bytes(), 0644)
+}
+
+// --- I18N TEMPLATES ---
+
+const i18nMessagesTemplate = `package {{.LowerCase}}
+
+import (
+	"database/sql"
+	"errors"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+)
+
+// Translation keys of the {{.PascalCase}} messages. The error handler translates
+// them to the client's locale; the texts live in the project's locale files.
+const (
+	msg{{.PascalCase}}NotFound         = "{{.SnakeCase}}.not_found"
+	msg{{.PascalCase}}Conflict         = "{{.SnakeCase}}.conflict"
+	msg{{.PascalCase}}ValidationFailed = "{{.SnakeCase}}.validation_failed"
+)
+
+// localize{{.PascalCase}}Error replaces not-found and unique violation errors
+// from the service with errors carrying their translation keys. Other errors
+// are returned unchanged.
+func localize{{.PascalCase}}Error(err error) error {
+	var pgErr interface{ SQLState() string }
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return appErr.NewNotFoundErr(errors.New(msg{{.PascalCase}}NotFound))
+	case errors.As(err, &pgErr) && pgErr.SQLState() == "23505":
+		return appErr.NewConflictErr(errors.New(msg{{.PascalCase}}Conflict))
+	default:
+		return err
+	}
+}
+`
@@ -0,0 +1,66 @@
+package crud
+
+const (
+	idTypeInt64 = "int64"
+	idTypeUUID  = "uuid"
+)
+
+// UUID reports whether the entity is keyed by a UUID instead of an int64.
+func (d TemplateData) UUID() bool {
+	return d.IDType == idTypeUUID
+}
+
+// IDGoType is the Go type of the entity's primary key.
+func (d TemplateData) IDGoType() string {
+	if d.UUID() {
+		return "uuid.UUID"
+	}
+	return "int64"
+}
+
+// IDArg converts the parsed id path parameter to IDGoType.
+func (d TemplateData) IDArg() string {
+	if d.UUID() {
+		return "id"
+	}
+	return "int64(id)"
+}
+
+// IDParam is the swag type of the id path parameter.
+func (d TemplateData) IDParam() string {
+	if d.UUID() {
+		return "string"
+	}
+	return "int"
+}
+
+// SampleID is a well-formed id for generated requests and contract tests.
+func (d TemplateData) SampleID() string {
+	if d.UUID() {
+		return "3f2b6c1e-8a4d-4e2f-9b7a-1c5d0e6f7a81"
+	}
+	return "1"
+}
+
+// --- UUID ID TEMPLATES ---
+
+const uuidParamTemplate = `package {{.LowerCase}}
+
+import (
+	"fmt"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	"github.com/google/uuid"
+)
+
+// parse{{.PascalCase}}ID reads the id path parameter, which must be a UUID.
+func parse{{.PascalCase}}ID(c *ports.HttpContext) (uuid.UUID, error) {
+	raw := c.Params("id")
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, appErr.NewBadRequestErr(fmt.Errorf("invalid {{.LowerCase}} id %q: must be a UUID", raw))
+	}
+	return id, nil
+}
+`
@@ -0,0 +1,156 @@
+package crud
+
+// --- IN-MEMORY REPOSITORY TEMPLATES ---
+
+// inmemStoreTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const inmemStoreTemplate = `package inmem
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// ErrNotFound is returned for unknown IDs. Align it with the error the
+// generic postgres repository returns if your handlers inspect it.
+var ErrNotFound = errors.New("inmem: record not found")
+
+// Store is a thread-safe, map-backed table keyed by ID.
+type Store[T any] struct {
+	mu     sync.RWMutex
+	rows   map[int64]T
+	nextID int64
+	getID  func(T) int64
+	setID  func(*T, int64)
+}
+
+func NewStore[T any](getID func(T) int64, setID func(*T, int64)) *Store[T] {
+	return &Store[T]{
+		rows:  make(map[int64]T),
+		getID: getID,
+		setID: setID,
+	}
+}
+
+func (s *Store[T]) Get(id int64) (T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	row, ok := s.rows[id]
+	if !ok {
+		var zero T
+		return zero, ErrNotFound
+	}
+	return row, nil
+}
+
+func (s *Store[T]) Create(row *T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	s.setID(row, s.nextID)
+	s.rows[s.nextID] = *row
+}
+
+func (s *Store[T]) Update(row T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.getID(row)
+	if _, ok := s.rows[id]; !ok {
+		return ErrNotFound
+	}
+	s.rows[id] = row
+	return nil
+}
+
+func (s *Store[T]) Delete(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rows[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.rows, id)
+	return nil
+}
+
+// List returns the rows accepted by filter (all rows when nil), ordered by ID
+// and cut down to the requested page.
+func (s *Store[T]) List(pagination dto.Pagination, filter func(T) bool) ([]T, *dto.Pagination) {
+	s.mu.RLock()
+	rows := make([]T, 0, len(s.rows))
+	for _, row := range s.rows {
+		if filter == nil || filter(row) {
+			rows = append(rows, row)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(rows, func(i, j int) bool { return s.getID(rows[i]) < s.getID(rows[j]) })
+
+	start, end := pageBounds(pagination, len(rows))
+	return rows[start:end], &pagination
+}
+
+// pageBounds translates the requested page into slice bounds.
+func pageBounds(pagination dto.Pagination, total int) (start, end int) {
+	// TODO: Read the page and page size from dto.Pagination the same way the
+	// generic repository does, and record the total on the returned pagination.
+	return 0, total
+}
+`
+
+const inmemRepositoryTemplate = `package inmem
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+)
+
+var _ repository.{{.PascalCase}} = (*{{.PascalCase}}Repository)(nil)
+
+// {{.PascalCase}}Repository is a database-free repository.{{.PascalCase}} for unit tests.
+type {{.PascalCase}}Repository struct {
+	store *Store[dto.{{.PascalCase}}]
+	// Filter, when set, restricts FindAll results so tests can emulate query filters.
+	Filter func(dto.{{.PascalCase}}) bool
+}
+
+func New{{.PascalCase}}Repository() *{{.PascalCase}}Repository {
+	return &{{.PascalCase}}Repository{
+		store: NewStore(
+			func({{.CamelCase}} dto.{{.PascalCase}}) int64 { return {{.CamelCase}}.ID },
+			func({{.CamelCase}} *dto.{{.PascalCase}}, id int64) { {{.CamelCase}}.ID = id },
+		),
+	}
+}
+
+func (r *{{.PascalCase}}Repository) GetByID(ctx context.Context, id int64) (dto.{{.PascalCase}}, error) {
+	return r.store.Get(id)
+}
+
+func (r *{{.PascalCase}}Repository) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	r.store.Create({{.CamelCase}})
+	return nil
+}
+
+func (r *{{.PascalCase}}Repository) Update(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	return r.store.Update(*{{.CamelCase}})
+}
+
+func (r *{{.PascalCase}}Repository) Delete(ctx context.Context, id int64) error {
+	return r.store.Delete(id)
+}
+
+func (r *{{.PascalCase}}Repository) FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+	{{.CamelCase}}s, resultPagination := r.store.List(pagination, r.Filter)
+	return {{.CamelCase}}s, resultPagination, nil
+}
+`
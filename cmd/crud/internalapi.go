@@ -0,0 +1,320 @@
+package crud
+
+const internalRoutePrefix = "/internal/api/v1"
+
+// ResponseType is the type the public read and write handlers return. With
+// --internal-api it is a restricted response DTO, and the full dto is only
+// served by the internal controller. Derived fields and visibilities in the
+// spec wrap the dto in a response applying them.
+func (d TemplateData) ResponseType() string {
+	if d.InternalAPI {
+		return "public" + d.PascalCase + "Response"
+	}
+	if d.ShapesResponse() {
+		return d.CamelCase + "Response"
+	}
+	return "dto." + d.PascalCase
+}
+
+// ResponseMapper is the function mapping a dto to ResponseType, empty when the
+// handlers return the dto itself. Its plural form, with an s appended, maps a
+// slice.
+func (d TemplateData) ResponseMapper() string {
+	if d.InternalAPI {
+		return "toPublic" + d.PascalCase + "Response"
+	}
+	if d.ShapesResponse() {
+		return "to" + d.PascalCase + "Response"
+	}
+	return ""
+}
+
+// InternalResponseType is the type the internal handlers return, the full dto
+// unless it has write-only fields to hide.
+func (d TemplateData) InternalResponseType() string {
+	if len(d.WriteOnlyFields()) > 0 {
+		return "internal" + d.PascalCase + "Response"
+	}
+	return "dto." + d.PascalCase
+}
+
+// InternalRoutePrefix is the route prefix of the service-to-service handlers.
+func (d TemplateData) InternalRoutePrefix() string {
+	return internalRoutePrefix
+}
+
+// --- INTERNAL API TEMPLATES ---
+
+const publicResponseTemplate = `package {{.LowerCase}}
+
+import (
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .MaskedFields}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+{{- end}}
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// public{{.PascalCase}}Response is the {{.PascalCase}} representation served on the
+// public API. Only add fields that are safe to expose to end users; the
+// internal API returns the full dto.{{.PascalCase}}.
+type public{{.PascalCase}}Response struct {
+	ID {{.IDGoType}} ` + "`json:\"id\"`" + `
+	// TODO: Add the public {{.PascalCase}} fields.
+{{- with .ResponseFields false}}
+	// Masked or derived from the full dto.{{$.PascalCase}}.
+{{- range .}}
+	{{.}}
+{{- end}}
+{{- end}}
+}
+
+func toPublic{{.PascalCase}}Response(entity dto.{{.PascalCase}}) public{{.PascalCase}}Response {
+	return public{{.PascalCase}}Response{
+		ID: entity.ID,
+		// TODO: Copy the public {{.PascalCase}} fields.
+{{- range .ResponseValues ""}}
+		{{.}}
+{{- end}}
+	}
+}
+
+func toPublic{{.PascalCase}}Responses(entities []dto.{{.PascalCase}}) []public{{.PascalCase}}Response {
+	responses := make([]public{{.PascalCase}}Response, len(entities))
+	for i, entity := range entities {
+		responses[i] = toPublic{{.PascalCase}}Response(entity)
+	}
+	return responses
+}
+{{- with .InternalResponseFields}}
+
+// internal{{$.PascalCase}}Response is the full dto.{{$.PascalCase}} the internal handlers
+// return, without its write-only fields. Its fields take the place of the dto
+// fields with the same JSON name.
+type internal{{$.PascalCase}}Response struct {
+	dto.{{$.PascalCase}}
+{{- range .}}
+	{{.}}
+{{- end}}
+}
+
+func toInternal{{$.PascalCase}}Response(entity dto.{{$.PascalCase}}) internal{{$.PascalCase}}Response {
+	return internal{{$.PascalCase}}Response{ {{- $.PascalCase}}: entity}
+}
+
+func toInternal{{$.PascalCase}}Responses(entities []dto.{{$.PascalCase}}) []internal{{$.PascalCase}}Response {
+	responses := make([]internal{{$.PascalCase}}Response, len(entities))
+	for i, entity := range entities {
+		responses[i] = toInternal{{$.PascalCase}}Response(entity)
+	}
+	return responses
+}
+{{- end}}
+`
+
+const internalControllerTemplate = `package {{.LowerCase}}
+
+import (
+{{- if not .UUID}}
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+{{- end}}
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if or .FilterFields .Include .Scoped .SortFields}}
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- end}}
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+	"{{.ServiceImport}}"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/validator"
+	"go.elastic.co/apm"
+)
+
+// {{.PascalCase}}Internal holds the service-to-service read handlers, which
+// return the full dto.{{.PascalCase}} instead of the public response.
+type {{.PascalCase}}Internal interface {
+	GetPaginated{{.PascalCase}}s(c *ports.HttpContext) error
+	Get{{.PascalCase}}ByID(c *ports.HttpContext) error
+}
+
+type {{.CamelCase}}InternalController struct {
+	{{.CamelCase}}Service    service.{{.PascalCase}}
+	customValidation validator.CustomValidation
+	log              ports.LoggerWithTraceID
+}
+
+func NewInternal(log ports.LoggerWithTraceID, {{.CamelCase}}Service service.{{.PascalCase}}, customValidation validator.CustomValidation) {{.PascalCase}}Internal {
+	return &{{.CamelCase}}InternalController{
+		{{.CamelCase}}Service:    {{.CamelCase}}Service,
+		customValidation: customValidation,
+		log:              log,
+	}
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Get {{.PascalCase}} by ID (internal)
+// @Description	This route will fetch a specific {{.LowerCase}} with all of its fields
+// @Tags			{{.PascalCase}}Internal
+// @Accept			json
+// @Produce		json
+// @Param			id	path		{{.IDParam}}	true	"{{.PascalCase}} ID"{{if .UUID}}	format(uuid){{end}}
+{{- if .Include}}
+// @Param			include	query	string	false	"Comma-separated relations to nest in the response"
+{{- end}}
+{{- if .Scoped}}
+// @Param			{{.ScopeOverride}}	query	bool	false	"Include the {{.LowerCase}}s outside the default scope"
+{{- end}}
+// @Success		200	{{.SwagResponse .InternalResponseType}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		404	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			{{.InternalRoutePrefix}}/{{.KebabCase}}/{id} [get]
+{{else -}}
+// Get{{.PascalCase}}ByID handles GET {{.InternalRoutePrefix}}/{{.KebabCase}}/{id}.
+{{end -}}
+func (ctrl *{{.CamelCase}}InternalController) Get{{.PascalCase}}ByID(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "GetInternal{{.PascalCase}}ByID", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	id, err := {{if .UUID}}parse{{.PascalCase}}ID(c){{else}}c.ParamsInt("id"){{end}}
+	if err != nil {
+		return {{if .UUID}}err{{else}}appErr.NewBadRequestErr(err){{end}}
+	}
+{{- if .Include}}
+
+	include, err := httpUtils.ParseInclude(c, {{.CamelCase}}Includes)
+	if err != nil {
+		return err
+	}
+	ctx = dto.WithInclude(ctx, include)
+{{- end}}
+{{- if .Scoped}}
+
+	unscoped, err := httpUtils.ParseUnscoped(c, "{{.ScopeOverride}}")
+	if err != nil {
+		return err
+	}
+	if unscoped {
+		ctx = dto.WithUnscoped(ctx)
+	}
+{{- end}}
+
+	entity, err := ctrl.{{.CamelCase}}Service.Get{{.PascalCase}}ByID(ctx, {{.IDArg}})
+	if err != nil {
+		return {{if .I18n}}localize{{.PascalCase}}Error(err){{else}}err{{end}}
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, {{else}}c.JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   {{if .WriteOnlyFields}}toInternal{{.PascalCase}}Response(entity){{else}}entity{{end}},
+	}{{if .Envelope.IsSet}}){{end}})
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Get All {{.PascalCase}}s (internal)
+// @Description	Get all paginated {{.LowerCase}}s with all of their fields
+// @Tags			{{.PascalCase}}Internal
+// @Accept			json
+// @Produce		json
+// @Param			params	query		httpUtils.ListRequest	false	"Pagination and filter parameters"
+{{- if .Include}}
+// @Param			include	query	string	false	"Comma-separated relations to nest in the response"
+{{- end}}
+{{- if .Scoped}}
+// @Param			{{.ScopeOverride}}	query	bool	false	"Include the {{.LowerCase}}s outside the default scope"
+{{- end}}
+// @Success		200		{{.SwagResponse (print "[]" .InternalResponseType)}}
+{{- if .PaginationHeaders}}
+// @Header			200		{integer}	X-Total-Count	"Number of matching {{.LowerCase}}s"
+// @Header			200		{string}	Link	"URLs of the previous and next pages"
+{{- end}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			{{.InternalRoutePrefix}}/{{.KebabCase}}/ [get]
+{{else -}}
+// GetPaginated{{.PascalCase}}s handles GET {{.InternalRoutePrefix}}/{{.KebabCase}}/.
+{{end -}}
+func (ctrl *{{.CamelCase}}InternalController) GetPaginated{{.PascalCase}}s(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "GetInternalPaginated{{.PascalCase}}s", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	// Keep in sync with the columnMapping of the public controller.
+{{- if .SortColumnMapping}}
+	columnMapping := map[string]string{
+{{- range .SortColumnMapping}}
+		{{.}}
+{{- end}}
+	}
+{{- else}}
+	columnMapping := map[string]string{}
+{{- end}}
+
+	pagination, err := httpUtils.ParseAndValidatePagination(ctx, c, ctrl.customValidation, ctrl.log, columnMapping)
+	if err != nil {
+		return err
+	}
+{{- if .Pagination.IsSet}}
+	pagination = httpUtils.ApplyPaginationDefaults(c, pagination, {{.CamelCase}}PaginationDefaults)
+{{- end}}
+{{- if .FilterFields}}
+
+	filters, err := httpUtils.ParseFilters(c, {{.CamelCase}}Filters)
+	if err != nil {
+		return err
+	}
+	ctx = dto.WithFilters(ctx, filters)
+{{- end}}
+{{- if .SortFields}}
+
+	sorts, err := httpUtils.ParseSort(c, columnMapping)
+	if err != nil {
+		return err
+	}
+	ctx = dto.WithSorts(ctx, sorts)
+{{- end}}
+{{- if .Include}}
+
+	include, err := httpUtils.ParseInclude(c, {{.CamelCase}}Includes)
+	if err != nil {
+		return err
+	}
+	ctx = dto.WithInclude(ctx, include)
+{{- end}}
+{{- if .Scoped}}
+
+	unscoped, err := httpUtils.ParseUnscoped(c, "{{.ScopeOverride}}")
+	if err != nil {
+		return err
+	}
+	if unscoped {
+		ctx = dto.WithUnscoped(ctx)
+	}
+{{- end}}
+
+	paginatedResult, resultPagination, err := ctrl.{{.CamelCase}}Service.GetPaginated{{.PascalCase}}s(ctx, pagination)
+	if err != nil {
+		return err
+	}
+{{- if .PaginationHeaders}}
+	httpUtils.SetPaginationHeaders(c, resultPagination)
+{{- end}}
+
+	resp := {{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Data: {{if .WriteOnlyFields}}toInternal{{.PascalCase}}Responses(paginatedResult){{else}}paginatedResult{{end}},
+		Meta: &ports.Meta{
+			Pagination: &resultPagination.Pagination,
+		},
+	}{{if .Envelope.IsSet}}){{end}}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, resp){{else}}c.JSON(resp){{end}}
+}
+`
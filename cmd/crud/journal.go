@@ -0,0 +1,280 @@
+package crud
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// journalPath lists the crud runs that wrote into the working tree, oldest
+	// first, for undo.
+	journalPath = filepath.Join(".crudgen", "journal.json")
+	// journalBackupDir keeps, per run, the content the files the run changed
+	// had before it.
+	journalBackupDir = filepath.Join(".crudgen", "journal")
+)
+
+// journalEntry is a run in the journal.
+type journalEntry struct {
+	ID    string        `json:"id"`
+	Time  time.Time     `json:"time"`
+	Spec  string        `json:"spec"`
+	Files []journalFile `json:"files"`
+}
+
+// journalFile is a file a run created or changed.
+type journalFile struct {
+	Path string `json:"path"`
+	// PreviousHash is the hash of the file before the run, empty when the run
+	// created it. Its content is kept under the run's backup directory.
+	PreviousHash string `json:"previous_hash,omitempty"`
+	// Hash is the hash of the file the run left, empty when it left none.
+	Hash string `json:"hash"`
+}
+
+// generationJournal records the files a run changes. A nil journal, for runs
+// that do not write into the working tree, records nothing.
+type generationJournal struct {
+	entry journalEntry
+}
+
+func newGenerationJournal(spec string) *generationJournal {
+	now := time.Now().UTC()
+	return &generationJournal{entry: journalEntry{ID: now.Format("20060102T150405.000000000Z"), Time: now, Spec: spec}}
+}
+
+// track keeps the content of path before the run first changes it.
+func (j *generationJournal) track(path string) error {
+	if j == nil || slices.ContainsFunc(j.entry.Files, func(f journalFile) bool { return f.Path == path }) {
+		return nil
+	}
+	file := journalFile{Path: path}
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		backup := filepath.Join(journalBackupDir, j.entry.ID, path)
+		if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(backup, content, 0644); err != nil {
+			return err
+		}
+		file.PreviousHash = hashContent(content)
+	}
+	j.entry.Files = append(j.entry.Files, file)
+	return nil
+}
+
+// trackEdits tracks the files the registrations edit outside the FileWriter.
+// A file that cannot be tracked is still edited, and left alone by undo.
+func (j *generationJournal) trackEdits(paths ...string) {
+	for _, path := range paths {
+		if err := j.track(path); err != nil {
+			fmt.Printf("Error journaling %s, which undo will not revert: %v\n", path, err)
+		}
+	}
+}
+
+// finish hashes the files the run left and appends the run to the journal. It
+// is deferred, so a run that stopped halfway can be undone too.
+func (j *generationJournal) finish() {
+	if j == nil || len(j.entry.Files) == 0 {
+		return
+	}
+	// Files tracked but neither there before nor written, e.g. docs swag
+	// did not generate, are dropped.
+	files := j.entry.Files[:0]
+	for _, file := range j.entry.Files {
+		file.Hash = currentHash(file.Path)
+		if file.PreviousHash != "" || file.Hash != "" {
+			files = append(files, file)
+		}
+	}
+	j.entry.Files = files
+	if len(files) == 0 {
+		return
+	}
+	entries, err := readJournal()
+	if err == nil {
+		err = writeJournal(append(entries, j.entry))
+	}
+	if err != nil {
+		fmt.Printf("Error recording the run in %s, which undo will not revert: %v\n", journalPath, err)
+	}
+}
+
+// journalingWriter tracks every file in the journal before writing it.
+type journalingWriter struct {
+	FileWriter
+	journal *generationJournal
+}
+
+func (w journalingWriter) WriteFile(path string, content []byte) error {
+	if err := w.journal.track(path); err != nil {
+		return fmt.Errorf("journaling %s: %w", path, err)
+	}
+	return w.FileWriter.WriteFile(path, content)
+}
+
+// currentHash is the hash of the file at path, empty when there is none.
+func currentHash(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return hashContent(content)
+}
+
+func readJournal() ([]journalEntry, error) {
+	content, err := os.ReadFile(journalPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []journalEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", journalPath, err)
+	}
+	return entries, nil
+}
+
+func writeJournal(entries []journalEntry) error {
+	if len(entries) == 0 {
+		err := os.Remove(journalPath)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	content, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(journalPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(journalPath, append(content, '\n'), 0644)
+}
+
+var undoForce bool
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Reverts the most recent crud run.",
+	Long: `Every crud run writing into the working tree is recorded in .crudgen/journal.json,
+with the files it created and the previous content of the files it changed.
+This command reverts the most recent run: the files it created are removed and
+the files it changed are restored, the docs --swag-init regenerated included,
+whatever else the working tree holds. Run it again to revert the run before.
+Files edited since the run are left alone and fail the command unless --force
+is given. For example:
+
+go run . undo`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return undoLastRun(undoForce)
+	},
+}
+
+func init() {
+	undoCmd.Flags().BoolVar(&undoForce, "force", false, "Also revert the files edited since the run, losing the edits")
+	rootCmd.AddCommand(undoCmd)
+}
+
+func undoLastRun(force bool) error {
+	entries, err := readJournal()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("Nothing to undo.")
+		return nil
+	}
+	last := entries[len(entries)-1]
+	backupDir := filepath.Join(journalBackupDir, last.ID)
+
+	if !force {
+		var edited []string
+		for _, file := range last.Files {
+			if currentHash(file.Path) != file.Hash {
+				edited = append(edited, file.Path)
+			}
+		}
+		if len(edited) > 0 {
+			return fmt.Errorf("%s changed since the run of %q; revert with --force to lose the changes", strings.Join(edited, ", "), last.Spec)
+		}
+	}
+
+	// Every previous content is read and checked before any file is touched,
+	// so a missing or damaged backup fails the command with the tree intact.
+	previous := map[string][]byte{}
+	for _, file := range last.Files {
+		if file.PreviousHash == "" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(backupDir, file.Path))
+		if err != nil {
+			return fmt.Errorf("reading the previous content of %s: %w", file.Path, err)
+		}
+		if hashContent(content) != file.PreviousHash {
+			return fmt.Errorf("the previous content of %s kept in %s does not match its hash", file.Path, backupDir)
+		}
+		previous[file.Path] = content
+	}
+
+	removed, restored := 0, 0
+	for _, file := range last.Files {
+		content, ok := previous[file.Path]
+		if !ok {
+			if err := os.Remove(file.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			removeEmptyDirs(filepath.Dir(file.Path))
+			removed++
+			continue
+		}
+		mode := os.FileMode(0644)
+		if info, err := os.Stat(file.Path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file.Path, content, mode); err != nil {
+			return err
+		}
+		restored++
+	}
+
+	if err := writeJournal(entries[:len(entries)-1]); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(backupDir); err != nil {
+		return err
+	}
+	removeEmptyDirs(journalBackupDir)
+	fmt.Printf("Reverted the run of %q: removed %d file(s) and restored %d.\n", last.Spec, removed, restored)
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents while they are empty, up to the
+// working directory.
+func removeEmptyDirs(dir string) {
+	for dir != "." && dir != string(filepath.Separator) && os.Remove(dir) == nil {
+		dir = filepath.Dir(dir)
+	}
+}
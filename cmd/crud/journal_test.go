@@ -0,0 +1,99 @@
+package crud
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUndoLastRun(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare changes the tree the run left before undo.
+		prepare func(t *testing.T, backupDir string)
+		force   bool
+		wantErr bool
+		// want maps the paths to their content after undo, "" for none.
+		want map[string]string
+	}{
+		{
+			name:    "reverts the run",
+			prepare: func(*testing.T, string) {},
+			want:    map[string]string{"changed.go": "before", "created/widget.go": ""},
+		},
+		{
+			name:    "refuses edited files",
+			prepare: func(t *testing.T, _ string) { writeTestFile(t, "changed.go", "edited") },
+			wantErr: true,
+			want:    map[string]string{"changed.go": "edited", "created/widget.go": "generated"},
+		},
+		{
+			name:    "reverts edited files with force",
+			prepare: func(t *testing.T, _ string) { writeTestFile(t, "changed.go", "edited") },
+			force:   true,
+			want:    map[string]string{"changed.go": "before", "created/widget.go": ""},
+		},
+		{
+			name: "touches nothing when a backup is damaged",
+			prepare: func(t *testing.T, backupDir string) {
+				writeTestFile(t, filepath.Join(backupDir, "changed.go"), "damaged")
+			},
+			force:   true,
+			wantErr: true,
+			want:    map[string]string{"changed.go": "after", "created/widget.go": "generated"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			writeTestFile(t, "changed.go", "before")
+
+			journal := newGenerationJournal("Widget")
+			// The created file comes first, so undo reaches it before the
+			// backup of the changed one.
+			for _, path := range []string{"created/widget.go", "changed.go"} {
+				if err := journal.track(path); err != nil {
+					t.Fatal(err)
+				}
+			}
+			writeTestFile(t, "changed.go", "after")
+			writeTestFile(t, "created/widget.go", "generated")
+			journal.finish()
+			tt.prepare(t, filepath.Join(journalBackupDir, journal.entry.ID))
+
+			err := undoLastRun(tt.force)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("undoLastRun() error = %v, want error %v", err, tt.wantErr)
+			}
+			for path, want := range tt.want {
+				content, err := os.ReadFile(path)
+				if want == "" {
+					if !os.IsNotExist(err) {
+						t.Errorf("%s was not removed: %q, %v", path, content, err)
+					}
+					continue
+				}
+				if string(content) != want {
+					t.Errorf("%s = %q, want %q", path, content, want)
+				}
+			}
+			entries, err := readJournal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wantEntries := map[bool]int{true: 1, false: 0}[tt.wantErr]; len(entries) != wantEntries {
+				t.Errorf("journal has %d run(s), want %d", len(entries), wantEntries)
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
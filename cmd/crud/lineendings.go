@@ -0,0 +1,44 @@
+package crud
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+)
+
+const (
+	lineEndingsLF   = "lf"
+	lineEndingsCRLF = "crlf"
+	lineEndingsAuto = "auto"
+)
+
+// useCRLF reports whether generated files get CRLF line endings. auto
+// follows the project's go.mod, and the platform when there is none, so the
+// files match a working tree checked out with core.autocrlf.
+func useCRLF(lineEndings string) bool {
+	switch lineEndings {
+	case lineEndingsCRLF:
+		return true
+	case lineEndingsAuto:
+		if content, err := os.ReadFile("go.mod"); err == nil {
+			return bytes.Contains(content, []byte("\r\n"))
+		}
+		return runtime.GOOS == "windows"
+	}
+	return false
+}
+
+// normalizeLineEndings turns CRLF line endings into LF, the line endings the
+// templates are written in and the header hash is computed over.
+func normalizeLineEndings(content []byte) []byte {
+	return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+}
+
+// withLineEndings gives content LF line endings, or CRLF ones if crlf is set.
+func withLineEndings(content []byte, crlf bool) []byte {
+	content = normalizeLineEndings(content)
+	if !crlf {
+		return content
+	}
+	return bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+}
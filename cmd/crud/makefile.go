@@ -0,0 +1,94 @@
+package crud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const makefilePath = "Makefile"
+
+// registerMakeTargets writes the entity's targets into the Makefile at path,
+// creating it if needed. The marked block is replaced on later runs and the
+// rest of the file is left as it was.
+func registerMakeTargets(path string, data TemplateData) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	crlf := bytes.Contains(content, []byte("\r\n"))
+	content = normalizeLineEndings(content)
+
+	tmpl, err := template.New(path).Parse(makeTargetsTemplate)
+	if err != nil {
+		return err
+	}
+	// The markers delimit the entity's targets, so a later run replaces them
+	// instead of adding them again.
+	begin, end := "# gocrud-gen:begin "+data.KebabCase+"\n", "# gocrud-gen:end "+data.KebabCase+"\n"
+	var block bytes.Buffer
+	block.WriteString(begin)
+	if err := tmpl.Execute(&block, data); err != nil {
+		return err
+	}
+	block.WriteString(end)
+
+	content, replaced, err := replaceMarkedBlock(content, begin, end, block.Bytes())
+	if err != nil {
+		return err
+	}
+	if !replaced {
+		if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+			content = append(content, '\n')
+		}
+		if len(content) > 0 {
+			content = append(content, '\n')
+		}
+		content = append(content, block.Bytes()...)
+	}
+	return os.WriteFile(path, withLineEndings(content, crlf), 0644)
+}
+
+// replaceMarkedBlock replaces the lines from begin to end in content with
+// block, which includes the markers. It reports false when content has
+// neither marker yet.
+func replaceMarkedBlock(content []byte, begin, end string, block []byte) ([]byte, bool, error) {
+	start := bytes.Index(content, []byte(begin))
+	stop := bytes.Index(content, []byte(end))
+	switch {
+	case start >= 0 && stop > start:
+		return append(content[:start:start], append(block, content[stop+len(end):]...)...), true, nil
+	case start >= 0 || stop >= 0:
+		return nil, false, fmt.Errorf("unbalanced %q markers", strings.TrimSpace(begin))
+	default:
+		return content, false, nil
+	}
+}
+
+// --- MAKEFILE TEMPLATES ---
+
+const makeTargetsTemplate = `.PHONY: migrate-{{.KebabCase}} test-{{.KebabCase}} mocks-{{.KebabCase}}
+
+migrate-{{.KebabCase}}: ## Apply the {{.SnakeCase}} migrations to DATABASE_URL
+	@for migration in $(sort $(wildcard migrations/{{.SnakeCase}}_*.up.sql)); do \
+		echo "$$migration"; psql "$(DATABASE_URL)" -v ON_ERROR_STOP=1 -f "$$migration" || exit 1; \
+	done
+
+test-{{.KebabCase}}: ## Run the {{.PascalCase}} tests
+{{- if .TestServices}}
+	docker compose -f docker-compose.test.yml up -d --wait{{range .TestServices}} {{.Name}}{{end}}
+	$(MAKE) migrate-{{.KebabCase}} DATABASE_URL='{{.TestDatabaseURL}}'
+	{{range .TestServices}}{{.Env}} {{end}}go test -run '{{.PascalCase}}' ./...
+{{- else}}
+	go test -run '{{.PascalCase}}' ./...
+{{- end}}
+
+mocks-{{.KebabCase}}: ## Regenerate the {{.PascalCase}} service and repository mocks
+	mockery --dir internal/service --name {{.PascalCase}} --output internal/mocks/service --outpkg service
+{{- if not .Stub}}
+	mockery --dir internal/transport/repository --name {{.PascalCase}} --output internal/mocks/repository --outpkg repository
+{{- end}}
+`
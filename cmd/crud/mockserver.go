@@ -0,0 +1,226 @@
+package crud
+
+// --- MOCK SERVER TEMPLATES ---
+
+// mockServerMainTemplate is shared by every entity, so it is generated once
+// and left alone on later runs. Entities register themselves from their own files.
+const mockServerMainTemplate = `// Command mockserver serves the generated REST routes from in-memory storage
+// for frontend development and consumer contract tests.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+var registrations []func(mux *http.ServeMux)
+
+func main() {
+	addr := flag.String("addr", ":8081", "address to listen on")
+	seed := flag.Int("seed", 10, "number of fake records to create per entity")
+	flag.Parse()
+
+	seedCount = *seed
+	mux := http.NewServeMux()
+	for _, register := range registrations {
+		register(mux)
+	}
+
+	log.Printf("mock server listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+var seedCount int
+
+type resource struct {
+	mu     sync.Mutex
+	rows   map[int64]map[string]any
+	nextID int64
+}
+
+func newResource(fake func(id int64) map[string]any) *resource {
+	r := &resource{rows: make(map[int64]map[string]any)}
+	for i := 0; i < seedCount; i++ {
+		r.nextID++
+		r.rows[r.nextID] = fake(r.nextID)
+	}
+	return r
+}
+
+// register serves reads under readPrefix and writes under writePrefix, which
+// differ when write operations live in the admin router group.
+func (r *resource) register(mux *http.ServeMux, readPrefix, writePrefix string) {
+	mux.HandleFunc("GET "+readPrefix+"/", r.list)
+	mux.HandleFunc("GET "+readPrefix+"/{id}", r.get)
+	mux.HandleFunc("POST "+writePrefix+"/", r.create)
+	mux.HandleFunc("PUT "+writePrefix+"/{id}", r.update)
+	mux.HandleFunc("DELETE "+writePrefix+"/{id}", r.delete)
+}
+
+func (r *resource) list(w http.ResponseWriter, req *http.Request) {
+	page := queryInt(req, "page", 1)
+	pageSize := queryInt(req, "page_size", 10)
+
+	r.mu.Lock()
+	ids := make([]int64, 0, len(r.rows))
+	for id := range r.rows {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	data := []map[string]any{}
+	for i := (page - 1) * pageSize; i >= 0 && i < len(ids) && i < page*pageSize; i++ {
+		data = append(data, r.rows[ids[i]])
+	}
+	total := len(ids)
+	r.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": true,
+		"data":   data,
+		"meta": map[string]any{
+			"pagination": map[string]any{"page": page, "page_size": pageSize, "total": total},
+		},
+	})
+}
+
+func (r *resource) get(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathID(w, req)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	row, found := r.rows[id]
+	r.mu.Unlock()
+	if !found {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": true, "data": row})
+}
+
+func (r *resource) create(w http.ResponseWriter, req *http.Request) {
+	var row map[string]any
+	if err := json.NewDecoder(req.Body).Decode(&row); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	r.mu.Lock()
+	r.nextID++
+	row["id"] = r.nextID
+	r.rows[r.nextID] = row
+	r.mu.Unlock()
+	writeJSON(w, http.StatusCreated, map[string]any{"status": true, "data": row})
+}
+
+func (r *resource) update(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathID(w, req)
+	if !ok {
+		return
+	}
+	var row map[string]any
+	if err := json.NewDecoder(req.Body).Decode(&row); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	r.mu.Lock()
+	_, found := r.rows[id]
+	if found {
+		row["id"] = id
+		r.rows[id] = row
+	}
+	r.mu.Unlock()
+	if !found {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": true, "data": row})
+}
+
+func (r *resource) delete(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathID(w, req)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	delete(r.rows, id)
+	r.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func pathID(w http.ResponseWriter, req *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(req.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return 0, false
+	}
+	return id, true
+}
+
+func queryInt(req *http.Request, key string, fallback int) int {
+	value, err := strconv.Atoi(req.URL.Query().Get(key))
+	if err != nil || value < 1 {
+		return fallback
+	}
+	return value
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+{{- if .ProblemJSON}}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"type":   "about:blank",
+		"title":  http.StatusText(status),
+		"status": status,
+		"detail": message,
+	})
+{{- else}}
+	writeJSON(w, status, map[string]any{"status": false, "message": message})
+{{- end}}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+`
+
+const mockServerEntityTemplate = `package main
+
+{{if .HasJSONField -}}
+import (
+	"encoding/json"
+	"net/http"
+)
+{{- else -}}
+import "net/http"
+{{- end}}
+
+func init() {
+	registrations = append(registrations, func(mux *http.ServeMux) {
+		newResource(fake{{.PascalCase}}).register(mux, "/api/v1/{{.KebabCase}}", "{{.WriteRoutePrefix}}/{{.KebabCase}}")
+	})
+}
+
+// fake{{.PascalCase}} builds one seeded {{.PascalCase}} record.
+func fake{{.PascalCase}}(id int64) map[string]any {
+{{- if not .Entity.Fields}}
+	// TODO: Add representative values for the {{.PascalCase}} fields.
+{{- end}}
+	return map[string]any{
+{{- range .MockFields}}
+		{{.}}
+{{- end}}
+	}
+}
+`
@@ -0,0 +1,183 @@
+package crud
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// projectModule is the module path of the project the code is generated into.
+const projectModule = "git.snapp.ninja/snappshop/delivery/harley"
+
+// moduleGroupName is a Go package name, which the group's wiring file uses.
+var moduleGroupName = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// groupedLayers maps the directories of the entity's own layers to where they
+// go inside internal/features/<group> with --module-group. Shared files stay
+// where they are.
+var groupedLayers = []struct{ from, to string }{
+	{"internal/transport/repository/postgres", "repository/postgres"},
+	{"internal/service", "service"},
+	{"internal/transport/http/rest/controller/v1", "controller/v1"},
+}
+
+// FeatureDir is the directory of the entity's module group.
+func (d TemplateData) FeatureDir() string {
+	return "internal/features/" + d.ModuleGroup
+}
+
+// ServiceImport is the import path of the package the entity's service is in.
+func (d TemplateData) ServiceImport() string {
+	if d.ModuleGroup == "" {
+		return projectModule + "/internal/service"
+	}
+	return projectModule + "/" + d.FeatureDir() + "/service"
+}
+
+// FeatureImport is the import path of layer inside the entity's module group,
+// e.g. {{.FeatureImport "repository/postgres"}}.
+func (d TemplateData) FeatureImport(layer string) string {
+	return projectModule + "/" + d.FeatureDir() + "/" + layer
+}
+
+// groupPath moves path into the entity's module group if it belongs to one of
+// the grouped layers.
+func (d TemplateData) groupPath(path string) string {
+	if d.ModuleGroup == "" {
+		return path
+	}
+	slashed := filepath.ToSlash(path)
+	for _, layer := range groupedLayers {
+		if rest, ok := strings.CutPrefix(slashed, layer.from+"/"); ok {
+			return filepath.FromSlash(d.FeatureDir() + "/" + layer.to + "/" + rest)
+		}
+	}
+	return path
+}
+
+// groupStep rewrites the paths of a next step written for the default layout
+// into the entity's module group.
+func (d TemplateData) groupStep(step string) string {
+	if d.ModuleGroup == "" {
+		return step
+	}
+	for _, layer := range groupedLayers {
+		step = strings.ReplaceAll(step, "'"+layer.from+"/", "'"+d.FeatureDir()+"/"+layer.to+"/")
+	}
+	return step
+}
+
+// --- MODULE GROUP TEMPLATES ---
+
+// featureModuleTemplate is shared by the entities of a group, so it is
+// generated once and left alone on later runs.
+const featureModuleTemplate = `package {{.ModuleGroup}}
+
+import (
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/validator"
+)
+
+// Deps are the dependencies shared by the entities of the {{.ModuleGroup}} feature.
+// The initializer builds them once and passes them to each entity's constructor.
+type Deps struct {
+	DB               ports.Database
+	Log              ports.LoggerWithTraceID
+	CustomValidation validator.CustomValidation
+}
+`
+
+const featureWiringTemplate = `package {{.ModuleGroup}}
+
+import (
+{{- if .ReadReplicas}}
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- end}}
+{{- if .EncryptsFields}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/encryption"
+{{- end}}
+	{{.LowerCase}} "{{.FeatureImport "controller/v1"}}/{{.CamelCase}}"
+{{- if not .Stub}}
+	"{{.FeatureImport "repository/postgres"}}"
+{{- end}}
+	"{{.ServiceImport}}"
+{{- if .GDPR}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/gdpr"
+{{- end}}
+{{- if .Saga}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/saga"
+{{- end}}
+{{- if not .Stub}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+{{- end}}
+{{- if .Webhooks}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/webhook"
+{{- end}}
+{{- if .Worker}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/worker"
+{{- end}}
+)
+
+// {{.PascalCase}} holds the wired layers of the {{.PascalCase}} entity.
+type {{.PascalCase}} struct {
+{{- if not .Stub}}
+	Repository repository.{{.PascalCase}}
+{{- end}}
+	Service    service.{{.PascalCase}}
+	Controller {{.LowerCase}}.{{.PascalCase}}
+{{- if .Admin}}
+	Admin      {{.LowerCase}}.{{.PascalCase}}Admin
+{{- end}}
+{{- if .GDPR}}
+	Subject    gdpr.Subject
+	Export     {{.LowerCase}}.{{.PascalCase}}Export
+{{- end}}
+{{- if .CheckDuplicate}}
+	Duplicates {{.LowerCase}}.{{.PascalCase}}Duplicates
+{{- end}}
+{{- if .Approval}}
+	Approvals  {{.LowerCase}}.{{.PascalCase}}Approvals
+{{- end}}
+}
+
+// New{{.PascalCase}} wires the {{.PascalCase}} repository, service and controller.
+func New{{.PascalCase}}(deps Deps{{if .Webhooks}}, webhooks *webhook.{{.PascalCase}}Dispatcher{{end}}{{if .Worker}}, {{.CamelCase}}Worker *worker.{{.PascalCase}}Worker{{end}}{{if .Timeout}}, timeouts service.{{.PascalCase}}Timeouts{{end}}{{if .ReadReplicas}}, replicaDB ports.Database{{end}}{{if .EncryptsFields}}, keys encryption.KeyProvider{{end}}{{if .GDPR}}, subjects gdpr.Registry{{end}}{{if .Clients}}, clients service.{{.PascalCase}}Clients{{end}}) {{.PascalCase}} {
+	var wired {{.PascalCase}}
+{{- if .Stub}}
+	wired.Service = service.New{{.PascalCase}}Service(deps.Log)
+{{- else}}
+{{- if .ReadReplicas}}
+	wired.Repository = repository.New{{.PascalCase}}ReadSplit(
+		postgres.New{{.PascalCase}}Repository(deps.DB, deps.Log),
+		postgres.New{{.PascalCase}}Repository(replicaDB, deps.Log),
+	)
+{{- else}}
+	wired.Repository = postgres.New{{.PascalCase}}Repository(deps.DB, deps.Log)
+{{- end}}
+{{- if .EncryptsFields}}
+	wired.Repository = repository.New{{.PascalCase}}Encrypting(wired.Repository, keys)
+{{- end}}
+	wired.Service = service.New{{.PascalCase}}Service(deps.Log, wired.Repository{{if .Webhooks}}, webhooks{{end}}{{if .Worker}}, {{.CamelCase}}Worker{{end}}{{if .Timeout}}, timeouts{{end}}{{if .LoadsRelations}}, postgres.New{{.PascalCase}}Relations(deps.DB, deps.Log){{end}}{{if .SoftDelete}}, postgres.New{{.PascalCase}}Trash(deps.DB, deps.Log){{end}}{{if .History}}, postgres.New{{.PascalCase}}History(deps.DB, deps.Log){{end}}{{if .Saga}}, saga.New(saga.NewPostgresStore(deps.DB, deps.Log), deps.Log){{end}}{{if .Clients}}, clients{{end}})
+{{- end}}
+	wired.Controller = {{.LowerCase}}.New(deps.Log, wired.Service, deps.CustomValidation)
+{{- if .Admin}}
+	wired.Admin = {{.LowerCase}}.NewAdmin(deps.Log, wired.Service, deps.CustomValidation)
+{{- end}}
+{{- if .GDPR}}
+{{- if .EncryptsFields}}
+	wired.Subject = service.New{{.PascalCase}}DataSubjectService(deps.Log, repository.New{{.PascalCase}}DataSubjectEncrypting(postgres.New{{.PascalCase}}DataSubject(deps.DB, deps.Log), keys))
+{{- else}}
+	wired.Subject = service.New{{.PascalCase}}DataSubjectService(deps.Log, postgres.New{{.PascalCase}}DataSubject(deps.DB, deps.Log))
+{{- end}}
+	subjects.Register("{{.PascalCase}}", wired.Subject)
+	wired.Export = {{.LowerCase}}.NewExport(deps.Log, wired.Subject)
+{{- end}}
+{{- if .CheckDuplicate}}
+	wired.Duplicates = {{.LowerCase}}.NewDuplicates(deps.Log, service.New{{.PascalCase}}DuplicateService(deps.Log, postgres.New{{.PascalCase}}Duplicates(deps.DB, deps.Log)))
+{{- end}}
+{{- if .Approval}}
+	wired.Approvals = {{.LowerCase}}.NewApprovals(deps.Log, service.New{{.PascalCase}}ApprovalService(deps.Log, postgres.New{{.PascalCase}}Approvals(deps.DB, deps.Log), wired.Service), deps.CustomValidation)
+{{- end}}
+	return wired
+}
+`
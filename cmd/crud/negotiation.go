@@ -0,0 +1,79 @@
+package crud
+
+// --- CONTENT NEGOTIATION TEMPLATES ---
+
+// negotiationTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const negotiationTemplate = `package httpUtils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Encoder serializes response bodies for one media type.
+type Encoder interface {
+	ContentType() string
+	Encode(body any) ([]byte, error)
+}
+
+// encoders are offered in order of preference; the first one is the default
+// when the Accept header is missing or matches nothing.
+var encoders = []Encoder{jsonEncoder{}, xmlEncoder{}, msgpackEncoder{}}
+
+// RegisterEncoder adds or replaces the encoder for its media type.
+// Call it during initialization, before the server starts handling requests.
+func RegisterEncoder(encoder Encoder) {
+	for i, existing := range encoders {
+		if existing.ContentType() == encoder.ContentType() {
+			encoders[i] = encoder
+			return
+		}
+	}
+	encoders = append(encoders, encoder)
+}
+
+// Respond writes body with the given status, encoded in the media type the
+// client prefers according to its Accept header.
+func Respond(c *ports.HttpContext, status int, body any) error {
+	offers := make([]string, len(encoders))
+	for i, encoder := range encoders {
+		offers[i] = encoder.ContentType()
+	}
+
+	encoder := encoders[0]
+	if accepted := c.Accepts(offers...); accepted != "" {
+		for _, candidate := range encoders {
+			if candidate.ContentType() == accepted {
+				encoder = candidate
+				break
+			}
+		}
+	}
+
+	payload, err := encoder.Encode(body)
+	if err != nil {
+		return err
+	}
+	c.Set("Content-Type", encoder.ContentType())
+	return c.Status(status).Send(payload)
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string             { return "application/json" }
+func (jsonEncoder) Encode(body any) ([]byte, error) { return json.Marshal(body) }
+
+type xmlEncoder struct{}
+
+func (xmlEncoder) ContentType() string             { return "application/xml" }
+func (xmlEncoder) Encode(body any) ([]byte, error) { return xml.Marshal(body) }
+
+type msgpackEncoder struct{}
+
+func (msgpackEncoder) ContentType() string             { return "application/msgpack" }
+func (msgpackEncoder) Encode(body any) ([]byte, error) { return msgpack.Marshal(body) }
+`
@@ -0,0 +1,44 @@
+package crud
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+const (
+	overwriteAlways = "always"
+	overwriteOnce   = "once"
+)
+
+// OverwriteConfig sets, per artifact, whether crud regenerates a file that
+// already exists. Artifacts are named after their built-in template, the
+// files 'templates list' prints without the extension, e.g.
+//
+//	overwrite:
+//	  controller: always
+//	  request: once
+//
+// Artifacts generated "always" are machine-owned and replaced on every run;
+// those generated "once", the default, are left to their owners.
+type OverwriteConfig map[string]string
+
+func (c OverwriteConfig) validate() error {
+	for _, artifact := range slices.Sorted(maps.Keys(c)) {
+		if _, ok := builtinTemplates[artifact]; !ok {
+			return fmt.Errorf("overwrite: unknown artifact %q%s", artifact, suggest(artifact, slices.Sorted(maps.Keys(builtinTemplates))))
+		}
+		switch policy := c[artifact]; policy {
+		case overwriteAlways, overwriteOnce:
+		default:
+			return fmt.Errorf("overwrite.%s: invalid policy %q: must be %q or %q", artifact, policy, overwriteAlways, overwriteOnce)
+		}
+	}
+	return nil
+}
+
+// regenerates reports whether an existing file of artifact is generated
+// again.
+func (c OverwriteConfig) regenerates(artifact string) bool {
+	return c[artifact] == overwriteAlways
+}
@@ -0,0 +1,128 @@
+package crud
+
+// --- PACT TEMPLATES ---
+
+const pactConsumerTemplate = `//go:build pact
+
+package pact
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/consumer"
+	"github.com/pact-foundation/pact-go/v2/matchers"
+)
+
+// Test{{.PascalCase}}Consumer is an example consumer contract; copy it into the
+// consuming service and replace the raw HTTP calls with its real client.
+func Test{{.PascalCase}}Consumer(t *testing.T) {
+	mockProvider, err := consumer.NewV2Pact(consumer.MockHTTPProviderConfig{
+		Consumer: "{{.KebabCase}}-consumer",
+		Provider: providerName(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mockProvider.
+		AddInteraction().
+		Given("{{.PascalCase}} {{.SampleID}} exists").
+		UponReceiving("a request for {{.PascalCase}} {{.SampleID}}").
+		WithRequest(http.MethodGet, "/api/v1/{{.KebabCase}}/{{.SampleID}}").
+		WillRespondWith(http.StatusOK, func(b *consumer.V2ResponseBuilder) {
+{{- if .Envelope.None}}
+			b.JSONBody(matchers.MapMatcher{
+				"id": matchers.Like({{if .UUID}}"{{.SampleID}}"{{else}}1{{end}}),
+			})
+{{- else}}
+			b.JSONBody(matchers.MapMatcher{
+{{- if not .Envelope.IsSet}}
+				"status": matchers.Like(true),
+{{- end}}
+				"{{.Envelope.DataField}}": matchers.MapMatcher{
+					"id": matchers.Like({{if .UUID}}"{{.SampleID}}"{{else}}1{{end}}),
+				},
+			})
+{{- end}}
+		}).
+		ExecuteTest(t, func(config consumer.MockServerConfig) error {
+			resp, err := http.Get(fmt.Sprintf("http://%s:%d/api/v1/{{.KebabCase}}/{{.SampleID}}", config.Host, config.Port))
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("unexpected status %d", resp.StatusCode)
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+const pactProviderTemplate = `//go:build pact
+
+package pact
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/pact-foundation/pact-go/v2/provider"
+)
+
+// Test{{.PascalCase}}Provider verifies the running API against every pact
+// recorded for the {{.PascalCase}} endpoints. Start the service first and
+// point PACT_PROVIDER_BASE_URL at it.
+func Test{{.PascalCase}}Provider(t *testing.T) {
+	baseURL := os.Getenv("PACT_PROVIDER_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	pactFiles, err := filepath.Glob(filepath.Join("pacts", "*-"+providerName()+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pactFiles) == 0 {
+		t.Skip("no pact files found in ./pacts")
+	}
+
+	err = provider.NewVerifier().VerifyProvider(t, provider.VerifyRequest{
+		Provider:        providerName(),
+		ProviderBaseURL: baseURL,
+		PactFiles:       pactFiles,
+		StateHandlers: models.StateHandlers{
+			"{{.PascalCase}} {{.SampleID}} exists": func(setup bool, state models.ProviderState) (models.ProviderStateResponse, error) {
+				// TODO: Seed {{.PascalCase}} 1 when setup is true and remove it otherwise.
+				return models.ProviderStateResponse{}, nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+// pactHelpersTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const pactHelpersTemplate = `//go:build pact
+
+package pact
+
+import "os"
+
+func providerName() string {
+	if name := os.Getenv("PACT_PROVIDER"); name != "" {
+		return name
+	}
+	return "harley"
+}
+`
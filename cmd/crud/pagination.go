@@ -0,0 +1,145 @@
+package crud
+
+import "fmt"
+
+// PaginationConfig sets the pagination defaults baked into the generated list
+// handlers. Entities overrides them per entity, keyed by PascalCase name.
+type PaginationConfig struct {
+	PaginationDefaults `yaml:",inline"`
+	Entities           map[string]PaginationDefaults `yaml:"entities"`
+}
+
+// PaginationDefaults are the pagination settings of one entity. Zero values
+// leave the framework defaults in place.
+type PaginationDefaults struct {
+	DefaultPageSize int    `yaml:"default_page_size"`
+	MaxPageSize     int    `yaml:"max_page_size"`
+	DefaultSort     string `yaml:"default_sort"`
+}
+
+// IsSet reports whether any default overrides the framework behaviour.
+func (d PaginationDefaults) IsSet() bool {
+	return d != PaginationDefaults{}
+}
+
+// forEntity returns the defaults of entity, falling back to the global ones
+// for every setting the entity does not override.
+func (c PaginationConfig) forEntity(entity string) PaginationDefaults {
+	defaults := c.PaginationDefaults
+	override := c.Entities[entity]
+	if override.DefaultPageSize != 0 {
+		defaults.DefaultPageSize = override.DefaultPageSize
+	}
+	if override.MaxPageSize != 0 {
+		defaults.MaxPageSize = override.MaxPageSize
+	}
+	if override.DefaultSort != "" {
+		defaults.DefaultSort = override.DefaultSort
+	}
+	return defaults
+}
+
+func (c PaginationConfig) validate() error {
+	scopes := map[string]PaginationDefaults{"pagination": c.PaginationDefaults}
+	for entity := range c.Entities {
+		scopes["pagination.entities."+entity] = c.forEntity(entity)
+	}
+	for scope, d := range scopes {
+		if d.DefaultPageSize < 0 || d.MaxPageSize < 0 {
+			return fmt.Errorf("%s: page sizes must not be negative", scope)
+		}
+		if d.MaxPageSize > 0 && d.DefaultPageSize > d.MaxPageSize {
+			return fmt.Errorf("%s: default_page_size %d exceeds max_page_size %d", scope, d.DefaultPageSize, d.MaxPageSize)
+		}
+	}
+	return nil
+}
+
+// --- PAGINATION TEMPLATES ---
+
+// paginationDefaultsTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const paginationDefaultsTemplate = `package httpUtils
+
+import (
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// PaginationDefaults overrides the framework pagination defaults for one
+// entity. Zero values keep the framework behaviour.
+type PaginationDefaults struct {
+	DefaultPageSize int
+	MaxPageSize     int
+	DefaultSort     string
+}
+
+// ApplyPaginationDefaults fills in the page size and sort the request left out
+// and caps the page size, after ParseAndValidatePagination has run.
+func ApplyPaginationDefaults(c *ports.HttpContext, pagination dto.Pagination, defaults PaginationDefaults) dto.Pagination {
+	if defaults.DefaultPageSize > 0 && c.Query("page_size") == "" {
+		pagination.PageSize = defaults.DefaultPageSize
+	}
+	if defaults.MaxPageSize > 0 && pagination.PageSize > defaults.MaxPageSize {
+		pagination.PageSize = defaults.MaxPageSize
+	}
+	if defaults.DefaultSort != "" && c.Query("sort") == "" {
+		pagination.Sort = defaults.DefaultSort
+	}
+	return pagination
+}
+`
+
+// paginationHeadersTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const paginationHeadersTemplate = `package httpUtils
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// TotalCountHeader carries the number of rows matching a list request.
+const TotalCountHeader = "X-Total-Count"
+
+// SetPaginationHeaders also returns the pagination of a list response in
+// GitHub-style headers: TotalCountHeader and a Link header to the previous and
+// next pages, which keep the other query parameters of the request.
+func SetPaginationHeaders(c *ports.HttpContext, pagination *dto.Pagination) {
+	page, total := pageAndTotal(pagination)
+	c.Set(TotalCountHeader, strconv.FormatInt(total, 10))
+
+	var links []string
+	if page > 1 {
+		links = append(links, pageLink(c, page-1, "prev"))
+	}
+	if pagination.PageSize > 0 && int64(page)*int64(pagination.PageSize) < total {
+		links = append(links, pageLink(c, page+1, "next"))
+	}
+	if len(links) > 0 {
+		c.Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// pageAndTotal reads the current page, counted from 1, and the total number of
+// matching rows from the pagination a repository returned.
+func pageAndTotal(pagination *dto.Pagination) (page int, total int64) {
+	return max(pagination.Page, 1), pagination.TotalRows
+}
+
+func pageLink(c *ports.HttpContext, page int, rel string) string {
+	link, err := url.Parse(c.OriginalURL())
+	if err != nil {
+		link = &url.URL{Path: c.Path()}
+	}
+	query := link.Query()
+	query.Set("page", strconv.Itoa(page))
+	link.RawQuery = query.Encode()
+	return fmt.Sprintf("<%s>; rel=%q", link, rel)
+}
+`
@@ -0,0 +1,249 @@
+package crud
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	partitionRange = "range"
+	partitionHash  = "hash"
+
+	defaultPartitionPremake = 3
+	maxHashPartitions       = 1024
+)
+
+// partitionIntervals maps a range partition interval to the to_char pattern
+// naming its partitions.
+var partitionIntervals = map[string]string{
+	"day":   "YYYYMMDD",
+	"week":  `IYYY"w"IW`,
+	"month": "YYYYMM",
+	"year":  "YYYY",
+}
+
+// PartitionSpec declares how a high-volume entity's table is partitioned:
+//
+//	partition:
+//	  strategy: range    # or hash
+//	  key: placed_at     # a time field for range, any field or id for hash
+//	  interval: month    # range only: day, week, month or year
+//	  premake: 3         # range only: future partitions kept ready (default 3)
+//	  partitions: 8      # hash only: number of partitions
+type PartitionSpec struct {
+	Strategy   string `yaml:"strategy"`
+	Key        string `yaml:"key"`
+	Interval   string `yaml:"interval"`
+	Premake    int    `yaml:"premake"`
+	Partitions int    `yaml:"partitions"`
+}
+
+func (p PartitionSpec) validate(entity EntitySpec) error {
+	var key *FieldSpec
+	for i, field := range entity.Fields {
+		if field.Name == p.Key {
+			key = &entity.Fields[i]
+		}
+	}
+	if key == nil && p.Key != "id" {
+		return fmt.Errorf("%s: partition key %q is not a field", entity.Name, p.Key)
+	}
+	if key != nil && key.Nullable {
+		return fmt.Errorf("%s: partition key %s must not be nullable", entity.Name, p.Key)
+	}
+	for _, field := range entity.Fields {
+		if field.Unique && field.Name != p.Key {
+			return fmt.Errorf("%s: unique field %s cannot be enforced across partitions of %s", entity.Name, field.Name, p.Key)
+		}
+	}
+
+	switch p.Strategy {
+	case partitionRange:
+		if key == nil || key.Type != "time" {
+			return fmt.Errorf("%s: range partition key %s must be a time field", entity.Name, p.Key)
+		}
+		if _, ok := partitionIntervals[p.Interval]; !ok {
+			return fmt.Errorf("%s: partition interval %q must be day, week, month or year", entity.Name, p.Interval)
+		}
+		if p.Premake < 0 {
+			return fmt.Errorf("%s: partition premake must not be negative", entity.Name)
+		}
+	case partitionHash:
+		if p.Partitions < 2 || p.Partitions > maxHashPartitions {
+			return fmt.Errorf("%s: hash partitions must be between 2 and %d", entity.Name, maxHashPartitions)
+		}
+	default:
+		return fmt.Errorf("%s: unknown partition strategy %q", entity.Name, p.Strategy)
+	}
+	return nil
+}
+
+// PartitionPremake is the number of future range partitions to keep ready.
+func (p PartitionSpec) PartitionPremake() int {
+	if p.Premake == 0 {
+		return defaultPartitionPremake
+	}
+	return p.Premake
+}
+
+// NamePattern is the to_char pattern of the range partition names.
+func (p PartitionSpec) NamePattern() string {
+	return partitionIntervals[p.Interval]
+}
+
+// HashRemainders lists the remainders of the hash partitions.
+func (p PartitionSpec) HashRemainders() []int {
+	remainders := make([]int, p.Partitions)
+	for i := range remainders {
+		remainders[i] = i
+	}
+	return remainders
+}
+
+// TableColumns renders the column definitions of the entity's table from the
+// spec, aligned on the type: the id, the fields and the foreign keys of
+// belongs_to relations, followed by the primary key.
+func (d TemplateData) TableColumns() []string {
+	type column struct{ name, definition string }
+	columns := []column{{"id", "BIGINT GENERATED BY DEFAULT AS IDENTITY"}}
+	if d.UUID() {
+		columns[0].definition = "UUID NOT NULL DEFAULT gen_random_uuid()"
+	}
+	for _, field := range d.Entity.Fields {
+		definition := fieldTypes[field.Type].SQL
+		if !field.Nullable {
+			definition += " NOT NULL"
+		}
+		if field.Unique {
+			definition += " UNIQUE"
+		}
+		if field.Default != "" {
+			definition += " DEFAULT " + field.Default
+		}
+		if field.Check != "" {
+			definition += " CHECK (" + field.Check + ")"
+		}
+		columns = append(columns, column{field.Name, definition})
+	}
+	for _, relation := range d.Entity.Relations {
+		if relation.Kind == relationBelongsTo {
+			definition := "BIGINT NOT NULL REFERENCES "
+			if relation.OnDelete == onDeleteSetNull {
+				definition = "BIGINT REFERENCES "
+			}
+			columns = append(columns, column{relation.ForeignKey(), definition + relation.TableIdent() + " (id)" + relation.OnDeleteClause()})
+		}
+	}
+
+	width := 0
+	for _, c := range columns {
+		width = max(width, len(c.name))
+	}
+	rendered := make([]string, 0, len(columns)+1)
+	for _, c := range columns {
+		rendered = append(rendered, fmt.Sprintf("%-*s %s", width, c.name, c.definition))
+	}
+
+	keys := []string{"id"}
+	if key := d.Entity.Partition.Key; key != "id" {
+		keys = append(keys, key)
+	}
+	return append(rendered, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+}
+
+// --- PARTITION TEMPLATES ---
+
+const partitionMigrationTemplate = `CREATE TABLE IF NOT EXISTS {{.TableIdent}} (
+{{- range $i, $column := .TableColumns}}{{if $i}},{{end}}
+    {{$column}}
+{{- end}}
+) PARTITION BY {{if eq .Entity.Partition.Strategy "range"}}RANGE{{else}}HASH{{end}} ({{.Entity.Partition.Key}});
+{{- if eq .Entity.Partition.Strategy "range"}}
+
+-- {{.SnakeCase}}_create_partition creates the partition holding for_time if it
+-- does not exist yet and returns its name. {{.PascalCase}}PartitionJob calls it to
+-- keep partitions ready ahead of time; rows outside every partition are rejected.
+CREATE OR REPLACE FUNCTION {{.SnakeCase}}_create_partition(for_time TIMESTAMPTZ) RETURNS TEXT AS $$
+DECLARE
+    start_at       TIMESTAMPTZ := date_trunc('{{.Entity.Partition.Interval}}', for_time);
+    end_at         TIMESTAMPTZ := start_at + INTERVAL '1 {{.Entity.Partition.Interval}}';
+    partition_name TEXT        := '{{.SnakeCase}}_' || to_char(start_at, '{{.Entity.Partition.NamePattern}}');
+BEGIN
+    EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF {{.TableIdent}} FOR VALUES FROM (%L) TO (%L)', partition_name, start_at, end_at);
+    RETURN partition_name;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT {{.SnakeCase}}_create_partition(now() + n * INTERVAL '1 {{.Entity.Partition.Interval}}')
+FROM generate_series(0, {{.Entity.Partition.PartitionPremake}}) AS n;
+{{- else}}
+{{range .Entity.Partition.HashRemainders}}
+CREATE TABLE IF NOT EXISTS {{$.SnakeCase}}_p{{.}} PARTITION OF {{$.TableIdent}} FOR VALUES WITH (MODULUS {{$.Entity.Partition.Partitions}}, REMAINDER {{.}});
+{{- end}}
+{{- end}}
+`
+
+const partitionJobTemplate = `package job
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+// {{.PascalCase}}PartitionSchedule is the cron expression the job is meant to run on.
+const {{.PascalCase}}PartitionSchedule = "0 2 * * *"
+
+// {{.CamelCase}}PartitionPremake is how many {{.Entity.Partition.Interval}}s ahead partitions are kept ready.
+const {{.CamelCase}}PartitionPremake = {{.Entity.Partition.PartitionPremake}}
+
+type {{.PascalCase}}PartitionJob struct {
+	db  *sql.DB
+	log ports.LoggerWithTraceID
+}
+
+func New{{.PascalCase}}PartitionJob(db *sql.DB, log ports.LoggerWithTraceID) *{{.PascalCase}}PartitionJob {
+	return &{{.PascalCase}}PartitionJob{
+		db:  db,
+		log: log,
+	}
+}
+
+// Run creates the {{.SnakeCase}} partitions of the current {{.Entity.Partition.Interval}} and the
+// next {{.CamelCase}}PartitionPremake ones that do not exist yet.
+func (j *{{.PascalCase}}PartitionJob) Run(ctx context.Context) error {
+	for n := 0; n <= {{.CamelCase}}PartitionPremake; n++ {
+		var partition string
+		err := j.db.QueryRowContext(ctx, "SELECT {{.SnakeCase}}_create_partition(now() + $1::int * INTERVAL '1 {{.Entity.Partition.Interval}}')", n).Scan(&partition)
+		if err != nil {
+			return fmt.Errorf("{{.LowerCase}} partitions: %w", err)
+		}
+		j.log.Info(ctx, fmt.Sprintf("{{.LowerCase}} partitions: %s is ready", partition))
+	}
+
+	// TODO: Detach partitions older than the retention period and archive or drop them:
+	// ALTER TABLE {{.TableIdent}} DETACH PARTITION <partition> CONCURRENTLY;
+	return nil
+}
+
+// Start runs the job every interval until ctx is cancelled, for deployments
+// without an external cron scheduler.
+func (j *{{.PascalCase}}PartitionJob) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.Run(ctx); err != nil {
+				j.log.Error(ctx, err.Error())
+			}
+		}
+	}
+}
+`
@@ -0,0 +1,174 @@
+package crud
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// presetSourcePrefix starts the first line of a stored preset, recording where
+// it was added from.
+const presetSourcePrefix = "# gocrud-gen preset from "
+
+// presetNamePattern matches the kebab-case names of presets, e.g.
+// platform-defaults.
+var presetNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+var presetCmd = &cobra.Command{
+	Use:   "preset",
+	Short: "Manages the organization presets configs are layered on.",
+	Long: `A preset is a generator config shared by the services of an organization, e.g.
+its features, profiles and template pack. It is added once per machine and
+layered under the config of every project naming it with preset, or of any
+run given --preset; the keys the project's config sets win. For example:
+
+gocrud-gen preset add platform-defaults https://git.example.com/platform/gocrud-gen/-/raw/main/preset.yaml
+gocrud-gen crud Product --preset platform-defaults`,
+	// The config is not loaded, as it may name the preset being added.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+}
+
+var presetAddCmd = &cobra.Command{
+	Use:   "add [name] [url]",
+	Short: "Adds a preset from a URL or file, replacing a preset of the same name.",
+	Long: `This command fetches the config at an https:// url, or reads it when url is a
+local path, checks it and stores it in the user config directory as the preset name. Run
+it again to pick up changes to the preset.`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return addPreset(args[0], args[1])
+	},
+}
+
+var presetListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "Lists the presets added on this machine and where they came from.",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listPresets()
+	},
+}
+
+func init() {
+	presetCmd.AddCommand(presetAddCmd, presetListCmd)
+	rootCmd.AddCommand(presetCmd)
+}
+
+// presetsDir holds the added presets, one <name>.yaml each.
+func presetsDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gocrud-gen", "presets"), nil
+}
+
+func addPreset(name, source string) error {
+	if !presetNamePattern.MatchString(name) {
+		return fmt.Errorf("preset name %q must be kebab-case", name)
+	}
+	// A preset can set the template pack, so a preset tampered with on the
+	// way changes the generated code; only HTTPS is trusted to fetch one.
+	if strings.HasPrefix(source, "http://") {
+		return fmt.Errorf("refusing to fetch preset %s over plain HTTP; use an https:// URL or a local file", source)
+	}
+	var content []byte
+	var err error
+	if strings.HasPrefix(source, "https://") {
+		content, err = downloadWithHeader(source, http.Header{})
+	} else {
+		content, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return fmt.Errorf("reading preset %s: %w", source, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return fmt.Errorf("parsing preset %s: %w", source, err)
+	}
+	if cfg.Preset != "" {
+		return fmt.Errorf("invalid preset %s: presets cannot name a preset", source)
+	}
+	if err := cfg.validate(); err != nil {
+		return fmt.Errorf("invalid preset %s: %w", source, err)
+	}
+
+	dir, err := presetsDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	stored := append([]byte(presetSourcePrefix+source+"\n"), content...)
+	if err := os.WriteFile(filepath.Join(dir, name+".yaml"), stored, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Preset %s added from %s; use it with --preset %s or preset: %s in %s.\n", name, source, name, name, defaultConfigFile)
+	return nil
+}
+
+// readPreset reads the config of the named preset.
+func readPreset(name string) ([]byte, error) {
+	if !presetNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("preset name %q must be kebab-case", name)
+	}
+	dir, err := presetsDir()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filepath.Join(dir, name+".yaml"))
+	if errors.Is(err, os.ErrNotExist) {
+		if hint := suggest(name, presetNames(dir)); hint != "" {
+			return nil, fmt.Errorf("unknown preset %q%s", name, hint)
+		}
+		return nil, fmt.Errorf("unknown preset %q; add it with 'gocrud-gen preset add %s [url]'", name, name)
+	}
+	return content, err
+}
+
+// presetNames lists the presets stored in dir.
+func presetNames(dir string) []string {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		names = append(names, strings.TrimSuffix(filepath.Base(path), ".yaml"))
+	}
+	return names
+}
+
+func listPresets() error {
+	dir, err := presetsDir()
+	if err != nil {
+		return err
+	}
+	names := presetNames(dir)
+	if len(names) == 0 {
+		fmt.Println("No presets added; add one with 'gocrud-gen preset add [name] [url]'.")
+		return nil
+	}
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Join(dir, name+".yaml"))
+		if err != nil {
+			return err
+		}
+		source := "unknown source"
+		if line, err := bufio.NewReader(bytes.NewReader(content)).ReadString('\n'); err == nil && strings.HasPrefix(line, presetSourcePrefix) {
+			source = strings.TrimSpace(strings.TrimPrefix(line, presetSourcePrefix))
+		}
+		fmt.Printf("%s\t%s\n", name, source)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package crud
+
+const (
+	errorsDetails     = "error-details"
+	errorsProblemJSON = "problem-json"
+)
+
+// ProblemJSON reports whether handlers answer errors with RFC 7807 bodies.
+func (d TemplateData) ProblemJSON() bool {
+	return d.Errors == errorsProblemJSON
+}
+
+// ErrorSchema is the swaggo type of the handlers' error responses.
+func (d TemplateData) ErrorSchema() string {
+	if d.ProblemJSON() {
+		return "httpUtils.Problem"
+	}
+	return "ports.ErrorDetails"
+}
+
+// --- PROBLEM DETAILS TEMPLATES ---
+
+// problemTemplate is shared by every entity, so it is generated once and left
+// alone on later runs.
+const problemTemplate = `package httpUtils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type     string ` + "`" + `json:"type"` + "`" + `
+	Title    string ` + "`" + `json:"title"` + "`" + `
+	Status   int    ` + "`" + `json:"status"` + "`" + `
+	Detail   string ` + "`" + `json:"detail,omitempty"` + "`" + `
+	Instance string ` + "`" + `json:"instance,omitempty"` + "`" + `
+}
+
+// ProblemStatus maps an error returned by a handler to its HTTP status.
+// Errors with a StatusCode method report their own; replace it to map the
+// project's appErr errors if they have none.
+var ProblemStatus = func(err error) int {
+	var coded interface{ StatusCode() int }
+	if errors.As(err, &coded) {
+		return coded.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// Problems wraps handler so the errors it returns are answered with problem
+// details instead of the framework's ErrorDetails. The detail of server
+// errors is left out so internals do not leak to clients.
+func Problems(handler func(c *ports.HttpContext) error) func(c *ports.HttpContext) error {
+	return func(c *ports.HttpContext) error {
+		err := handler(c)
+		if err == nil {
+			return nil
+		}
+		status := ProblemStatus(err)
+		problem := Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(status),
+			Status:   status,
+			Instance: c.Path(),
+		}
+		if status < http.StatusInternalServerError {
+			problem.Detail = err.Error()
+		}
+		body, err := json.Marshal(problem)
+		if err != nil {
+			return err
+		}
+		c.Set("Content-Type", ProblemContentType)
+		return c.Status(status).Send(body)
+	}
+}
+`
@@ -0,0 +1,107 @@
+package crud
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/spf13/pflag"
+)
+
+// Profile bundles crud flags, keyed by flag name without the dashes, e.g.
+//
+//	profiles:
+//	  service:
+//	    inmem: true
+//	    makefile: true
+//	    id-type: uuid
+type Profile map[string]string
+
+// builtinProfiles are available without being configured. A profile of the
+// same name in the config replaces them.
+var builtinProfiles = map[string]Profile{
+	"minimal": {},
+	"full": {
+		"inmem":     "true",
+		"fuzz":      "true",
+		"bench":     "true",
+		"pact":      "true",
+		"http-file": "true",
+		"makefile":  "true",
+		"webhooks":  "true",
+	},
+}
+
+// profileName picks the profile of the run: --profile wins over the entity's
+// profile in the spec, which wins over the config's.
+func profileName(flag, specFile, entityName string) (string, error) {
+	if flag != "" {
+		return flag, nil
+	}
+	if specFile != "" {
+		spec, err := loadSpec(specFile)
+		if err != nil {
+			return "", err
+		}
+		if entity, err := spec.entity(entityName); err == nil && entity.Profile != "" {
+			return entity.Profile, nil
+		}
+	}
+	return config.Profile, nil
+}
+
+// applyProfile sets the flags bundled in the named profile, leaving alone the
+// flags given on the command line.
+func applyProfile(name string, flags *pflag.FlagSet) error {
+	if name == "" {
+		return nil
+	}
+	profile, err := lookupProfile(name)
+	if err != nil {
+		return err
+	}
+
+	for _, flag := range slices.Sorted(maps.Keys(profile)) {
+		if flag == "profile" || flags.Lookup(flag) == nil {
+			return fmt.Errorf("profile %s: unknown flag %q", name, flag)
+		}
+		if flags.Changed(flag) {
+			continue
+		}
+		if err := flags.Set(flag, profile[flag]); err != nil {
+			return fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// lookupProfile finds the named profile in the config, then among the built-in
+// ones.
+func lookupProfile(name string) (Profile, error) {
+	if profile, ok := config.Profiles[name]; ok {
+		return profile, nil
+	}
+	if profile, ok := builtinProfiles[name]; ok {
+		return profile, nil
+	}
+	known := slices.Sorted(maps.Keys(builtinProfiles))
+	for configured := range config.Profiles {
+		if !slices.Contains(known, configured) {
+			known = append(known, configured)
+		}
+	}
+	return nil, fmt.Errorf("unknown profile %q%s", name, suggest(name, known))
+}
+
+// enables reports whether the profile turns on any of flags: a bool flag set
+// to true, or a string flag set to anything but an empty value.
+func (p Profile) enables(flags ...string) bool {
+	for _, flag := range flags {
+		switch value := p[flag]; value {
+		case "", "false":
+		default:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package crud
+
+// --- READ REPLICA TEMPLATES ---
+
+// readFromPrimaryTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const readFromPrimaryTemplate = `package repository
+
+import "context"
+
+type readFromPrimaryKey struct{}
+
+// ReadFromPrimary returns a copy of ctx whose reads the read-split
+// repositories send to the primary instead of a replica. Use it to read your
+// own writes, which the replicas may not have applied yet.
+func ReadFromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readFromPrimaryKey{}, true)
+}
+
+// ReadsFromPrimary reports whether ctx was marked with ReadFromPrimary.
+func ReadsFromPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(readFromPrimaryKey{}).(bool)
+	return primary
+}
+`
+
+const readSplitRepositoryTemplate = `package repository
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// {{.CamelCase}}ReadSplit sends the reads of a {{.PascalCase}} repository to the replica
+// pool and everything else to the primary. Reads on a context marked with
+// ReadFromPrimary go to the primary as well.
+type {{.CamelCase}}ReadSplit struct {
+	{{.PascalCase}}
+	replica {{.PascalCase}}
+}
+
+// New{{.PascalCase}}ReadSplit routes writes to primary and reads to replica, the same
+// repository built on the Database handle of the primary and of the replicas.
+func New{{.PascalCase}}ReadSplit(primary, replica {{.PascalCase}}) {{.PascalCase}} {
+	return &{{.CamelCase}}ReadSplit{primary, replica}
+}
+
+func (r *{{.CamelCase}}ReadSplit) reader(ctx context.Context) {{.PascalCase}} {
+	if ReadsFromPrimary(ctx) {
+		return r.{{.PascalCase}}
+	}
+	return r.replica
+}
+
+func (r *{{.CamelCase}}ReadSplit) GetByID(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error) {
+	return r.reader(ctx).GetByID(ctx, id)
+}
+
+func (r *{{.CamelCase}}ReadSplit) FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+	return r.reader(ctx).FindAll(ctx, pagination)
+}
+`
@@ -0,0 +1,251 @@
+package crud
+
+import "strings"
+
+// RelationLoader is a relation whose rows the list service method loads for a
+// whole page at once instead of one row at a time.
+type RelationLoader struct {
+	RelationSpec
+	// Field is the dto field the loaded rows are assigned to, e.g. Customer or
+	// Items.
+	Field string
+	// Key is the dto field the related rows are matched by: the foreign key of
+	// a belongs_to relation, the entity's own ID otherwise.
+	Key string
+	// Column is the column of the related table the keys are looked up in.
+	Column string
+}
+
+// Many reports whether each row has a slice of related rows.
+func (l RelationLoader) Many() bool {
+	return l.Kind == relationHasMany
+}
+
+// Method is the name of the loader, e.g. LoadCustomersFor.
+func (l RelationLoader) Method() string {
+	return "Load" + l.Entity + "sFor"
+}
+
+// Var is the variable the service keeps the loaded rows in, e.g.
+// customersByCustomerID.
+func (l RelationLoader) Var() string {
+	return l.Single() + "sBy" + l.Key
+}
+
+// JSONName is the name of the relation in responses and in the include query
+// parameter, e.g. customer or items.
+func (l RelationLoader) JSONName() string {
+	return toSnakeCase(l.Field)
+}
+
+// Single is the variable of one loaded row, e.g. customer.
+func (l RelationLoader) Single() string {
+	return strings.ToLower(l.Entity[:1]) + l.Entity[1:]
+}
+
+// RelationLoaders lists the relations of the entity that can be loaded in
+// batches. Many-to-many relations go through a join table and are left out.
+func (d TemplateData) RelationLoaders() []RelationLoader {
+	var loaders []RelationLoader
+	for _, relation := range d.Entity.Relations {
+		loader := RelationLoader{RelationSpec: relation, Field: relation.Entity, Key: "ID", Column: d.SnakeCase + "_id"}
+		switch relation.Kind {
+		case relationBelongsTo:
+			loader.Key = FieldSpec{Name: relation.ForeignKey()}.GoName()
+			loader.Column = "id"
+		case relationHasMany:
+			loader.Field = relation.Entity + "s"
+		case relationHasOne:
+		default:
+			continue
+		}
+		loaders = append(loaders, loader)
+	}
+	return loaders
+}
+
+// LoadsRelations reports whether the list service method loads the entity's
+// relations with the generated loaders, which query PostgreSQL.
+func (d TemplateData) LoadsRelations() bool {
+	return len(d.RelationLoaders()) > 0 && d.Postgres() && !d.AppendOnly && !d.Stub
+}
+
+// IncludeNames renders the relations a request may include as a Go string
+// slice literal.
+func (d TemplateData) IncludeNames() string {
+	quoted := make([]string, 0, len(d.RelationLoaders()))
+	for _, loader := range d.RelationLoaders() {
+		quoted = append(quoted, `"`+loader.JSONName()+`"`)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+// includeNames lists the relations a request may include, quoted for the next
+// steps.
+func (d TemplateData) includeNames() string {
+	var names []string
+	for _, loader := range d.RelationLoaders() {
+		names = append(names, "'"+loader.JSONName()+"'")
+	}
+	return strings.Join(names, ", ")
+}
+
+// relationFields describes the dto fields the loaders fill, for the next steps.
+func (d TemplateData) relationFields() string {
+	var fields []string
+	for _, loader := range d.RelationLoaders() {
+		switch {
+		case loader.Kind == relationBelongsTo:
+			fields = append(fields, "'"+loader.Key+" "+d.IDGoType()+"'", "'"+loader.Field+" *dto."+loader.Entity+"'")
+		case loader.Many():
+			fields = append(fields, "'"+loader.Field+" []dto."+loader.Entity+"'")
+		default:
+			fields = append(fields, "'"+loader.Field+" *dto."+loader.Entity+"'")
+		}
+	}
+	if len(fields) == 1 {
+		return fields[0]
+	}
+	return strings.Join(fields[:len(fields)-1], ", ") + " and " + fields[len(fields)-1]
+}
+
+// --- RELATION LOADER TEMPLATES ---
+
+const relationsInterfaceTemplate = `package repository
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// {{.PascalCase}}Relations loads the rows related to a page of {{.LowerCase}}s with one
+// query per relation. The maps are keyed by the {{.LowerCase}}'s foreign key for
+// belongs_to relations and by its ID otherwise.
+type {{.PascalCase}}Relations interface {
+{{- range .RelationLoaders}}
+	{{.Method}}(ctx context.Context, {{$.CamelCase}}s []dto.{{$.PascalCase}}) (map[{{$.IDGoType}}]{{if .Many}}[]{{end}}dto.{{.Entity}}, error)
+{{- end}}
+}
+`
+
+const relationsRepositoryTemplate = `package postgres
+
+import (
+	"context"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+type {{.CamelCase}}Relations struct {
+	db  ports.Database
+	log ports.LoggerWithTraceID
+}
+
+func New{{.PascalCase}}Relations(db ports.Database, log ports.LoggerWithTraceID) repository.{{.PascalCase}}Relations {
+	return &{{.CamelCase}}Relations{
+		db:  db,
+		log: log,
+	}
+}
+{{- range .RelationLoaders}}
+
+func (r *{{$.CamelCase}}Relations) {{.Method}}(ctx context.Context, {{$.CamelCase}}s []dto.{{$.PascalCase}}) (map[{{$.IDGoType}}]{{if .Many}}[]{{end}}dto.{{.Entity}}, error) {
+	keys := distinct{{$.PascalCase}}Keys({{$.CamelCase}}s, func({{$.CamelCase}} dto.{{$.PascalCase}}) {{$.IDGoType}} { return {{$.CamelCase}}.{{.Key}} })
+	result := make(map[{{$.IDGoType}}]{{if .Many}}[]{{end}}dto.{{.Entity}}, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	// TODO: Select the {{.Table}} rows of all keys in one query and add each to
+	// result under its {{.Column}}.
+	// Example:
+	// SELECT * FROM {{.TableIdent}} WHERE {{.Column}} = ANY($1)
+	return result, nil
+}
+{{- end}}
+
+// distinct{{.PascalCase}}Keys collects the distinct keys of {{.CamelCase}}s, skipping zero
+// keys such as a foreign key that is not set.
+func distinct{{.PascalCase}}Keys({{.CamelCase}}s []dto.{{.PascalCase}}, keyOf func(dto.{{.PascalCase}}) {{.IDGoType}}) []{{.IDGoType}} {
+	var zero {{.IDGoType}}
+	seen := make(map[{{.IDGoType}}]bool, len({{.CamelCase}}s))
+	var keys []{{.IDGoType}}
+	for _, {{.CamelCase}} := range {{.CamelCase}}s {
+		key := keyOf({{.CamelCase}})
+		if key == zero || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+`
+
+// includeDTOTemplate and includeParserTemplate are shared by every entity, so
+// they are generated once and left alone on later runs.
+const includeDTOTemplate = `package dto
+
+import (
+	"context"
+	"slices"
+)
+
+type includeKey struct{}
+
+// WithInclude returns a copy of ctx carrying the relations the request asks
+// to nest in the response.
+func WithInclude(ctx context.Context, relations []string) context.Context {
+	return context.WithValue(ctx, includeKey{}, relations)
+}
+
+// Included reports whether the relations attached by WithInclude hold
+// relation.
+func Included(ctx context.Context, relation string) bool {
+	relations, _ := ctx.Value(includeKey{}).([]string)
+	return slices.Contains(relations, relation)
+}
+`
+
+const includeParserTemplate = `package httpUtils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+// ParseInclude reads the comma-separated include query parameter, e.g.
+// include=customer,items, naming the relations to nest in the response.
+// Relations that are not in allowed are rejected with a bad request error.
+func ParseInclude(c *ports.HttpContext, allowed []string) ([]string, error) {
+	raw := c.Query("include")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var include []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(include, name) {
+			continue
+		}
+		if !slices.Contains(allowed, name) {
+			return nil, appErr.NewBadRequestErr(fmt.Errorf("unknown relation %q to include, must be one of %s", name, strings.Join(allowed, ", ")))
+		}
+		include = append(include, name)
+	}
+	return include, nil
+}
+`
@@ -0,0 +1,137 @@
+package crud
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	reportJSON     = "json"
+	reportMarkdown = "markdown"
+)
+
+var reportOptions struct {
+	Spec   string
+	Format string
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Reports which features each entity of a spec is generated with.",
+	Long: `This command cross-tabulates the entities of a spec against the features
+they are generated with, resolved from the generator config, the entity's
+features and its profile the same way crud resolves them, and prints the
+matrix as a Markdown table or JSON. For example, to see which entities lack
+tests or auditing:
+
+go run . report --spec entities.yaml --format json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printReport()
+	},
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&reportOptions.Spec, "spec", "", "Path to the entities spec file, or '-' to read it from stdin")
+	reportCmd.Flags().StringVar(&reportOptions.Format, "format", reportMarkdown, "Report syntax: 'markdown' or 'json'")
+	reportCmd.MarkFlagRequired("spec")
+	rootCmd.AddCommand(reportCmd)
+}
+
+// entityReport is the row of an entity in the feature matrix.
+type entityReport struct {
+	Entity  string `json:"entity"`
+	Profile string `json:"profile,omitempty"`
+	// SoftDelete is on with the soft_delete feature, --soft-delete or
+	// --retention-job.
+	SoftDelete bool `json:"soft_delete"`
+	Cache      bool `json:"cache"`
+	// Events is on when changes are published, with --webhooks or --cdc.
+	Events bool `json:"events"`
+	// Tests is on when any of --inmem, --fuzz, --bench and --pact is.
+	Tests bool `json:"tests"`
+	// Audit is on with the audit feature or --history.
+	Audit bool `json:"audit"`
+}
+
+func printReport() error {
+	switch reportOptions.Format {
+	case reportJSON, reportMarkdown:
+	default:
+		return fmt.Errorf("invalid --format %q: must be %q or %q", reportOptions.Format, reportMarkdown, reportJSON)
+	}
+
+	spec, err := loadSpec(reportOptions.Spec)
+	if err != nil {
+		return err
+	}
+	rows := make([]entityReport, 0, len(spec.Entities))
+	for _, entity := range spec.Entities {
+		row, err := reportEntity(entity)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+
+	if reportOptions.Format == reportJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rows)
+	}
+	tmpl, err := template.New(reportMarkdown).Funcs(template.FuncMap{"check": reportCheck}).Parse(markdownReportTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(os.Stdout, rows)
+}
+
+// reportEntity resolves the features of entity as a crud run without flags
+// would: its profile, or the config's, sets the flags, and the features of
+// the entity win over those of the config.
+func reportEntity(entity EntitySpec) (entityReport, error) {
+	name := entity.Profile
+	if name == "" {
+		name = config.Profile
+	}
+	var profile Profile
+	if name != "" {
+		var err error
+		if profile, err = lookupProfile(name); err != nil {
+			return entityReport{}, fmt.Errorf("%s: %w", entity.Name, err)
+		}
+	}
+
+	opts := Options{SoftDelete: profile.enables("soft-delete"), RetentionJob: profile["retention-job"]}
+	features := resolveFeatures(opts, config.Features, entity.Features)
+	return entityReport{
+		Entity:     entity.Name,
+		Profile:    name,
+		SoftDelete: features.SoftDelete,
+		Cache:      features.Caching,
+		Events:     profile.enables("webhooks", "cdc"),
+		Tests:      profile.enables("inmem", "fuzz", "bench", "pact"),
+		Audit:      features.Audit || profile.enables("history"),
+	}, nil
+}
+
+func reportCheck(on bool) string {
+	if on {
+		return "yes"
+	}
+	return "no"
+}
+
+// --- REPORT TEMPLATES ---
+
+const markdownReportTemplate = `| Entity | Profile | Soft delete | Cache | Events | Tests | Audit |
+| --- | --- | --- | --- | --- | --- | --- |
+{{- range .}}
+| {{.Entity}} | {{or .Profile "-"}} | {{check .SoftDelete}} | {{check .Cache}} | {{check .Events}} | {{check .Tests}} | {{check .Audit}} |
+{{- end}}
+`
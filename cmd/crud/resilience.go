@@ -0,0 +1,207 @@
+package crud
+
+// --- RESILIENCE TEMPLATES ---
+
+// resiliencePolicyTemplate is shared by every entity, so it is generated once
+// and left alone on later runs.
+const resiliencePolicyTemplate = `package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("repository: circuit breaker is open")
+
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive transient failures that opens the circuit.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before a trial call is let through.
+	OpenTimeout time.Duration
+}
+
+type ResiliencePolicy struct {
+	retry   RetryConfig
+	breaker CircuitBreakerConfig
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+}
+
+func NewResiliencePolicy(retry RetryConfig, breaker CircuitBreakerConfig) *ResiliencePolicy {
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+	return &ResiliencePolicy{
+		retry:   retry,
+		breaker: breaker,
+	}
+}
+
+// IsTransient reports whether err is a Postgres error worth retrying for reads:
+// connection failures, serialization failures, deadlocks and server restarts.
+func IsTransient(err error) bool {
+	code := sqlState(err)
+	return strings.HasPrefix(code, "08") || code == "57P01" || code == "53300" || isRetryableWrite(err)
+}
+
+// isRetryableWrite reports whether err guarantees the transaction was rolled
+// back, which is the only case where retrying a write is safe.
+func isRetryableWrite(err error) bool {
+	code := sqlState(err)
+	return code == "40001" || code == "40P01"
+}
+
+func sqlState(err error) string {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		return pgErr.SQLState()
+	}
+	return ""
+}
+
+// Read runs fn with retries on transient errors behind the circuit breaker.
+func (p *ResiliencePolicy) Read(ctx context.Context, fn func(ctx context.Context) error) error {
+	return p.do(ctx, IsTransient, fn)
+}
+
+// Write runs fn behind the circuit breaker, retrying only when the database rolled the statement back.
+func (p *ResiliencePolicy) Write(ctx context.Context, fn func(ctx context.Context) error) error {
+	return p.do(ctx, isRetryableWrite, fn)
+}
+
+func (p *ResiliencePolicy) do(ctx context.Context, retryable func(error) bool, fn func(ctx context.Context) error) error {
+	delay := p.retry.BaseDelay
+	for attempt := 1; ; attempt++ {
+		if !p.allow() {
+			return ErrCircuitOpen
+		}
+
+		err := fn(ctx)
+		p.record(err)
+		if err == nil || !retryable(err) || attempt >= p.retry.MaxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if p.retry.MaxDelay > 0 && delay > p.retry.MaxDelay {
+			delay = p.retry.MaxDelay
+		}
+	}
+}
+
+func (p *ResiliencePolicy) allow() bool {
+	if p.breaker.FailureThreshold <= 0 {
+		return true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failures < p.breaker.FailureThreshold {
+		return true
+	}
+	// Half-open: let a single trial call through once the timeout has elapsed.
+	if time.Since(p.openedAt) >= p.breaker.OpenTimeout {
+		p.openedAt = time.Now()
+		return true
+	}
+	return false
+}
+
+func (p *ResiliencePolicy) record(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Only infrastructure failures count; not-found or validation errors mean the database is healthy.
+	if err == nil || !IsTransient(err) {
+		p.failures = 0
+		return
+	}
+	p.failures++
+	if p.failures == p.breaker.FailureThreshold {
+		p.openedAt = time.Now()
+	}
+}
+`
+
+const resilientRepositoryTemplate = `package repository
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// {{.CamelCase}}Resilient decorates a {{.PascalCase}} repository with the shared
+// retry and circuit-breaker policy. Methods it does not override pass straight through.
+type {{.CamelCase}}Resilient struct {
+	{{.PascalCase}}
+	policy *ResiliencePolicy
+}
+
+func New{{.PascalCase}}Resilient(next {{.PascalCase}}, policy *ResiliencePolicy) {{.PascalCase}} {
+	return &{{.CamelCase}}Resilient{
+		{{.PascalCase}}: next,
+		policy:         policy,
+	}
+}
+
+func (r *{{.CamelCase}}Resilient) GetByID(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error) {
+	var result dto.{{.PascalCase}}
+	err := r.policy.Read(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.{{.PascalCase}}.GetByID(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (r *{{.CamelCase}}Resilient) FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+	var (
+		result           []dto.{{.PascalCase}}
+		resultPagination *dto.Pagination
+	)
+	err := r.policy.Read(ctx, func(ctx context.Context) error {
+		var err error
+		result, resultPagination, err = r.{{.PascalCase}}.FindAll(ctx, pagination)
+		return err
+	})
+	return result, resultPagination, err
+}
+
+func (r *{{.CamelCase}}Resilient) Create(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	return r.policy.Write(ctx, func(ctx context.Context) error {
+		return r.{{.PascalCase}}.Create(ctx, {{.CamelCase}})
+	})
+}
+
+func (r *{{.CamelCase}}Resilient) Update(ctx context.Context, {{.CamelCase}} *dto.{{.PascalCase}}) error {
+	return r.policy.Write(ctx, func(ctx context.Context) error {
+		return r.{{.PascalCase}}.Update(ctx, {{.CamelCase}})
+	})
+}
+
+func (r *{{.CamelCase}}Resilient) Delete(ctx context.Context, id {{.IDGoType}}) error {
+	return r.policy.Write(ctx, func(ctx context.Context) error {
+		return r.{{.PascalCase}}.Delete(ctx, id)
+	})
+}
+`
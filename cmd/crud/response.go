@@ -0,0 +1,135 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// ShapesResponse reports whether the handlers return a response built from the
+// dto rather than the dto itself, because the spec derives fields or restricts
+// the visibility of some.
+func (d TemplateData) ShapesResponse() bool {
+	return len(d.Entity.Derived) > 0 || len(d.HiddenFields()) > 0 || len(d.MaskedFields()) > 0
+}
+
+// ResponseFields renders the fields a response adds to or hides from the dto,
+// aligned like gofmt would. A field of the response takes the place of the
+// dto field with the same JSON name: with hide, the hidden fields are nil
+// pointers the encoder omits, masked fields hold the masked value and derived
+// fields their computed one.
+func (d TemplateData) ResponseFields(hide bool) []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	if hide {
+		for _, field := range d.HiddenFields() {
+			fmt.Fprintf(w, "%s\t*struct{}\t`json:\"%s,omitempty\"`\n", field.GoName(), field.Name)
+		}
+	}
+	for _, field := range d.MaskedFields() {
+		fmt.Fprintf(w, "%s\tstring\t`json:\"%s\"`\n", field.GoName(), field.Name)
+	}
+	for _, derived := range d.Entity.Derived {
+		fmt.Fprintf(w, "%s\t%s\t`json:\"%s\"`\n", derived.GoName(), derived.GoType(), derived.Name)
+	}
+	w.Flush()
+	return splitLines(buf.String())
+}
+
+// InternalResponseFields renders the fields hiding the write-only fields from
+// the internal handlers, aligned like gofmt would.
+func (d TemplateData) InternalResponseFields() []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	for _, field := range d.WriteOnlyFields() {
+		fmt.Fprintf(w, "%s\t*struct{}\t`json:\"%s,omitempty\"`\n", field.GoName(), field.Name)
+	}
+	w.Flush()
+	return splitLines(buf.String())
+}
+
+// ResponseValues renders the masked and derived fields of the response literal
+// after lead, a field of the literal already set such as "Order: entity",
+// aligned like gofmt would.
+func (d TemplateData) ResponseValues(lead string) []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	if key, value, ok := strings.Cut(lead, " "); ok {
+		fmt.Fprintf(w, "%s\t%s,\n", key, value)
+	}
+	for _, field := range d.MaskedFields() {
+		fmt.Fprintf(w, "%s:\thttpUtils.Mask(entity.%s),\n", field.GoName(), field.GoName())
+	}
+	for _, derived := range d.Entity.Derived {
+		fmt.Fprintf(w, "%s:\t%s,\n", derived.GoName(), d.derivedGo(derived))
+	}
+	w.Flush()
+	return splitLines(buf.String())
+}
+
+// splitLines splits the output of a tabwriter into its lines, none for empty
+// output.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// --- RESPONSE TEMPLATES ---
+
+// shapedResponseTemplate is the response of the handlers when the spec derives
+// fields or restricts the visibility of some. With --internal-api the public
+// response takes its place.
+const shapedResponseTemplate = `package {{.LowerCase}}
+
+import (
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .MaskedFields}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+{{- end}}
+)
+
+// {{.CamelCase}}Response is dto.{{.PascalCase}} as the handlers return it. Its fields take
+// the place of the dto fields with the same JSON name.
+type {{.CamelCase}}Response struct {
+	dto.{{.PascalCase}}
+{{- range .ResponseFields true}}
+	{{.}}
+{{- end}}
+}
+
+func to{{.PascalCase}}Response(entity dto.{{.PascalCase}}) {{.CamelCase}}Response {
+	return {{.CamelCase}}Response{
+{{- range .ResponseValues (print .PascalCase ": entity")}}
+		{{.}}
+{{- end}}
+	}
+}
+
+func to{{.PascalCase}}Responses(entities []dto.{{.PascalCase}}) []{{.CamelCase}}Response {
+	responses := make([]{{.CamelCase}}Response, len(entities))
+	for i, entity := range entities {
+		responses[i] = to{{.PascalCase}}Response(entity)
+	}
+	return responses
+}
+`
+
+// maskTemplate is shared by every entity, so it is generated once and left
+// alone on later runs.
+const maskTemplate = `package httpUtils
+
+import "strings"
+
+// Mask hides all but the last four characters of value, or all of them when
+// value is too short to show any safely.
+func Mask(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+}
+`
@@ -0,0 +1,97 @@
+package crud
+
+// --- RETENTION JOB TEMPLATES ---
+
+const (
+	retentionPurge   = "purge"
+	retentionArchive = "archive"
+)
+
+const retentionJobTemplate = `package job
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+{{- if eq .RetentionJob "archive"}}
+
+// {{.CamelCase}}RetentionQuery moves soft-deleted rows older than the cutoff
+// into {{.SnakeCase}}_archive in a single atomic statement.
+const {{.CamelCase}}RetentionQuery = ` + "`" + `
+WITH moved AS (
+	DELETE FROM {{.TableIdent}}
+	WHERE deleted_at IS NOT NULL AND deleted_at < $1
+	RETURNING *
+)
+INSERT INTO {{.SnakeCase}}_archive SELECT * FROM moved` + "`" + `
+{{- else}}
+
+// {{.CamelCase}}RetentionQuery permanently removes soft-deleted rows older than the cutoff.
+const {{.CamelCase}}RetentionQuery = ` + "`" + `
+DELETE FROM {{.TableIdent}}
+WHERE deleted_at IS NOT NULL AND deleted_at < $1` + "`" + `
+{{- end}}
+
+// {{.PascalCase}}RetentionSchedule is the cron expression the job is meant to run on.
+const {{.PascalCase}}RetentionSchedule = "0 3 * * *"
+
+type {{.PascalCase}}RetentionJob struct {
+	db        *sql.DB
+	log       ports.LoggerWithTraceID
+	retention time.Duration
+}
+
+func New{{.PascalCase}}RetentionJob(db *sql.DB, log ports.LoggerWithTraceID, retention time.Duration) *{{.PascalCase}}RetentionJob {
+	return &{{.PascalCase}}RetentionJob{
+		db:        db,
+		log:       log,
+		retention: retention,
+	}
+}
+
+// Run {{if eq .RetentionJob "archive"}}archives{{else}}purges{{end}} every {{.LowerCase}} soft-deleted longer than the retention period ago.
+func (j *{{.PascalCase}}RetentionJob) Run(ctx context.Context) error {
+	cutoff := time.Now().UTC().Add(-j.retention)
+	result, err := j.db.ExecContext(ctx, {{.CamelCase}}RetentionQuery, cutoff)
+	if err != nil {
+		return fmt.Errorf("{{.LowerCase}} retention: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("{{.LowerCase}} retention: %w", err)
+	}
+	if affected > 0 {
+		j.log.Info(ctx, fmt.Sprintf("{{.LowerCase}} retention: {{if eq .RetentionJob "archive"}}archived{{else}}purged{{end}} %d rows deleted before %s", affected, cutoff.Format(time.RFC3339)))
+	}
+	return nil
+}
+
+// Start runs the job every interval until ctx is cancelled, for deployments
+// without an external cron scheduler.
+func (j *{{.PascalCase}}RetentionJob) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.Run(ctx); err != nil {
+				j.log.Error(ctx, err.Error())
+			}
+		}
+	}
+}
+`
+
+const retentionArchiveMigrationTemplate = `CREATE TABLE IF NOT EXISTS {{.SnakeCase}}_archive (
+    LIKE {{.TableIdent}} INCLUDING ALL
+);
+`
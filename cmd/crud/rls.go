@@ -0,0 +1,106 @@
+package crud
+
+const (
+	rlsTenant = "tenant"
+	rlsOwner  = "owner"
+)
+
+// RLSColumn is the column the row-level security policy matches on.
+func (d TemplateData) RLSColumn() string {
+	return d.RLS + "_id"
+}
+
+// RLSSetting is the Postgres setting holding the caller's tenant or user id.
+func (d TemplateData) RLSSetting() string {
+	if d.RLS == rlsOwner {
+		return "app.user_id"
+	}
+	return "app.tenant_id"
+}
+
+// RLSSettingConst is the repository package constant naming RLSSetting.
+func (d TemplateData) RLSSettingConst() string {
+	if d.RLS == rlsOwner {
+		return "UserSetting"
+	}
+	return "TenantSetting"
+}
+
+// RLSColumnType is the SQL type the setting is cast to: the type of the
+// column in the spec, or BIGINT without one.
+func (d TemplateData) RLSColumnType() string {
+	for _, field := range d.Entity.Fields {
+		if field.Name == d.RLSColumn() {
+			return fieldTypes[field.Type].SQL
+		}
+	}
+	return "BIGINT"
+}
+
+// --- ROW-LEVEL SECURITY TEMPLATES ---
+
+const rlsMigrationTemplate = `-- Row-level security: {{.SnakeCase}} rows are only visible to and writable by the
+-- {{.RLS}} whose id is in the {{.RLSSetting}} setting of the transaction. Without
+-- the setting no rows match. Maintenance jobs need a role with BYPASSRLS.
+ALTER TABLE {{.TableIdent}} ENABLE ROW LEVEL SECURITY;
+ALTER TABLE {{.TableIdent}} FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS {{.SnakeCase}}_{{.RLS}}_isolation ON {{.TableIdent}};
+CREATE POLICY {{.SnakeCase}}_{{.RLS}}_isolation ON {{.TableIdent}}
+    USING ({{.RLSColumn}} = NULLIF(current_setting('{{.RLSSetting}}', true), '')::{{.RLSColumnType}})
+    WITH CHECK ({{.RLSColumn}} = NULLIF(current_setting('{{.RLSSetting}}', true), '')::{{.RLSColumnType}});
+`
+
+// rlsScopeTemplate is shared by every entity, so it is generated once and left
+// alone on later runs.
+const rlsScopeTemplate = `package repository
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+)
+
+// RLSSetting names a Postgres setting read by the row-level security policies.
+type RLSSetting string
+
+const (
+	TenantSetting RLSSetting = "app.tenant_id"
+	UserSetting   RLSSetting = "app.user_id"
+)
+
+type rlsKey struct{}
+
+// WithRLS returns a copy of ctx carrying value for setting. Call it from the
+// auth middleware with the caller's tenant or user id.
+func WithRLS(ctx context.Context, setting RLSSetting, value string) context.Context {
+	settings := maps.Clone(rlsFromContext(ctx))
+	if settings == nil {
+		settings = map[RLSSetting]string{}
+	}
+	settings[setting] = value
+	return context.WithValue(ctx, rlsKey{}, settings)
+}
+
+func rlsFromContext(ctx context.Context) map[RLSSetting]string {
+	settings, _ := ctx.Value(rlsKey{}).(map[RLSSetting]string)
+	return settings
+}
+
+// BeginScoped starts a transaction with the settings attached by WithRLS set
+// for its duration only, so they never leak to other users of the pooled
+// connection. Tables with row-level security show no rows outside of it.
+func BeginScoped(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for setting, value := range rlsFromContext(ctx) {
+		if _, err := tx.ExecContext(ctx, "SELECT set_config($1, $2, true)", string(setting), value); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+`
@@ -0,0 +1,142 @@
+package crud
+
+// --- ROUTES TEMPLATES ---
+
+// routesTemplate registers the entity's handlers in one place, so adding an
+// endpoint to the controller and to its routes happens in the same package.
+const routesTemplate = `package {{.LowerCase}}
+
+import (
+	"slices"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if .ProblemJSON}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+{{- end}}
+)
+
+// Handler is a route handler or middleware.
+type Handler = func(c *ports.HttpContext) error
+
+// Router is the router group the {{.PascalCase}} routes are registered on. Adapt
+// the project's router to it where its methods differ.
+type Router interface {
+	Add(method, path string, handlers ...Handler)
+}
+{{- if .ProblemJSON}}
+
+// route registers handler behind the middleware shared by the group's routes,
+// each answering the errors it returns with problem details.
+func route(router Router, middleware []Handler, method, path string, handler Handler) {
+	handlers := make([]Handler, 0, len(middleware)+1)
+	for _, h := range append(slices.Clone(middleware), handler) {
+		handlers = append(handlers, httpUtils.Problems(h))
+	}
+	router.Add(method, path, handlers...)
+}
+{{- else}}
+
+// route registers handler behind the middleware shared by the group's routes.
+func route(router Router, middleware []Handler, method, path string, handler Handler) {
+	router.Add(method, path, append(slices.Clone(middleware), handler)...)
+}
+{{- end}}
+
+// RegisterRoutes registers the {{.PascalCase}} routes on the /api/v1 router group,
+// each behind middleware.
+func RegisterRoutes(router Router, ctrl {{.PascalCase}}, middleware ...Handler) {
+{{- if .AppendOnly}}
+	route(router, middleware, "GET", "/{{.KebabCase}}/", ctrl.List{{.PascalCase}}s)
+	route(router, middleware, "POST", "/{{.KebabCase}}/", ctrl.Create{{.PascalCase}})
+{{- if .ClickHouse}}
+	route(router, middleware, "GET", "/{{.KebabCase}}/buckets", ctrl.Count{{.PascalCase}}Buckets)
+{{- end}}
+{{- else}}
+	route(router, middleware, "GET", "/{{.KebabCase}}/", ctrl.GetPaginated{{.PascalCase}}s)
+{{- if and .SoftDelete (not .Admin)}}
+	// Registered before /:id, which would otherwise match it.
+	route(router, middleware, "GET", "/{{.KebabCase}}/trash", ctrl.GetDeleted{{.PascalCase}}s)
+{{- end}}
+	route(router, middleware, "GET", "/{{.KebabCase}}/:id", ctrl.Get{{.PascalCase}}ByID)
+{{- if not .Admin}}
+	route(router, middleware, "POST", "/{{.KebabCase}}/", ctrl.Create{{.PascalCase}})
+	route(router, middleware, "PUT", "/{{.KebabCase}}/:id", ctrl.Update{{.PascalCase}})
+	route(router, middleware, "DELETE", "/{{.KebabCase}}/:id", ctrl.Delete{{.PascalCase}})
+{{- if .Clone}}
+	route(router, middleware, "POST", "/{{.KebabCase}}/:id/clone", ctrl.Clone{{.PascalCase}})
+{{- end}}
+{{- if .SoftDelete}}
+	route(router, middleware, "POST", "/{{.KebabCase}}/:id/restore", ctrl.Restore{{.PascalCase}})
+{{- end}}
+{{- if .History}}
+	route(router, middleware, "GET", "/{{.KebabCase}}/:id/history", ctrl.Get{{.PascalCase}}History)
+{{- end}}
+{{- if .DiffsVersions}}
+	route(router, middleware, "GET", "/{{.KebabCase}}/:id/history/:versionA/diff/:versionB", ctrl.Diff{{.PascalCase}}Versions)
+{{- end}}
+{{- end}}
+{{- end}}
+}
+{{- if .Admin}}
+
+// RegisterAdminRoutes registers the {{.PascalCase}} write routes on the /admin/api/v1
+// router group, each behind middleware, which should include the admin auth.
+func RegisterAdminRoutes(router Router, ctrl {{.PascalCase}}Admin, middleware ...Handler) {
+{{- if .SoftDelete}}
+	route(router, middleware, "GET", "/{{.KebabCase}}/trash", ctrl.GetDeleted{{.PascalCase}}s)
+{{- end}}
+	route(router, middleware, "POST", "/{{.KebabCase}}/", ctrl.Create{{.PascalCase}})
+	route(router, middleware, "PUT", "/{{.KebabCase}}/:id", ctrl.Update{{.PascalCase}})
+	route(router, middleware, "DELETE", "/{{.KebabCase}}/:id", ctrl.Delete{{.PascalCase}})
+{{- if .Clone}}
+	route(router, middleware, "POST", "/{{.KebabCase}}/:id/clone", ctrl.Clone{{.PascalCase}})
+{{- end}}
+{{- if .SoftDelete}}
+	route(router, middleware, "POST", "/{{.KebabCase}}/:id/restore", ctrl.Restore{{.PascalCase}})
+{{- end}}
+{{- if .History}}
+	route(router, middleware, "GET", "/{{.KebabCase}}/:id/history", ctrl.Get{{.PascalCase}}History)
+{{- end}}
+{{- if .DiffsVersions}}
+	route(router, middleware, "GET", "/{{.KebabCase}}/:id/history/:versionA/diff/:versionB", ctrl.Diff{{.PascalCase}}Versions)
+{{- end}}
+}
+{{- end}}
+{{- if .GDPR}}
+
+// RegisterExportRoutes registers the route exporting a user's {{.LowerCase}}s for
+// data subject access requests on the /api/v1 router group, each behind
+// middleware, which should only let the user or support staff through.
+func RegisterExportRoutes(router Router, ctrl {{.PascalCase}}Export, middleware ...Handler) {
+	route(router, middleware, "GET", "/users/:id/{{.KebabCase}}/export", ctrl.Export{{.PascalCase}}s)
+}
+{{- end}}
+{{- if .Approval}}
+
+// RegisterApprovalRoutes registers the routes submitting changes to {{.LowerCase}}s
+// and reviewing them on the /api/v1 router group, each behind middleware.
+func RegisterApprovalRoutes(router Router, ctrl {{.PascalCase}}Approvals, middleware ...Handler) {
+	route(router, middleware, "POST", "/{{.KebabCase}}/:id/changes", ctrl.Submit{{.PascalCase}}Change)
+	route(router, middleware, "POST", "/{{.KebabCase}}/changes/:changeID/approve", ctrl.Approve{{.PascalCase}}Change)
+	route(router, middleware, "POST", "/{{.KebabCase}}/changes/:changeID/reject", ctrl.Reject{{.PascalCase}}Change)
+}
+{{- end}}
+{{- if .CheckDuplicate}}
+
+// RegisterDuplicateRoutes registers the route checking a new {{.LowerCase}} for
+// duplicates on the /api/v1 router group, each behind middleware.
+func RegisterDuplicateRoutes(router Router, ctrl {{.PascalCase}}Duplicates, middleware ...Handler) {
+	route(router, middleware, "POST", "/{{.KebabCase}}/check-duplicate", ctrl.CheckDuplicate{{.PascalCase}})
+}
+{{- end}}
+{{- if .InternalAPI}}
+
+// RegisterInternalRoutes registers the {{.PascalCase}} routes serving full data on
+// the {{.InternalRoutePrefix}} router group, each behind middleware, which should
+// include the service-to-service auth.
+func RegisterInternalRoutes(router Router, ctrl {{.PascalCase}}Internal, middleware ...Handler) {
+	route(router, middleware, "GET", "/{{.KebabCase}}/", ctrl.GetPaginated{{.PascalCase}}s)
+	route(router, middleware, "GET", "/{{.KebabCase}}/:id", ctrl.Get{{.PascalCase}}ByID)
+}
+{{- end}}
+`
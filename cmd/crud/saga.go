@@ -0,0 +1,213 @@
+package crud
+
+// --- SAGA TEMPLATES ---
+
+// sagaTemplate is shared by every entity, so it is generated once and left
+// alone on later runs.
+const sagaTemplate = `package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	"github.com/google/uuid"
+)
+
+// Status is how far a saga got.
+type Status string
+
+const (
+	Running      Status = "running"
+	Completed    Status = "completed"
+	Compensating Status = "compensating"
+	Compensated  Status = "compensated"
+	// Failed sagas could not undo all of their steps and need an operator.
+	Failed Status = "failed"
+)
+
+// Step is one step of a saga. Action does its work, here or on another
+// service, and Compensate undoes it when a later step fails. Compensate is nil
+// for steps with nothing to undo; it may be called again after a crash, so it
+// must be idempotent.
+type Step struct {
+	Name       string
+	Action     func(ctx context.Context) error
+	Compensate func(ctx context.Context) error
+}
+
+// State is the progress of a saga as the Store keeps it. A saga left running
+// or compensating was interrupted, and Completed tells which steps to undo.
+type State struct {
+	ID        string
+	Name      string
+	Status    Status
+	Completed []string
+	Error     string
+}
+
+// Store persists the state of sagas. Save is called before the first step and
+// after every step, and must insert or replace the state with the same ID.
+type Store interface {
+	Save(ctx context.Context, state State) error
+}
+
+// Orchestrator runs sagas, persisting their state in a Store.
+type Orchestrator struct {
+	store Store
+	log   ports.LoggerWithTraceID
+}
+
+func New(store Store, log ports.LoggerWithTraceID) *Orchestrator {
+	return &Orchestrator{
+		store: store,
+		log:   log,
+	}
+}
+
+// Run runs steps in order as the saga name. When a step fails, the steps that
+// completed are compensated in reverse order and the step's error is returned,
+// joined with those of the compensations that failed too.
+func (o *Orchestrator) Run(ctx context.Context, name string, steps ...Step) error {
+	state := State{ID: uuid.NewString(), Name: name, Status: Running}
+	if err := o.store.Save(ctx, state); err != nil {
+		return fmt.Errorf("saga %s: %w", name, err)
+	}
+	for i, step := range steps {
+		if err := step.Action(ctx); err != nil {
+			return o.compensate(ctx, state, steps[:i], fmt.Errorf("saga %s: %s: %w", name, step.Name, err))
+		}
+		state.Completed = append(state.Completed, step.Name)
+		o.save(ctx, state)
+	}
+	state.Status = Completed
+	o.save(ctx, state)
+	return nil
+}
+
+// compensate undoes the completed steps on a context that is not canceled with
+// ctx, so the steps of a saga that timed out are undone too.
+func (o *Orchestrator) compensate(ctx context.Context, state State, completed []Step, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+	state.Status = Compensating
+	state.Error = cause.Error()
+	o.save(ctx, state)
+
+	errs := []error{cause}
+	for i := len(completed) - 1; i >= 0; i-- {
+		if completed[i].Compensate == nil {
+			continue
+		}
+		if err := completed[i].Compensate(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("compensating %s: %w", completed[i].Name, err))
+		}
+	}
+
+	state.Status = Compensated
+	if len(errs) > 1 {
+		state.Status = Failed
+	}
+	o.save(ctx, state)
+	return errors.Join(errs...)
+}
+
+// save persists state once a step ran. A failed save only loses track of the
+// saga, so it is logged rather than failing the steps that already ran.
+func (o *Orchestrator) save(ctx context.Context, state State) {
+	if err := o.store.Save(ctx, state); err != nil {
+		o.log.Error(ctx, fmt.Sprintf("saving saga %s %s as %s: %v", state.Name, state.ID, state.Status, err))
+	}
+}
+`
+
+// sagaStoreTemplate is shared by every entity, so it is generated once and
+// left alone on later runs.
+const sagaStoreTemplate = `package saga
+
+import (
+	"context"
+	"fmt"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+type postgresStore struct {
+	db  ports.Database
+	log ports.LoggerWithTraceID
+}
+
+// NewPostgresStore keeps the state of sagas in the saga_state table.
+func NewPostgresStore(db ports.Database, log ports.LoggerWithTraceID) Store {
+	return &postgresStore{
+		db:  db,
+		log: log,
+	}
+}
+
+func (s *postgresStore) Save(ctx context.Context, state State) error {
+	// TODO: Upsert the state on s.db, encoding Completed as a JSON array.
+	// Example:
+	// INSERT INTO saga_state (id, name, status, completed, error) VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+	// ON CONFLICT (id) DO UPDATE SET status = EXCLUDED.status, completed = EXCLUDED.completed, error = EXCLUDED.error, updated_at = now()
+	return fmt.Errorf("saving saga %s is not implemented", state.ID)
+}
+`
+
+// sagaMigrationTemplate is shared by every entity, so it is generated once and
+// left alone on later runs.
+const sagaMigrationTemplate = `-- saga_state holds the progress of every saga. Sagas still running or
+-- compensating long after updated_at were interrupted and need recovering.
+CREATE TABLE IF NOT EXISTS saga_state (
+    id         UUID        PRIMARY KEY,
+    name       TEXT        NOT NULL,
+    status     TEXT        NOT NULL CHECK (status IN ('running', 'completed', 'compensating', 'compensated', 'failed')),
+    completed  JSONB       NOT NULL DEFAULT '[]',
+    error      TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS saga_state_unfinished_idx ON saga_state (updated_at) WHERE status IN ('running', 'compensating', 'failed');
+`
+
+const sagaStepsTemplate = `package service
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/saga"
+)
+
+// create{{.PascalCase}}Saga names the saga creating {{.LowerCase}}s in the saga_state table.
+const create{{.PascalCase}}Saga = "create_{{.SnakeCase}}"
+
+// create{{.PascalCase}}Steps lists the steps of the saga creating {{.CamelCase}}. The row is
+// inserted first and deleted again when a later step fails, so the steps on
+// other services can refer to its id.
+func (s *{{.CamelCase}}Service) create{{.PascalCase}}Steps({{.CamelCase}} *dto.{{.PascalCase}}) []saga.Step {
+	return []saga.Step{
+		{
+			Name: "insert_{{.SnakeCase}}",
+			Action: func(ctx context.Context) error {
+				return s.{{.CamelCase}}Repository.Create(ctx, {{.CamelCase}})
+			},
+			Compensate: func(ctx context.Context) error {
+				return s.{{.CamelCase}}Repository.Delete(ctx, {{.CamelCase}}.ID)
+			},
+		},
+		// TODO: Add a step for each service the {{.LowerCase}} is created on, with the
+		// compensation undoing it.
+		// Example:
+		// {
+		// 	Name: "reserve_stock",
+		// 	Action: func(ctx context.Context) error {
+		// 		return s.inventory.Reserve(ctx, {{.CamelCase}}.ID)
+		// 	},
+		// 	Compensate: func(ctx context.Context) error {
+		// 		return s.inventory.Release(ctx, {{.CamelCase}}.ID)
+		// 	},
+		// },
+	}
+}
+`
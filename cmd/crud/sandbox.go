@@ -0,0 +1,215 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"text/template"
+	"text/template/parse"
+)
+
+// sandboxDenied are the functions a sandboxed template can never use: call
+// runs any function value the template data holds.
+var sandboxDenied = []string{"call"}
+
+// environmentAccess matches generated code reading the environment or running
+// commands, which sandboxed templates refuse to emit. It is a heuristic the
+// template text is checked with before rendering; the imports of the rendered
+// Go code are checked against sandboxDeniedImports.
+var environmentAccess = regexp.MustCompile(`\bos\.(Getenv|LookupEnv|Environ|ExpandEnv|Setenv|StartProcess)\b|"os/exec"|"syscall"|"unsafe"|"plugin"|//go:generate\b`)
+
+// sandboxDeniedImports are the packages the Go code of sandboxed templates may
+// not import, under any name, unless the built-in template does: they read
+// the environment and files such as /proc/self/environ, run commands, load
+// code or reach the network.
+var sandboxDeniedImports = []string{"C", "net", "net/http", "os", "os/exec", "plugin", "syscall", "unsafe"}
+
+var identifierName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SandboxConfig restricts the custom templates, for template sets vendored
+// from third parties:
+//
+//	template_sandbox:
+//	  enabled: true
+//	  deny: [printf, Options]  # functions and data members refused on top of call
+//
+// A sandboxed template may not use the denied functions and members, nor emit
+// code reading the environment or running commands: Go code importing
+// sandboxDeniedImports or holding go:generate directives the built-in template
+// does not. Built-in templates are trusted and never checked. The sandbox
+// only covers what the templates emit; commands the project runs on the
+// generated files, e.g. exec plugins of its own tooling, are out of its reach.
+type SandboxConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Deny    []string `yaml:"deny"`
+}
+
+func (c SandboxConfig) validate() error {
+	for _, name := range c.Deny {
+		if !identifierName.MatchString(name) {
+			return fmt.Errorf("template_sandbox.deny: %q is not a function or field name", name)
+		}
+	}
+	return nil
+}
+
+// check parses the custom template content of file on its own and refuses it
+// when it breaks the sandbox. Parse errors are left to the caller, which
+// parses content with the rest of the set.
+func (c SandboxConfig) check(file, content string) error {
+	if !c.Enabled {
+		return nil
+	}
+	tmpl, err := template.New(file).Parse(content)
+	if err != nil {
+		return nil
+	}
+	denied := append(slices.Clone(sandboxDenied), c.Deny...)
+	for _, defined := range tmpl.Templates() {
+		if defined.Tree == nil {
+			continue
+		}
+		if err := checkSandboxed(defined.Tree, defined.Tree.Root, denied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRendered refuses the output of a sandboxed template emitting more
+// environment access than the built-in template tmplStr it customises would
+// for data. Templates can assemble the code from pieces the parse check sees
+// none of, e.g. os.{{"Get" | printf "%senv"}}, or import a package under
+// another name, so the output is checked too.
+func (c SandboxConfig) checkRendered(path, tmplStr string, data TemplateData, rendered []byte) error {
+	if !c.Enabled {
+		return nil
+	}
+	accesses := environmentAccess.FindAll(rendered, -1)
+	var imports []string
+	if filepath.Ext(path) == ".go" {
+		var err error
+		if imports, err = sandboxImports(path, rendered); err != nil {
+			return fmt.Errorf("%s: the custom templates emit Go code the template sandbox cannot check: %w", path, err)
+		}
+	}
+	if len(accesses) == 0 && len(imports) == 0 {
+		return nil
+	}
+	builtin, err := template.New(path).Parse(tmplStr)
+	if err != nil {
+		return err
+	}
+	var want bytes.Buffer
+	if err := builtin.Execute(&want, data); err != nil {
+		return err
+	}
+	allowed := environmentAccess.FindAll(want.Bytes(), -1)
+	for _, access := range accesses {
+		i := slices.IndexFunc(allowed, func(a []byte) bool { return bytes.Equal(a, access) })
+		if i < 0 {
+			return fmt.Errorf("%s: the custom templates emit %s, which the template sandbox refuses", path, access)
+		}
+		allowed = slices.Delete(allowed, i, i+1)
+	}
+	allowedImports, _ := sandboxImports(path, want.Bytes())
+	for _, imported := range imports {
+		if !slices.Contains(allowedImports, imported) {
+			return fmt.Errorf("%s: the custom templates import %q, which the template sandbox refuses", path, imported)
+		}
+	}
+	return nil
+}
+
+// sandboxImports returns the packages of sandboxDeniedImports the Go file src
+// imports, whatever name it imports them under.
+func sandboxImports(path string, src []byte) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, src, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+	var imports []string
+	for _, spec := range file.Imports {
+		imported, err := strconv.Unquote(spec.Path.Value)
+		if err == nil && slices.Contains(sandboxDeniedImports, imported) {
+			imports = append(imports, imported)
+		}
+	}
+	return imports, nil
+}
+
+// checkSandboxed walks node, returning the first use of a denied name or
+// environment access.
+func checkSandboxed(tree *parse.Tree, node parse.Node, denied []string) error {
+	refuse := func(node parse.Node, format string, args ...any) error {
+		location, _ := tree.ErrorContext(node)
+		return fmt.Errorf("%s: %s, which the template sandbox refuses", location, fmt.Sprintf(format, args...))
+	}
+	var children []parse.Node
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return nil
+		}
+		children = node.Nodes
+	case *parse.TextNode:
+		if access := environmentAccess.Find(node.Text); access != nil {
+			return refuse(node, "emits %s", access)
+		}
+	case *parse.StringNode:
+		// Quoted, so a constant import path like "os/exec" matches too.
+		if access := environmentAccess.FindString(strconv.Quote(node.Text)); access != "" {
+			return refuse(node, "emits %s", access)
+		}
+	case *parse.ActionNode:
+		children = []parse.Node{node.Pipe}
+	case *parse.IfNode:
+		children = []parse.Node{node.Pipe, node.List, node.ElseList}
+	case *parse.RangeNode:
+		children = []parse.Node{node.Pipe, node.List, node.ElseList}
+	case *parse.WithNode:
+		children = []parse.Node{node.Pipe, node.List, node.ElseList}
+	case *parse.TemplateNode:
+		children = []parse.Node{node.Pipe}
+	case *parse.PipeNode:
+		if node == nil {
+			return nil
+		}
+		for _, cmd := range node.Cmds {
+			children = append(children, cmd.Args...)
+		}
+	case *parse.IdentifierNode:
+		if slices.Contains(denied, node.Ident) {
+			return refuse(node, "uses the function %s", node.Ident)
+		}
+	case *parse.FieldNode:
+		return checkSandboxedNames(node, node.Ident, denied, refuse)
+	case *parse.VariableNode:
+		return checkSandboxedNames(node, node.Ident[1:], denied, refuse)
+	case *parse.ChainNode:
+		if err := checkSandboxed(tree, node.Node, denied); err != nil {
+			return err
+		}
+		return checkSandboxedNames(node, node.Field, denied, refuse)
+	}
+	for _, child := range children {
+		if err := checkSandboxed(tree, child, denied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkSandboxedNames(node parse.Node, names, denied []string, refuse func(parse.Node, string, ...any) error) error {
+	for _, name := range names {
+		if slices.Contains(denied, name) {
+			return refuse(node, "uses %s", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package crud
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSandboxConfigCheckRendered(t *testing.T) {
+	const builtin = "package widget\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint\n"
+	tests := []struct {
+		name     string
+		path     string
+		builtin  string
+		rendered string
+		// wantErr is a snippet of the refusal, empty when the output passes.
+		wantErr string
+	}{
+		{
+			name:     "same as the built-in",
+			path:     "widget.go",
+			builtin:  builtin,
+			rendered: builtin,
+		},
+		{
+			name:     "aliased os",
+			path:     "widget.go",
+			builtin:  builtin,
+			rendered: "package widget\n\nimport e \"os\"\n\nvar _ = e.Getenv(\"TOKEN\")\n",
+			wantErr:  `import "os"`,
+		},
+		{
+			name:     "os read of /proc/self/environ",
+			path:     "widget.go",
+			builtin:  builtin,
+			rendered: "package widget\n\nimport \"os\"\n\nvar _, _ = os.ReadFile(\"/proc/self/environ\")\n",
+			wantErr:  `import "os"`,
+		},
+		{
+			name:     "dot-imported net/http",
+			path:     "widget.go",
+			builtin:  builtin,
+			rendered: "package widget\n\nimport . \"net/http\"\n\nvar _, _ = Get(\"http://example.com\")\n",
+			wantErr:  `import "net/http"`,
+		},
+		{
+			name:     "go:generate directive",
+			path:     "widget.go",
+			builtin:  builtin,
+			rendered: "//go:generate sh -c env\npackage widget\n",
+			wantErr:  "emit //go:generate",
+		},
+		{
+			name:     "imported by the built-in too",
+			path:     "widget.go",
+			builtin:  "package widget\n\nimport \"os\"\n\nvar _ = os.Args\n",
+			rendered: "package widget\n\nimport sys \"os\"\n\nvar _ = sys.Args\n",
+		},
+		{
+			name:     "not Go",
+			path:     "widget.go",
+			builtin:  builtin,
+			rendered: "package widget\n\nimport (\n",
+			wantErr:  "cannot check",
+		},
+		{
+			name:     "other files are not parsed",
+			path:     "widget.http",
+			builtin:  "GET /widgets\n",
+			rendered: "import e \"os\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SandboxConfig{Enabled: true}.checkRendered(tt.path, tt.builtin, TemplateData{}, []byte(tt.rendered))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkRendered() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkRendered() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSandboxConfigCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  SandboxConfig
+		content string
+		// wantErr is a snippet of the refusal, empty when the template passes.
+		wantErr string
+	}{
+		{
+			name:    "plain template",
+			config:  SandboxConfig{Enabled: true},
+			content: "package {{.PackageName}}\n\nfunc {{.PascalCase | printf \"%s\"}}() {}\n",
+		},
+		{
+			name:    "disabled",
+			content: "{{call .Hook}}",
+		},
+		{
+			name:    "call",
+			config:  SandboxConfig{Enabled: true},
+			content: "{{call .Hook}}",
+			wantErr: "uses the function call",
+		},
+		{
+			name:    "configured function",
+			config:  SandboxConfig{Enabled: true, Deny: []string{"printf"}},
+			content: "{{.PascalCase | printf \"%s\"}}",
+			wantErr: "uses the function printf",
+		},
+		{
+			name:    "configured member",
+			config:  SandboxConfig{Enabled: true, Deny: []string{"Options"}},
+			content: "{{.Options.DB}}",
+			wantErr: "Options",
+		},
+		{
+			name:    "configured member through a variable",
+			config:  SandboxConfig{Enabled: true, Deny: []string{"Options"}},
+			content: "{{$data := .}}{{$data.Options.DB}}",
+			wantErr: "Options",
+		},
+		{
+			name:    "environment read in the text",
+			config:  SandboxConfig{Enabled: true},
+			content: "var token = os.Getenv(\"TOKEN\")\n",
+			wantErr: "emits os.Getenv",
+		},
+		{
+			name:    "denied import in a string",
+			config:  SandboxConfig{Enabled: true},
+			content: "import {{\"os/exec\"}}\n",
+			wantErr: `emits "os/exec"`,
+		},
+		{
+			name:    "inside a defined template",
+			config:  SandboxConfig{Enabled: true},
+			content: "{{define \"hook\"}}{{call .Hook}}{{end}}{{template \"hook\" .}}",
+			wantErr: "uses the function call",
+		},
+		{
+			name:    "parse errors are left to the caller",
+			config:  SandboxConfig{Enabled: true},
+			content: "{{call .Hook",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.check("widget.go.tmpl", tt.content)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("check() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("check() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
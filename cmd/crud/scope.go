@@ -0,0 +1,166 @@
+package crud
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// defaultScopeOverride is the query parameter lifting a default scope that
+// does not name its own.
+const defaultScopeOverride = "unscoped"
+
+// ScopeSpec is a default scope of an entity, a SQL condition its list and get
+// queries add unless the request lifts it:
+//
+//	default_scope:
+//	  where: status != 'archived'
+//	  override: include_archived  # query parameter lifting the scope (default unscoped)
+type ScopeSpec struct {
+	Where    string `yaml:"where"`
+	Override string `yaml:"override"`
+}
+
+// scopeIdentifier matches the identifiers and string literals of a scope
+// condition, one of which must be a column of the entity.
+var scopeIdentifier = regexp.MustCompile(`'[^']*'|[A-Za-z_][A-Za-z0-9_]*`)
+
+func (s ScopeSpec) validate(entity EntitySpec, columns map[string]bool) error {
+	if strings.TrimSpace(s.Where) == "" {
+		return fmt.Errorf("%s: default_scope needs a where condition", entity.Name)
+	}
+	if strings.Contains(s.Where, ";") {
+		return fmt.Errorf("%s: default_scope where %q must be a single condition", entity.Name, s.Where)
+	}
+	if s.Override != "" && !fieldNamePattern.MatchString(s.Override) {
+		return fmt.Errorf("%s: default_scope override %q must be a snake_case query parameter", entity.Name, s.Override)
+	}
+	if !slices.ContainsFunc(scopeIdentifier.FindAllString(s.Where, -1), func(identifier string) bool { return columns[identifier] }) {
+		return fmt.Errorf("%s: default_scope where %q uses none of the entity's columns", entity.Name, s.Where)
+	}
+	return nil
+}
+
+// ScopeOverride is the query parameter that lifts the entity's default scope
+// when set to true.
+func (d TemplateData) ScopeOverride() string {
+	if d.Entity.DefaultScope == nil || d.Entity.DefaultScope.Override == "" {
+		return defaultScopeOverride
+	}
+	return d.Entity.DefaultScope.Override
+}
+
+// Scoped reports whether the list and get queries add the default scope of the
+// spec, which the Postgres repository does.
+func (d TemplateData) Scoped() bool {
+	return d.Entity.DefaultScope != nil && d.Postgres() && !d.AppendOnly
+}
+
+// GetOverride is the name of the repository method replacing the generic read
+// by id when the get endpoint selects fields or the entity has a default
+// scope: GetByID itself, or the method the observed GetByID calls. It is
+// empty when the generic read is kept.
+func (d TemplateData) GetOverride() string {
+	switch {
+	case !d.SparseFields && !d.Scoped():
+		return ""
+	case !d.ObservesQueries():
+		return "GetByID"
+	case d.SparseFields:
+		return "getSelected"
+	default:
+		return "getScoped"
+	}
+}
+
+// GetGenericCondition is the condition under which the overriding read falls
+// back to the generic one.
+func (d TemplateData) GetGenericCondition() string {
+	var conditions []string
+	if d.SparseFields {
+		conditions = append(conditions, `columns == "*"`)
+	}
+	if d.Scoped() {
+		conditions = append(conditions, "!scoped")
+	}
+	return strings.Join(conditions, " && ")
+}
+
+// GetOverrideDoc renders the doc comment of the overriding read, wrapped like
+// the hand-written comments.
+func (d TemplateData) GetOverrideDoc() []string {
+	var doc []string
+	if d.SparseFields {
+		doc = append(doc, "reads only the columns of the fieldset the controller attached to ctx")
+	}
+	if d.Scoped() {
+		doc = append(doc, "hides the "+d.LowerCase+"s outside the default scope unless the request lifted it")
+	}
+	text := d.GetOverride() + " " + joinAnd(doc)
+	if d.SparseFields {
+		text += "; the fields left out keep their zero values"
+	}
+	return wrapComment(text+".", "")
+}
+
+// --- DEFAULT SCOPE TEMPLATES ---
+
+// scopeDTOTemplate, scopeParserTemplate and scopeClauseTemplate are shared by
+// every entity, so they are generated once and left alone on later runs.
+const scopeDTOTemplate = `package dto
+
+import "context"
+
+type unscopedKey struct{}
+
+// WithUnscoped returns a copy of ctx lifting the default scopes of the
+// repositories, for requests that explicitly asked for every row.
+func WithUnscoped(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedKey{}, true)
+}
+
+// Unscoped reports whether WithUnscoped lifted the default scopes of ctx.
+func Unscoped(ctx context.Context) bool {
+	unscoped, _ := ctx.Value(unscopedKey{}).(bool)
+	return unscoped
+}
+`
+
+const scopeParserTemplate = `package httpUtils
+
+import (
+	"fmt"
+	"strconv"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+)
+
+// ParseUnscoped reads the boolean query parameter param, e.g.
+// include_archived=true, which lifts the default scope of the listed or read
+// entity. Values that are not booleans are rejected with a bad request error.
+func ParseUnscoped(c *ports.HttpContext, param string) (bool, error) {
+	raw := c.Query(param)
+	if raw == "" {
+		return false, nil
+	}
+	unscoped, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, appErr.NewBadRequestErr(fmt.Errorf("%s must be true or false, got %q", param, raw))
+	}
+	return unscoped, nil
+}
+`
+
+const scopeClauseTemplate = `package repository
+
+// AndScope adds the default scope of an entity to the SQL condition where,
+// which may be empty.
+func AndScope(where, scope string) string {
+	if where == "" {
+		return "(" + scope + ")"
+	}
+	return "(" + scope + ") AND " + where
+}
+`
@@ -0,0 +1,209 @@
+package crud
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// releasesURL lists the GitHub releases of the generator.
+const releasesURL = "https://api.github.com/repos/thisPeyman/gocrud-gen/releases"
+
+// checksumsAsset is the release asset holding the sha256 sums of the binaries,
+// one "<hex>  <asset>" line each.
+const checksumsAsset = "checksums.txt"
+
+var selfUpdateOptions struct {
+	Version string
+	Check   bool
+}
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Replaces this binary with the latest release.",
+	Long: `This command looks up the latest GitHub release of gocrud-gen, downloads the
+binary built for this platform (the gocrud-gen_<os>_<arch> asset), verifies it
+against the release's checksums.txt and replaces the running binary with it.
+Set GITHUB_TOKEN to avoid the API's rate limit. For example:
+
+gocrud-gen self-update --version v1.4.0`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return selfUpdate(selfUpdateOptions.Version, selfUpdateOptions.Check)
+	},
+}
+
+func init() {
+	selfUpdateCmd.Flags().StringVar(&selfUpdateOptions.Version, "version", "", "Release tag to install instead of the latest, e.g. v1.4.0")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateOptions.Check, "check", false, "Only report whether an update is available")
+	rootCmd.AddCommand(selfUpdateCmd)
+}
+
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func (r githubRelease) assetURL(name string) (string, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL, true
+		}
+	}
+	return "", false
+}
+
+func selfUpdate(tag string, checkOnly bool) error {
+	url := releasesURL + "/latest"
+	if tag != "" {
+		url = releasesURL + "/tags/" + tag
+	}
+	var release githubRelease
+	body, err := download(url)
+	if err != nil {
+		return fmt.Errorf("looking up the release: %w", err)
+	}
+	if err := json.Unmarshal(body, &release); err != nil {
+		return fmt.Errorf("decoding the release: %w", err)
+	}
+
+	if release.TagName == version {
+		fmt.Printf("gocrud-gen %s is up to date.\n", version)
+		return nil
+	}
+	if checkOnly {
+		fmt.Printf("gocrud-gen %s is available (running %s); update with 'gocrud-gen self-update'.\n", release.TagName, version)
+		return nil
+	}
+
+	asset := fmt.Sprintf("gocrud-gen_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		asset += ".exe"
+	}
+	binaryURL, ok := release.assetURL(asset)
+	if !ok {
+		return fmt.Errorf("release %s has no binary for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	checksumsURL, ok := release.assetURL(checksumsAsset)
+	if !ok {
+		return fmt.Errorf("release %s has no %s to verify the binary against", release.TagName, checksumsAsset)
+	}
+
+	checksums, err := download(checksumsURL)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", checksumsAsset, err)
+	}
+	want, err := lookupChecksum(checksums, asset)
+	if err != nil {
+		return err
+	}
+	binary, err := download(binaryURL)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", asset, err)
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", asset, got, want)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return err
+	}
+	if err := replaceExecutable(executable, binary); err != nil {
+		return fmt.Errorf("replacing %s: %w", executable, err)
+	}
+	fmt.Printf("Updated gocrud-gen from %s to %s.\n", version, release.TagName)
+	return nil
+}
+
+// download fetches url from GitHub, authenticated with GITHUB_TOKEN if set.
+func download(url string) ([]byte, error) {
+	header := http.Header{}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return downloadWithHeader(url, header)
+}
+
+func downloadWithHeader(url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func lookupChecksum(checksums []byte, asset string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", checksumsAsset, asset)
+}
+
+// replaceExecutable writes binary next to path and renames it over path, so
+// the binary is never left half-written. A running binary can't be replaced
+// on Windows, so it is moved aside first.
+func replaceExecutable(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	next, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".new-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(next.Name())
+	if _, err := next.Write(binary); err != nil {
+		next.Close()
+		return err
+	}
+	if err := next.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(next.Name(), info.Mode().Perm()|0111); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(next.Name(), path)
+}
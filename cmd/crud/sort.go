@@ -0,0 +1,243 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+func (f FieldSpec) validateSort() error {
+	switch {
+	case !f.Sortable:
+	case f.Type == "json":
+		return fmt.Errorf("json fields cannot be sortable")
+	case f.Hidden():
+		return fmt.Errorf("%s fields cannot be sortable, as the order of the responses would reveal them", f.Visibility)
+	}
+	return nil
+}
+
+// SortFields lists the spec fields the list endpoint may sort by.
+func (d TemplateData) SortFields() []FieldSpec {
+	return d.fieldsWith(func(field FieldSpec) bool { return field.Sortable })
+}
+
+// SortColumnMapping renders the column mapping entries of the sortable fields,
+// mapping the name a request sorts by to its column, aligned like gofmt would.
+func (d TemplateData) SortColumnMapping() []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	for _, field := range d.SortFields() {
+		fmt.Fprintf(w, "%q:\t%q,\n", field.Name, field.Name)
+	}
+	w.Flush()
+	return splitLines(buf.String())
+}
+
+// ListOverride is the name of the repository method replacing the generic
+// listing when the list endpoint filters, selects fields or sorts by several
+// columns, or the entity has a default scope: FindAll itself, or the method
+// the observed FindAll calls. It is empty when the generic listing is kept.
+func (d TemplateData) ListOverride() string {
+	switch {
+	case len(d.FilterFields()) == 0 && !d.SparseFields && len(d.SortFields()) == 0 && !d.Scoped():
+		return ""
+	case !d.ObservesQueries():
+		return "FindAll"
+	case len(d.FilterFields()) > 0:
+		return "findFiltered"
+	case d.SparseFields:
+		return "findSelected"
+	case len(d.SortFields()) > 0:
+		return "findSorted"
+	default:
+		return "findScoped"
+	}
+}
+
+// listParts lists what the list request attached to ctx for the overriding
+// listing: the condition under which it is absent and how the doc describes
+// it.
+func (d TemplateData) listParts() (conditions, docs []string) {
+	if len(d.FilterFields()) > 0 {
+		conditions = append(conditions, "len(filters) == 0")
+		docs = append(docs, "the whitelisted filters")
+	}
+	if d.SparseFields {
+		conditions = append(conditions, `columns == "*"`)
+		docs = append(docs, "the columns of the sparse fieldset")
+	}
+	if len(d.SortFields()) > 0 {
+		conditions = append(conditions, "len(sorts) == 0")
+		docs = append(docs, "the sort")
+	}
+	if d.Scoped() {
+		conditions = append(conditions, "!scoped")
+	}
+	return conditions, docs
+}
+
+// ListGenericCondition is the condition under which the overriding listing
+// falls back to the generic one: the request did not filter, select fields or
+// sort, and no default scope applies.
+func (d TemplateData) ListGenericCondition() string {
+	conditions, _ := d.listParts()
+	return strings.Join(conditions, " && ")
+}
+
+// ListOverrideDoc renders the doc comment of the overriding listing, wrapped
+// like the hand-written comments.
+func (d TemplateData) ListOverrideDoc() []string {
+	_, docs := d.listParts()
+	var text string
+	if len(docs) > 0 {
+		text = d.ListOverride() + " narrows the generic listing with " + joinAnd(docs) + " the controller attached to ctx"
+		if d.Scoped() {
+			text += ", and to the default scope unless the request lifted it"
+		}
+	} else {
+		text = d.ListOverride() + " narrows the generic listing to the default scope unless the request lifted it"
+	}
+	return wrapComment(text+".", "")
+}
+
+// ListWhereOptional reports whether the overriding listing may query without
+// a WHERE clause: the request only selected fields or sorted, and lifted the
+// default scope.
+func (d TemplateData) ListWhereOptional() bool {
+	return len(d.FilterFields()) > 0 || (d.Scoped() && (d.SparseFields || len(d.SortFields()) > 0))
+}
+
+// wrapComment wraps text into // comment lines of at most 80 columns,
+// counting the indent that precedes each.
+func wrapComment(text, indent string) []string {
+	var lines []string
+	line := "//"
+	for _, word := range strings.Fields(text) {
+		if line != "//" && len(indent)+len(line)+1+len(word) > 80 {
+			lines = append(lines, line)
+			line = "//"
+		}
+		line += " " + word
+	}
+	return append(lines, line)
+}
+
+// joinAnd joins items with commas and a final "and".
+func joinAnd(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// --- SORT TEMPLATES ---
+
+// sortDTOTemplate, sortParserTemplate and orderByTemplate are shared by every
+// entity, so they are generated once and left alone on later runs.
+const sortDTOTemplate = `package dto
+
+import "context"
+
+// Sort is one whitelisted column of a list request's sort, carried from the
+// controller to the repository on the request context.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+type sortsKey struct{}
+
+// WithSorts returns a copy of ctx carrying sorts.
+func WithSorts(ctx context.Context, sorts []Sort) context.Context {
+	return context.WithValue(ctx, sortsKey{}, sorts)
+}
+
+// SortsFromContext returns the sorts attached by WithSorts, if any.
+func SortsFromContext(ctx context.Context) []Sort {
+	sorts, _ := ctx.Value(sortsKey{}).([]Sort)
+	return sorts
+}
+`
+
+const sortParserTemplate = `package httpUtils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// ParseSort reads the comma-separated sort query parameter, e.g.
+// sort=-created_at,name, against columnMapping, which maps the names a
+// request may sort by to their columns. A leading - sorts a column in
+// descending order. Names that are not in columnMapping, or repeated, are
+// rejected with a bad request error.
+func ParseSort(c *ports.HttpContext, columnMapping map[string]string) ([]dto.Sort, error) {
+	raw := c.Query("sort")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var (
+		sorts []dto.Sort
+		seen  = make(map[string]bool)
+	)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		name := strings.TrimLeft(part, "+-")
+		column, ok := columnMapping[name]
+		if !ok {
+			allowed := make([]string, 0, len(columnMapping))
+			for name := range columnMapping {
+				allowed = append(allowed, name)
+			}
+			sort.Strings(allowed)
+			return nil, appErr.NewBadRequestErr(fmt.Errorf("cannot sort by %q, must be one of %s", name, strings.Join(allowed, ", ")))
+		}
+		if seen[name] {
+			return nil, appErr.NewBadRequestErr(fmt.Errorf("sort names %s more than once", name))
+		}
+		seen[name] = true
+		sorts = append(sorts, dto.Sort{Column: column, Desc: strings.HasPrefix(part, "-")})
+	}
+	return sorts, nil
+}
+`
+
+const orderByTemplate = `package repository
+
+import (
+	"strings"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// BuildOrderBy turns sorts into the list of an ORDER BY clause, ending with
+// the id so rows that tie keep the same order from page to page. Columns come
+// from the controllers' column mappings and are quoted, never taken from user
+// input.
+func BuildOrderBy(sorts []dto.Sort) string {
+	var (
+		terms []string
+		hasID bool
+	)
+	for _, sort := range sorts {
+		direction := "ASC"
+		if sort.Desc {
+			direction = "DESC"
+		}
+		terms = append(terms, "\""+sort.Column+"\" "+direction)
+		hasID = hasID || sort.Column == "id"
+	}
+	if !hasID {
+		terms = append(terms, "\"id\" ASC")
+	}
+	return strings.Join(terms, ", ")
+}
+`
@@ -0,0 +1,209 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// SparseFieldColumns renders the entries of the fields whitelist, mapping the
+// JSON name of each field a request may select to the columns it is read
+// from, aligned like gofmt would. Hidden fields cannot be selected, and a
+// derived field is read from the fields its expression uses. The relations the
+// service loads are selected like fields, along with the foreign keys they are
+// loaded by.
+func (d TemplateData) SparseFieldColumns() []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	for _, column := range d.dataColumns() {
+		if !column.Hidden() {
+			fmt.Fprintf(w, "%q:\t{%q},\n", column.Name, column.Name)
+		}
+	}
+	for _, derived := range d.Entity.Derived {
+		columns := derivedColumns(derived)
+		for i, column := range columns {
+			columns[i] = fmt.Sprintf("%q", column)
+		}
+		fmt.Fprintf(w, "%q:\t{%s},\n", derived.Name, strings.Join(columns, ", "))
+	}
+	if d.LoadsRelations() {
+		for _, loader := range d.RelationLoaders() {
+			if loader.Kind == relationBelongsTo {
+				fmt.Fprintf(w, "%q:\t{%q},\n", loader.JSONName(), loader.RelationSpec.ForeignKey())
+			} else {
+				fmt.Fprintf(w, "%q:\t{},\n", loader.JSONName())
+			}
+		}
+	}
+	w.Flush()
+	return splitLines(buf.String())
+}
+
+// derivedColumns lists the fields the expression of a derived field reads, in
+// the order they first appear.
+func derivedColumns(derived DerivedSpec) []string {
+	expr, err := parser.ParseExpr(derived.Expr)
+	if err != nil {
+		// The spec was validated when it was loaded.
+		return nil
+	}
+	var columns []string
+	replaceIdents(expr, func(ident *ast.Ident) ast.Expr {
+		if ident.Name != "true" && ident.Name != "false" && !slices.Contains(columns, ident.Name) {
+			columns = append(columns, ident.Name)
+		}
+		return ident
+	})
+	return columns
+}
+
+// --- SPARSE FIELDSET TEMPLATES ---
+
+// sparseFieldsDTOTemplate, sparseFieldsParserTemplate and
+// sparseSelectTemplate are shared by every entity, so they are generated once
+// and left alone on later runs.
+const sparseFieldsDTOTemplate = `package dto
+
+import "context"
+
+// Fields is the sparse fieldset of a request, carried from the controller to
+// the repository on the request context: the JSON names the response keeps and
+// the columns the repository reads for them. Both are empty when the request
+// does not select fields.
+type Fields struct {
+	Names   []string
+	Columns []string
+}
+
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx carrying fields.
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// FieldsFromContext returns the fieldset attached by WithFields, if any.
+func FieldsFromContext(ctx context.Context) Fields {
+	fields, _ := ctx.Value(fieldsKey{}).(Fields)
+	return fields
+}
+`
+
+const sparseFieldsParserTemplate = `package httpUtils
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// ParseFields reads the comma-separated fields query parameter, e.g.
+// fields=name,price, against fields, which maps the JSON names a request may
+// select to the columns they are read from. The id is always selected. Names
+// that are not whitelisted are rejected with a bad request error; without the
+// parameter the fieldset is empty and every field is returned.
+func ParseFields(c *ports.HttpContext, fields map[string][]string) (dto.Fields, error) {
+	raw := c.Query("fields")
+	if raw == "" {
+		return dto.Fields{}, nil
+	}
+
+	selected := dto.Fields{Names: []string{"id"}, Columns: []string{"id"}}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(selected.Names, name) {
+			continue
+		}
+		columns, ok := fields[name]
+		if !ok {
+			allowed := make([]string, 0, len(fields))
+			for name := range fields {
+				allowed = append(allowed, name)
+			}
+			sort.Strings(allowed)
+			return dto.Fields{}, appErr.NewBadRequestErr(fmt.Errorf("unknown field %q, must be one of id, %s", name, strings.Join(allowed, ", ")))
+		}
+		selected.Names = append(selected.Names, name)
+		for _, column := range columns {
+			if !slices.Contains(selected.Columns, column) {
+				selected.Columns = append(selected.Columns, column)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// SelectFields returns v, a response or a slice of responses, as JSON objects
+// holding only the fields of the fieldset. Without a fieldset v is returned as
+// it is.
+func SelectFields(v any, fields dto.Fields) (any, error) {
+	if len(fields.Names) == 0 {
+		return v, nil
+	}
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &objects); err == nil {
+		for i, object := range objects {
+			objects[i] = selectKeys(object, fields.Names)
+		}
+		return objects, nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &object); err != nil {
+		return nil, err
+	}
+	return selectKeys(object, fields.Names), nil
+}
+
+func selectKeys(object map[string]json.RawMessage, names []string) map[string]json.RawMessage {
+	selected := make(map[string]json.RawMessage, len(names))
+	for _, name := range names {
+		if value, ok := object[name]; ok {
+			selected[name] = value
+		}
+	}
+	return selected
+}
+`
+
+const sparseSelectTemplate = `package repository
+
+import (
+	"context"
+	"strings"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+// SelectList renders the columns of the fieldset the controller attached to
+// ctx as a SELECT list, or * when the request does not select fields. Columns
+// come from the controllers' whitelists and are quoted, never taken from user
+// input.
+func SelectList(ctx context.Context) string {
+	columns := dto.FieldsFromContext(ctx).Columns
+	if len(columns) == 0 {
+		return "*"
+	}
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = "\"" + column + "\""
+	}
+	return strings.Join(quoted, ", ")
+}
+`
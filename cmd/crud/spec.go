@@ -0,0 +1,325 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec declares the entities of a service, their fields and the relations
+// between them. It is read from a YAML file such as entities.yaml:
+//
+//	entities:
+//	  - name: Order
+//	    fields:
+//	      - name: total
+//	        type: float
+//	        filters: [eq, gte, between]
+//	    relations:
+//	      - kind: belongs_to
+//	        entity: Customer
+//
+// The file is checked against specSchema before it is decoded.
+type Spec struct {
+	Entities []EntitySpec `yaml:"entities"`
+}
+
+// EntitySpec describes one entity. Every entity has an implicit int64 id
+// primary key, which is not listed among its fields.
+type EntitySpec struct {
+	Name      string         `yaml:"name"`
+	Fields    []FieldSpec    `yaml:"fields"`
+	Relations []RelationSpec `yaml:"relations"`
+	// Derived lists response fields computed from the entity's fields.
+	Derived []DerivedSpec `yaml:"derived"`
+	// DefaultScope, when set, hides the rows outside it from list and get.
+	DefaultScope *ScopeSpec `yaml:"default_scope"`
+	// Partition, when set, partitions the entity's table.
+	Partition *PartitionSpec `yaml:"partition"`
+	// Cassandra lays out the entity's table for --db cassandra.
+	Cassandra *CassandraSpec `yaml:"cassandra"`
+	// Collection names the entity's Firestore collection or Spanner table,
+	// see TemplateData.Collection.
+	Collection string `yaml:"collection"`
+	// Features overrides the feature toggles of the generator config.
+	Features FeatureConfig `yaml:"features"`
+	// Profile overrides the profile of the generator config for the entity.
+	Profile string `yaml:"profile"`
+	// Source, when set, generates an anti-corruption layer translating the
+	// payloads of the third-party system the entity comes from.
+	Source *SourceSpec `yaml:"source"`
+	// EnrichWith lists the services the entity's service calls to fill in its
+	// fields, see TemplateData.Clients.
+	EnrichWith ServiceList `yaml:"enrich_with"`
+}
+
+// FieldSpec describes one column of an entity. Name is snake_case, as in the
+// database; Type is one of the keys of fieldTypes. Filters lists the filter
+// operators the list endpoint accepts for the field. Fields may also be written
+// in the name:type[:options] shorthand, see UnmarshalYAML.
+type FieldSpec struct {
+	Name     string   `yaml:"name"`
+	Type     string   `yaml:"type"`
+	Nullable bool     `yaml:"nullable"`
+	Unique   bool     `yaml:"unique"`
+	Filters  []string `yaml:"filters"`
+	// Default is the SQL default of the column, e.g. 0 or 'draft'.
+	Default string `yaml:"default"`
+	// Check is the SQL check constraint of the column, e.g. quantity >= 0.
+	// Simple comparisons and IN lists are mirrored as request validation.
+	Check string `yaml:"check"`
+	// Example is a realistic value of the field, shared by the swagger docs,
+	// .http files and mock data, e.g. 42 or 2024-05-01T10:00:00Z.
+	Example string `yaml:"example"`
+	// Visibility restricts what the responses show of the field: internal,
+	// write_only or masked. Empty returns it as stored.
+	Visibility string `yaml:"visibility"`
+	// PII marks personal data, e.g. an email or phone number, which logs
+	// redact.
+	PII bool `yaml:"pii"`
+	// Encrypted fields are personal data stored envelope-encrypted, so the
+	// database only sees ciphertext.
+	Encrypted bool `yaml:"encrypted"`
+	// Sortable fields may be named in the sort query parameter of the list
+	// endpoint, e.g. sort=-created_at,name.
+	Sortable bool `yaml:"sortable"`
+	// ResetOnClone fields, such as codes or audit timestamps, are left at their
+	// zero values in the copies of the clone endpoint.
+	ResetOnClone bool `yaml:"reset_on_clone"`
+}
+
+// RelationSpec links an entity to another entity of the spec. A belongs_to
+// relation adds a <entity>_id foreign key column to the declaring entity.
+type RelationSpec struct {
+	Kind   string `yaml:"kind"`
+	Entity string `yaml:"entity"`
+	// OnDelete is what deleting the related row does to a belongs_to row:
+	// cascade, restrict or set_null. Empty keeps the database default.
+	OnDelete string `yaml:"on_delete"`
+}
+
+const (
+	relationBelongsTo  = "belongs_to"
+	relationHasOne     = "has_one"
+	relationHasMany    = "has_many"
+	relationManyToMany = "many_to_many"
+)
+
+// fieldType maps a spec field type to its Go and PostgreSQL types and the
+// filter operators that make sense for it.
+type fieldType struct {
+	Go      string
+	SQL     string
+	Filters []string
+}
+
+var (
+	equalityFilters   = []string{filterEq, filterNeq, filterIn}
+	comparisonFilters = []string{filterEq, filterNeq, filterGt, filterGte, filterIn, filterBetween}
+	textFilters       = []string{filterEq, filterNeq, filterIn, filterLike}
+)
+
+var fieldTypes = map[string]fieldType{
+	"string": {Go: "string", SQL: "VARCHAR(255)", Filters: textFilters},
+	"text":   {Go: "string", SQL: "TEXT", Filters: textFilters},
+	"int":    {Go: "int", SQL: "INTEGER", Filters: comparisonFilters},
+	"int64":  {Go: "int64", SQL: "BIGINT", Filters: comparisonFilters},
+	"float":  {Go: "float64", SQL: "DOUBLE PRECISION", Filters: comparisonFilters},
+	"bool":   {Go: "bool", SQL: "BOOLEAN", Filters: []string{filterEq, filterNeq}},
+	"time":   {Go: "time.Time", SQL: "TIMESTAMPTZ", Filters: comparisonFilters},
+	"uuid":   {Go: "string", SQL: "UUID", Filters: equalityFilters},
+	"json":   {Go: "json.RawMessage", SQL: "JSONB"},
+}
+
+var (
+	entityNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	fieldNamePattern  = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// stdinSpecPath is the --spec value reading the spec from stdin.
+const stdinSpecPath = "-"
+
+// stdinSpec caches the spec read from stdin, which can only be read once but
+// is loaded by several steps of a run.
+var stdinSpec []byte
+
+// loadSpec reads and validates the spec at path, or from stdin for "-".
+func loadSpec(path string) (Spec, error) {
+	content, err := readSpec(path)
+	if err != nil {
+		return Spec{}, fmt.Errorf("reading spec: %w", err)
+	}
+	if path == stdinSpecPath {
+		path = "<stdin>"
+	}
+
+	// The schema is checked first, so mistakes are reported with their
+	// position instead of as decoding errors.
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return Spec{}, fmt.Errorf("parsing spec %s: %w", path, err)
+	}
+	if err := validateSpecSchema(&document); err != nil {
+		return Spec{}, fmt.Errorf("invalid spec %s:\n%w", path, err)
+	}
+
+	var spec Spec
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil {
+		return Spec{}, fmt.Errorf("parsing spec %s: %w", path, err)
+	}
+	if err := spec.validate(); err != nil {
+		return Spec{}, fmt.Errorf("invalid spec %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+func readSpec(path string) ([]byte, error) {
+	if path != stdinSpecPath {
+		return os.ReadFile(path)
+	}
+	if stdinSpec == nil {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		stdinSpec = content
+	}
+	return stdinSpec, nil
+}
+
+func (s Spec) validate() error {
+	if len(s.Entities) == 0 {
+		return fmt.Errorf("no entities defined")
+	}
+
+	names := make(map[string]bool, len(s.Entities))
+	for _, entity := range s.Entities {
+		if !entityNamePattern.MatchString(entity.Name) {
+			return fmt.Errorf("entity name %q must be PascalCase", entity.Name)
+		}
+		if names[entity.Name] {
+			return fmt.Errorf("entity %s is defined more than once", entity.Name)
+		}
+		names[entity.Name] = true
+	}
+
+	for _, entity := range s.Entities {
+		columns := map[string]bool{"id": true}
+		for _, field := range entity.Fields {
+			if !fieldNamePattern.MatchString(field.Name) {
+				return fmt.Errorf("%s: field name %q must be snake_case", entity.Name, field.Name)
+			}
+			if columns[field.Name] {
+				return fmt.Errorf("%s: field %s is defined more than once", entity.Name, field.Name)
+			}
+			columns[field.Name] = true
+			fieldType, ok := fieldTypes[field.Type]
+			if !ok {
+				return fmt.Errorf("%s.%s: unknown type %q", entity.Name, field.Name, field.Type)
+			}
+			for _, operator := range field.Filters {
+				if !slices.Contains(fieldType.Filters, operator) {
+					return fmt.Errorf("%s.%s: filter operator %q is not supported for %s fields", entity.Name, field.Name, operator, field.Type)
+				}
+			}
+			if err := field.validateExample(); err != nil {
+				return fmt.Errorf("%s.%s: %w", entity.Name, field.Name, err)
+			}
+			if err := field.validateVisibility(); err != nil {
+				return fmt.Errorf("%s.%s: %w", entity.Name, field.Name, err)
+			}
+			if err := field.validateEncryption(); err != nil {
+				return fmt.Errorf("%s.%s: %w", entity.Name, field.Name, err)
+			}
+			if err := field.validateSort(); err != nil {
+				return fmt.Errorf("%s.%s: %w", entity.Name, field.Name, err)
+			}
+		}
+		for _, relation := range entity.Relations {
+			switch relation.Kind {
+			case relationBelongsTo, relationHasOne, relationHasMany, relationManyToMany:
+			default:
+				return fmt.Errorf("%s: unknown relation kind %q", entity.Name, relation.Kind)
+			}
+			if !names[relation.Entity] {
+				return fmt.Errorf("%s: relation to undefined entity %q%s", entity.Name, relation.Entity, suggest(relation.Entity, slices.Sorted(maps.Keys(names))))
+			}
+			switch relation.OnDelete {
+			case "", onDeleteCascade, onDeleteRestrict, onDeleteSetNull:
+			default:
+				return fmt.Errorf("%s: unknown on_delete %q of the relation to %s", entity.Name, relation.OnDelete, relation.Entity)
+			}
+			if relation.OnDelete != "" && relation.Kind != relationBelongsTo {
+				return fmt.Errorf("%s: on_delete is only supported on belongs_to relations", entity.Name)
+			}
+			if relation.Kind == relationBelongsTo {
+				column := relation.ForeignKey()
+				if columns[column] {
+					return fmt.Errorf("%s: foreign key %s collides with a field", entity.Name, column)
+				}
+				columns[column] = true
+			}
+		}
+		if entity.DefaultScope != nil {
+			if err := entity.DefaultScope.validate(entity, columns); err != nil {
+				return err
+			}
+		}
+		for _, derived := range entity.Derived {
+			if err := derived.validate(entity, columns); err != nil {
+				return err
+			}
+			columns[derived.Name] = true
+		}
+		if entity.Partition != nil {
+			if err := entity.Partition.validate(entity); err != nil {
+				return err
+			}
+		}
+		if entity.Cassandra != nil {
+			if err := entity.Cassandra.validate(entity); err != nil {
+				return err
+			}
+		}
+		if entity.Collection != "" && !collectionNamePattern.MatchString(entity.Collection) {
+			return fmt.Errorf("%s: collection %q must be letters, digits and '_', starting with a letter", entity.Name, entity.Collection)
+		}
+		if entity.Source != nil {
+			if err := entity.Source.validate(entity); err != nil {
+				return err
+			}
+		}
+		for i, service := range entity.EnrichWith {
+			if !serviceNamePattern.MatchString(service) {
+				return fmt.Errorf("%s: enrich_with service %q must be kebab-case", entity.Name, service)
+			}
+			if slices.Contains(entity.EnrichWith[:i], service) {
+				return fmt.Errorf("%s: enrich_with lists %s more than once", entity.Name, service)
+			}
+		}
+	}
+	return nil
+}
+
+// entity returns the spec of the named entity.
+func (s Spec) entity(name string) (EntitySpec, error) {
+	for _, entity := range s.Entities {
+		if entity.Name == name {
+			return entity, nil
+		}
+	}
+	return EntitySpec{}, fmt.Errorf("entity %s is not defined in the spec", name)
+}
+
+// ForeignKey is the column a belongs_to relation adds to the declaring entity.
+func (r RelationSpec) ForeignKey() string {
+	return toSnakeCase(r.Entity) + "_id"
+}
@@ -0,0 +1,399 @@
+package crud
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var specSchemaCmd = &cobra.Command{
+	Use:   "spec-schema",
+	Short: "Prints the JSON Schema of the entities spec file.",
+	Long: `This command prints the JSON Schema every spec is validated against before
+generation. Point your editor at it for completion and inline errors, e.g. with
+the YAML language server add this line to the top of entities.yaml:
+
+# yaml-language-server: $schema=./spec.schema.json
+
+go run . spec-schema > spec.schema.json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(specSchema())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(specSchemaCmd)
+}
+
+// jsonSchema is the subset of JSON Schema the spec schema uses.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	MinItems             int                    `json:"minItems,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Minimum              *int                   `json:"minimum,omitempty"`
+	Maximum              *int                   `json:"maximum,omitempty"`
+	OneOf                []*jsonSchema          `json:"oneOf,omitempty"`
+
+	// patternHint explains Pattern in error messages.
+	patternHint string
+	// shorthand is the object schema a string in the field shorthand expands to.
+	shorthand *jsonSchema
+}
+
+// specSchema describes the spec file. It is built from the same tables the
+// generator uses, so it cannot fall behind the supported types and options.
+func specSchema() *jsonSchema {
+	types := make([]string, 0, len(fieldTypes))
+	for name := range fieldTypes {
+		types = append(types, name)
+	}
+	slices.Sort(types)
+	intervals := make([]string, 0, len(partitionIntervals))
+	for name := range partitionIntervals {
+		intervals = append(intervals, name)
+	}
+	slices.Sort(intervals)
+	zero, two, maxPartitions := 0, 2, maxHashPartitions
+
+	field := object("A column of the entity.", map[string]*jsonSchema{
+		"name":     {Description: "Column name.", Type: "string", Pattern: fieldNamePattern.String(), patternHint: "must be snake_case"},
+		"type":     {Description: "Column type.", Type: "string", Enum: types},
+		"nullable": {Description: "Whether the column accepts NULL.", Type: "boolean"},
+		"unique":   {Description: "Whether the column has a unique constraint.", Type: "boolean"},
+		"filters": {
+			Description: "Filter operators the list endpoint accepts for the field.",
+			Type:        "array",
+			Items:       &jsonSchema{Type: "string", Enum: []string{filterEq, filterNeq, filterGt, filterGte, filterIn, filterLike, filterBetween}},
+		},
+		"default": {Description: "SQL default of the column, e.g. 0 or 'draft'."},
+		"check":   {Description: "SQL check constraint of the column, e.g. quantity >= 0."},
+		"example": {Description: "Realistic value of the field for swagger docs, .http files and mock data, e.g. 42."},
+		"visibility": {
+			Description: "What the responses show of the field: internal leaves it to the internal API, write_only never returns it and masked hides all but its last characters.",
+			Type:        "string",
+			Enum:        []string{visibilityInternal, visibilityWriteOnly, visibilityMasked},
+		},
+		"pii":            {Description: "Whether the column holds personal data, which logs redact.", Type: "boolean"},
+		"encrypted":      {Description: "Whether the column holds personal data stored envelope-encrypted; only non-nullable strings without filters, unique, sortable, default or check.", Type: "boolean"},
+		"sortable":       {Description: "Whether the list endpoint may sort by the column, e.g. sort=-created_at,name.", Type: "boolean"},
+		"reset_on_clone": {Description: "Whether the clone endpoint leaves the column at its zero value instead of copying it; unique columns need it and nullable.", Type: "boolean"},
+	}, "name", "type")
+	shorthand := &jsonSchema{
+		Description: "A column in the name:type[:options] shorthand, e.g. quantity:int:default=0,check=quantity >= 0.",
+		Type:        "string",
+		Pattern:     `^[^:]+:[^:]+(:.*)?$`,
+		patternHint: "must be a field mapping or name:type[:options]",
+		shorthand:   field,
+	}
+	relation := object("A relation to another entity of the spec.", map[string]*jsonSchema{
+		"kind":      {Description: "Relation kind; belongs_to adds a <entity>_id column.", Type: "string", Enum: []string{relationBelongsTo, relationHasOne, relationHasMany, relationManyToMany}},
+		"entity":    {Description: "Name of the related entity.", Type: "string", Pattern: entityNamePattern.String(), patternHint: "must be PascalCase"},
+		"on_delete": {Description: "What deleting the related row does to a belongs_to row.", Type: "string", Enum: []string{onDeleteCascade, onDeleteRestrict, onDeleteSetNull}},
+	}, "kind", "entity")
+	derived := object("A response field computed from the entity's fields, neither requested nor stored.", map[string]*jsonSchema{
+		"name": {Description: "Field name.", Type: "string", Pattern: fieldNamePattern.String(), patternHint: "must be snake_case"},
+		"type": {Description: "Field type; a number, string or bool.", Type: "string", Enum: types},
+		"expr": {Description: "Go expression of the entity's fields and literals, e.g. price * quantity.", Type: "string"},
+	}, "name", "type", "expr")
+	partition := object("How the entity's table is partitioned.", map[string]*jsonSchema{
+		"strategy":   {Description: "Partitioning strategy.", Type: "string", Enum: []string{partitionRange, partitionHash}},
+		"key":        {Description: "Partition key: a time field for range, any field or id for hash.", Type: "string", Pattern: fieldNamePattern.String(), patternHint: "must be snake_case"},
+		"interval":   {Description: "Range only: the span of each partition.", Type: "string", Enum: intervals},
+		"premake":    {Description: "Range only: future partitions kept ready.", Type: "integer", Minimum: &zero},
+		"partitions": {Description: "Hash only: number of partitions.", Type: "integer", Minimum: &two, Maximum: &maxPartitions},
+	}, "strategy", "key")
+	cassandra := object("How the entity's table is keyed with --db cassandra; id alone by default.", map[string]*jsonSchema{
+		"partition_key":  {Description: "Columns deciding which rows are stored together.", Type: "array", Items: &jsonSchema{Type: "string", Pattern: fieldNamePattern.String(), patternHint: "must be snake_case"}},
+		"clustering_key": {Description: "Columns ordering the rows of a partition, each optionally followed by asc or desc.", Type: "array", Items: &jsonSchema{Type: "string", Pattern: clusteringColumn.String(), patternHint: "must be a snake_case column optionally followed by asc or desc"}},
+	})
+	defaultScope := object("A SQL condition the list and get queries add unless the request lifts it.", map[string]*jsonSchema{
+		"where":    {Description: "Condition on the entity's columns, e.g. status != 'archived'.", Type: "string"},
+		"override": {Description: "Query parameter lifting the scope when true; unscoped by default.", Type: "string", Pattern: fieldNamePattern.String(), patternHint: "must be snake_case"},
+	}, "where")
+	features := object("Feature toggles overriding those of the generator config.", map[string]*jsonSchema{
+		"soft_delete": {Description: "Rows are soft-deleted through a deleted_at column.", Type: "boolean"},
+		"tracing":     {Description: "Operations are traced.", Type: "boolean"},
+		"caching":     {Description: "Reads are cached.", Type: "boolean"},
+		"audit":       {Description: "Changes are audited.", Type: "boolean"},
+	})
+	source := object("The third-party system the entity is sourced from, whose payloads an anti-corruption layer translates.", map[string]*jsonSchema{
+		"system": {Description: "Name of the system, e.g. acme-crm.", Type: "string", Pattern: serviceNamePattern.String(), patternHint: "must be kebab-case"},
+		"fields": {Description: "Maps fields of the entity to the fields of the system's payload, e.g. title: productName.", Type: "object"},
+	}, "system")
+	service := &jsonSchema{Description: "A service the entity's service calls to fill in its fields, e.g. pricing-service.", Type: "string", Pattern: serviceNamePattern.String(), patternHint: "must be kebab-case"}
+	entity := object("An entity of the service; its int64 id is implicit.", map[string]*jsonSchema{
+		"name":          {Description: "Entity name.", Type: "string", Pattern: entityNamePattern.String(), patternHint: "must be PascalCase"},
+		"fields":        {Type: "array", Items: &jsonSchema{OneOf: []*jsonSchema{field, shorthand}}},
+		"relations":     {Type: "array", Items: relation},
+		"derived":       {Type: "array", Items: derived},
+		"default_scope": defaultScope,
+		"partition":     partition,
+		"cassandra":     cassandra,
+		"collection":    {Description: "Firestore collection or Spanner table of the entity; orderItems or OrderItems for OrderItem by default.", Type: "string", Pattern: collectionNamePattern.String(), patternHint: "must be letters, digits and '_', starting with a letter"},
+		"features":      features,
+		"profile":       {Description: "Profile crud generates the entity with, see the generator config.", Type: "string"},
+		"source":        source,
+		"enrich_with":   {OneOf: []*jsonSchema{service, {Type: "array", Items: service}}},
+	}, "name")
+
+	spec := object("The entities of a service, their fields and the relations between them.", map[string]*jsonSchema{
+		"entities": {Type: "array", Items: entity, MinItems: 1},
+	}, "entities")
+	spec.Schema = "https://json-schema.org/draft/2020-12/schema"
+	spec.Title = "gocrud-gen spec"
+	return spec
+}
+
+func object(description string, properties map[string]*jsonSchema, required ...string) *jsonSchema {
+	closed := false
+	return &jsonSchema{
+		Description:          description,
+		Type:                 "object",
+		Properties:           properties,
+		Required:             required,
+		AdditionalProperties: &closed,
+	}
+}
+
+// specError is a schema violation at a position of the spec file.
+type specError struct {
+	Line, Column int
+	Path         string
+	Message      string
+}
+
+func (e specError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// validateSpecSchema checks the parsed spec document against specSchema and
+// returns every violation, joined.
+func validateSpecSchema(document *yaml.Node) error {
+	if document.Kind == yaml.DocumentNode && len(document.Content) > 0 {
+		document = document.Content[0]
+	}
+	if document.Kind == 0 {
+		return errors.New("the spec is empty")
+	}
+	var errs []error
+	specSchema().validate(document, "spec", &errs)
+	return errors.Join(errs...)
+}
+
+func (s *jsonSchema) validate(node *yaml.Node, path string, errs *[]error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	fail := func(at *yaml.Node, format string, args ...any) {
+		*errs = append(*errs, specError{Line: at.Line, Column: at.Column, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.OneOf) > 0 {
+		var matching []*jsonSchema
+		var expected []string
+		for _, branch := range s.OneOf {
+			if branch.accepts(node) {
+				matching = append(matching, branch)
+			}
+			expected = append(expected, describeType(branch.Type))
+		}
+		if len(matching) != 1 {
+			fail(node, "must be %s", strings.Join(expected, " or "))
+			return
+		}
+		matching[0].validate(node, path, errs)
+		return
+	}
+	if !s.accepts(node) {
+		fail(node, "must be %s", describeType(s.Type))
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		seen := make(map[string]bool, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			seen[key.Value] = true
+			property, ok := s.Properties[key.Value]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					fail(key, "unknown property %q%s", key.Value, suggest(key.Value, mapKeys(s.Properties)))
+				}
+				continue
+			}
+			property.validate(value, path+"."+key.Value, errs)
+		}
+		for _, name := range s.Required {
+			if !seen[name] {
+				fail(node, "missing required property %q", name)
+			}
+		}
+	case yaml.SequenceNode:
+		if len(node.Content) < s.MinItems {
+			fail(node, "must have at least %d item(s)", s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range node.Content {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case yaml.ScalarNode:
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, node.Value) {
+			fail(node, "unknown value %q%s (one of %s)", node.Value, suggest(node.Value, s.Enum), strings.Join(s.Enum, ", "))
+		}
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(node.Value) {
+			fail(node, "%q %s", node.Value, s.patternHint)
+			return
+		}
+		if s.Type == "integer" {
+			value, _ := strconv.Atoi(node.Value)
+			if s.Minimum != nil && value < *s.Minimum {
+				fail(node, "must be at least %d", *s.Minimum)
+			}
+			if s.Maximum != nil && value > *s.Maximum {
+				fail(node, "must be at most %d", *s.Maximum)
+			}
+		}
+		if s.shorthand != nil {
+			expanded, err := expandFieldShorthand(node)
+			if err != nil {
+				fail(node, "%v", err)
+				return
+			}
+			s.shorthand.validate(expanded, path, errs)
+		}
+	}
+}
+
+// accepts reports whether node has the schema's type.
+func (s *jsonSchema) accepts(node *yaml.Node) bool {
+	switch s.Type {
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	case "string":
+		return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!str"
+	case "boolean":
+		return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!bool"
+	case "integer":
+		return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!int"
+	default:
+		return node.Kind == yaml.ScalarNode
+	}
+}
+
+func describeType(schemaType string) string {
+	switch schemaType {
+	case "object":
+		return "a mapping"
+	case "array":
+		return "a list"
+	case "string":
+		return "a string"
+	case "boolean":
+		return "true or false"
+	case "integer":
+		return "an integer"
+	default:
+		return "a value"
+	}
+}
+
+// expandFieldShorthand rewrites a field in the shorthand as the equivalent
+// mapping, positioned at the shorthand, so it is checked like a mapping.
+func expandFieldShorthand(node *yaml.Node) (*yaml.Node, error) {
+	var field FieldSpec
+	if err := field.UnmarshalYAML(node); err != nil {
+		return nil, err
+	}
+	scalar := func(tag, value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value, Line: node.Line, Column: node.Column}
+	}
+	expanded := &yaml.Node{Kind: yaml.MappingNode, Line: node.Line, Column: node.Column}
+	add := func(key string, value *yaml.Node) {
+		expanded.Content = append(expanded.Content, scalar("!!str", key), value)
+	}
+	add("name", scalar("!!str", field.Name))
+	add("type", scalar("!!str", field.Type))
+	if len(field.Filters) > 0 {
+		filters := &yaml.Node{Kind: yaml.SequenceNode, Line: node.Line, Column: node.Column}
+		for _, operator := range field.Filters {
+			filters.Content = append(filters.Content, scalar("!!str", operator))
+		}
+		add("filters", filters)
+	}
+	return expanded, nil
+}
+
+func mapKeys(properties map[string]*jsonSchema) []string {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// suggest returns a "did you mean" hint naming the candidate closest to
+// word, or nothing when none is close.
+func suggest(word string, candidates []string) string {
+	word = strings.ToLower(word)
+	best, bestDistance := "", len(word)/3+2
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		distance := editDistance(word, lower)
+		if strings.HasPrefix(word, lower) || strings.HasPrefix(lower, word) {
+			distance = min(distance, 1)
+		}
+		if distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
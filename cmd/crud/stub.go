@@ -0,0 +1,69 @@
+package crud
+
+// --- STUB SERVICE TEMPLATES ---
+
+// serviceStubTemplate is written to the same path as the real service so that
+// deleting it and re-running without --stub swaps in the repository-backed one.
+const serviceStubTemplate = `package service
+
+import (
+	"context"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+type {{.PascalCase}} interface {
+	Get{{.PascalCase}}ByID(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error)
+	Update{{.PascalCase}}(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}) (dto.{{.PascalCase}}, error)
+	Create{{.PascalCase}}(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}) (dto.{{.PascalCase}}, error)
+	Delete{{.PascalCase}}(ctx context.Context, id {{.IDGoType}}) error
+	GetPaginated{{.PascalCase}}s(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error)
+}
+
+// {{.CamelCase}}StubService returns canned data so clients can integrate
+// against the API before the repository and database exist.
+type {{.CamelCase}}StubService struct {
+	log ports.LoggerWithTraceID
+}
+
+func New{{.PascalCase}}Service(log ports.LoggerWithTraceID) {{.PascalCase}} {
+	return &{{.CamelCase}}StubService{
+		log: log,
+	}
+}
+
+// sample{{.PascalCase}} builds the canned {{.PascalCase}} every stub method returns.
+func sample{{.PascalCase}}(id {{.IDGoType}}) dto.{{.PascalCase}} {
+	// TODO: Fill in representative values for the remaining fields.
+	return dto.{{.PascalCase}}{ID: id}
+}
+
+func (s *{{.CamelCase}}StubService) Get{{.PascalCase}}ByID(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error) {
+	return sample{{.PascalCase}}(id), nil
+}
+
+func (s *{{.CamelCase}}StubService) Update{{.PascalCase}}(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}) (dto.{{.PascalCase}}, error) {
+	return {{.CamelCase}}, nil
+}
+
+func (s *{{.CamelCase}}StubService) Create{{.PascalCase}}(ctx context.Context, {{.CamelCase}} dto.{{.PascalCase}}) (dto.{{.PascalCase}}, error) {
+	{{.CamelCase}}.ID = {{if .UUID}}uuid.New(){{else}}1{{end}}
+	return {{.CamelCase}}, nil
+}
+
+func (s *{{.CamelCase}}StubService) Delete{{.PascalCase}}(ctx context.Context, id {{.IDGoType}}) error {
+	return nil
+}
+
+func (s *{{.CamelCase}}StubService) GetPaginated{{.PascalCase}}s(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+{{- if .UUID}}
+	return []dto.{{.PascalCase}}{sample{{.PascalCase}}(uuid.New()), sample{{.PascalCase}}(uuid.New())}, &pagination, nil
+{{- else}}
+	return []dto.{{.PascalCase}}{sample{{.PascalCase}}(1), sample{{.PascalCase}}(2)}, &pagination, nil
+{{- end}}
+}
+`
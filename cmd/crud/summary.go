@@ -0,0 +1,73 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// templateEndpoints counts the endpoints served by the controllers generated
+// from each built-in template.
+var templateEndpoints = map[string]int{
+	"controller":            5,
+	"appendOnlyController":  2,
+	"clickHouseBuckets":     1,
+	"internalController":    2,
+	"webhookController":     3,
+	"apiKeyController":      3,
+	"dataSubjectController": 1,
+	"cloneController":       1,
+	"duplicateController":   1,
+	"trashController":       2,
+	"historyController":     1,
+	"historyDiffController": 1,
+	"approvalController":    3,
+}
+
+// generationSummary counts what a crud run generated, for tracking the
+// boilerplate saved and for PR descriptions.
+type generationSummary struct {
+	Entity      string `json:"entity"`
+	Created     int    `json:"files_created"`
+	Regenerated int    `json:"files_regenerated"`
+	Skipped     int    `json:"files_skipped"`
+	Lines       int    `json:"lines_generated"`
+	Endpoints   int    `json:"endpoints_added"`
+	Migrations  int    `json:"migrations_created"`
+	Tests       int    `json:"tests_generated"`
+}
+
+// add counts the file of artifact generated at path. Only newly created
+// files add endpoints, migrations and tests.
+func (s *generationSummary) add(path, artifact string, content []byte, regenerated bool) {
+	s.Lines += bytes.Count(content, []byte("\n"))
+	if regenerated {
+		s.Regenerated++
+		return
+	}
+	s.Created++
+	s.Endpoints += templateEndpoints[artifact]
+	switch filepath.Ext(path) {
+	case ".sql", ".cql":
+		s.Migrations++
+	}
+	if strings.HasSuffix(path, "_test.go") {
+		s.Tests++
+	}
+}
+
+func (s generationSummary) String() string {
+	return fmt.Sprintf("%d file(s) created, %d regenerated, %d skipped; %d lines, %d endpoint(s), %d migration(s), %d test file(s)",
+		s.Created, s.Regenerated, s.Skipped, s.Lines, s.Endpoints, s.Migrations, s.Tests)
+}
+
+func (s generationSummary) writeJSON(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
@@ -0,0 +1,102 @@
+package crud
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultSwagCommand = "swag init"
+	defaultSwagOutput  = "docs/swagger.json"
+)
+
+// SwagConfig controls how API docs are regenerated after --swag-init.
+type SwagConfig struct {
+	// Command is run from the working directory, e.g. "swag init -g cmd/main.go".
+	Command string `yaml:"command"`
+	// Output is the swagger.json the command writes, checked for the new routes.
+	Output string `yaml:"output"`
+}
+
+func (cfg SwagConfig) output() string {
+	if cfg.Output == "" {
+		return defaultSwagOutput
+	}
+	return cfg.Output
+}
+
+// docsFiles lists the files swag init writes next to the configured output,
+// which the journal tracks for undo.
+func (cfg SwagConfig) docsFiles() []string {
+	dir := filepath.Dir(cfg.output())
+	return []string{filepath.Join(dir, "docs.go"), filepath.Join(dir, "swagger.json"), filepath.Join(dir, "swagger.yaml")}
+}
+
+// regenerateSwagDocs runs the configured swag command and verifies that every
+// route generated for the entity made it into the docs.
+func regenerateSwagDocs(cfg SwagConfig, data TemplateData) error {
+	command := strings.TrimSpace(cfg.Command)
+	if command == "" {
+		command = defaultSwagCommand
+	}
+	output := cfg.output()
+
+	fmt.Printf("Running: %s\n", command)
+	args := strings.Fields(command)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running %q: %w", command, err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		return fmt.Errorf("reading generated docs: %w", err)
+	}
+	var docs struct {
+		Paths map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(content, &docs); err != nil {
+		return fmt.Errorf("parsing %s: %w", output, err)
+	}
+
+	var missing []string
+	for _, route := range documentedRoutes(data) {
+		if _, ok := docs.Paths[route]; !ok {
+			missing = append(missing, route)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("routes missing from %s: %s", output, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// documentedRoutes lists the @Router paths the generated controllers declare.
+func documentedRoutes(data TemplateData) []string {
+	base := "/api/v1/" + data.KebabCase
+	if data.ClickHouse() {
+		return []string{base + "/", base + "/buckets"}
+	}
+	if data.AppendOnly {
+		return []string{base + "/"}
+	}
+	routes := []string{base + "/", base + "/{id}"}
+	if data.Admin {
+		admin := data.WriteRoutePrefix() + "/" + data.KebabCase
+		routes = append(routes, admin+"/", admin+"/{id}")
+	}
+	if data.InternalAPI {
+		internal := data.InternalRoutePrefix() + "/" + data.KebabCase
+		routes = append(routes, internal+"/", internal+"/{id}")
+	}
+	if data.Webhooks {
+		routes = append(routes, base+"/webhooks", base+"/webhooks/{id}")
+	}
+	return routes
+}
@@ -0,0 +1,185 @@
+package crud
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// templatePackPattern matches a remote template set in templates: the path of
+// a repository, optionally followed by // and the directory of the set in it,
+// pinned to a tag, e.g. github.com/org/crud-templates@v1.4.0 or
+// git.example.com/group/platform//crud-templates@v2.0.0.
+var templatePackPattern = regexp.MustCompile(`^([a-z0-9.-]+\.[a-z]{2,})/([\w.-]+(?:/[\w.-]+)+)(?://([\w.-]+(?:/[\w.-]+)*))?@([\w.+-]+)$`)
+
+var templatesChecksumPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// templatePack is a custom template set shared by several projects from a
+// tagged repository.
+type templatePack struct {
+	Host    string
+	Repo    string
+	Dir     string
+	Version string
+}
+
+// parseTemplatePack reads ref as a template pack; a local directory of that
+// name wins.
+func parseTemplatePack(ref string) (templatePack, bool) {
+	match := templatePackPattern.FindStringSubmatch(ref)
+	if match == nil || slices.ContainsFunc(strings.Split(match[2]+"/"+match[3], "/"), isDotSegment) {
+		return templatePack{}, false
+	}
+	if info, err := os.Stat(ref); err == nil && info.IsDir() {
+		return templatePack{}, false
+	}
+	return templatePack{Host: match[1], Repo: match[2], Dir: match[3], Version: match[4]}, true
+}
+
+// isDotSegment reports whether a segment of a pack's path is . or .., which
+// would point its cache outside the cache directory.
+func isDotSegment(segment string) bool {
+	return segment == "." || segment == ".."
+}
+
+func (p templatePack) String() string {
+	ref := p.Host + "/" + p.Repo
+	if p.Dir != "" {
+		ref += "//" + p.Dir
+	}
+	return ref + "@" + p.Version
+}
+
+// archiveURL is the tarball of the pack's tag, from GitHub or else from the
+// GitLab API the other hosts are expected to serve.
+func (p templatePack) archiveURL() string {
+	if p.Host == "github.com" {
+		return fmt.Sprintf("https://github.com/%s/archive/refs/tags/%s.tar.gz", p.Repo, p.Version)
+	}
+	return fmt.Sprintf("https://%s/%s/-/archive/%s/%s-%s.tar.gz", p.Host, p.Repo, p.Version, path.Base(p.Repo), p.Version)
+}
+
+// resolveTemplates returns the directory of the custom template set configured
+// as templates, fetching a template pack into the user's cache unless it is
+// already there. A pinned checksum is verified against the pack's archive;
+// without one, the checksum to pin is printed on the first fetch.
+func resolveTemplates(templates, checksum string) (string, error) {
+	pack, ok := parseTemplatePack(templates)
+	if !ok {
+		return templates, nil
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	root := filepath.Join(cache, "gocrud-gen", "templates", pack.Host, filepath.FromSlash(pack.Repo)+"@"+pack.Version)
+	dir := filepath.Join(root, filepath.FromSlash(pack.Dir))
+	sumFile := filepath.Join(root, ".sha256")
+
+	if cached, err := os.ReadFile(sumFile); err == nil && (checksum == "" || string(cached) == checksum) {
+		return dir, nil
+	}
+
+	archive, err := downloadPack(pack)
+	if err != nil {
+		return "", fmt.Errorf("fetching template pack %s: %w", pack, err)
+	}
+	sum := sha256.Sum256(archive)
+	got := "sha256:" + hex.EncodeToString(sum[:])
+	if checksum == "" {
+		fmt.Fprintf(os.Stderr, "Fetched template pack %s; pin it with templates_checksum: %s\n", pack, got)
+	} else if got != checksum {
+		return "", fmt.Errorf("checksum mismatch for template pack %s: got %s, want %s", pack, got, checksum)
+	}
+
+	if err := extractPack(archive, pack.Dir, root); err != nil {
+		return "", fmt.Errorf("extracting template pack %s: %w", pack, err)
+	}
+	if err := os.WriteFile(sumFile, []byte(got), 0644); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// downloadPack fetches the archive of pack, authenticated with GITHUB_TOKEN on
+// GitHub and GITLAB_TOKEN elsewhere, if set.
+func downloadPack(pack templatePack) ([]byte, error) {
+	if pack.Host == "github.com" {
+		return download(pack.archiveURL())
+	}
+	header := http.Header{}
+	if token := os.Getenv("GITLAB_TOKEN"); token != "" {
+		header.Set("PRIVATE-TOKEN", token)
+	}
+	return downloadWithHeader(pack.archiveURL(), header)
+}
+
+// extractPack writes the templates in dir of the tarball archive to root,
+// replacing what root held. The archive's top-level directory, named after
+// the repository and tag, is skipped, and only the .tmpl files directly in
+// dir are kept, so entries cannot be written outside root.
+func extractPack(archive []byte, dir, root string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(root), 0755); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(root), filepath.Base(root)+".new-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	target := filepath.Join(staging, filepath.FromSlash(dir))
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+
+	if dir == "" {
+		dir = "."
+	}
+	found := 0
+	reader := tar.NewReader(gz)
+	for {
+		entry, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		_, name, ok := strings.Cut(entry.Name, "/")
+		if !ok || entry.Typeflag != tar.TypeReg || path.Dir(name) != dir || !strings.HasSuffix(name, customTemplateExt) {
+			continue
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(target, path.Base(name)), content, 0644); err != nil {
+			return err
+		}
+		found++
+	}
+	if found == 0 {
+		return fmt.Errorf("no %s files in %s", customTemplateExt, dir)
+	}
+
+	if err := os.RemoveAll(root); err != nil {
+		return err
+	}
+	return os.Rename(staging, root)
+}
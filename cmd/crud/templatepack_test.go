@@ -0,0 +1,116 @@
+package crud
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// packArchive returns a tarball of files, by entry name, as the archive
+// endpoints serve them.
+func packArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(files[name]))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archive.Bytes()
+}
+
+func TestExtractPack(t *testing.T) {
+	files := map[string]string{
+		"pack-v1/controller.go.tmpl":           "root controller",
+		"pack-v1/README.md":                    "readme",
+		"pack-v1/templates/controller.go.tmpl": "controller",
+		"pack-v1/templates/service.go.tmpl":    "service",
+		"pack-v1/templates/nested/dto.go.tmpl": "nested",
+		"pack-v1/templates/../../escape.tmpl":  "escape",
+		"top-level.tmpl":                       "no directory",
+	}
+	tests := []struct {
+		name    string
+		archive []byte
+		dir     string
+		// want maps the files root holds afterwards to their content.
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "templates of dir",
+			archive: packArchive(t, files),
+			dir:     "templates",
+			want:    map[string]string{"templates/controller.go.tmpl": "controller", "templates/service.go.tmpl": "service"},
+		},
+		{
+			name:    "templates of the top level",
+			archive: packArchive(t, files),
+			want:    map[string]string{"controller.go.tmpl": "root controller"},
+		},
+		{
+			name:    "no templates",
+			archive: packArchive(t, files),
+			dir:     "docs",
+			wantErr: true,
+			want:    map[string]string{"previous.go.tmpl": "previous"},
+		},
+		{
+			name:    "not a tarball",
+			archive: []byte("<html>Not Found</html>"),
+			dir:     "templates",
+			wantErr: true,
+			want:    map[string]string{"previous.go.tmpl": "previous"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "packs", "pack")
+			writeTestFile(t, filepath.Join(root, "previous.go.tmpl"), "previous")
+
+			err := extractPack(tt.archive, tt.dir, root)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractPack() error = %v, want error %v", err, tt.wantErr)
+			}
+			got := map[string]string{}
+			err = filepath.WalkDir(filepath.Dir(root), func(path string, entry os.DirEntry, err error) error {
+				if err != nil || entry.IsDir() {
+					return err
+				}
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				got[filepath.ToSlash(rel)] = string(content)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractPack() left %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
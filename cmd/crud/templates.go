@@ -0,0 +1,626 @@
+package crud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// customTemplateExt is the extension of the files in a custom template set.
+	customTemplateExt = ".tmpl"
+	// partialPrefix starts the files of a set holding partials, which every
+	// template can include and which can redefine the blocks of built-ins.
+	partialPrefix = "_"
+)
+
+// builtinTemplates names the templates crud generates from. A custom template
+// set overrides one with a file named after it, e.g. controller.tmpl.
+var builtinTemplates = map[string]string{
+	"adminctlEntity":                adminctlEntityTemplate,
+	"adminctlMain":                  adminctlMainTemplate,
+	"apiKey":                        apiKeyTemplate,
+	"apiKeyController":              apiKeyControllerTemplate,
+	"apiKeyDTO":                     apiKeyDTOTemplate,
+	"apiKeyMigration":               apiKeyMigrationTemplate,
+	"apiKeyRepository":              apiKeyRepositoryTemplate,
+	"appendOnlyController":          appendOnlyControllerTemplate,
+	"appendOnlyIndexMigration":      appendOnlyIndexMigrationTemplate,
+	"appendOnlyRepository":          appendOnlyRepositoryTemplate,
+	"appendOnlyRepositoryInterface": appendOnlyRepositoryInterfaceTemplate,
+	"appendOnlyService":             appendOnlyServiceTemplate,
+	"approvalController":            approvalControllerTemplate,
+	"approvalDTO":                   approvalDTOTemplate,
+	"approvalInterface":             approvalInterfaceTemplate,
+	"approvalRepository":            approvalRepositoryTemplate,
+	"approvalService":               approvalServiceTemplate,
+	"boltRepository":                boltRepositoryTemplate,
+	"boltStore":                     boltStoreTemplate,
+	"caller":                        callerTemplate,
+	"cassandraRepository":           cassandraRepositoryTemplate,
+	"cassandraTableMigration":       cassandraTableMigrationTemplate,
+	"cdcChange":                     cdcChangeTemplate,
+	"cdcDebezium":                   cdcDebeziumTemplate,
+	"cdcListener":                   cdcListenerTemplate,
+	"cdcNotifyMigration":            cdcNotifyMigrationTemplate,
+	"cdcOp":                         cdcOpTemplate,
+	"claims":                        claimsTemplate,
+	"clickHouseBuckets":             clickHouseBucketsControllerTemplate,
+	"clickHouseRepository":          clickHouseRepositoryTemplate,
+	"clickHouseTableMigration":      clickHouseTableMigrationTemplate,
+	"clientPort":                    clientPortTemplate,
+	"cloneController":               cloneControllerTemplate,
+	"cloneService":                  cloneServiceTemplate,
+	"constraintsMigration":          constraintsMigrationTemplate,
+	"controller":                    controllerTemplate,
+	"correlation":                   correlationTemplate,
+	"cors":                          corsTemplate,
+	"dataSubjectController":         dataSubjectControllerTemplate,
+	"dataSubjectInterface":          dataSubjectInterfaceTemplate,
+	"dataSubjectRepository":         dataSubjectRepositoryTemplate,
+	"dataSubjectService":            dataSubjectServiceTemplate,
+	"dialectRepository":             dialectRepositoryTemplate,
+	"duplicateController":           duplicateControllerTemplate,
+	"duplicateInterface":            duplicateInterfaceTemplate,
+	"duplicateRepository":           duplicateRepositoryTemplate,
+	"duplicateService":              duplicateServiceTemplate,
+	"duplicatesDTO":                 duplicatesDTOTemplate,
+	"encryptingRepository":          encryptingRepositoryTemplate,
+	"envelope":                      envelopeTemplate,
+	"envelopeEncryption":            envelopeEncryptionTemplate,
+	"envoyRoutes":                   envoyRoutesTemplate,
+	"featureFlag":                   featureFlagTemplate,
+	"featureModule":                 featureModuleTemplate,
+	"featureWiring":                 featureWiringTemplate,
+	"featureGate":                   featureGateTemplate,
+	"fieldChangeDTO":                fieldChangeDTOTemplate,
+	"filterClause":                  filterClauseTemplate,
+	"filterDTO":                     filterDTOTemplate,
+	"filterParser":                  filterParserTemplate,
+	"firestoreRepository":           firestoreRepositoryTemplate,
+	"foreignKeysMigration":          foreignKeysMigrationTemplate,
+	"gdprRegistry":                  gdprRegistryTemplate,
+	"graphQLLoaders":                graphQLLoadersTemplate,
+	"graphQLResolver":               graphQLResolverTemplate,
+	"graphQLScalars":                graphQLScalarsTemplate,
+	"graphQLSchema":                 graphQLSchemaTemplate,
+	"grpcClient":                    grpcClientTemplate,
+	"healthChecks":                  healthChecksTemplate,
+	"healthRegistry":                healthRegistryTemplate,
+	"historyController":             historyControllerTemplate,
+	"historyDiffController":         historyDiffControllerTemplate,
+	"historyInterface":              historyInterfaceTemplate,
+	"historyMigration":              historyMigrationTemplate,
+	"historyRepository":             historyRepositoryTemplate,
+	"historyService":                historyServiceTemplate,
+	"httpClient":                    httpClientTemplate,
+	"i18nMessages":                  i18nMessagesTemplate,
+	"httpFile":                      httpFileTemplate,
+	"includeDTO":                    includeDTOTemplate,
+	"includeParser":                 includeParserTemplate,
+	"inmemRepository":               inmemRepositoryTemplate,
+	"inmemStore":                    inmemStoreTemplate,
+	"internalController":            internalControllerTemplate,
+	"kongRoutes":                    kongRoutesTemplate,
+	"mask":                          maskTemplate,
+	"mockServerEntity":              mockServerEntityTemplate,
+	"mockServerMain":                mockServerMainTemplate,
+	"negotiation":                   negotiationTemplate,
+	"oracleTableMigration":          oracleTableMigrationTemplate,
+	"orderBy":                       orderByTemplate,
+	"pactConsumer":                  pactConsumerTemplate,
+	"pactHelpers":                   pactHelpersTemplate,
+	"pactProvider":                  pactProviderTemplate,
+	"pageStateDTO":                  pageStateDTOTemplate,
+	"pageStateParser":               pageStateParserTemplate,
+	"paginationDefaults":            paginationDefaultsTemplate,
+	"paginationHeaders":             paginationHeadersTemplate,
+	"partitionJob":                  partitionJobTemplate,
+	"partitionMigration":            partitionMigrationTemplate,
+	"pendingChangeDTO":              pendingChangeDTOTemplate,
+	"pendingChangeMigration":        pendingChangeMigrationTemplate,
+	"piiMigration":                  piiMigrationTemplate,
+	"problem":                       problemTemplate,
+	"publicResponse":                publicResponseTemplate,
+	"reactAdminCreate":              reactAdminCreateTemplate,
+	"reactAdminDataProvider":        reactAdminDataProviderTemplate,
+	"reactAdminEdit":                reactAdminEditTemplate,
+	"reactAdminList":                reactAdminListTemplate,
+	"reactAdminResource":            reactAdminResourceTemplate,
+	"readFromPrimary":               readFromPrimaryTemplate,
+	"readSplitRepository":           readSplitRepositoryTemplate,
+	"redact":                        redactTemplate,
+	"redactEntity":                  redactEntityTemplate,
+	"relationsInterface":            relationsInterfaceTemplate,
+	"relationsRepository":           relationsRepositoryTemplate,
+	"repository":                    repositoryTemplate,
+	"repositoryBench":               repositoryBenchTemplate,
+	"request":                       requestTemplate,
+	"requestFuzz":                   requestFuzzTemplate,
+	"resiliencePolicy":              resiliencePolicyTemplate,
+	"resilientRepository":           resilientRepositoryTemplate,
+	"retentionArchiveMigration":     retentionArchiveMigrationTemplate,
+	"retentionJob":                  retentionJobTemplate,
+	"retryingRepository":            retryingRepositoryTemplate,
+	"rlsMigration":                  rlsMigrationTemplate,
+	"rlsScope":                      rlsScopeTemplate,
+	"routes":                        routesTemplate,
+	"saga":                          sagaTemplate,
+	"sagaMigration":                 sagaMigrationTemplate,
+	"sagaSteps":                     sagaStepsTemplate,
+	"sagaStore":                     sagaStoreTemplate,
+	"scopeClause":                   scopeClauseTemplate,
+	"scopeDTO":                      scopeDTOTemplate,
+	"scopeParser":                   scopeParserTemplate,
+	"serializationRetry":            serializationRetryTemplate,
+	"service":                       serviceTemplate,
+	"serviceClients":                serviceClientsTemplate,
+	"serviceStub":                   serviceStubTemplate,
+	"shapedResponse":                shapedResponseTemplate,
+	"spannerRepository":             spannerRepositoryTemplate,
+	"spannerTableMigration":         spannerTableMigrationTemplate,
+	"sparseFieldsDTO":               sparseFieldsDTOTemplate,
+	"sparseFieldsParser":            sparseFieldsParserTemplate,
+	"sparseSelect":                  sparseSelectTemplate,
+	"sortDTO":                       sortDTOTemplate,
+	"sortParser":                    sortParserTemplate,
+	"sourceAdapter":                 sourceAdapterTemplate,
+	"sourceMapper":                  sourceMapperTemplate,
+	"sourcePort":                    sourcePortTemplate,
+	"sqlServerTableMigration":       sqlServerTableMigrationTemplate,
+	"templHandler":                  templHandlerTemplate,
+	"templPages":                    templPagesTemplate,
+	"timeBucketDTO":                 timeBucketDTOTemplate,
+	"traefikRoutes":                 traefikRoutesTemplate,
+	"trashController":               trashControllerTemplate,
+	"trashInterface":                trashInterfaceTemplate,
+	"trashRepository":               trashRepositoryTemplate,
+	"trashService":                  trashServiceTemplate,
+	"uuidParam":                     uuidParamTemplate,
+	"versionDTO":                    versionDTOTemplate,
+	"webhookController":             webhookControllerTemplate,
+	"webhookDTO":                    webhookDTOTemplate,
+	"webhookDispatcher":             webhookDispatcherTemplate,
+	"webhookMigration":              webhookMigrationTemplate,
+	"webhookRepository":             webhookRepositoryTemplate,
+	"worker":                        workerTemplate,
+}
+
+// parseTemplate parses the template of the file at path. It starts from the
+// built-in template name, adds the partials of the custom template set in dir
+// and then the set's override of the template, if any. An override consisting
+// only of {{define}}s replaces just those blocks of the built-in template.
+func parseTemplate(path, dir, name string) (*template.Template, error) {
+	tmpl, err := template.New(path).Parse(builtinTemplates[name])
+	if err != nil || dir == "" {
+		return tmpl, err
+	}
+
+	partials, err := filepath.Glob(filepath.Join(dir, partialPrefix+"*"+customTemplateExt))
+	if err != nil {
+		return nil, err
+	}
+	for _, partial := range partials {
+		content, err := os.ReadFile(partial)
+		if err != nil {
+			return nil, fmt.Errorf("reading partial: %w", err)
+		}
+		if err := config.TemplateSandbox.check(partial, string(content)); err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.New(partialName(partial)).Parse(string(content)); err != nil {
+			return nil, err
+		}
+	}
+
+	override := filepath.Join(dir, name+customTemplateExt)
+	content, err := os.ReadFile(override)
+	if errors.Is(err, os.ErrNotExist) {
+		return tmpl, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading custom template: %w", err)
+	}
+	if err := config.TemplateSandbox.check(override, string(content)); err != nil {
+		return nil, err
+	}
+	if _, err := tmpl.New(override).Parse(string(content)); err != nil {
+		return nil, err
+	}
+	// Parsing into the override's own name keeps its errors pointing at its
+	// file; its body, when it has one, replaces the built-in one.
+	if overridden := tmpl.Lookup(override); overridden.Tree != nil && !parse.IsEmptyTree(overridden.Tree.Root) {
+		if _, err := tmpl.AddParseTree(path, overridden.Tree); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+// partialName is the name a partial is included by: its file name without
+// the prefix and extension, so _imports.tmpl is {{template "imports" .}}.
+func partialName(path string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), partialPrefix), customTemplateExt)
+}
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Lists and checks custom templates.",
+	Long: `A custom template set is a directory, configured as templates in the generator
+config, with files named after the built-in templates they replace, e.g.
+controller.tmpl. The templates are Go text/templates executed with the same
+data as the built-in ones.
+
+Projects sharing a set configure it as a template pack instead: a repository
+on GitHub or GitLab, optionally followed by // and the directory of the set in
+it, pinned to a tag, e.g. github.com/org/crud-templates@v1.4.0. The pack is
+fetched once into the user cache directory and verified against
+templates_checksum, which the first fetch prints when it is missing.
+
+Files starting with _ hold partials: _imports.tmpl is included anywhere as
+{{template "imports" .}}, and {{define}}s in partials are shared by all
+templates. A file with only {{define}}s replaces just those blocks of the
+built-in template instead of all of it, e.g. controller.tmpl containing
+
+{{define "controllerServiceError"}}wrapError(err){{end}}
+
+The built-in blocks are controllerImports, controllerServiceError,
+serviceImports and repositoryImports. The feature toggles of the config and
+spec are available as .Features, e.g. {{if .Features.Audit}}.
+
+Template sets vendored from third parties can be sandboxed with
+template_sandbox in the config: the sandboxed templates cannot use call or the
+functions and fields listed in its deny, nor emit code reading the
+environment or running commands, e.g. importing os or net/http under any
+name, where the built-in template does not.`,
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "Lists the built-in templates a custom template set can override.",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, name := range slices.Sorted(maps.Keys(builtinTemplates)) {
+			fmt.Println(name + customTemplateExt)
+		}
+	},
+}
+
+var lintRender, lintSandbox bool
+
+var templatesLintCmd = &cobra.Command{
+	Use:   "lint [dir]",
+	Short: "Checks a custom template set before it is used for generation.",
+	Long: `This command parses every template of a custom template set and checks that
+it overrides a built-in template and only uses fields and methods of the
+template data. With --render it also executes each template against a sample
+entity to catch errors that only show at runtime. It exits with an error when
+any template has problems, so it can be used as a CI check. With --sandbox
+the set is checked as if template_sandbox was enabled, before trusting a
+vendored set with generation. For example:
+
+go run . templates lint ./crud-templates --render`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := config.Templates
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		if dir == "" {
+			return errors.New("no template set given and none configured")
+		}
+		if lintSandbox {
+			config.TemplateSandbox.Enabled = true
+		}
+		return lintTemplates(dir, lintRender)
+	},
+}
+
+func init() {
+	templatesLintCmd.Flags().BoolVar(&lintRender, "render", false, "Also execute every template against a sample entity")
+	templatesLintCmd.Flags().BoolVar(&lintSandbox, "sandbox", false, "Check the templates as if template_sandbox was enabled in the config")
+	templatesCmd.AddCommand(templatesListCmd, templatesLintCmd)
+	rootCmd.AddCommand(templatesCmd)
+}
+
+func lintTemplates(dir string, render bool) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+customTemplateExt))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no %s files in %s", customTemplateExt, dir)
+	}
+
+	// Partials can change every template, so with any partial all built-in
+	// templates are checked, otherwise only the overridden ones.
+	var names, problems []string
+	for _, path := range paths {
+		file := filepath.Base(path)
+		if strings.HasPrefix(file, partialPrefix) {
+			names = slices.Collect(maps.Keys(builtinTemplates))
+			continue
+		}
+		name := strings.TrimSuffix(file, customTemplateExt)
+		if _, ok := builtinTemplates[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: %q is not a built-in template%s", path, name, suggest(name, slices.Sorted(maps.Keys(builtinTemplates)))))
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	for _, name := range names {
+		for _, err := range lintTemplate(dir, name, render) {
+			if !slices.Contains(problems, err.Error()) {
+				problems = append(problems, err.Error())
+			}
+		}
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problem(s) in %s", len(problems), dir)
+	}
+	fmt.Printf("Checked %d template(s) in %s\n", len(names), dir)
+	return nil
+}
+
+// lintTemplate checks the named built-in template as customised by the set in
+// dir.
+func lintTemplate(dir, name string, render bool) []error {
+	tmpl, err := parseTemplate(name+customTemplateExt, dir, name)
+	if err != nil {
+		return []error{err}
+	}
+
+	var checker templateChecker
+	for _, defined := range tmpl.Templates() {
+		if defined.Tree != nil {
+			checker.walk(defined.Tree, defined.Tree.Root, scope{".": reflect.TypeFor[TemplateData](), "$": reflect.TypeFor[TemplateData]()})
+		}
+	}
+	for _, included := range checker.included {
+		if tmpl.Lookup(included.Name) == nil {
+			location, _ := included.tree.ErrorContext(included)
+			checker.errs = append(checker.errs, fmt.Errorf("%s: no template or partial %q%s", location, included.Name, suggest(included.Name, definedNames(tmpl))))
+		}
+	}
+	if render && len(checker.errs) == 0 {
+		var rendered bytes.Buffer
+		if err := tmpl.Execute(&rendered, sampleTemplateData()); err != nil {
+			checker.errs = append(checker.errs, err)
+		} else if err := config.TemplateSandbox.checkRendered(name+customTemplateExt, builtinTemplates[name], sampleTemplateData(), rendered.Bytes()); err != nil {
+			checker.errs = append(checker.errs, err)
+		}
+	}
+	return checker.errs
+}
+
+func definedNames(tmpl *template.Template) []string {
+	var names []string
+	for _, defined := range tmpl.Templates() {
+		names = append(names, defined.Name())
+	}
+	return names
+}
+
+// sampleTemplateData is a representative entity the templates are rendered
+// against by templates lint --render.
+func sampleTemplateData() TemplateData {
+	data := newTemplateData("SampleItem", Options{Swagger: swaggerSwaggo, IDType: idTypeInt64})
+	data.Entity = EntitySpec{
+		Name: "SampleItem",
+		Fields: []FieldSpec{
+			{Name: "title", Type: "string", Filters: []string{filterEq, filterLike}, Check: "length(title) <= 100", Example: "Blue ceramic mug"},
+			{Name: "quantity", Type: "int", Default: "0", Check: "quantity >= 0"},
+			{Name: "note", Type: "text", Nullable: true},
+			{Name: "placed_at", Type: "time"},
+		},
+		Partition: &PartitionSpec{Strategy: partitionRange, Key: "placed_at", Interval: "month"},
+	}
+	return data
+}
+
+// scope maps "." and the template variables to their static types; a nil
+// type is unknown and not checked.
+type scope map[string]reflect.Type
+
+func (s scope) with(name string, t reflect.Type) scope {
+	next := make(scope, len(s)+1)
+	for key, value := range s {
+		next[key] = value
+	}
+	next[name] = t
+	return next
+}
+
+// templateChecker reports the fields and methods a template uses that the
+// template data does not have.
+type templateChecker struct {
+	errs []error
+	// included lists the {{template}} calls, checked once all are parsed.
+	included []includedTemplate
+}
+
+type includedTemplate struct {
+	*parse.TemplateNode
+	tree *parse.Tree
+}
+
+func (c *templateChecker) walk(tree *parse.Tree, node parse.Node, s scope) scope {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return s
+		}
+		// Variables declared by an action stay visible until the end of
+		// the enclosing list.
+		inner := s
+		for _, child := range node.Nodes {
+			inner = c.walk(tree, child, inner)
+		}
+	case *parse.ActionNode:
+		return c.pipe(tree, node.Pipe, s)
+	case *parse.IfNode:
+		inner := c.pipe(tree, node.Pipe, s)
+		c.walk(tree, node.List, inner)
+		c.walk(tree, node.ElseList, inner)
+	case *parse.WithNode:
+		inner := c.pipe(tree, node.Pipe, s)
+		c.walk(tree, node.List, inner.with(".", c.pipeType(tree, node.Pipe, s)))
+		c.walk(tree, node.ElseList, inner)
+	case *parse.RangeNode:
+		inner := c.pipe(tree, node.Pipe, s)
+		key, elem := rangeTypes(c.pipeType(tree, node.Pipe, s))
+		if decl := node.Pipe.Decl; len(decl) == 1 {
+			inner = inner.with(decl[0].Ident[0], elem)
+		} else if len(decl) == 2 {
+			inner = inner.with(decl[0].Ident[0], key).with(decl[1].Ident[0], elem)
+		}
+		c.walk(tree, node.List, inner.with(".", elem))
+		c.walk(tree, node.ElseList, s)
+	case *parse.TemplateNode:
+		c.included = append(c.included, includedTemplate{node, tree})
+		c.pipe(tree, node.Pipe, s)
+	}
+	return s
+}
+
+// pipe checks the commands of pipe and returns s with its declared variables.
+func (c *templateChecker) pipe(tree *parse.Tree, pipe *parse.PipeNode, s scope) scope {
+	if pipe == nil {
+		return s
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			c.typeOf(tree, arg, s)
+		}
+	}
+	if len(pipe.Decl) == 1 && !pipe.IsAssign {
+		return s.with(pipe.Decl[0].Ident[0], c.pipeType(tree, pipe, s))
+	}
+	return s
+}
+
+// pipeType is the static type of pipe's result, nil when unknown.
+func (c *templateChecker) pipeType(tree *parse.Tree, pipe *parse.PipeNode, s scope) reflect.Type {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+	var quiet templateChecker
+	return quiet.typeOf(tree, pipe.Cmds[0].Args[0], s)
+}
+
+// typeOf checks the field chain of node and returns its static type.
+func (c *templateChecker) typeOf(tree *parse.Tree, node parse.Node, s scope) reflect.Type {
+	switch node := node.(type) {
+	case *parse.DotNode:
+		return s["."]
+	case *parse.FieldNode:
+		return c.chain(tree, node, s["."], node.Ident)
+	case *parse.VariableNode:
+		return c.chain(tree, node, s[node.Ident[0]], node.Ident[1:])
+	case *parse.ChainNode:
+		var base reflect.Type
+		if pipe, ok := node.Node.(*parse.PipeNode); ok {
+			c.pipe(tree, pipe, s)
+			base = c.pipeType(tree, pipe, s)
+		} else {
+			base = c.typeOf(tree, node.Node, s)
+		}
+		return c.chain(tree, node, base, node.Field)
+	case *parse.PipeNode:
+		c.pipe(tree, node, s)
+		return c.pipeType(tree, node, s)
+	}
+	return nil
+}
+
+// chain resolves the fields and methods of names on t, reporting the first
+// one t does not have.
+func (c *templateChecker) chain(tree *parse.Tree, node parse.Node, t reflect.Type, names []string) reflect.Type {
+	for _, name := range names {
+		if t == nil {
+			return nil
+		}
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			t = t.Elem()
+			continue
+		case reflect.Struct:
+		default:
+			return nil
+		}
+		if method, ok := t.MethodByName(name); ok {
+			t = resultType(method.Type)
+			continue
+		}
+		if method, ok := reflect.PointerTo(t).MethodByName(name); ok {
+			t = resultType(method.Type)
+			continue
+		}
+		if field, ok := t.FieldByName(name); ok && field.IsExported() {
+			t = field.Type
+			continue
+		}
+		location, _ := tree.ErrorContext(node)
+		c.errs = append(c.errs, fmt.Errorf("%s: %s has no field or method %s%s", location, t.Name(), name, suggest(name, members(t))))
+		return nil
+	}
+	return t
+}
+
+func resultType(method reflect.Type) reflect.Type {
+	if method.NumOut() == 0 {
+		return nil
+	}
+	return method.Out(0)
+}
+
+// rangeTypes are the key and element types of ranging over t.
+func rangeTypes(t reflect.Type) (key, elem reflect.Type) {
+	if t == nil {
+		return nil, nil
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return reflect.TypeFor[int](), t.Elem()
+	case reflect.Map:
+		return t.Key(), t.Elem()
+	case reflect.Int:
+		return t, t
+	}
+	return nil, nil
+}
+
+// members lists the exported fields and methods of struct type t.
+func members(t reflect.Type) []string {
+	var names []string
+	for _, field := range reflect.VisibleFields(t) {
+		if field.IsExported() && !field.Anonymous {
+			names = append(names, field.Name)
+		}
+	}
+	for i := 0; i < t.NumMethod(); i++ {
+		names = append(names, t.Method(i).Name)
+	}
+	return names
+}
@@ -0,0 +1,221 @@
+package crud
+
+// --- TRASH TEMPLATES ---
+
+const trashInterfaceTemplate = `package repository
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// {{.PascalCase}}Trash reads and restores the soft-deleted {{.LowerCase}}s, those whose
+// deleted_at is set, which the generic repository no longer returns.
+type {{.PascalCase}}Trash interface {
+	FindDeleted(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error)
+	// Restore clears the deleted_at of the {{.LowerCase}} with the given id, failing
+	// when no soft-deleted {{.LowerCase}} has it.
+	Restore(ctx context.Context, id {{.IDGoType}}) error
+}
+`
+
+const trashRepositoryTemplate = `package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+type {{.CamelCase}}Trash struct {
+	db  ports.Database
+	log ports.LoggerWithTraceID
+}
+
+func New{{.PascalCase}}Trash(db ports.Database, log ports.LoggerWithTraceID) repository.{{.PascalCase}}Trash {
+	return &{{.CamelCase}}Trash{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *{{.CamelCase}}Trash) FindDeleted(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+	// TODO: Select a page of the soft-deleted rows on r.db, most recently
+	// deleted first, and count them for the returned pagination.
+	// Example:
+	// SELECT * FROM {{.TableIdent}} WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id LIMIT $1 OFFSET $2
+	return nil, nil, fmt.Errorf("{{.SnakeCase}} trash listing is not implemented")
+}
+
+func (r *{{.CamelCase}}Trash) Restore(ctx context.Context, id {{.IDGoType}}) error {
+	// TODO: Clear deleted_at on r.db and return a not found error when no row
+	// was affected.
+	// Example:
+	// UPDATE {{.TableIdent}} SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL
+	return fmt.Errorf("{{.SnakeCase}} restore is not implemented: %v", id)
+}
+`
+
+const trashServiceTemplate = `package service
+
+import (
+	"context"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+// GetDeleted{{.PascalCase}}s returns a page of the soft-deleted {{.LowerCase}}s.
+func (s *{{.CamelCase}}Service) GetDeleted{{.PascalCase}}s(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}, *dto.Pagination, error) {
+{{- if .Timeout}}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.List)
+	defer cancel()
+{{end}}
+	return s.trash.FindDeleted(ctx, pagination)
+}
+
+// Restore{{.PascalCase}} brings back the soft-deleted {{.LowerCase}} with the given id and
+// returns it as the other endpoints now see it.
+func (s *{{.CamelCase}}Service) Restore{{.PascalCase}}(ctx context.Context, id {{.IDGoType}}) (dto.{{.PascalCase}}, error) {
+{{- if .Timeout}}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Update)
+	defer cancel()
+{{end}}
+	if err := s.trash.Restore(ctx, id); err != nil {
+		return dto.{{.PascalCase}}{}, err
+	}
+	return s.{{.CamelCase}}Repository.GetByID(ctx, id)
+}
+`
+
+const trashControllerTemplate = `package {{.LowerCase}}
+
+import (
+{{- if not .UUID}}
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+{{- end}}
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+	"go.elastic.co/apm"
+)
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Get deleted {{.PascalCase}}s
+// @Description	Get the paginated soft-deleted {{.LowerCase}}s, most recently deleted first
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			params	query		httpUtils.ListRequest	false	"Pagination parameters"
+// @Success		200		{{.SwagResponse (print "[]" .ResponseType)}}
+{{- if .PaginationHeaders}}
+// @Header			200		{integer}	X-Total-Count	"Number of deleted {{.LowerCase}}s"
+// @Header			200		{string}	Link	"URLs of the previous and next pages"
+{{- end}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			{{.WriteRoutePrefix}}/{{.KebabCase}}/trash [get]
+{{else -}}
+// GetDeleted{{.PascalCase}}s handles GET {{.WriteRoutePrefix}}/{{.KebabCase}}/trash.
+{{end -}}
+func (ctrl *{{.CamelCase}}Controller) GetDeleted{{.PascalCase}}s(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "GetDeleted{{.PascalCase}}s", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
+
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return err
+	}
+{{- end}}
+
+	// The trash is listed in deletion order, so no columns are filterable or
+	// sortable.
+	pagination, err := httpUtils.ParseAndValidatePagination(ctx, c, ctrl.customValidation, ctrl.log, nil)
+	if err != nil {
+		return err
+	}
+{{- if .Pagination.IsSet}}
+	pagination = httpUtils.ApplyPaginationDefaults(c, pagination, {{.CamelCase}}PaginationDefaults)
+{{- end}}
+
+	deleted, resultPagination, err := ctrl.{{.CamelCase}}Service.GetDeleted{{.PascalCase}}s(ctx, pagination)
+	if err != nil {
+		return err
+	}
+{{- if .PaginationHeaders}}
+	httpUtils.SetPaginationHeaders(c, resultPagination)
+{{- end}}
+
+	resp := {{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Data: {{with .ResponseMapper}}{{.}}s(deleted){{else}}deleted{{end}},
+		Meta: &ports.Meta{
+			Pagination: &resultPagination.Pagination,
+		},
+	}{{if .Envelope.IsSet}}){{end}}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, resp){{else}}c.JSON(resp){{end}}
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Restore a {{.PascalCase}}
+// @Description	This route will restore a soft-deleted {{.LowerCase}}
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			id	path		{{.IDParam}}	true	"ID of the deleted {{.PascalCase}}"{{if .UUID}}	format(uuid){{end}}
+// @Success		200	{{.SwagResponse .ResponseType}}
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		404	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			{{.WriteRoutePrefix}}/{{.KebabCase}}/{id}/restore [post]
+{{else -}}
+// Restore{{.PascalCase}} handles POST {{.WriteRoutePrefix}}/{{.KebabCase}}/{id}/restore.
+{{end -}}
+func (ctrl *{{.CamelCase}}Controller) Restore{{.PascalCase}}(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Restore{{.PascalCase}}", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+{{- if .Claims}}
+
+	ctx, err := withCaller(ctx, c)
+	if err != nil {
+		return err
+	}
+{{- end}}
+
+	id, err := {{if .UUID}}parse{{.PascalCase}}ID(c){{else}}c.ParamsInt("id"){{end}}
+	if err != nil {
+		return {{if .UUID}}err{{else}}appErr.NewBadRequestErr(err){{end}}
+	}
+
+	restored, err := ctrl.{{.CamelCase}}Service.Restore{{.PascalCase}}(ctx, {{.IDArg}})
+	if err != nil {
+		return {{if .I18n}}localize{{.PascalCase}}Error(err){{else}}err{{end}}
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, {{else}}c.JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   {{with .ResponseMapper}}{{.}}(restored){{else}}restored{{end}},
+	}{{if .Envelope.IsSet}}){{end}})
+}
+`
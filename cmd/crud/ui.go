@@ -0,0 +1,466 @@
+package crud
+
+// --- ADMIN UI TEMPLATES ---
+
+const (
+	uiTempl      = "templ"
+	uiReactAdmin = "react-admin"
+)
+
+const templHandlerTemplate = `package {{.LowerCase}}
+
+import (
+	"bytes"
+	"fmt"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"{{.ServiceImport}}"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/validator"
+	"github.com/a-h/templ"
+)
+
+// basePath is where the {{.PascalCase}} pages are served; the links and HTMX
+// requests in pages.templ are built from it.
+const basePath = "/admin/ui/{{.KebabCase}}"
+
+{{- if .Pagination.IsSet}}
+
+// {{.CamelCase}}PaginationDefaults come from the pagination section of the generator config.
+var {{.CamelCase}}PaginationDefaults = httpUtils.PaginationDefaults{
+	DefaultPageSize: {{.Pagination.DefaultPageSize}},
+	MaxPageSize:     {{.Pagination.MaxPageSize}},
+	DefaultSort:     {{printf "%q" .Pagination.DefaultSort}},
+}
+{{- end}}
+
+const defaultPageSize = {{or .Pagination.DefaultPageSize 10}}
+
+// Handler serves the server-rendered {{.PascalCase}} admin pages.
+type Handler interface {
+	List(c *ports.HttpContext) error
+	New(c *ports.HttpContext) error
+	Create(c *ports.HttpContext) error
+	Edit(c *ports.HttpContext) error
+	Update(c *ports.HttpContext) error
+	Delete(c *ports.HttpContext) error
+}
+
+type handler struct {
+	{{.CamelCase}}Service    service.{{.PascalCase}}
+	customValidation validator.CustomValidation
+	log              ports.LoggerWithTraceID
+}
+
+func New(log ports.LoggerWithTraceID, {{.CamelCase}}Service service.{{.PascalCase}}, customValidation validator.CustomValidation) Handler {
+	return &handler{
+		{{.CamelCase}}Service:    {{.CamelCase}}Service,
+		customValidation: customValidation,
+		log:              log,
+	}
+}
+
+// {{.CamelCase}}Form is bound from the create and edit forms.
+type {{.CamelCase}}Form struct {
+	// TODO: Add the editable fields.
+	// Example:
+	// Name string ` + "`form:\"name\" validate:\"required\"`" + `
+}
+
+// List handles GET /admin/ui/{{.KebabCase}}. HTMX requests from the filter
+// form and the pagination links only get the table back.
+func (h *handler) List(c *ports.HttpContext) error {
+	ctx := c.Context()
+
+	// Keep in sync with the columnMapping of the REST controller.
+	columnMapping := map[string]string{}
+
+	pagination, err := httpUtils.ParseAndValidatePagination(ctx, c, h.customValidation, h.log, columnMapping)
+	if err != nil {
+		return err
+	}
+{{- if .Pagination.IsSet}}
+	pagination = httpUtils.ApplyPaginationDefaults(c, pagination, {{.CamelCase}}PaginationDefaults)
+{{- end}}
+
+	items, _, err := h.{{.CamelCase}}Service.GetPaginated{{.PascalCase}}s(ctx, pagination)
+	if err != nil {
+		return err
+	}
+
+	page := c.QueryInt("page", 1)
+	hasNext := len(items) == c.QueryInt("page_size", defaultPageSize)
+	if c.Get("HX-Request") == "true" {
+		return render(c, {{.PascalCase}}Table(items, page, hasNext))
+	}
+	return render(c, {{.PascalCase}}ListPage(items, page, hasNext))
+}
+
+// New handles GET /admin/ui/{{.KebabCase}}/new.
+func (h *handler) New(c *ports.HttpContext) error {
+	return render(c, {{.PascalCase}}FormPage(dto.{{.PascalCase}}{}, nil))
+}
+
+// Create handles POST /admin/ui/{{.KebabCase}} from the HTMX form.
+func (h *handler) Create(c *ports.HttpContext) error {
+	return h.save(c, 0)
+}
+
+// Edit handles GET /admin/ui/{{.KebabCase}}/{id}/edit.
+func (h *handler) Edit(c *ports.HttpContext) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return appErr.NewBadRequestErr(err)
+	}
+
+	entity, err := h.{{.CamelCase}}Service.Get{{.PascalCase}}ByID(c.Context(), int64(id))
+	if err != nil {
+		return err
+	}
+	return render(c, {{.PascalCase}}FormPage(entity, nil))
+}
+
+// Update handles PUT /admin/ui/{{.KebabCase}}/{id} from the HTMX form.
+func (h *handler) Update(c *ports.HttpContext) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return appErr.NewBadRequestErr(err)
+	}
+	return h.save(c, int64(id))
+}
+
+// Delete handles DELETE /admin/ui/{{.KebabCase}}/{id}. The empty response
+// replaces the table row, which removes it from the page.
+func (h *handler) Delete(c *ports.HttpContext) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return appErr.NewBadRequestErr(err)
+	}
+
+	if err := h.{{.CamelCase}}Service.Delete{{.PascalCase}}(c.Context(), int64(id)); err != nil {
+		return err
+	}
+	return c.Status(200).Send(nil)
+}
+
+// save creates the {{.PascalCase}} when id is zero and updates it otherwise.
+// Invalid input re-renders the form with the validation errors, with a 200
+// status because HTMX does not swap error responses by default.
+func (h *handler) save(c *ports.HttpContext, id int64) error {
+	ctx := c.Context()
+
+	var form {{.CamelCase}}Form
+	if err := c.BodyParser(&form); err != nil {
+		h.log.Error(ctx, err.Error())
+		return appErr.NewBadRequestErr(err)
+	}
+
+	// TODO: Map form to a dto.{{.PascalCase}} struct.
+	// Example:
+	// entityDto := dto.{{.PascalCase}}{
+	// 	Name: form.Name,
+	// }
+	var entityDto dto.{{.PascalCase}}
+	entityDto.ID = id
+
+	if validationErrs := h.customValidation.ValidateStruct(form); validationErrs != nil {
+		messages := make([]string, len(validationErrs))
+		for i, validationErr := range validationErrs {
+			messages[i] = fmt.Sprint(validationErr)
+		}
+		return render(c, {{.PascalCase}}Form(entityDto, messages))
+	}
+
+	var err error
+	if id == 0 {
+		_, err = h.{{.CamelCase}}Service.Create{{.PascalCase}}(ctx, entityDto)
+	} else {
+		_, err = h.{{.CamelCase}}Service.Update{{.PascalCase}}(ctx, entityDto)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.Set("HX-Redirect", basePath)
+	return c.SendStatus(204)
+}
+
+func render(c *ports.HttpContext, component templ.Component) error {
+	var body bytes.Buffer
+	if err := component.Render(c.Context(), &body); err != nil {
+		return err
+	}
+	c.Set("Content-Type", "text/html; charset=utf-8")
+	return c.Status(200).Send(body.Bytes())
+}
+`
+
+const templPagesTemplate = `package {{.LowerCase}}
+
+import (
+	"fmt"
+	"strconv"
+
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+)
+
+templ layout(title string) {
+	<!DOCTYPE html>
+	<html lang="en">
+		<head>
+			<meta charset="utf-8"/>
+			<title>{ title }</title>
+			<script src="https://unpkg.com/htmx.org@2.0.4"></script>
+		</head>
+		<body>
+			{ children... }
+		</body>
+	</html>
+}
+
+templ {{.PascalCase}}ListPage(items []dto.{{.PascalCase}}, page int, hasNext bool) {
+	@layout("{{.PascalCase}}s") {
+		<h1>{{.PascalCase}}s</h1>
+		<a href={ templ.URL(basePath + "/new") }>New {{.PascalCase}}</a>
+		<form id="{{.KebabCase}}-filters" hx-get={ basePath } hx-target="#{{.KebabCase}}-table" hx-swap="outerHTML" hx-push-url="true">
+			<!-- TODO: Add filter inputs named after the columnMapping keys of List. -->
+			<button type="submit">Filter</button>
+		</form>
+		@{{.PascalCase}}Table(items, page, hasNext)
+	}
+}
+
+templ {{.PascalCase}}Table(items []dto.{{.PascalCase}}, page int, hasNext bool) {
+	<div id="{{.KebabCase}}-table">
+		<table>
+			<thead>
+				<tr>
+					<th>ID</th>
+					<!-- TODO: Add a header per listed {{.PascalCase}} field. -->
+					<th></th>
+				</tr>
+			</thead>
+			<tbody hx-confirm="Delete this {{.LowerCase}}?" hx-target="closest tr" hx-swap="outerHTML">
+				for _, item := range items {
+					<tr>
+						<td>{ strconv.FormatInt(item.ID, 10) }</td>
+						<!-- TODO: Add a cell per listed {{.PascalCase}} field. -->
+						<td>
+							<a href={ templ.URL(fmt.Sprintf("%s/%d/edit", basePath, item.ID)) }>Edit</a>
+							<button hx-delete={ fmt.Sprintf("%s/%d", basePath, item.ID) }>Delete</button>
+						</td>
+					</tr>
+				}
+			</tbody>
+		</table>
+		<nav hx-target="#{{.KebabCase}}-table" hx-swap="outerHTML" hx-push-url="true" hx-include="#{{.KebabCase}}-filters">
+			if page > 1 {
+				<a hx-get={ fmt.Sprintf("%s?page=%d", basePath, page-1) }>Previous</a>
+			}
+			if hasNext {
+				<a hx-get={ fmt.Sprintf("%s?page=%d", basePath, page+1) }>Next</a>
+			}
+		</nav>
+	</div>
+}
+
+templ {{.PascalCase}}FormPage(entity dto.{{.PascalCase}}, errs []string) {
+	@layout("{{.PascalCase}}") {
+		if entity.ID == 0 {
+			<h1>New {{.PascalCase}}</h1>
+		} else {
+			<h1>Edit {{.PascalCase}} { strconv.FormatInt(entity.ID, 10) }</h1>
+		}
+		@{{.PascalCase}}Form(entity, errs)
+	}
+}
+
+templ {{.PascalCase}}Form(entity dto.{{.PascalCase}}, errs []string) {
+	<form
+		id="{{.KebabCase}}-form"
+		if entity.ID == 0 {
+			hx-post={ basePath }
+		} else {
+			hx-put={ fmt.Sprintf("%s/%d", basePath, entity.ID) }
+		}
+		hx-target="this"
+		hx-swap="outerHTML"
+	>
+		if len(errs) > 0 {
+			<ul class="errors">
+				for _, err := range errs {
+					<li>{ err }</li>
+				}
+			</ul>
+		}
+		<!-- TODO: Add an input per editable {{.PascalCase}} field, named like the {{.CamelCase}}Form form tags. -->
+		<button type="submit">Save</button>
+		<a href={ templ.URL(basePath) }>Cancel</a>
+	</form>
+}
+`
+
+const defaultReactAdminDir = "web/admin/src"
+
+// UIConfig controls where frontend code generated by --ui is written.
+type UIConfig struct {
+	// ReactAdminDir is the source folder of the react-admin app, which holds
+	// the shared data provider and a resources/<entity> folder per entity.
+	ReactAdminDir string `yaml:"react_admin_dir"`
+}
+
+// reactAdminDir returns the configured react-admin source folder.
+func (c UIConfig) reactAdminDir() string {
+	if c.ReactAdminDir == "" {
+		return defaultReactAdminDir
+	}
+	return c.ReactAdminDir
+}
+
+// reactAdminDataProviderTemplate is shared by every entity, so it is generated
+// once and left alone on later runs. Entities register their routes from their
+// own resource modules.
+const reactAdminDataProviderTemplate = `import { fetchUtils, type DataProvider } from "react-admin";
+
+// routes maps each resource name to its REST prefixes. Reads and writes may
+// live under different prefixes when writes are served by the admin API.
+export const routes: Record<string, { read: string; write: string }> = {};
+
+const apiUrl: string = import.meta.env.VITE_API_URL ?? "";
+
+const route = (resource: string) => {
+  const prefixes = routes[resource];
+  if (!prefixes) {
+    throw new Error("No routes registered for resource " + resource);
+  }
+  return prefixes;
+};
+
+const request = (path: string, options?: fetchUtils.Options) =>
+  fetchUtils.fetchJson(apiUrl + path, options).then(({ json }) => json);
+
+export const dataProvider: DataProvider = {
+  getList: async (resource, params) => {
+    const { page, perPage } = params.pagination ?? { page: 1, perPage: 10 };
+    const query = new URLSearchParams({ page: String(page), page_size: String(perPage) });
+    for (const [key, value] of Object.entries(params.filter ?? {})) {
+      query.set(key, String(value));
+    }
+    // TODO: Map params.sort to the sort parameters httpUtils.ListRequest expects.
+    const json = await request(route(resource).read + "/?" + query.toString());
+    return { data: json.data ?? [], total: json.meta?.pagination?.total ?? 0 };
+  },
+
+  getOne: async (resource, params) => {
+    const json = await request(route(resource).read + "/" + params.id);
+    return { data: json.data };
+  },
+
+  getMany: async (resource, params) => {
+    const records = await Promise.all(
+      params.ids.map((id) => request(route(resource).read + "/" + id).then((json) => json.data)),
+    );
+    return { data: records };
+  },
+
+  getManyReference: (resource, params) =>
+    dataProvider.getList(resource, {
+      ...params,
+      filter: { ...params.filter, [params.target]: params.id },
+    }),
+
+  create: async (resource, params) => {
+    const json = await request(route(resource).write + "/", {
+      method: "POST",
+      body: JSON.stringify(params.data),
+    });
+    return { data: json.data };
+  },
+
+  update: async (resource, params) => {
+    const json = await request(route(resource).write + "/" + params.id, {
+      method: "PUT",
+      body: JSON.stringify(params.data),
+    });
+    return { data: json.data };
+  },
+
+  updateMany: async (resource, params) => {
+    await Promise.all(
+      params.ids.map((id) =>
+        request(route(resource).write + "/" + id, { method: "PUT", body: JSON.stringify(params.data) }),
+      ),
+    );
+    return { data: params.ids };
+  },
+
+  delete: async (resource, params) => {
+    await request(route(resource).write + "/" + params.id, { method: "DELETE" });
+    return { data: params.previousData as any };
+  },
+
+  deleteMany: async (resource, params) => {
+    await Promise.all(params.ids.map((id) => request(route(resource).write + "/" + id, { method: "DELETE" })));
+    return { data: params.ids };
+  },
+};
+`
+
+const reactAdminResourceTemplate = `import { routes } from "../../dataProvider";
+import { {{.PascalCase}}Create } from "./{{.PascalCase}}Create";
+import { {{.PascalCase}}Edit } from "./{{.PascalCase}}Edit";
+import { {{.PascalCase}}List } from "./{{.PascalCase}}List";
+
+routes["{{.KebabCase}}"] = {
+  read: "/api/v1/{{.KebabCase}}",
+  write: "{{.WriteRoutePrefix}}/{{.KebabCase}}",
+};
+
+// Spread into a resource of the app: <Resource {...{{.CamelCase}}Resource} />.
+export const {{.CamelCase}}Resource = {
+  name: "{{.KebabCase}}",
+  options: { label: "{{.PascalCase}}s" },
+  list: {{.PascalCase}}List,
+  edit: {{.PascalCase}}Edit,
+  create: {{.PascalCase}}Create,
+};
+`
+
+const reactAdminListTemplate = `import { Datagrid, DeleteButton, EditButton, List, NumberField } from "react-admin";
+
+export const {{.PascalCase}}List = () => (
+  <List>
+    <Datagrid rowClick="edit">
+      <NumberField source="id" />
+      {/* TODO: Add a field per listed {{.PascalCase}} field, e.g. <TextField source="name" />. */}
+      <EditButton />
+      <DeleteButton mutationMode="pessimistic" />
+    </Datagrid>
+  </List>
+);
+`
+
+const reactAdminEditTemplate = `import { Edit, NumberInput, SimpleForm } from "react-admin";
+
+export const {{.PascalCase}}Edit = () => (
+  <Edit mutationMode="pessimistic">
+    <SimpleForm>
+      <NumberInput source="id" disabled />
+      {/* TODO: Add an input per update{{.PascalCase}}Request field, e.g. <TextInput source="name" />. */}
+    </SimpleForm>
+  </Edit>
+);
+`
+
+const reactAdminCreateTemplate = `import { Create, SimpleForm } from "react-admin";
+
+export const {{.PascalCase}}Create = () => (
+  <Create redirect="list">
+    <SimpleForm>
+      {/* TODO: Add an input per create{{.PascalCase}}Request field, e.g. <TextInput source="name" />. */}
+    </SimpleForm>
+  </Create>
+);
+`
@@ -0,0 +1,87 @@
+package crud
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// usageReportFile collects the runs of the generator in a project when
+// usage_report is enabled in the config. It never leaves the machine; platform
+// teams gather the files of their projects to see what is used.
+var usageReportFile = filepath.Join(".crudgen", "usage.json")
+
+// usageRun is a run of the generator in the usage report. Only the names of
+// the flags are recorded, as their values may hold paths and tokens.
+type usageRun struct {
+	Time    time.Time `json:"time"`
+	Version string    `json:"version"`
+	Command string    `json:"command"`
+	Flags   []string  `json:"flags,omitempty"`
+	Preset  string    `json:"preset,omitempty"`
+	// Templates is the custom template set or template pack as configured.
+	Templates string `json:"templates,omitempty"`
+}
+
+// pendingUsage is the run being made, recorded once it succeeds.
+var pendingUsage *usageRun
+
+// startUsage notes the run of cmd when the usage report is enabled. It is
+// called before the template pack is resolved, so the pack is recorded as
+// configured rather than as its cache directory.
+func startUsage(cmd *cobra.Command) {
+	if !config.UsageReport {
+		return
+	}
+	run := usageRun{
+		Time:      time.Now().UTC(),
+		Version:   version,
+		Command:   cmd.CommandPath(),
+		Preset:    config.Preset,
+		Templates: config.Templates,
+	}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		run.Flags = append(run.Flags, flag.Name)
+	})
+	pendingUsage = &run
+}
+
+// recordUsage appends the pending run to the usage report. The report is not
+// worth failing a run that succeeded, so errors are only printed.
+func recordUsage() {
+	if pendingUsage == nil {
+		return
+	}
+	if err := appendUsage(*pendingUsage); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not record the run in %s: %v\n", usageReportFile, err)
+	}
+}
+
+func appendUsage(run usageRun) error {
+	var runs []usageRun
+	content, err := os.ReadFile(usageReportFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(content, &runs); err != nil {
+			return fmt.Errorf("parsing the report: %w", err)
+		}
+	}
+
+	content, err = json.MarshalIndent(append(runs, run), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(usageReportFile), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(usageReportFile, append(content, '\n'), 0644)
+}
@@ -0,0 +1,101 @@
+package crud
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+var versionJSON bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Prints the versions of the generator and its templates.",
+	Long: `This command prints the generator version, a hash of the built-in template set
+compiled into it, the hash of the custom template set configured as templates,
+if any, and the Go version the binary was built with. Use --json in bug reports
+and to pin the generator in CI.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info, err := collectVersionInfo(config.Templates)
+		if err != nil {
+			return err
+		}
+		if versionJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(info)
+		}
+		fmt.Printf("gocrud-gen %s\n", info.Version)
+		fmt.Printf("templates: built-in %s (sha256:%s)\n", info.Templates.Version, info.Templates.Hash)
+		if custom := info.CustomTemplates; custom != nil {
+			fmt.Printf("custom templates: %s (sha256:%s)\n", custom.Dir, custom.Hash)
+		}
+		fmt.Printf("go: %s %s\n", info.Go, info.Platform)
+		return nil
+	},
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Print the versions as JSON")
+	rootCmd.AddCommand(versionCmd)
+}
+
+type versionInfo struct {
+	Version         string               `json:"version"`
+	Templates       templateSetInfo      `json:"templates"`
+	CustomTemplates *customTemplatesInfo `json:"custom_templates,omitempty"`
+	Go              string               `json:"go"`
+	Platform        string               `json:"platform"`
+}
+
+type templateSetInfo struct {
+	// Version is the generator version the templates were released with.
+	Version string `json:"version"`
+	Hash    string `json:"hash"`
+}
+
+type customTemplatesInfo struct {
+	Dir  string `json:"dir"`
+	Hash string `json:"hash"`
+}
+
+func collectVersionInfo(customDir string) (versionInfo, error) {
+	builtin := sha256.New()
+	for _, name := range slices.Sorted(maps.Keys(builtinTemplates)) {
+		fmt.Fprintf(builtin, "%s\x00%s\x00", name, builtinTemplates[name])
+	}
+	info := versionInfo{
+		Version:   version,
+		Templates: templateSetInfo{Version: version, Hash: hex.EncodeToString(builtin.Sum(nil))},
+		Go:        runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if customDir == "" {
+		return info, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(customDir, "*"+customTemplateExt))
+	if err != nil {
+		return versionInfo{}, err
+	}
+	custom := sha256.New()
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return versionInfo{}, err
+		}
+		fmt.Fprintf(custom, "%s\x00%s\x00", filepath.Base(path), content)
+	}
+	info.CustomTemplates = &customTemplatesInfo{Dir: customDir, Hash: hex.EncodeToString(custom.Sum(nil))}
+	return info, nil
+}
@@ -0,0 +1,150 @@
+package crud
+
+import "fmt"
+
+// The visibilities of a field. Fields without one are returned as stored.
+const (
+	// visibilityInternal fields are left out of the public responses; with
+	// --internal-api the internal handlers still return them.
+	visibilityInternal = "internal"
+	// visibilityWriteOnly fields, such as password hashes, are accepted in
+	// requests but never returned.
+	visibilityWriteOnly = "write_only"
+	// visibilityMasked fields are returned with all but their last characters
+	// hidden.
+	visibilityMasked = "masked"
+)
+
+func (f FieldSpec) validateVisibility() error {
+	switch f.Visibility {
+	case "", visibilityInternal, visibilityWriteOnly:
+	case visibilityMasked:
+		if fieldTypes[f.Type].Go != "string" || f.Nullable {
+			return fmt.Errorf("only non-nullable string fields can be masked")
+		}
+	default:
+		return fmt.Errorf("unknown visibility %q", f.Visibility)
+	}
+	return nil
+}
+
+// Hidden reports whether the public handlers leave the field out.
+func (f FieldSpec) Hidden() bool {
+	return f.Visibility == visibilityInternal || f.Visibility == visibilityWriteOnly
+}
+
+// fieldsWith lists the entity's fields for which keep returns true.
+func (d TemplateData) fieldsWith(keep func(FieldSpec) bool) []FieldSpec {
+	var fields []FieldSpec
+	for _, field := range d.Entity.Fields {
+		if keep(field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// HiddenFields lists the internal and write-only fields.
+func (d TemplateData) HiddenFields() []FieldSpec {
+	return d.fieldsWith(FieldSpec.Hidden)
+}
+
+// WriteOnlyFields lists the fields no handler returns, not even the internal
+// ones.
+func (d TemplateData) WriteOnlyFields() []FieldSpec {
+	return d.fieldsWith(func(field FieldSpec) bool { return field.Visibility == visibilityWriteOnly })
+}
+
+// MaskedFields lists the fields returned masked.
+func (d TemplateData) MaskedFields() []FieldSpec {
+	return d.fieldsWith(func(field FieldSpec) bool { return field.Visibility == visibilityMasked })
+}
+
+// RedactedFields lists the fields logs must not show: every field with a
+// visibility and the personal data.
+func (d TemplateData) RedactedFields() []FieldSpec {
+	return d.fieldsWith(func(field FieldSpec) bool { return field.Visibility != "" || field.PII || field.Encrypted })
+}
+
+// --- VISIBILITY TEMPLATES ---
+
+// redactTemplate is shared by every entity, so it is generated once and left
+// alone on later runs.
+const redactTemplate = `package redact
+
+import "encoding/json"
+
+// Placeholder replaces the redacted values.
+const Placeholder = "[REDACTED]"
+
+// placeholderJSON is Placeholder encoded as a JSON string.
+var placeholderJSON, _ = json.Marshal(Placeholder)
+
+// Value renders v as JSON for logs, with the values of keys redacted in every
+// object it contains. A string, []byte or json.RawMessage v is taken as JSON
+// already. Anything that is not valid JSON is redacted as a whole, since it
+// cannot be redacted key by key.
+func Value(v any, keys ...string) string {
+	var payload []byte
+	switch v := v.(type) {
+	case string:
+		payload = []byte(v)
+	case []byte:
+		payload = v
+	case json.RawMessage:
+		payload = v
+	default:
+		var err error
+		if payload, err = json.Marshal(v); err != nil {
+			return Placeholder
+		}
+	}
+	if !json.Valid(payload) {
+		return Placeholder
+	}
+
+	redacted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		redacted[key] = true
+	}
+	return string(redactJSON(payload, redacted))
+}
+
+func redactJSON(payload json.RawMessage, keys map[string]bool) json.RawMessage {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &object); err == nil {
+		for key, value := range object {
+			if keys[key] {
+				object[key] = placeholderJSON
+			} else {
+				object[key] = redactJSON(value, keys)
+			}
+		}
+		encoded, _ := json.Marshal(object)
+		return encoded
+	}
+
+	var array []json.RawMessage
+	if err := json.Unmarshal(payload, &array); err == nil {
+		for i, value := range array {
+			array[i] = redactJSON(value, keys)
+		}
+		encoded, _ := json.Marshal(array)
+		return encoded
+	}
+	return payload
+}
+`
+
+const redactEntityTemplate = `package redact
+
+// {{.PascalCase}}Fields are the JSON keys of dto.{{.PascalCase}} that logs must not show,
+// the fields with a visibility or marked as personal data in the spec.
+var {{.PascalCase}}Fields = []string{ {{- range $i, $field := .RedactedFields}}{{if $i}}, {{end}}"{{$field.Name}}"{{end -}} }
+
+// {{.PascalCase}} renders v, a dto.{{.PascalCase}} or JSON holding {{.LowerCase}}s, for logs with
+// the {{.PascalCase}}Fields redacted.
+func {{.PascalCase}}(v any) string {
+	return Value(v, {{.PascalCase}}Fields...)
+}
+`
@@ -0,0 +1,401 @@
+package crud
+
+// --- WEBHOOK TEMPLATES ---
+
+const webhookDTOTemplate = `package dto
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type {{.PascalCase}}WebhookSubscription struct {
+	ID        int64          ` + "`json:\"id\" db:\"id\"`" + `
+	URL       string         ` + "`json:\"url\" db:\"url\"`" + `
+	Secret    string         ` + "`json:\"-\" db:\"secret\"`" + `
+	Events    pq.StringArray ` + "`json:\"events\" db:\"events\"`" + `
+	CreatedAt time.Time      ` + "`json:\"created_at\" db:\"created_at\"`" + `
+}
+`
+
+const webhookDispatcherTemplate = `package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+)
+
+const (
+	{{.PascalCase}}Created = "{{.SnakeCase}}.created"
+	{{.PascalCase}}Updated = "{{.SnakeCase}}.updated"
+	{{.PascalCase}}Deleted = "{{.SnakeCase}}.deleted"
+)
+
+type {{.PascalCase}}SubscriptionRepository interface {
+	Create(ctx context.Context, subscription *dto.{{.PascalCase}}WebhookSubscription) error
+	Delete(ctx context.Context, id int64) error
+	FindAll(ctx context.Context, pagination dto.Pagination) ([]dto.{{.PascalCase}}WebhookSubscription, *dto.Pagination, error)
+	FindByEvent(ctx context.Context, event string) ([]dto.{{.PascalCase}}WebhookSubscription, error)
+}
+
+type {{.CamelCase}}Payload struct {
+	Event      string    ` + "`json:\"event\"`" + `
+	OccurredAt time.Time ` + "`json:\"occurred_at\"`" + `
+	Data       any       ` + "`json:\"data\"`" + `
+}
+
+type {{.PascalCase}}Dispatcher struct {
+	repository {{.PascalCase}}SubscriptionRepository
+	client     *http.Client
+	log        ports.LoggerWithTraceID
+	maxRetries int
+	backoff    time.Duration
+}
+
+func New{{.PascalCase}}Dispatcher(log ports.LoggerWithTraceID, repository {{.PascalCase}}SubscriptionRepository) *{{.PascalCase}}Dispatcher {
+	return &{{.PascalCase}}Dispatcher{
+		repository: repository,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		log:        {{if .CorrelationID}}correlation.Logger(log){{else}}log{{end}},
+		maxRetries: 5,
+		backoff:    time.Second,
+	}
+}
+
+// Dispatch delivers the event to every subscription registered for it.
+// Deliveries run in the background so the caller is never blocked by a slow receiver.
+func (d *{{.PascalCase}}Dispatcher) Dispatch(ctx context.Context, event string, data any) {
+	subscriptions, err := d.repository.FindByEvent(ctx, event)
+	if err != nil {
+		d.log.Error(ctx, err.Error())
+		return
+	}
+
+	body, err := json.Marshal({{.CamelCase}}Payload{
+		Event:      event,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+	if err != nil {
+		d.log.Error(ctx, err.Error())
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		go d.deliver(context.WithoutCancel(ctx), subscription, body)
+	}
+}
+
+func (d *{{.PascalCase}}Dispatcher) deliver(ctx context.Context, subscription dto.{{.PascalCase}}WebhookSubscription, body []byte) {
+	mac := hmac.New(sha256.New, []byte(subscription.Secret))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	delay := d.backoff
+	for attempt := 1; attempt <= d.maxRetries; attempt++ {
+		err := d.post(ctx, subscription.URL, signature, body)
+		if err == nil {
+			return
+		}
+		d.log.Error(ctx, fmt.Sprintf("webhook delivery to %s failed (attempt %d/%d): %v", subscription.URL, attempt, d.maxRetries, err))
+
+		var status *{{.CamelCase}}StatusError
+		if attempt == d.maxRetries || (errors.As(err, &status) && !status.retryable()) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (d *{{.PascalCase}}Dispatcher) post(ctx context.Context, url, signature string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Signature", signature)
+{{- if .CorrelationID}}
+	if id := correlation.ID(ctx); id != "" {
+		req.Header.Set(correlation.Header, id)
+	}
+{{- end}}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return &{{.CamelCase}}StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// {{.CamelCase}}StatusError is a delivery the receiver answered with a status
+// other than 2xx.
+type {{.CamelCase}}StatusError struct {
+	StatusCode int
+}
+
+func (e *{{.CamelCase}}StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+// retryable reports whether delivering again may succeed: the receiver failed
+// or asked to be called later, instead of rejecting the delivery.
+func (e *{{.CamelCase}}StatusError) retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests
+}
+`
+
+const webhookRepositoryTemplate = `package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/repository"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/webhook"
+)
+
+// {{.CamelCase}}WebhookByEventQuery selects the subscriptions listening to event $1.
+const {{.CamelCase}}WebhookByEventQuery = ` + "`" + `SELECT * FROM "{{.SnakeCase}}_webhook_subscription" WHERE $1 = ANY(events)` + "`" + `
+
+// {{.CamelCase}}WebhookSelector is what FindByEvent needs of the database: the
+// sqlx method scanning rows into structs by their db tags.
+type {{.CamelCase}}WebhookSelector interface {
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+type {{.CamelCase}}WebhookRepository struct {
+	repository.GenericRepository[dto.{{.PascalCase}}WebhookSubscription]
+	db  ports.Database
+	log ports.LoggerWithTraceID
+}
+
+func New{{.PascalCase}}WebhookRepository(db ports.Database, log ports.LoggerWithTraceID) webhook.{{.PascalCase}}SubscriptionRepository {
+	return &{{.CamelCase}}WebhookRepository{
+		GenericRepository: repository.NewGenericRepository[dto.{{.PascalCase}}WebhookSubscription](db, log),
+		db:                db,
+		log:               log,
+	}
+}
+
+// FindByEvent returns the subscriptions listening to event.
+func (r *{{.CamelCase}}WebhookRepository) FindByEvent(ctx context.Context, event string) ([]dto.{{.PascalCase}}WebhookSubscription, error) {
+	db, ok := r.db.({{.CamelCase}}WebhookSelector)
+	if !ok {
+		return nil, fmt.Errorf("{{.SnakeCase}} webhook repository: %T cannot run queries", r.db)
+	}
+
+	var subscriptions []dto.{{.PascalCase}}WebhookSubscription
+	if err := db.SelectContext(ctx, &subscriptions, {{.CamelCase}}WebhookByEventQuery, event); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+`
+
+const webhookControllerTemplate = `package {{.LowerCase}}
+
+import (
+	"errors"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/adapters/errorUtil/appErr"
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+	dto "git.snapp.ninja/snappshop/delivery/harley/internal/DTO"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/consts"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/httpUtils"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/transport/http/rest/validator"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/utils"
+	"git.snapp.ninja/snappshop/delivery/harley/internal/webhook"
+	"github.com/lib/pq"
+	"go.elastic.co/apm"
+)
+
+{{if eq .Swagger "openapi-gen" -}}
+// +k8s:openapi-gen=true
+{{end -}}
+type registerWebhookRequest struct {
+	URL    string   ` + "`json:\"url\" validate:\"required,url\"`" + `
+	Secret string   ` + "`json:\"secret\" validate:\"required,min=16\"`" + `
+	Events []string ` + "`json:\"events\" validate:\"required,min=1,dive,oneof={{.SnakeCase}}.created {{.SnakeCase}}.updated {{.SnakeCase}}.deleted\"`" + `
+}
+
+type Webhook interface {
+	RegisterWebhook(c *ports.HttpContext) error
+	GetPaginatedWebhooks(c *ports.HttpContext) error
+	DeleteWebhook(c *ports.HttpContext) error
+}
+
+type webhookController struct {
+	subscriptionRepository webhook.{{.PascalCase}}SubscriptionRepository
+	customValidation       validator.CustomValidation
+	log                    ports.LoggerWithTraceID
+}
+
+func NewWebhook(log ports.LoggerWithTraceID, subscriptionRepository webhook.{{.PascalCase}}SubscriptionRepository, customValidation validator.CustomValidation) Webhook {
+	return &webhookController{
+		subscriptionRepository: subscriptionRepository,
+		customValidation:       customValidation,
+		log:                    log,
+	}
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Register a {{.PascalCase}} webhook
+// @Description	This route will register a URL to receive {{.LowerCase}} events
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			body	body		registerWebhookRequest	true	"Register webhook request"
+// @Success		201		{{.SwagResponse (print "dto." .PascalCase "WebhookSubscription")}}
+// @Failure		400		{object}	{{.ErrorSchema}}
+// @Failure		500		{object}	{{.ErrorSchema}}
+// @Router			/api/v1/{{.KebabCase}}/webhooks [post]
+{{else -}}
+// RegisterWebhook handles POST /api/v1/{{.KebabCase}}/webhooks.
+{{end -}}
+func (ctrl *webhookController) RegisterWebhook(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Register{{.PascalCase}}Webhook", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	var inputRequest registerWebhookRequest
+	if err := c.BodyParser(&inputRequest); err != nil {
+		ctrl.log.Error(ctx, err.Error())
+		return appErr.NewBadRequestErr(err)
+	}
+
+	validationErrs := ctrl.customValidation.ValidateStruct(inputRequest)
+	if validationErrs != nil {
+		return utils.WithFieldErrors(
+			appErr.NewBadRequestErr(errors.New(consts.ErrValidationFailedMsg)),
+			validationErrs...,
+		)
+	}
+
+	subscription := dto.{{.PascalCase}}WebhookSubscription{
+		URL:    inputRequest.URL,
+		Secret: inputRequest.Secret,
+		Events: pq.StringArray(inputRequest.Events),
+	}
+	if err := ctrl.subscriptionRepository.Create(ctx, &subscription); err != nil {
+		return err
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 201, {{else}}c.Status(201).JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Status: true,
+		Data:   subscription,
+	}{{if .Envelope.IsSet}}){{end}})
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Get All {{.PascalCase}} webhooks
+// @Description	Get all paginated {{.LowerCase}} webhook subscriptions
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			params	query		httpUtils.ListRequest	false	"Pagination parameters"
+// @Success		200		{{.SwagResponse (print "[]dto." .PascalCase "WebhookSubscription")}}
+// @Failure		400		{object}	{{.ErrorSchema}}
+// @Failure		500		{object}	{{.ErrorSchema}}
+// @Router			/api/v1/{{.KebabCase}}/webhooks [get]
+{{else -}}
+// GetPaginatedWebhooks handles GET /api/v1/{{.KebabCase}}/webhooks.
+{{end -}}
+func (ctrl *webhookController) GetPaginatedWebhooks(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "GetPaginated{{.PascalCase}}Webhooks", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	pagination, err := httpUtils.ParseAndValidatePagination(ctx, c, ctrl.customValidation, ctrl.log, map[string]string{})
+	if err != nil {
+		return err
+	}
+
+	subscriptions, resultPagination, err := ctrl.subscriptionRepository.FindAll(ctx, pagination)
+	if err != nil {
+		return err
+	}
+
+	return {{if .ContentNegotiation}}httpUtils.Respond(c, 200, {{else}}c.JSON({{end}}{{if .Envelope.IsSet}}httpUtils.Envelope(c, {{end}}ports.Response{
+		Data: subscriptions,
+		Meta: &ports.Meta{
+			Pagination: &resultPagination.Pagination,
+		},
+	}{{if .Envelope.IsSet}}){{end}})
+}
+
+{{if eq .Swagger "swaggo" -}}
+// @Summary		Delete a {{.PascalCase}} webhook
+// @Description	This route will delete a {{.LowerCase}} webhook subscription
+// @Tags			{{.PascalCase}}
+// @Accept			json
+// @Produce		json
+// @Param			id	path	int	true	"Webhook subscription ID"
+// @Success		204
+// @Failure		400	{object}	{{.ErrorSchema}}
+// @Failure		500	{object}	{{.ErrorSchema}}
+// @Router			/api/v1/{{.KebabCase}}/webhooks/{id} [delete]
+{{else -}}
+// DeleteWebhook handles DELETE /api/v1/{{.KebabCase}}/webhooks/{id}.
+{{end -}}
+func (ctrl *webhookController) DeleteWebhook(c *ports.HttpContext) error {
+	span, ctx := apm.StartSpan(c.Context(), "Delete{{.PascalCase}}Webhook", "controller")
+	defer span.End()
+{{- if .CorrelationID}}
+	ctx = correlation.FromRequest(ctx, c)
+{{- end}}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return appErr.NewBadRequestErr(err)
+	}
+
+	if err := ctrl.subscriptionRepository.Delete(ctx, int64(id)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+`
+
+const webhookMigrationTemplate = `CREATE TABLE IF NOT EXISTS {{.SnakeCase}}_webhook_subscription (
+    id         BIGSERIAL PRIMARY KEY,
+    url        TEXT        NOT NULL,
+    secret     TEXT        NOT NULL,
+    events     TEXT[]      NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+`
@@ -0,0 +1,137 @@
+package crud
+
+// --- WORKER TEMPLATES ---
+
+const workerTemplate = `package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"git.snapp.ninja/search-and-discovery/framework/pkg/ports"
+{{- if .CorrelationID}}
+	"git.snapp.ninja/snappshop/delivery/harley/internal/correlation"
+{{- end}}
+{{- if .UUID}}
+	"github.com/google/uuid"
+{{- end}}
+)
+
+const (
+	{{.PascalCase}}CreatedJob = "{{.SnakeCase}}.created"
+	{{.PascalCase}}UpdatedJob = "{{.SnakeCase}}.updated"
+)
+
+type {{.PascalCase}}Job struct {
+{{- if .CorrelationID}}
+{{- if .UUID}}
+	Type          string    ` + "`json:\"type\"`" + `
+	ID            uuid.UUID ` + "`json:\"id\"`" + `
+	CorrelationID string    ` + "`json:\"correlation_id,omitempty\"`" + `
+{{- else}}
+	Type          string ` + "`json:\"type\"`" + `
+	ID            int64  ` + "`json:\"id\"`" + `
+	CorrelationID string ` + "`json:\"correlation_id,omitempty\"`" + `
+{{- end}}
+{{- else if .UUID}}
+	Type string    ` + "`json:\"type\"`" + `
+	ID   uuid.UUID ` + "`json:\"id\"`" + `
+{{- else}}
+	Type string ` + "`json:\"type\"`" + `
+	ID   int64  ` + "`json:\"id\"`" + `
+{{- end}}
+}
+
+// {{.PascalCase}}Queue is the broker the worker publishes to and consumes from.
+// Consume must close the returned channel once ctx is cancelled.
+type {{.PascalCase}}Queue interface {
+	Publish(ctx context.Context, payload []byte) error
+	Consume(ctx context.Context) (<-chan []byte, error)
+}
+
+type {{.PascalCase}}Worker struct {
+	queue       {{.PascalCase}}Queue
+	log         ports.LoggerWithTraceID
+	concurrency int
+	wg          sync.WaitGroup
+}
+
+func New{{.PascalCase}}Worker(log ports.LoggerWithTraceID, queue {{.PascalCase}}Queue) *{{.PascalCase}}Worker {
+	return &{{.PascalCase}}Worker{
+		queue:       queue,
+		log:         {{if .CorrelationID}}correlation.Logger(log){{else}}log{{end}},
+		concurrency: 4,
+	}
+}
+
+func (w *{{.PascalCase}}Worker) Enqueue(ctx context.Context, job {{.PascalCase}}Job) error {
+{{- if .CorrelationID}}
+	if job.CorrelationID == "" {
+		job.CorrelationID = correlation.ID(ctx)
+	}
+{{- end}}
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return w.queue.Publish(ctx, payload)
+}
+
+// Start consumes jobs in the background until ctx is cancelled.
+func (w *{{.PascalCase}}Worker) Start(ctx context.Context) error {
+	messages, err := w.queue.Consume(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < w.concurrency; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			for message := range messages {
+				w.handle(ctx, message)
+			}
+		}()
+	}
+	return nil
+}
+
+// Shutdown waits for in-flight jobs to finish, giving up when ctx expires.
+// Cancel the context passed to Start before calling it.
+func (w *{{.PascalCase}}Worker) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *{{.PascalCase}}Worker) handle(ctx context.Context, message []byte) {
+	var job {{.PascalCase}}Job
+	if err := json.Unmarshal(message, &job); err != nil {
+		w.log.Error(ctx, err.Error())
+		return
+	}
+{{- if .CorrelationID}}
+	ctx = correlation.WithID(ctx, job.CorrelationID)
+{{- end}}
+
+	switch job.Type {
+	case {{.PascalCase}}CreatedJob:
+		// TODO: Post-process the newly created {{.PascalCase}}.
+	case {{.PascalCase}}UpdatedJob:
+		// TODO: Post-process the updated {{.PascalCase}}.
+	default:
+		w.log.Error(ctx, fmt.Sprintf("unknown {{.LowerCase}} job type: %s", job.Type))
+	}
+}
+`
@@ -0,0 +1,150 @@
+package crud
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// FileWriter receives the files crud generates. The working tree, --archive
+// and --dry-run each have one, and tests can collect the files in memory.
+type FileWriter interface {
+	// Exists reports whether path already exists. Existing files are skipped.
+	Exists(path string) (bool, error)
+	// ReadFile returns the content of the existing path, whose marked regions
+	// a regenerated file keeps.
+	ReadFile(path string) ([]byte, error)
+	// WriteFile writes the generated content of path.
+	WriteFile(path string, content []byte) error
+	// Close is called once every file was written.
+	Close() error
+}
+
+// newFileWriter returns the writer for the options of the run.
+func newFileWriter(opts Options, modes fileModes) FileWriter {
+	switch {
+	case opts.DryRun:
+		return dryRunWriter{}
+	case opts.Archive != "":
+		return &archiveWriter{path: opts.Archive, mode: modes.File, files: map[string][]byte{}}
+	}
+	return diskWriter{modes: modes}
+}
+
+func existsOnDisk(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// diskWriter writes into the working tree, closing every file as soon as it is
+// written.
+type diskWriter struct {
+	modes fileModes
+}
+
+func (w diskWriter) Exists(path string) (bool, error) {
+	return existsOnDisk(path)
+}
+
+func (w diskWriter) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (w diskWriter) WriteFile(path string, content []byte) error {
+	if err := w.modes.mkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+	file, err := w.modes.create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (w diskWriter) Close() error {
+	return nil
+}
+
+// archiveWriter collects every file, whatever the working tree holds, and
+// writes them into the archive on Close.
+type archiveWriter struct {
+	path  string
+	mode  os.FileMode
+	files map[string][]byte
+}
+
+func (w *archiveWriter) Exists(path string) (bool, error) {
+	return false, nil
+}
+
+func (w *archiveWriter) ReadFile(path string) ([]byte, error) {
+	return nil, os.ErrNotExist
+}
+
+func (w *archiveWriter) WriteFile(path string, content []byte) error {
+	w.files[path] = content
+	return nil
+}
+
+func (w *archiveWriter) Close() error {
+	return writeArchive(w.path, w.files, w.mode)
+}
+
+// dryRunWriter skips what exists like diskWriter but writes nothing.
+type dryRunWriter struct{}
+
+func (dryRunWriter) Exists(path string) (bool, error) {
+	return existsOnDisk(path)
+}
+
+func (dryRunWriter) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (dryRunWriter) WriteFile(path string, content []byte) error {
+	return nil
+}
+
+func (dryRunWriter) Close() error {
+	return nil
+}
+
+// memoryWriter keeps the generated files in memory, so tests can check what
+// crud generates without touching the working tree. Files it holds before the
+// run exist like files on disk.
+type memoryWriter struct {
+	files map[string][]byte
+}
+
+func newMemoryWriter() *memoryWriter {
+	return &memoryWriter{files: map[string][]byte{}}
+}
+
+func (w *memoryWriter) Exists(path string) (bool, error) {
+	_, ok := w.files[path]
+	return ok, nil
+}
+
+func (w *memoryWriter) ReadFile(path string) ([]byte, error) {
+	content, ok := w.files[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return content, nil
+}
+
+func (w *memoryWriter) WriteFile(path string, content []byte) error {
+	w.files[path] = content
+	return nil
+}
+
+func (w *memoryWriter) Close() error {
+	return nil
+}